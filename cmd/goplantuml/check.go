@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	goplantuml "github.com/jfeliu007/goplantuml/parser"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// checkCommand is the first argument running the architecture rules instead of rendering a diagram
+const checkCommand = "check"
+
+// rulesFile is the YAML schema of the file given in -rules to the check command
+type rulesFile struct {
+	Rules []ruleConfig `yaml:"rules"`
+}
+
+// ruleConfig is a rule of the rules file. Only the keys of one kind of rule can be used in each of them.
+type ruleConfig struct {
+	Package            string   `yaml:"package"`
+	MustNotDependOn    []string `yaml:"must-not-depend-on"`
+	Types              string   `yaml:"types"`
+	MustImplement      string   `yaml:"must-implement"`
+	Interface          string   `yaml:"interface"`
+	MaxImplementations *int     `yaml:"max-implementations"`
+}
+
+// runCheck parses the paths given after the check command and prints the violations of the rules file. It returns
+// the exit code of the command, 0 if every rule is respected, 1 if there are violations and 2 on errors.
+func runCheck(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet(checkCommand, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	rulesPath := flags.String("rules", "", "YAML file with the rules to check")
+	recursive := flags.Bool("recursive", false, "walk all directories recursively, like the paths ending with /...")
+	ignore := flags.String("ignore", "", "comma separated list of directories or glob patterns to skip when walking recursively")
+	importPaths := flags.Bool("import-paths", false, "use the import paths of the packages as their names in the rules")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	rules, err := loadRules(*rulesPath)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	paths, walk := getCheckPaths(flags.Args())
+	dirs, files, err := getPaths(paths)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	parser, err := goplantuml.NewClassDiagramWithOptions(&goplantuml.ClassDiagramOptions{
+		FileSystem:         afero.NewOsFs(),
+		Directories:        dirs,
+		Files:              files,
+		IgnoredDirectories: getIgnoredDirectories(*ignore),
+		Recursive:          *recursive || walk,
+		ImportPaths:        *importPaths,
+		RenderingOptions:   map[goplantuml.RenderingOption]interface{}{},
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	for _, warning := range parser.Warnings() {
+		fmt.Fprintf(stderr, "warning: %s\n", warning.Error())
+	}
+	violations, err := parser.Check(rules)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 2
+	}
+	for _, violation := range violations {
+		fmt.Fprintf(stdout, "%s:\n", violation.Rule)
+		for _, t := range violation.Types {
+			fmt.Fprintf(stdout, "    %s\n", t)
+		}
+	}
+	if len(violations) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// getCheckPaths returns the given paths without the /... suffix of go packages patterns and true if any of them had
+// it, so they are walked recursively
+func getCheckPaths(args []string) ([]string, bool) {
+	paths := []string{}
+	walk := false
+	for _, arg := range args {
+		if arg == "..." || strings.HasSuffix(arg, "/...") {
+			arg = strings.TrimSuffix(strings.TrimSuffix(arg, "..."), "/")
+			if arg == "" {
+				arg = "."
+			}
+			walk = true
+		}
+		paths = append(paths, arg)
+	}
+	return paths, walk
+}
+
+// loadRules reads the rules of the given YAML file
+func loadRules(path string) ([]goplantuml.Rule, error) {
+	if path == "" {
+		return nil, errors.New("-rules is required by the check command")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the rules file %s: %w", path, err)
+	}
+	file := rulesFile{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("could not read the rules file %s: %w", path, err)
+	}
+	rules := []goplantuml.Rule{}
+	for i, config := range file.Rules {
+		rule, err := config.getRule()
+		if err != nil {
+			return nil, fmt.Errorf("%s: rule %d: %w", path, i+1, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// getRule returns the rule of the kind given by the keys of the config
+func (c ruleConfig) getRule() (goplantuml.Rule, error) {
+	kinds := 0
+	for _, used := range []bool{
+		c.Package != "" || len(c.MustNotDependOn) > 0,
+		c.Types != "" || c.MustImplement != "",
+		c.Interface != "" || c.MaxImplementations != nil,
+	} {
+		if used {
+			kinds++
+		}
+	}
+	switch {
+	case kinds != 1:
+	case c.Package != "" && len(c.MustNotDependOn) > 0:
+		return &goplantuml.ForbiddenDependencyRule{Package: c.Package, Forbidden: c.MustNotDependOn}, nil
+	case c.Types != "" && c.MustImplement != "":
+		return &goplantuml.ImplementationRule{Types: c.Types, Interface: c.MustImplement}, nil
+	case c.Interface != "" && c.MaxImplementations != nil:
+		return &goplantuml.MaxImplementationsRule{Interface: c.Interface, Max: *c.MaxImplementations}, nil
+	}
+	return nil, errors.New("a rule needs either package and must-not-depend-on, types and must-implement or interface and max-implementations")
+}
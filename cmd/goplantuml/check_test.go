@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGetCheckPaths(t *testing.T) {
+	paths, walk := getCheckPaths([]string{"./...", "cmd/...", "...", "main.go"})
+	if expected := []string{".", "cmd", ".", "main.go"}; !reflect.DeepEqual(paths, expected) || !walk {
+		t.Errorf("Expected %v walked recursively, got %v %v", expected, paths, walk)
+	}
+	if _, walk := getCheckPaths([]string{"cmd", "main.go"}); walk {
+		t.Errorf("Expected the paths without /... not to be walked recursively")
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	tt := []struct {
+		Name    string
+		Content string
+		Rules   int
+		Error   bool
+	}{
+		{
+			Name: "valid",
+			Content: `rules:
+  - package: domain
+    must-not-depend-on: [store]
+  - types: 'Store$'
+    must-implement: domain.Repository
+  - interface: domain.Repository
+    max-implementations: 0
+`,
+			Rules: 3,
+		},
+		{Name: "empty", Content: "", Rules: 0},
+		{Name: "incomplete", Content: "rules:\n  - package: domain\n", Error: true},
+		{Name: "mixed", Content: "rules:\n  - package: domain\n    must-not-depend-on: [store]\n    interface: domain.Repository\n", Error: true},
+		{Name: "unknown key", Content: "rules:\n  - package: domain\n    must-not-depend: [store]\n", Error: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.yaml")
+			if err := os.WriteFile(path, []byte(tc.Content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			rules, err := loadRules(path)
+			if tc.Error {
+				if err == nil {
+					t.Errorf("Expected an error loading %q", tc.Content)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			if len(rules) != tc.Rules {
+				t.Errorf("Expected %d rules, got %d", tc.Rules, len(rules))
+			}
+		})
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"domain/domain.go": "package domain\n\ntype Repository interface {\n\tSave() error\n}\n",
+		"store/store.go":   "package store\n\ntype FileStore struct{}\n\nfunc (s *FileStore) Save() error {\n\treturn nil\n}\n",
+		"rules.yaml":       "rules:\n  - interface: domain.Repository\n    max-implementations: 1\n",
+		"strict.yaml":      "rules:\n  - interface: domain.Repository\n    max-implementations: 0\n",
+		"unknown.yaml":     "rules:\n  - interface: domain.Missing\n    max-implementations: 0\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tt := []struct {
+		Rules    string
+		Code     int
+		Expected string
+	}{
+		{Rules: "rules.yaml", Code: 0, Expected: ""},
+		{Rules: "strict.yaml", Code: 1, Expected: "domain.Repository must have at most 0 implementations:\n    store.FileStore\n"},
+		{Rules: "unknown.yaml", Code: 2, Expected: ""},
+	}
+	for _, tc := range tt {
+		stdout := &bytes.Buffer{}
+		code := runCheck([]string{"-rules", filepath.Join(dir, tc.Rules), dir + "/..."}, stdout, &bytes.Buffer{})
+		if code != tc.Code {
+			t.Errorf("Expected the exit code %d with %s, got %d", tc.Code, tc.Rules, code)
+		}
+		if stdout.String() != tc.Expected {
+			t.Errorf("Expected the output\n%s\nwith %s, got\n%s", tc.Expected, tc.Rules, stdout.String())
+		}
+	}
+}
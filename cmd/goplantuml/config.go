@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFileNames are the config files looked for in the parsed directory when -config is not given
+var configFileNames = []string{".goplantuml.yaml", ".goplantuml.yml", ".goplantuml.json"}
+
+// configDirectoriesKey is the key of the config file listing the paths parsed when none is given as argument
+const configDirectoriesKey = "directories"
+
+// getArgs returns the paths to parse after setting the flags found in the config file given in -config or in the
+// first parsed directory
+func getArgs(flags *flag.FlagSet, configPath string) ([]string, error) {
+	path, err := findConfig(configPath, flags.Args())
+	if err != nil || path == "" {
+		return flags.Args(), err
+	}
+	return loadConfig(flags, path)
+}
+
+// findConfig returns the given config file or the one found in the directory of the first argument, or the working
+// directory when there are no arguments. It returns an empty path when there is none.
+func findConfig(configPath string, args []string) (string, error) {
+	if configPath != "" {
+		if _, err := os.Stat(configPath); err != nil {
+			return "", fmt.Errorf("could not find the config file %s", configPath)
+		}
+		return configPath, nil
+	}
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+	}
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// loadConfig sets the flags found in the given YAML or JSON config file, keyed by their names, unless they were
+// given in the command line. It returns the arguments or, when there are none, the directories of the config file.
+func loadConfig(flags *flag.FlagSet, path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the config file %s: %w", path, err)
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("could not read the config file %s: %w", path, err)
+	}
+	explicit := map[string]struct{}{}
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = struct{}{}
+	})
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	args := flags.Args()
+	for _, key := range keys {
+		value, err := getConfigValue(values[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid value for %s: %w", path, key, err)
+		}
+		if key == configDirectoriesKey {
+			if len(args) == 0 && value != "" {
+				args = strings.Split(value, ",")
+			}
+			continue
+		}
+		if key == "config" || flags.Lookup(key) == nil {
+			return nil, fmt.Errorf("%s: unknown key %s", path, key)
+		}
+		if _, ok := explicit[key]; ok {
+			// Flags given in the command line take precedence
+			continue
+		}
+		if err := flags.Set(key, value); err != nil {
+			return nil, fmt.Errorf("%s: invalid value %q for %s: %w", path, value, key, err)
+		}
+	}
+	return args, nil
+}
+
+// getConfigValue returns the given config value as a flag value. Lists are joined with commas and maps, like
+// package-colors, are written as comma separated key=value pairs.
+func getConfigValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []interface{}:
+		elements := []string{}
+		for _, element := range v {
+			s, err := getConfigScalar(element)
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, s)
+		}
+		return strings.Join(elements, ","), nil
+	case map[string]interface{}:
+		pairs := []string{}
+		for key, element := range v {
+			s, err := getConfigScalar(element)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, key+"="+s)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	}
+	return getConfigScalar(value)
+}
+
+// getConfigScalar returns the given string, number or boolean as a flag value
+func getConfigScalar(value interface{}) (string, error) {
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		return "", fmt.Errorf("%v can not be nested", value)
+	}
+	return fmt.Sprint(value), nil
+}
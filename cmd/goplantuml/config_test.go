@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tt := []struct {
+		Name              string
+		Config            string
+		Args              []string
+		ExpectedArgs      []string
+		ExpectedTitle     string
+		ExpectedRecursive bool
+		ExpectedColors    string
+		ExpectedError     string
+	}{
+		{
+			Name:              "Config values",
+			Config:            "directories: [a, b]\ntitle: Config\nrecursive: true\npackage-colors: {models: LightBlue, api: '#FFEEDD'}\n",
+			ExpectedArgs:      []string{"a", "b"},
+			ExpectedTitle:     "Config",
+			ExpectedRecursive: true,
+			ExpectedColors:    "api=#FFEEDD,models=LightBlue",
+		},
+		{
+			Name:              "Flags take precedence",
+			Config:            `{"directories": ["a"], "title": "Config", "recursive": true}`,
+			Args:              []string{"-title", "Flag", "-recursive=false", "c"},
+			ExpectedArgs:      []string{"c"},
+			ExpectedTitle:     "Flag",
+			ExpectedRecursive: false,
+		},
+		{
+			Name:          "Unknown key",
+			Config:        "titel: Config\n",
+			ExpectedError: "unknown key titel",
+		},
+		{
+			Name:          "Invalid value",
+			Config:        "recursive: sometimes\n",
+			ExpectedError: `invalid value "sometimes" for recursive`,
+		},
+		{
+			Name:          "Nested value",
+			Config:        "title: [[a]]\n",
+			ExpectedError: "invalid value for title",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".goplantuml.yaml")
+			if err := os.WriteFile(path, []byte(tc.Config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			flags := flag.NewFlagSet("goplantuml", flag.ContinueOnError)
+			title := flags.String("title", "", "")
+			recursive := flags.Bool("recursive", false, "")
+			colors := flags.String("package-colors", "", "")
+			flags.String("config", "", "")
+			if err := flags.Parse(append([]string{"-config", path}, tc.Args...)); err != nil {
+				t.Fatal(err)
+			}
+			args, err := getArgs(flags, path)
+			if tc.ExpectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.ExpectedError) {
+					t.Fatalf("Expected error %s but got %v", tc.ExpectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			if !reflect.DeepEqual(args, tc.ExpectedArgs) {
+				t.Errorf("Expected the arguments %v, got %v", tc.ExpectedArgs, args)
+			}
+			if *title != tc.ExpectedTitle || *recursive != tc.ExpectedRecursive || *colors != tc.ExpectedColors {
+				t.Errorf("Expected %s, %t and %s, got %s, %t and %s", tc.ExpectedTitle, tc.ExpectedRecursive, tc.ExpectedColors, *title, *recursive, *colors)
+			}
+		})
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	dir := t.TempDir()
+	if path, err := findConfig("", []string{dir}); err != nil || path != "" {
+		t.Errorf("Expected no config file, got %s and %v", path, err)
+	}
+	expected := filepath.Join(dir, ".goplantuml.json")
+	if err := os.WriteFile(expected, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := findConfig("", []string{dir}); err != nil || path != expected {
+		t.Errorf("Expected %s, got %s and %v", expected, path, err)
+	}
+	if _, err := findConfig(filepath.Join(dir, "missing.yaml"), nil); err == nil {
+		t.Errorf("Expected an error for a missing config file")
+	}
+}
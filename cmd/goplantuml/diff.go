@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	goplantuml "github.com/jfeliu007/goplantuml/parser"
+)
+
+// validateDiffFlags returns an error if -diff is used with a format it can not write or with the given flags that
+// only work with a single tree
+func validateDiffFlags(format string, singleTreeFlags bool) error {
+	if format != "puml" && format != "text" {
+		return fmt.Errorf("unknown format %s, it must be puml or text when -diff is used", format)
+	}
+	if singleTreeFlags {
+		return errors.New("-diff can not be used with -url, -render-to or -watch")
+	}
+	return nil
+}
+
+// runDiff parses the two directories given in the options, the old tree and the new one, and writes their
+// structural difference as a PlantUML diagram or, with the text format, a summary. It returns true if the trees
+// are different.
+func runDiff(options *goplantuml.ClassDiagramOptions, format string, output string, force bool) (bool, error) {
+	if len(options.Directories) != 2 || len(options.Files) != 0 {
+		return false, errors.New("-diff needs two directories, the old one and the new one")
+	}
+	parsers := []*goplantuml.ClassParser{}
+	for _, dir := range options.Directories {
+		treeOptions := *options
+		treeOptions.Directories = []string{dir}
+		parser, err := goplantuml.NewClassDiagramWithOptions(&treeOptions)
+		if err != nil {
+			return false, err
+		}
+		for _, warning := range parser.Warnings() {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning.Error())
+		}
+		parsers = append(parsers, parser)
+	}
+	saveCache(options)
+	diff := goplantuml.CompareClassDiagrams(parsers[0], parsers[1])
+	render := func(_ *goplantuml.ClassParser, w io.Writer) error {
+		if format == "text" {
+			return diff.WriteText(w)
+		}
+		return diff.RenderTo(w)
+	}
+	var err error
+	if output != "" {
+		err = writeOutput(parsers[1], render, output, force)
+	} else {
+		err = render(parsers[1], os.Stdout)
+	}
+	return diff.HasChanges(), err
+}
@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	goplantuml "github.com/jfeliu007/goplantuml/parser"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
 )
 
 // RenderingOptionSlice will implements the sort interface
@@ -32,31 +39,215 @@ func (as RenderingOptionSlice) Swap(i, j int) {
 }
 
 func main() {
-	recursive := flag.Bool("recursive", false, "walk all directories recursively")
-	ignore := flag.String("ignore", "", "comma separated list of folders to ignore")
+	if len(os.Args) > 1 && os.Args[1] == checkCommand {
+		os.Exit(runCheck(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	recursive := flag.Bool("recursive", false, "walk all directories recursively (hidden, vendor and testdata directories are skipped)")
+	include := flag.String("include", "", "regular expression matched against package.TypeName. Only the matching types are rendered")
+	exclude := flag.String("exclude", "", "regular expression matched against package.TypeName. The matching types are not rendered. Applied after -include")
+	roots := flag.String("root", "", "comma separated list of types (e.g. store.Store) whose neighborhood, the types related to them in any direction, is the only part of the code rendered. Applied after -include and -exclude")
+	depth := flag.Int("depth", 0, "number of relationships followed from the -root types. Every type connected to them is rendered by default")
+	ignore := flag.String("ignore", "", "comma separated list of directories or glob patterns to skip when walking recursively, relative to the parsed directories (e.g. api/gen,third_party,*mocks)")
+	includeVendor := flag.Bool("include-vendor", false, "parse vendor directories when walking recursively")
+	includeTestdata := flag.Bool("include-testdata", false, "parse testdata directories when walking recursively")
+	followSymlinks := flag.Bool("follow-symlinks", false, "walk the symbolic links to directories when walking recursively. Every directory is parsed once, no matter how many links lead to it")
+	goos := flag.String("goos", "", "only parse the files built for the given operating system, following the file name suffixes and the build constraints (defaults to the current one when -goarch or -tags are used)")
+	goarch := flag.String("goarch", "", "only parse the files built for the given architecture (defaults to the current one when -goos or -tags are used)")
+	tags := flag.String("tags", "", "comma separated list of build tags used to evaluate the build constraints of the files. Every file is parsed when -goos, -goarch and -tags are not used")
+	workers := flag.Int("workers", 0, "number of directories parsed concurrently (defaults to the number of CPUs)")
+	useCache := flag.Bool("cache", false, "keep the types found in every directory in the user cache directory and reuse them in the next runs while the go files of the directory do not change")
+	methodSetName := flag.String("method-set", "pointer", "method set used to find the interfaces implemented by the types. Either pointer for the methods of *T, value for the methods of T only or both to label the implementations of *T only with *T")
+	includeEmptyInterfaces := flag.Bool("include-empty-interfaces", false, "show every type as an implementation of the interfaces without methods (e.g. type Marker interface{}), which are skipped by default since every type implements them")
+	ignorePromotedMethods := flag.Bool("ignore-promoted-methods", false, "only use the methods declared on a struct to find the interfaces it implements, not the ones promoted from embedded types")
+	ignoreConstructors := flag.Bool("ignore-constructors", false, "do not show package level constructor functions (e.g. NewFoo() *Foo) as static methods of the types they create")
+	includeFactories := flag.Bool("include-factories", false, "show any package level function returning a type of its package as a static method of that type. Ignored if -ignore-constructors is used")
+	anonymousStructClasses := flag.Bool("anonymous-struct-classes", false, "render the anonymous structs of struct fields as classes named after the struct and the field (e.g. Post.Meta) instead of inline")
+	aggregateChannels := flag.Bool("aggregate-channels", false, "show aggregations to the types sent through the channels of struct fields. Ignored if -show-aggregations is not used")
+	strict := flag.Bool("strict", false, "fail if a go file can not be parsed instead of skipping it")
+	verbose := flag.Bool("v", false, "print a warning with the position of every type expression that can not be rendered and is written as ?unknown? in the diagram")
+	importPaths := flag.Bool("import-paths", false, "use the import paths of the packages, read from their go.mod file, as namespaces so packages with the same name are not merged")
+	namespaceSegments := flag.Int("namespace-segments", 0, "shorten the namespaces to the given number of trailing import path elements. Namespaces that would collide keep more elements")
+	flat := flag.Bool("flat", false, "write the classes without namespaces, declared with their package qualified names (e.g. class \"parser.Struct\" as parser_Struct), for the renderers that do not support namespaces. -nested-namespaces and the namespace colors are ignored")
+	nestedNamespaces := flag.Bool("nested-namespaces", false, "render a namespace for every element of the package import paths, nested in the namespace of their parent. Best used with -import-paths")
+	namespaceSeparator := flag.String("namespace-separator", ".", "separator of the nested namespaces. Ignored if -nested-namespaces is not used")
+	packageColors := flag.String("package-colors", "", "comma separated list of package=color pairs to set the background color of the namespaces (e.g. models=#FFEEDD,store=LightBlue)")
+	autoColor := flag.Bool("auto-color", false, "give every namespace a light background color picked from the name of its package. Colors given in -package-colors take precedence")
+	includeGenerated := flag.Bool("include-generated", false, "parse generated files (files with a \"Code generated ... DO NOT EDIT.\" comment)")
+	includeTests := flag.Bool("include-tests", false, "parse the _test.go files too. Their types are rendered with the <<test>> stereotype and the external test packages (package foo_test) get their own namespace")
+	showPointerReceivers := flag.Bool("show-pointer-receivers", false, "mark the methods declared on a pointer receiver with «ptr» after their return values")
+	showDependencies := flag.Bool("show-dependencies", false, "render dashed dependency arrows to the parsed types used in method parameters and return values. Types already connected by other arrows are skipped")
+	showFunctions := flag.Bool("show-functions", false, "render the package level functions that are not constructors in a class named after their package")
 	showAggregations := flag.Bool("show-aggregations", false, "renders public aggregations even when -hide-connections is used (do not render by default)")
+	groupImplementations := flag.Bool("group-implementations", false, "render every interface and the types of its package that implement it in a together block so they are laid out near each other")
+	shortTypeNames := flag.Bool("short-type-names", false, "write the types of fields and methods qualified with an import path with their package name instead (e.g. s3.Client instead of github.com/aws/aws-sdk-go/service/s3.Client)")
+	maxTypeLength := flag.Int("max-type-length", 0, "write the types of fields and methods longer than the given number of characters without their package qualifiers and, if still too long, with an ellipsis in their middle (e.g. map[string][]*Gen…g, error]). The JSON export keeps the full types")
+	externalTypeNamesName := flag.String("external-type-names", "full", "how the types of the packages that were not parsed are written in fields and methods. Either full, base for only their name or ellipsis for ...")
+	externalTypesName := flag.String("external-types", "implicit", "how the relationships to the types of the packages that were not parsed are rendered. Either implicit to let PlantUML create their classes, drop to leave them out or stub to declare placeholder classes in an external namespace")
+	showEmbeddedFields := flag.Bool("show-embedded-fields", false, "list the embedded types among the fields of the structs that embed them (e.g. + «embed» *BaseHandler) besides the composition arrows")
+	showTags := flag.Bool("show-tags", false, "append the tags of the struct fields to the fields")
+	tagKey := flag.String("tag-key", "", "only append the value of the given key of the field tags (e.g. json). Ignored if -show-tags is not used")
 	hideFields := flag.Bool("hide-fields", false, "hides fields")
 	hideMethods := flag.Bool("hide-methods", false, "hides methods")
+	hideAbstractMethods := flag.Bool("hide-abstract-methods", false, "write the methods of the interfaces without the {abstract} modifier, which PlantUML writes in italics")
+	fieldLabelsName := flag.String("field-labels", "none", "label the aggregations with the names of the fields that reference the aggregated types. Either none, joined for a single aggregation labeled with the comma separated names of the fields or split for an aggregation for every field")
+	memberOrderName := flag.String("member-order", "visibility", "order of the fields and methods of every class. Either visibility for the private members before the public ones or source for the order of their declarations")
+	hideOrphans := flag.Bool("hide-orphans", false, "leave out the types without fields, methods and rendered connections")
+	listOrphans := flag.Bool("list-orphans", false, "print the types that -hide-orphans would leave out, one per line, instead of the diagram")
+	list := flag.Bool("list", false, "print the types of every package with the number of their fields, methods and relationships instead of the diagram, as a plain text table or, with -format json, as JSON")
 	hideConnections := flag.Bool("hide-connections", false, "hides all connections in the diagram")
 	showCompositions := flag.Bool("show-compositions", false, "Shows compositions even when -hide-connections is used")
 	showImplementations := flag.Bool("show-implementations", false, "Shows implementations even when -hide-connections is used")
 	showAliases := flag.Bool("show-aliases", false, "Shows aliases even when -hide-connections is used")
 	showConnectionLabels := flag.Bool("show-connection-labels", false, "Shows labels in the connections to identify the connections types (e.g. extends, implements, aggregates, alias of")
-	title := flag.String("title", "", "Title of the generated diagram")
+	title := flag.String("title", "", "Title of the generated diagram. auto uses the module path of the parsed code and the time it was generated")
+	header := flag.String("header", "", "header written on the top right corner of the diagram")
+	footer := flag.String("footer", "", "footer written on the bottom of the diagram")
+	linkTemplate := flag.String("link-template", "", "URL template to link every class to its source code. {path} is replaced by the path of the file relative to the parsed directory and {line} by the line of the declaration (e.g. https://github.com/org/repo/blob/main/{path}#L{line})")
+	preamble := flag.String("preamble", "", "file with lines, like skinparam directives, written as they are right after @startuml (e.g. style.iuml)")
 	notes := flag.String("notes", "", "Comma separated list of notes to be added to the diagram")
+	docNotes := flag.Bool("doc-notes", false, "Render the first sentence of the doc comment of every type as a note on top of it")
+	docNotesLength := flag.Int("doc-notes-length", 0, "Render the first given number of characters of the doc comments instead of their first sentence. Ignored if -doc-notes is not used")
 	output := flag.String("output", "", "output file path. If omitted, then this will default to standard output")
+	splitPackages := flag.Bool("split-by-package", false, "write the diagram of every package and an overview.puml diagram with the relationships among packages into the directory given in -output-dir")
+	outputDir := flag.String("output-dir", "", "directory the diagrams written by -split-by-package are written into")
+	format := flag.String("format", "puml", "output format. Either puml for a PlantUML class diagram, dot for a Graphviz digraph, json or yaml for the parsed structure, markdown for a document with a PlantUML diagram for each package, html for a single page with the diagram and a searchable index of the types or csv and tsv for a row per relationship")
+	htmlTemplate := flag.String("html-template", "", "html/template file used instead of the default page by -format html")
+	htmlImageLink := flag.Bool("html-image-link", false, "link the diagram of -format html as an image of -server instead of embedding the SVG rendered by it")
+	printURL := flag.Bool("url", false, "print a link to the diagram rendered as SVG by the PlantUML server instead of the diagram text")
+	renderTo := flag.String("render-to", "", "render the diagram with the PlantUML server into the given .svg or .png file")
+	server := flag.String("server", "https://www.plantuml.com/plantuml", "PlantUML server used by -url, -render-to, -serve and -format html")
+	timeout := flag.Duration("timeout", 30*time.Second, "maximum time to wait for the PlantUML server to render the diagram given in -render-to, served in /svg or embedded by -format html")
+	diff := flag.Bool("diff", false, "compare two directories, the old and the new version of the code, and write their structural differences as a diagram or, with -format text, as a summary. Exits with 1 if they are different")
+	serveAddress := flag.String("serve", "", "serve the diagram over HTTP on the given address (e.g. :8080) instead of writing it. GET /diagram returns the diagram and /svg the diagram rendered by the PlantUML server")
+	cacheTTL := flag.Duration("cache-ttl", 0, "time the code parsed by -serve is reused before it is parsed again. By default it is parsed for every request")
+	watchFiles := flag.Bool("watch", false, "keep running and regenerate the -output file every time a go file changes. Stop it with Ctrl-C")
+	force := flag.Bool("force", false, "overwrite the file given in -output if it already exists")
 	showOptionsAsNote := flag.Bool("show-options-as-note", false, "Show a note in the diagram with the none evident options ran with this CLI")
+	pointerAssociations := flag.Bool("pointer-associations", false, "render the fields holding a single pointer to a type (e.g. parent *Node) as 0..1 associations labeled with the field name instead of aggregations. Ignored if -show-aggregations is not used")
 	aggregatePrivateMembers := flag.Bool("aggregate-private-members", false, "Show aggregations for private members. Ignored if -show-aggregations is not used.")
+	exportedOnly := flag.Bool("exported-only", false, "Render only exported types and members. Exported members of unexported embedded types are shown in the types that embed them")
 	hidePrivateMembers := flag.Bool("hide-private-members", false, "Hide private fields and methods")
+	hidePrivateFields := flag.Bool("hide-private-fields", false, "Hide private fields")
+	hidePrivateMethods := flag.Bool("hide-private-methods", false, "Hide private methods. They are still used to find interface implementations")
+	configPath := flag.String("config", "", "YAML or JSON file with the values of the flags keyed by their names and the directories to parse. By default .goplantuml.yaml, .goplantuml.yml or .goplantuml.json is read from the first parsed directory. Flags given in the command line take precedence")
 	flag.Parse()
+	args, err := getArgs(flag.CommandLine, *configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
 	renderingOptions := map[goplantuml.RenderingOption]interface{}{
 		goplantuml.RenderConnectionLabels:  *showConnectionLabels,
 		goplantuml.RenderFields:            !*hideFields,
 		goplantuml.RenderMethods:           !*hideMethods,
+		goplantuml.RenderFunctions:         *showFunctions,
 		goplantuml.RenderAggregations:      *showAggregations,
 		goplantuml.RenderTitle:             *title,
+		goplantuml.RenderLinkTemplate:      *linkTemplate,
+		goplantuml.RenderExportedOnly:      *exportedOnly,
+		goplantuml.RenderDocNotes:          *docNotes,
+		goplantuml.RenderDocNotesLength:    *docNotesLength,
+		goplantuml.RenderNamespaceSegments: *namespaceSegments,
 		goplantuml.AggregatePrivateMembers: *aggregatePrivateMembers,
 		goplantuml.RenderPrivateMembers:    !*hidePrivateMembers,
+		goplantuml.RenderPrivateFields:     !*hidePrivateMembers && !*hidePrivateFields,
+		goplantuml.RenderPrivateMethods:    !*hidePrivateMembers && !*hidePrivateMethods,
+	}
+	methodSet, err := getMethodSet(*methodSetName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	externalNames, err := getExternalTypeNames(*externalTypeNamesName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if *shortTypeNames || externalNames != goplantuml.FullExternalTypeNames {
+		renderingOptions[goplantuml.RenderShortTypeNames] = *shortTypeNames
+		renderingOptions[goplantuml.RenderExternalTypeNames] = externalNames
+	}
+	memberOrder, err := getMemberOrder(*memberOrderName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if memberOrder != goplantuml.VisibilityMemberOrder {
+		renderingOptions[goplantuml.RenderMemberOrder] = memberOrder
+	}
+	fieldLabels, err := getFieldLabels(*fieldLabelsName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if fieldLabels != goplantuml.NoFieldLabels {
+		renderingOptions[goplantuml.RenderFieldLabels] = fieldLabels
+	}
+	externalTypes, err := getExternalTypes(*externalTypesName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if externalTypes != goplantuml.ImplicitExternalTypes {
+		renderingOptions[goplantuml.RenderExternalTypes] = externalTypes
+	}
+	if *groupImplementations {
+		renderingOptions[goplantuml.RenderGroupImplementations] = true
+	}
+	colors, err := getPackageColors(*packageColors)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if len(colors) > 0 || *autoColor {
+		renderingOptions[goplantuml.RenderPackageColors] = colors
+		renderingOptions[goplantuml.RenderAutoColors] = *autoColor
+	}
+	if *showDependencies {
+		renderingOptions[goplantuml.RenderDependencies] = true
+	}
+	if *showPointerReceivers {
+		renderingOptions[goplantuml.RenderPointerReceivers] = true
+	}
+	if *header != "" {
+		renderingOptions[goplantuml.RenderHeader] = *header
+	}
+	if *footer != "" {
+		renderingOptions[goplantuml.RenderFooter] = *footer
+	}
+	if *preamble != "" {
+		lines, err := getPreamble(*preamble)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		renderingOptions[goplantuml.RenderPreamble] = lines
+	}
+	if *hideAbstractMethods {
+		renderingOptions[goplantuml.RenderAbstractMethods] = false
+	}
+	if *hideOrphans {
+		renderingOptions[goplantuml.RenderHideOrphans] = true
+	}
+	if *pointerAssociations {
+		renderingOptions[goplantuml.RenderPointerAssociations] = true
+	}
+	if *maxTypeLength > 0 {
+		renderingOptions[goplantuml.RenderMaxTypeLength] = *maxTypeLength
+	}
+	if *showEmbeddedFields {
+		renderingOptions[goplantuml.RenderEmbeddedFields] = true
+	}
+	if *showTags {
+		renderingOptions[goplantuml.RenderTags] = true
+		renderingOptions[goplantuml.RenderTagKey] = *tagKey
+	}
+	if *flat {
+		renderingOptions[goplantuml.RenderFlat] = true
+	}
+	if *nestedNamespaces {
+		renderingOptions[goplantuml.RenderNestedNamespaces] = true
+		renderingOptions[goplantuml.RenderNamespaceSeparator] = *namespaceSeparator
 	}
 	if *hideConnections {
 		renderingOptions[goplantuml.RenderAliases] = *showAliases
@@ -84,79 +275,569 @@ func main() {
 		}
 	}
 	renderingOptions[goplantuml.RenderNotes] = strings.Join(noteList, "\n")
-	dirs, err := getDirectories()
-
+	render, err := getRenderer(*format)
+	if *format == "html" {
+		render, err = getHTMLRenderer(*server, *htmlTemplate, !*htmlImageLink, *timeout)
+	}
+	if *diff {
+		if err := validateDiffFlags(*format, *printURL || *renderTo != "" || *watchFiles); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
+		}
+		err = nil
+	}
 	if err != nil {
-		fmt.Println("usage:\ngoplantuml <DIR>\nDIR Must be a valid directory")
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
-	ignoredDirectories, err := getIgnoredDirectories(*ignore)
-	if err != nil {
+	if *printURL {
+		if *format != "puml" {
+			fmt.Fprintln(os.Stderr, "-url can only be used with -format puml")
+			os.Exit(1)
+		}
+		render = getURLRenderer(*server)
+	}
+	if *renderTo != "" {
+		if *format != "puml" || *printURL || *output != "" {
+			fmt.Fprintln(os.Stderr, "-render-to can not be used with -output, -url or -format")
+			os.Exit(1)
+		}
+		imageFormat := strings.ToLower(strings.TrimPrefix(filepath.Ext(*renderTo), "."))
+		if imageFormat != "svg" && imageFormat != "png" {
+			fmt.Fprintf(os.Stderr, "unknown image format for %s, it must be a .svg or .png file\n", *renderTo)
+			os.Exit(1)
+		}
+		render = getImageRenderer(*server, imageFormat, *timeout)
+		*output = *renderTo
+	}
+	dirs, files, err := getPaths(args)
 
-		fmt.Println("usage:\ngoplantuml [-ignore=<DIRLIST>]\nDIRLIST Must be a valid comma separated list of existing directories")
+	if err != nil {
+		fmt.Println("usage:\ngoplantuml <PATH>\nPATH Must be a valid directory or go file")
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
+	ignoredDirectories := getIgnoredDirectories(*ignore)
+	rootTypes := getRoots(*roots)
+	if *depth < 0 || (*depth > 0 && len(rootTypes) == 0) {
+		fmt.Fprintln(os.Stderr, "-depth must be a positive number used with -root")
+		os.Exit(1)
+	}
+	if *title == "auto" {
+		renderingOptions[goplantuml.RenderTitle] = getAutoTitle(append(dirs, files...), time.Now())
+	}
 
-	result, err := goplantuml.NewClassDiagram(dirs, ignoredDirectories, *recursive)
-	result.SetRenderingOptions(renderingOptions)
+	options := &goplantuml.ClassDiagramOptions{
+		FileSystem:            afero.NewOsFs(),
+		Directories:           dirs,
+		Files:                 files,
+		IgnoredDirectories:    ignoredDirectories,
+		Recursive:             *recursive,
+		IncludeVendor:         *includeVendor,
+		IncludeTestdata:       *includeTestdata,
+		FollowSymlinks:        *followSymlinks,
+		IncludeGenerated:      *includeGenerated,
+		IgnorePromotedMethods: *ignorePromotedMethods,
+		Workers:               *workers,
+		Include:               *include,
+		Exclude:               *exclude,
+		Roots:                 rootTypes,
+		Depth:                 *depth,
+		IgnoreConstructors:    *ignoreConstructors,
+		IncludeFactories:      *includeFactories,
+		ImportPaths:           *importPaths,
+		Strict:                *strict,
+		AggregateChannels:     *aggregateChannels,
+		MethodSet:             methodSet,
+		RenderingOptions:      renderingOptions,
+	}
+	options.AnonymousStructClasses = *anonymousStructClasses
+	options.IncludeEmptyInterfaces = *includeEmptyInterfaces
+	options.IncludeTests = *includeTests
+	options.Verbose = *verbose
+	options.BuildContext = getBuildContext(*goos, *goarch, *tags)
+	options.Cache, err = getCache(*useCache, *serveAddress != "" || *watchFiles)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
-	rendered := result.Render()
-	var writer io.Writer
-	if *output != "" {
-		writer, err = os.Create(*output)
+	if *listOrphans {
+		if *serveAddress != "" || *diff || *watchFiles || *printURL || *renderTo != "" {
+			fmt.Fprintln(os.Stderr, "-list-orphans can not be used with -serve, -diff, -watch, -url or -render-to")
+			os.Exit(1)
+		}
+		render = renderOrphans
+	}
+	if *list {
+		if *listOrphans || *serveAddress != "" || *diff || *watchFiles || *printURL || *renderTo != "" {
+			fmt.Fprintln(os.Stderr, "-list can not be used with -list-orphans, -serve, -diff, -watch, -url or -render-to")
+			os.Exit(1)
+		}
+		switch *format {
+		case "puml":
+			render = (*goplantuml.ClassParser).RenderSummaryTo
+		case "json":
+			render = renderSummaryJSON
+		default:
+			fmt.Fprintln(os.Stderr, "-list can only be used with -format json")
+			os.Exit(1)
+		}
+	}
+	if *splitPackages {
+		if *outputDir == "" {
+			fmt.Fprintln(os.Stderr, "-split-by-package requires -output-dir")
+			os.Exit(1)
+		}
+		if *format != "puml" || *output != "" || *listOrphans || *list || *serveAddress != "" || *diff || *watchFiles || *printURL || *renderTo != "" {
+			fmt.Fprintln(os.Stderr, "-split-by-package can not be used with -format, -output, -list-orphans, -list, -serve, -diff, -watch, -url or -render-to")
+			os.Exit(1)
+		}
+		if err := splitByPackage(options, *outputDir, *force); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	if *serveAddress != "" {
+		if *output != "" || *watchFiles || *diff || *printURL || *renderTo != "" {
+			fmt.Fprintln(os.Stderr, "-serve can not be used with -output, -watch, -diff, -url or -render-to")
+			os.Exit(1)
+		}
+		if err := serve(*serveAddress, options, *server, *cacheTTL, *timeout); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	if *diff {
+		changed, err := runDiff(options, *format, *output, *force)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
+		}
+		if changed {
+			os.Exit(1)
+		}
+		return
+	}
+	if *watchFiles {
+		if *output == "" {
+			fmt.Fprintln(os.Stderr, "-watch requires -output")
+			os.Exit(1)
+		}
+		written := false
+		watch(options, func() error {
+			// The output file is always overwritten once it was written by this process
+			err := generate(options, render, *output, *force || written)
+			written = written || err == nil
+			return err
+		})
+		return
+	}
+	if err := generate(options, render, *output, *force); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+// generate parses the code with the given options and renders it into the output file or the standard output if
+// no output file is given
+func generate(options *goplantuml.ClassDiagramOptions, render renderer, output string, force bool) error {
+	result, err := goplantuml.NewClassDiagramWithOptions(options)
+	if err != nil {
+		return err
+	}
+	for _, warning := range result.Warnings() {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning.Error())
+	}
+	saveCache(options)
+	if output != "" {
+		return writeOutput(result, render, output, force)
+	}
+	return render(result, os.Stdout)
+}
+
+// getCache returns the cache of the parsed directories. The -cache flag uses the one saved in the user cache
+// directory, while serving or watching the directories are cached in memory anyway since they are parsed again and
+// again. Nothing is cached otherwise.
+func getCache(useCache bool, longRunning bool) (*goplantuml.Cache, error) {
+	if useCache {
+		path, err := goplantuml.DefaultCachePath()
+		if err != nil {
+			return nil, err
+		}
+		return goplantuml.OpenCache(path)
+	}
+	if longRunning {
+		return goplantuml.NewCache(), nil
+	}
+	return nil, nil
+}
+
+// saveCache saves the cache of the parsed directories, if any, printing a warning if it can not be saved since the
+// diagram is still right
+func saveCache(options *goplantuml.ClassDiagramOptions) {
+	if options.Cache == nil {
+		return
+	}
+	if err := options.Cache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: the cache could not be saved: %s\n", err.Error())
+	}
+}
+
+// renderer writes the parsed structure into the given writer in one of the supported output formats
+type renderer func(result *goplantuml.ClassParser, w io.Writer) error
+
+// getRenderer returns the renderer for the given -format value
+func getRenderer(format string) (renderer, error) {
+	switch format {
+	case "puml":
+		return (*goplantuml.ClassParser).RenderTo, nil
+	case "json":
+		return renderJSON, nil
+	case "yaml":
+		return renderYAML, nil
+	case "dot":
+		return (*goplantuml.ClassParser).RenderDOTTo, nil
+	case "markdown":
+		return (*goplantuml.ClassParser).RenderMarkdownTo, nil
+	case "csv":
+		return (*goplantuml.ClassParser).RenderCSVTo, nil
+	case "tsv":
+		return (*goplantuml.ClassParser).RenderTSVTo, nil
+	}
+	return nil, fmt.Errorf("unknown format %s, it must be puml, json, yaml, dot, markdown, html, csv or tsv", format)
+}
+
+// getURLRenderer returns a renderer that writes the link to the SVG diagram in the given PlantUML server. A warning
+// is printed when the link is longer than what most servers accept.
+func getURLRenderer(server string) renderer {
+	return func(result *goplantuml.ClassParser, w io.Writer) error {
+		url, err := result.EncodeURL(strings.TrimSuffix(server, "/") + "/svg")
+		if err != nil {
+			return err
+		}
+		if len(url) > goplantuml.MaxURLLength {
+			fmt.Fprintf(os.Stderr, "warning: the URL is %d characters long and most PlantUML servers only accept up to %d\n", len(url), goplantuml.MaxURLLength)
+		}
+		_, err = fmt.Fprintln(w, url)
+		return err
+	}
+}
+
+// getImageRenderer returns a renderer that writes the image of the diagram rendered by the given PlantUML server.
+// The request is cancelled after the given timeout or when the process is interrupted.
+func getImageRenderer(server string, format string, timeout time.Duration) renderer {
+	return func(result *goplantuml.ClassParser, w io.Writer) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return result.RenderImage(ctx, server, format, w)
+	}
+}
+
+// getHTMLRenderer returns a renderer that writes the HTML report of the diagram with the given template file, or
+// the default one if it is empty. The SVG embedded in the report is rendered by the given PlantUML server, see
+// getImageRenderer.
+func getHTMLRenderer(server string, templatePath string, inlineSVG bool, timeout time.Duration) (renderer, error) {
+	options := &goplantuml.HTMLOptions{ServerBase: server, InlineSVG: inlineSVG}
+	if templatePath != "" {
+		text, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, err
+		}
+		options.Template, err = goplantuml.NewHTMLTemplate(string(text))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return func(result *goplantuml.ClassParser, w io.Writer) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return result.RenderHTMLTo(ctx, w, options)
+	}, nil
+}
+
+// renderOrphans writes the orphan types, one per line
+func renderOrphans(result *goplantuml.ClassParser, w io.Writer) error {
+	for _, orphan := range result.Orphans() {
+		if _, err := fmt.Fprintln(w, orphan); err != nil {
+			return err
 		}
-	} else {
-		writer = os.Stdout
 	}
-	fmt.Fprint(writer, rendered)
+	return nil
+}
+
+// renderSummaryJSON writes the summary of the types of every package as JSON
+func renderSummaryJSON(result *goplantuml.ClassParser, w io.Writer) error {
+	exported, err := result.ExportSummaryJSON()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(exported)
+	return err
+}
+
+func renderJSON(result *goplantuml.ClassParser, w io.Writer) error {
+	exported, err := result.ExportJSON()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(exported)
+	return err
+}
+
+func renderYAML(result *goplantuml.ClassParser, w io.Writer) error {
+	exported, err := result.ExportYAML()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(exported)
+	return err
+}
+
+// writeOutput renders the diagram into a temporary file next to the output path and then renames it, so the
+// output file is never left half written. Parent directories are created if needed.
+func writeOutput(result *goplantuml.ClassParser, render renderer, output string, force bool) error {
+	if _, err := os.Stat(output); err == nil && !force {
+		return fmt.Errorf("could not write %s: the file already exists, use -force to overwrite it", output)
+	}
+	dir := filepath.Dir(output)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not write %s: %w", output, err)
+	}
+	file, err := os.CreateTemp(dir, "."+filepath.Base(output)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("could not write %s: %w", output, err)
+	}
+	defer os.Remove(file.Name())
+	err = render(result, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), output)
+	}
+	if err != nil {
+		return fmt.Errorf("could not write %s: %w", output, err)
+	}
+	return nil
 }
 
-func getDirectories() ([]string, error) {
+// getPaths returns the directories and the go files of the given arguments
+func getPaths(args []string) ([]string, []string, error) {
 
-	args := flag.Args()
 	if len(args) < 1 {
-		return nil, errors.New("DIR missing")
+		return nil, nil, errors.New("PATH missing")
 	}
 	dirs := []string{}
-	for _, dir := range args {
-		fi, err := os.Stat(dir)
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("could not find directory %s", dir)
-		}
-		if !fi.Mode().IsDir() {
-			return nil, fmt.Errorf("%s is not a directory", dir)
+	files := []string{}
+	for _, path := range args {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not find %s", path)
 		}
-		dirAbs, err := filepath.Abs(dir)
+		pathAbs, err := filepath.Abs(path)
 		if err != nil {
-			return nil, fmt.Errorf("could not find directory %s", dir)
+			return nil, nil, fmt.Errorf("could not find %s", path)
 		}
-		dirs = append(dirs, dirAbs)
+		switch {
+		case fi.Mode().IsDir():
+			dirs = append(dirs, pathAbs)
+		case filepath.Ext(path) == ".go":
+			files = append(files, pathAbs)
+		default:
+			return nil, nil, fmt.Errorf("%s is not a directory or a go file", path)
+		}
+	}
+	return dirs, files, nil
+}
+
+// getAutoTitle returns the title used by -title auto, the module path of the first parsed path, or its name when
+// it is not part of a module, and the given generation time
+func getAutoTitle(paths []string, now time.Time) string {
+	name := ""
+	if len(paths) > 0 {
+		dir := paths[0]
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+		name = goplantuml.ModulePath(afero.NewOsFs(), dir)
+		if name == "" {
+			if abs, err := filepath.Abs(dir); err == nil {
+				name = filepath.Base(abs)
+			}
+		}
+	}
+	return fmt.Sprintf("%s\ngenerated on %s", name, now.Format("2006-01-02 15:04"))
+}
+
+// getPreamble returns the lines of the given -preamble file
+func getPreamble(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the preamble: %w", err)
+	}
+	text := strings.TrimRight(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+// methodSets are the values of -method-set
+var methodSets = map[string]goplantuml.MethodSet{
+	"pointer": goplantuml.PointerMethodSet,
+	"value":   goplantuml.ValueMethodSet,
+	"both":    goplantuml.BothMethodSets,
+}
+
+// getMethodSet returns the method set given in -method-set
+func getMethodSet(name string) (goplantuml.MethodSet, error) {
+	methodSet, ok := methodSets[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown method set %s, it must be pointer, value or both", name)
+	}
+	return methodSet, nil
+}
+
+// colorRegexp matches the colors PlantUML accepts: hexadecimal RGB values and color names
+var colorRegexp = regexp.MustCompile(`^#?([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6}|[A-Za-z]+)$`)
+
+// externalTypeNames are the values of -external-type-names
+var externalTypeNames = map[string]goplantuml.ExternalTypeNames{
+	"full":     goplantuml.FullExternalTypeNames,
+	"base":     goplantuml.BaseExternalTypeNames,
+	"ellipsis": goplantuml.ElidedExternalTypeNames,
+}
+
+// getExternalTypeNames returns the ExternalTypeNames for the given -external-type-names value
+func getExternalTypeNames(name string) (goplantuml.ExternalTypeNames, error) {
+	names, ok := externalTypeNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown external type names %s, it must be full, base or ellipsis", name)
 	}
-	return dirs, nil
+	return names, nil
+}
+
+// memberOrders are the values of -member-order
+var memberOrders = map[string]goplantuml.MemberOrder{
+	"visibility": goplantuml.VisibilityMemberOrder,
+	"source":     goplantuml.SourceMemberOrder,
 }
 
-func getIgnoredDirectories(list string) ([]string, error) {
+// getMemberOrder returns the MemberOrder for the given -member-order value
+func getMemberOrder(name string) (goplantuml.MemberOrder, error) {
+	order, ok := memberOrders[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown member order %s, it must be visibility or source", name)
+	}
+	return order, nil
+}
+
+// fieldLabels are the values of -field-labels
+var fieldLabels = map[string]goplantuml.FieldLabels{
+	"none":   goplantuml.NoFieldLabels,
+	"joined": goplantuml.JoinedFieldLabels,
+	"split":  goplantuml.SplitFieldLabels,
+}
+
+// getFieldLabels returns the FieldLabels for the given -field-labels value
+func getFieldLabels(name string) (goplantuml.FieldLabels, error) {
+	labels, ok := fieldLabels[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown field labels %s, it must be none, joined or split", name)
+	}
+	return labels, nil
+}
+
+// externalTypes are the values of -external-types
+var externalTypes = map[string]goplantuml.ExternalTypes{
+	"implicit": goplantuml.ImplicitExternalTypes,
+	"drop":     goplantuml.DropExternalTypes,
+	"stub":     goplantuml.StubExternalTypes,
+}
+
+// getExternalTypes returns the ExternalTypes for the given -external-types value
+func getExternalTypes(name string) (goplantuml.ExternalTypes, error) {
+	types, ok := externalTypes[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown external types %s, it must be implicit, drop or stub", name)
+	}
+	return types, nil
+}
+
+// getPackageColors returns the colors of the -package-colors list by package
+func getPackageColors(list string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pack, color, ok := strings.Cut(entry, "=")
+		pack, color = strings.TrimSpace(pack), strings.TrimSpace(color)
+		if !ok || pack == "" || !colorRegexp.MatchString(color) {
+			return nil, fmt.Errorf("invalid package color %s, it must be package=color with a color like #FFEEDD or LightBlue", entry)
+		}
+		result[pack] = color
+	}
+	return result, nil
+}
+
+// getRoots returns the types of the -root list
+func getRoots(list string) []string {
+	var result []string
+	for _, root := range strings.Split(list, ",") {
+		if root = strings.TrimSpace(root); root != "" {
+			result = append(result, root)
+		}
+	}
+	return result
+}
+
+// getBuildContext returns the build context of the -goos, -goarch and -tags flags or nil if none is used
+func getBuildContext(goos string, goarch string, tags string) *goplantuml.BuildContext {
+	if goos == "" && goarch == "" && strings.TrimSpace(tags) == "" {
+		return nil
+	}
+	buildContext := &goplantuml.BuildContext{GOOS: goos, GOARCH: goarch}
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			buildContext.Tags = append(buildContext.Tags, tag)
+		}
+	}
+	return buildContext
+}
+
+// getIgnoredDirectories returns the entries of the -ignore list. They are matched against the paths relative to the
+// parsed directories, and entries that are existing directories are ignored by their absolute path as well.
+func getIgnoredDirectories(list string) []string {
 	result := []string{}
 	list = strings.TrimSpace(list)
 	if list == "" {
-		return result, nil
+		return result
 	}
 	split := strings.Split(list, ",")
 	for _, dir := range split {
-		dirAbs, err := filepath.Abs(strings.TrimSpace(dir))
-		if err != nil {
-			return nil, fmt.Errorf("could not find directory %s", dir)
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		result = append(result, dir)
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			if dirAbs, err := filepath.Abs(dir); err == nil {
+				result = append(result, dirAbs)
+			}
 		}
-		result = append(result, dirAbs)
 	}
-	return result, nil
+	return result
 }
 
 func getLegend(ro map[goplantuml.RenderingOption]interface{}) (string, error) {
@@ -183,6 +864,18 @@ func getLegend(ro map[goplantuml.RenderingOption]interface{}) (string, error) {
 			result = fmt.Sprintf("%sRender Methods: %t\n", result, val.(bool))
 		case goplantuml.AggregatePrivateMembers:
 			result = fmt.Sprintf("%sPritave Aggregations: %t\n", result, val.(bool))
+		case goplantuml.RenderPrivateFields:
+			result = fmt.Sprintf("%sRender Private Fields: %t\n", result, val.(bool))
+		case goplantuml.RenderPrivateMethods:
+			result = fmt.Sprintf("%sRender Private Methods: %t\n", result, val.(bool))
+		case goplantuml.RenderExportedOnly:
+			result = fmt.Sprintf("%sExported Only: %t\n", result, val.(bool))
+		case goplantuml.RenderDocNotes:
+			result = fmt.Sprintf("%sDoc Notes: %t\n", result, val.(bool))
+		case goplantuml.RenderFunctions:
+			result = fmt.Sprintf("%sRender Functions: %t\n", result, val.(bool))
+		case goplantuml.RenderHideOrphans:
+			result = fmt.Sprintf("%sHide Orphans: %t\n", result, val.(bool))
 		}
 	}
 	return strings.TrimSpace(result), nil
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	goplantuml "github.com/jfeliu007/goplantuml/parser"
+)
+
+// diagramContentTypes are the content types of the formats served by /diagram
+var diagramContentTypes = map[string]string{
+	"puml":     "text/plain; charset=utf-8",
+	"dot":      "text/vnd.graphviz; charset=utf-8",
+	"json":     "application/json",
+	"yaml":     "application/yaml",
+	"markdown": "text/markdown; charset=utf-8",
+	"csv":      "text/csv; charset=utf-8",
+	"tsv":      "text/tab-separated-values; charset=utf-8",
+}
+
+// queryRenderingOptions are the boolean query parameters of the served diagrams and the function that sets them
+// into the rendering options
+var queryRenderingOptions = map[string]func(ro map[goplantuml.RenderingOption]interface{}, val bool){
+	"hide-private": func(ro map[goplantuml.RenderingOption]interface{}, val bool) {
+		ro[goplantuml.RenderPrivateMembers] = !val
+		ro[goplantuml.RenderPrivateFields] = !val
+		ro[goplantuml.RenderPrivateMethods] = !val
+	},
+	"hide-fields":       func(ro map[goplantuml.RenderingOption]interface{}, val bool) { ro[goplantuml.RenderFields] = !val },
+	"hide-methods":      func(ro map[goplantuml.RenderingOption]interface{}, val bool) { ro[goplantuml.RenderMethods] = !val },
+	"exported-only":     func(ro map[goplantuml.RenderingOption]interface{}, val bool) { ro[goplantuml.RenderExportedOnly] = val },
+	"show-aggregations": func(ro map[goplantuml.RenderingOption]interface{}, val bool) { ro[goplantuml.RenderAggregations] = val },
+}
+
+// cachedParser is a parsed tree and when it was parsed
+type cachedParser struct {
+	parser   *goplantuml.ClassParser
+	parsedAt time.Time
+}
+
+// diagramServer serves the diagram of the parsed directories. The code is parsed again for every request unless
+// a cache TTL is given. Parsing and rendering happen one request at a time since the parsers are shared, while the
+// PlantUML server is called without holding the lock.
+type diagramServer struct {
+	options  goplantuml.ClassDiagramOptions
+	server   string
+	cacheTTL time.Duration
+	timeout  time.Duration
+	mutex    sync.Mutex
+	cache    map[string]*cachedParser
+}
+
+// serve starts the HTTP server on the given address until the process is interrupted
+func serve(address string, options *goplantuml.ClassDiagramOptions, server string, cacheTTL time.Duration, timeout time.Duration) error {
+	s := &diagramServer{
+		options:  *options,
+		server:   server,
+		cacheTTL: cacheTTL,
+		timeout:  timeout,
+		cache:    map[string]*cachedParser{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/diagram", s.handleDiagram)
+	mux.HandleFunc("/svg", s.handleSVG)
+	httpServer := &http.Server{Addr: address, Handler: mux}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+	fmt.Printf("serving the diagram on %s/diagram and %s/svg\n", address, address)
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// handleDiagram writes the diagram in the format given by the format query parameter, puml by default
+func (s *diagramServer) handleDiagram(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "puml"
+	}
+	render, err := getRenderer(format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, status, err := s.render(r.Context(), r.URL.Query(), render)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", diagramContentTypes[format])
+	w.Write(result)
+}
+
+// handleSVG writes the diagram rendered as SVG by the PlantUML server
+func (s *diagramServer) handleSVG(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	text, status, err := s.render(r.Context(), r.URL.Query(), (*goplantuml.ClassParser).RenderTo)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+	image := &bytes.Buffer{}
+	if err := goplantuml.RenderPlantUMLImage(ctx, s.server, "svg", string(text), image); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(image.Bytes())
+}
+
+// render parses the code, or takes it from the cache, and renders it with the rendering options changed by the
+// given query. Parsing stops when the request is canceled. It returns the HTTP status to use when it fails.
+func (s *diagramServer) render(ctx context.Context, query url.Values, render renderer) ([]byte, int, error) {
+	renderingOptions := map[goplantuml.RenderingOption]interface{}{}
+	for option, val := range s.options.RenderingOptions {
+		renderingOptions[option] = val
+	}
+	for name, set := range queryRenderingOptions {
+		if value := query.Get(name); value != "" {
+			val, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, http.StatusBadRequest, fmt.Errorf("invalid value %s for %s", value, name)
+			}
+			set(renderingOptions, val)
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	parser, err := s.getParser(ctx, query.Get("include"), query.Get("exclude"))
+	if err != nil && ctx.Err() != nil {
+		return nil, http.StatusServiceUnavailable, err
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := parser.SetRenderingOptions(renderingOptions); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	result := &bytes.Buffer{}
+	if err := render(parser, result); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return result.Bytes(), http.StatusOK, nil
+}
+
+// getParser returns the code parsed with the given include and exclude expressions. The parsers are cached for the
+// cache TTL. It must be called holding the lock.
+func (s *diagramServer) getParser(ctx context.Context, include string, exclude string) (*goplantuml.ClassParser, error) {
+	key := include + "\x00" + exclude
+	if cached, ok := s.cache[key]; ok && time.Since(cached.parsedAt) < s.cacheTTL {
+		return cached.parser, nil
+	}
+	options := s.options
+	if include != "" {
+		options.Include = include
+	}
+	if exclude != "" {
+		options.Exclude = exclude
+	}
+	parser, err := goplantuml.NewClassDiagramCtx(ctx, &options)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range parser.Warnings() {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning.Error())
+	}
+	saveCache(&options)
+	if s.cacheTTL > 0 {
+		s.cache[key] = &cachedParser{parser: parser, parsedAt: time.Now()}
+	}
+	return parser, nil
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	goplantuml "github.com/jfeliu007/goplantuml/parser"
+	"github.com/spf13/afero"
+)
+
+// newTestServer returns a diagramServer of the given directory without a cache TTL
+func newTestServer(directory string) *diagramServer {
+	return &diagramServer{
+		options: goplantuml.ClassDiagramOptions{
+			FileSystem:       afero.NewOsFs(),
+			Directories:      []string{directory},
+			Recursive:        true,
+			RenderingOptions: map[goplantuml.RenderingOption]interface{}{},
+		},
+		timeout: time.Second,
+		cache:   map[string]*cachedParser{},
+	}
+}
+
+func TestHandleDiagram(t *testing.T) {
+	tt := []struct {
+		Name                string
+		Method              string
+		Query               string
+		ExpectedStatus      int
+		ExpectedContentType string
+		Expected            string
+		Unexpected          string
+	}{
+		{
+			Name:                "Default format",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "text/plain; charset=utf-8",
+			Expected:            "class Store << (S,Aquamarine) >> {",
+		},
+		{
+			Name:                "Hidden fields",
+			Query:               "hide-fields=true",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "text/plain; charset=utf-8",
+			Expected:            "hide fields",
+		},
+		{
+			Name:                "Exported only",
+			Query:               "exported-only=1",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "text/plain; charset=utf-8",
+			Expected:            "class Store",
+			Unexpected:          "entities",
+		},
+		{
+			Name:                "Include",
+			Query:               `include=^domain\.`,
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "text/plain; charset=utf-8",
+			Expected:            "interface Repository",
+			Unexpected:          "Store",
+		},
+		{
+			Name:                "Exclude",
+			Query:               "exclude=Legacy",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "text/plain; charset=utf-8",
+			Expected:            "class Store",
+			Unexpected:          "LegacyStore",
+		},
+		{
+			Name:                "JSON",
+			Query:               "format=json",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "application/json",
+			Expected:            `"name": "Repository"`,
+		},
+		{
+			Name:                "YAML",
+			Query:               "format=yaml",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "application/yaml",
+			Expected:            "name: Repository",
+		},
+		{
+			Name:                "DOT",
+			Query:               "format=dot",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "text/vnd.graphviz; charset=utf-8",
+			Expected:            `digraph "classes" {`,
+		},
+		{
+			Name:                "Markdown",
+			Query:               "format=markdown",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "text/markdown; charset=utf-8",
+			Expected:            "```plantuml",
+		},
+		{
+			Name:                "CSV",
+			Query:               "format=csv",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "text/csv; charset=utf-8",
+			Expected:            "source_package,source_type,target_package,target_type,kind,label",
+		},
+		{
+			Name:                "TSV",
+			Query:               "format=tsv",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "text/tab-separated-values; charset=utf-8",
+			Expected:            "source_package\tsource_type",
+		},
+		{
+			Name:           "Unknown format",
+			Query:          "format=pdf",
+			ExpectedStatus: http.StatusBadRequest,
+			Expected:       "unknown format pdf",
+		},
+		{
+			Name:           "Invalid boolean",
+			Query:          "hide-methods=maybe",
+			ExpectedStatus: http.StatusBadRequest,
+			Expected:       "invalid value maybe for hide-methods",
+		},
+		{
+			Name:           "Invalid include",
+			Query:          "include=(",
+			ExpectedStatus: http.StatusBadRequest,
+			Expected:       "invalid include expression",
+		},
+		{
+			Name:           "Method not allowed",
+			Method:         http.MethodPost,
+			ExpectedStatus: http.StatusMethodNotAllowed,
+			Expected:       "method not allowed",
+		},
+	}
+	s := newTestServer("../../testingsupport/crosspackage")
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			method := tc.Method
+			if method == "" {
+				method = http.MethodGet
+			}
+			request := httptest.NewRequest(method, "/diagram?"+tc.Query, nil)
+			recorder := httptest.NewRecorder()
+			s.handleDiagram(recorder, request)
+			if recorder.Code != tc.ExpectedStatus {
+				t.Fatalf("Expected the status %d, got %d: %s", tc.ExpectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if contentType := recorder.Header().Get("Content-Type"); tc.ExpectedContentType != "" && contentType != tc.ExpectedContentType {
+				t.Errorf("Expected the content type %s, got %s", tc.ExpectedContentType, contentType)
+			}
+			body := recorder.Body.String()
+			if !strings.Contains(body, tc.Expected) {
+				t.Errorf("Expected the response to contain %q, got\n%s", tc.Expected, body)
+			}
+			if tc.Unexpected != "" && strings.Contains(body, tc.Unexpected) {
+				t.Errorf("Expected the response not to contain %q, got\n%s", tc.Unexpected, body)
+			}
+		})
+	}
+}
+
+func TestHandleDiagramCanceled(t *testing.T) {
+	s := newTestServer("../../testingsupport/crosspackage")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request := httptest.NewRequest(http.MethodGet, "/diagram", nil).WithContext(ctx)
+	recorder := httptest.NewRecorder()
+	s.handleDiagram(recorder, request)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the status %d for a canceled request, got %d: %s", http.StatusServiceUnavailable, recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestHandleSVG(t *testing.T) {
+	var received string
+	plantUML := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/svg" {
+			w.Header().Set("X-PlantUML-Diagram-Error", "Syntax Error?")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		io.WriteString(w, "<svg></svg>")
+	}))
+	defer plantUML.Close()
+
+	s := newTestServer("../../testingsupport/crosspackage")
+	s.server = plantUML.URL
+	recorder := httptest.NewRecorder()
+	s.handleSVG(recorder, httptest.NewRequest(http.MethodGet, "/svg?hide-methods=true", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected the status %d, got %d: %s", http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "image/svg+xml" {
+		t.Errorf("Expected the content type image/svg+xml, got %s", contentType)
+	}
+	if recorder.Body.String() != "<svg></svg>" {
+		t.Errorf("Expected the image of the PlantUML server, got %s", recorder.Body.String())
+	}
+	if !strings.Contains(received, "class Store") || !strings.Contains(received, "hide methods") {
+		t.Errorf("Expected the diagram with the query options to be sent to the PlantUML server, got\n%s", received)
+	}
+
+	s.server = plantUML.URL + "/broken"
+	recorder = httptest.NewRecorder()
+	s.handleSVG(recorder, httptest.NewRequest(http.MethodGet, "/svg", nil))
+	if recorder.Code != http.StatusBadGateway || !strings.Contains(recorder.Body.String(), "Syntax Error?") {
+		t.Errorf("Expected the status %d with the error of the PlantUML server, got %d: %s", http.StatusBadGateway, recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	s.handleSVG(recorder, httptest.NewRequest(http.MethodPut, "/svg", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected the status %d, got %d", http.StatusMethodNotAllowed, recorder.Code)
+	}
+}
+
+func TestServeCacheTTL(t *testing.T) {
+	dir := t.TempDir()
+	writeSource := func(src string) {
+		if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get := func(s *diagramServer, query string) string {
+		recorder := httptest.NewRecorder()
+		s.handleDiagram(recorder, httptest.NewRequest(http.MethodGet, "/diagram?"+query, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("Expected the status %d, got %d: %s", http.StatusOK, recorder.Code, recorder.Body.String())
+		}
+		return recorder.Body.String()
+	}
+	writeSource("package models\n\ntype User struct{}\n")
+	cached := newTestServer(dir)
+	cached.cacheTTL = time.Hour
+	uncached := newTestServer(dir)
+	get(cached, "")
+	get(uncached, "")
+
+	writeSource("package models\n\ntype User struct{}\n\ntype Group struct{}\n")
+	if result := get(cached, "hide-fields=true"); strings.Contains(result, "Group") || !strings.Contains(result, "hide fields") {
+		t.Errorf("Expected the cached code to be rendered with the options of the query, got\n%s", result)
+	}
+	if result := get(cached, "exclude=Admin"); !strings.Contains(result, "Group") {
+		t.Errorf("Expected the code to be parsed again for other filters, got\n%s", result)
+	}
+	if result := get(uncached, ""); !strings.Contains(result, "Group") {
+		t.Errorf("Expected the code to be parsed again without a cache TTL, got\n%s", result)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	goplantuml "github.com/jfeliu007/goplantuml/parser"
+)
+
+// overviewFileName is the file with the overview diagram written by -split-by-package
+const overviewFileName = "overview.puml"
+
+// unsafeFileNameCharacters matches the characters of the package names that are replaced in their file names
+var unsafeFileNameCharacters = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// splitByPackage parses the code and writes the diagram of every package and the overview diagram with the
+// relationships among them into the given directory
+func splitByPackage(options *goplantuml.ClassDiagramOptions, dir string, force bool) error {
+	result, err := goplantuml.NewClassDiagramWithOptions(options)
+	if err != nil {
+		return err
+	}
+	for _, warning := range result.Warnings() {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning.Error())
+	}
+	saveCache(options)
+	if err := writeOutput(result, (*goplantuml.ClassParser).RenderOverviewTo, filepath.Join(dir, overviewFileName), force); err != nil {
+		return err
+	}
+	used := map[string]struct{}{overviewFileName: {}}
+	for _, pack := range result.Packages() {
+		if len(result.Structs(pack)) == 0 {
+			continue
+		}
+		pack := pack
+		render := func(result *goplantuml.ClassParser, w io.Writer) error {
+			return result.RenderPackageTo(w, pack)
+		}
+		if err := writeOutput(result, render, filepath.Join(dir, getPackageFileName(pack, used)), force); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getPackageFileName returns the name of the file with the diagram of the given package, its name with the
+// characters that are not safe in file names replaced. Names already used get a numeric suffix.
+func getPackageFileName(pack string, used map[string]struct{}) string {
+	base := unsafeFileNameCharacters.ReplaceAllString(pack, "_")
+	name := base + ".puml"
+	for i := 2; ; i++ {
+		if _, ok := used[name]; !ok {
+			break
+		}
+		name = fmt.Sprintf("%s_%d.puml", base, i)
+	}
+	used[name] = struct{}{}
+	return name
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestGetPackageFileName(t *testing.T) {
+	used := map[string]struct{}{overviewFileName: {}}
+	tt := []struct {
+		Package  string
+		Expected string
+	}{
+		{Package: "models", Expected: "models.puml"},
+		{Package: "example.com/repo/models", Expected: "example.com_repo_models.puml"},
+		{Package: "example.com/repo:models", Expected: "example.com_repo_models_2.puml"},
+		{Package: "overview", Expected: "overview_2.puml"},
+	}
+	for _, tc := range tt {
+		if result := getPackageFileName(tc.Package, used); result != tc.Expected {
+			t.Errorf("Expected %s for %s, got %s", tc.Expected, tc.Package, result)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"time"
+
+	goplantuml "github.com/jfeliu007/goplantuml/parser"
+	"github.com/spf13/afero"
+)
+
+// watchInterval is how often the watched go files are checked for changes. Changes are only handled once the
+// files stop changing for a whole interval so several files saved at once are regenerated only once.
+const watchInterval = 500 * time.Millisecond
+
+// fileState is what is compared to find out if a file changed
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watch calls generate every time a go file in the Directories or one of the Files of the given options changes,
+// until the process is interrupted. Errors returned by generate are printed and watching continues.
+func watch(options *goplantuml.ClassDiagramOptions, generate func() error) {
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupted)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	regenerate := func() {
+		if err := generate(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s\n", time.Now().Format("15:04:05"), err.Error())
+			return
+		}
+		fmt.Printf("%s diagram regenerated\n", time.Now().Format("15:04:05"))
+	}
+	regenerate()
+	last := getFileStates(options)
+	pending := false
+	for {
+		select {
+		case <-interrupted:
+			return
+		case <-ticker.C:
+			current := getFileStates(options)
+			if !reflect.DeepEqual(current, last) {
+				last = current
+				pending = true
+				continue
+			}
+			if pending {
+				pending = false
+				regenerate()
+			}
+		}
+	}
+}
+
+// getFileStates returns the state of all the go files that are parsed for the Directories and Files of the given
+// options. The directories skipped when parsing, like vendor or the IgnoredDirectories, are not walked. Files that
+// cannot be read are left out so they are seen as changed once they can be read again.
+func getFileStates(options *goplantuml.ClassDiagramOptions) map[string]fileState {
+	states := map[string]fileState{}
+	addFile := func(path string, info fs.FileInfo) {
+		states[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	addDirectory := func(dir string) {
+		entries, err := afero.ReadDir(options.FileSystem, dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && filepath.Ext(entry.Name()) == ".go" {
+				addFile(filepath.Join(dir, entry.Name()), entry)
+			}
+		}
+	}
+	for _, dir := range options.Directories {
+		if !options.Recursive {
+			addDirectory(dir)
+			continue
+		}
+		// The directories walked before an error are still watched
+		goplantuml.WalkDirectory(options, dir, addDirectory)
+	}
+	for _, file := range options.Files {
+		if info, err := options.FileSystem.Stat(file); err == nil {
+			addFile(file, info)
+		}
+	}
+	return states
+}
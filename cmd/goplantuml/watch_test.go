@@ -0,0 +1,97 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	goplantuml "github.com/jfeliu007/goplantuml/parser"
+	"github.com/spf13/afero"
+)
+
+func TestGetFileStates(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, path := range []string{
+		"src/a.go",
+		"src/README.md",
+		"src/sub/b.go",
+		"src/sub/ignored/c.go",
+		"src/vendor/example.com/lib/lib.go",
+		"src/testdata/fixture.go",
+		"src/.git/hooks.go",
+		"other/single.go",
+	} {
+		if err := afero.WriteFile(fs, filepath.FromSlash(path), []byte("package a\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tt := []struct {
+		Name     string
+		Options  goplantuml.ClassDiagramOptions
+		Expected []string
+	}{
+		{
+			Name:     "Directory",
+			Options:  goplantuml.ClassDiagramOptions{Directories: []string{"src"}},
+			Expected: []string{"src/a.go"},
+		},
+		{
+			Name:     "Recursive",
+			Options:  goplantuml.ClassDiagramOptions{Directories: []string{"src"}, Recursive: true},
+			Expected: []string{"src/a.go", "src/sub/b.go", "src/sub/ignored/c.go"},
+		},
+		{
+			Name:     "Ignored directories",
+			Options:  goplantuml.ClassDiagramOptions{Directories: []string{"src"}, Recursive: true, IgnoredDirectories: []string{"ignored"}},
+			Expected: []string{"src/a.go", "src/sub/b.go"},
+		},
+		{
+			Name:     "Vendor and testdata",
+			Options:  goplantuml.ClassDiagramOptions{Directories: []string{"src"}, Recursive: true, IncludeVendor: true, IncludeTestdata: true},
+			Expected: []string{"src/a.go", "src/sub/b.go", "src/sub/ignored/c.go", "src/testdata/fixture.go", "src/vendor/example.com/lib/lib.go"},
+		},
+		{
+			Name:     "Single files",
+			Options:  goplantuml.ClassDiagramOptions{Files: []string{"other/single.go", "other/missing.go"}},
+			Expected: []string{"other/single.go"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			options := tc.Options
+			options.FileSystem = fs
+			files := []string{}
+			for path := range getFileStates(&options) {
+				files = append(files, filepath.ToSlash(path))
+			}
+			sort.Strings(files)
+			if !reflect.DeepEqual(files, tc.Expected) {
+				t.Errorf("Expected the files %v, got %v", tc.Expected, files)
+			}
+		})
+	}
+}
+
+func TestGetFileStatesChanges(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := filepath.Join("src", "a.go")
+	if err := afero.WriteFile(fs, path, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	options := &goplantuml.ClassDiagramOptions{FileSystem: fs, Directories: []string{"src"}, Recursive: true}
+	before := getFileStates(options)
+	if err := afero.WriteFile(fs, filepath.Join("src", "vendor", "lib.go"), []byte("package lib\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if after := getFileStates(options); !reflect.DeepEqual(after, before) {
+		t.Errorf("Expected the files under vendor to be ignored, got %v", after)
+	}
+	if err := fs.Chtimes(path, time.Now(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if after := getFileStates(options); reflect.DeepEqual(after, before) {
+		t.Errorf("Expected the change of %s to be seen", path)
+	}
+}
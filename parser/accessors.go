@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RelationshipKind is the kind of a Relationship between two types
+type RelationshipKind string
+
+const (
+	// RelationshipExtends is an interface embedding another interface
+	RelationshipExtends RelationshipKind = "extends"
+	// RelationshipImplements is a type implementing an interface
+	RelationshipImplements RelationshipKind = "implements"
+	// RelationshipComposes is a type embedding another type
+	RelationshipComposes RelationshipKind = "composes"
+	// RelationshipAggregates is a type with a field of another type
+	RelationshipAggregates RelationshipKind = "aggregates"
+	// RelationshipDepends is a type using another type in the parameters or return values of its methods or
+	// constructors without any other relationship to it
+	RelationshipDepends RelationshipKind = "depends"
+)
+
+// Relationship is an edge of the class diagram. From and To are fully qualified type names and From is always the
+// type that extends, implements, embeds, holds or uses To.
+type Relationship struct {
+	Kind RelationshipKind
+	From string
+	To   string
+	// Label holds the names of the fields of From that make an aggregation, separated by commas. It is empty for the
+	// other kinds
+	Label string
+}
+
+// Packages returns the sorted names of the parsed packages. They are import paths if the ImportPaths option is set.
+func (p *ClassParser) Packages() []string {
+	p.Finalize()
+	packages := make([]string, 0, len(p.structure))
+	for pack := range p.structure {
+		packages = append(packages, pack)
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// Structs returns the structs, interfaces and aliases declared in the given package sorted by name, or nil if the
+// package was not parsed. The returned structs are the ones used to render the diagram and must not be modified.
+func (p *ClassParser) Structs(pkg string) []*Struct {
+	p.Finalize()
+	if _, ok := p.structure[pkg]; !ok {
+		return nil
+	}
+	structures := p.getStructures(pkg)
+	result := make([]*Struct, 0, len(structures))
+	for _, structure := range structures {
+		result = append(result, structure)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Relationships returns all the relationships among the parsed types sorted by From, To and Kind. Aggregations made
+// through private fields and methods are included. The returned slice is a copy that can be modified freely.
+func (p *ClassParser) Relationships() []Relationship {
+	return p.getRelationships(p.Packages())
+}
+
+// getRelationships returns the relationships of the types of the given packages, see Relationships. It does not
+// find the relationships so it can be called while finalizing.
+func (p *ClassParser) getRelationships(packages []string) []Relationship {
+	var result []Relationship
+	for _, pack := range packages {
+		for name, structure := range p.getStructures(pack) {
+			modelType := p.getModelType(structure, pack, name)
+			from := getFullTypeName(pack, name)
+			related := map[string]struct{}{}
+			addRelationships := func(kind RelationshipKind, targets []string) {
+				seen := map[string]struct{}{}
+				for _, to := range targets {
+					if _, ok := seen[to]; ok {
+						continue
+					}
+					seen[to] = struct{}{}
+					related[to] = struct{}{}
+					result = append(result, Relationship{Kind: kind, From: from, To: to, Label: p.getRelationshipLabel(structure, kind, to)})
+				}
+			}
+			addRelationships(RelationshipExtends, modelType.Extends)
+			addRelationships(RelationshipImplements, modelType.Implements)
+			addRelationships(RelationshipComposes, modelType.Compositions)
+			aggregations := append(modelType.Aggregations, modelType.PrivateAggregations...)
+			for i, a := range aggregations {
+				if !strings.Contains(a, ".") {
+					aggregations[i] = fmt.Sprintf("%s.%s", p.getPackageName(a, structure), a)
+				}
+			}
+			addRelationships(RelationshipAggregates, aggregations)
+			var dependencies []string
+			for _, t := range p.getMethodDependencies(structure, name) {
+				if _, ok := related[t]; !ok {
+					dependencies = append(dependencies, t)
+				}
+			}
+			addRelationships(RelationshipDepends, dependencies)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].From != result[j].From {
+			return result[i].From < result[j].From
+		}
+		if result[i].To != result[j].To {
+			return result[i].To < result[j].To
+		}
+		return result[i].Kind < result[j].Kind
+	})
+	return result
+}
+
+// getRelationshipLabel returns the label of the relationship of the given kind from the structure to the given type,
+// which is the names of the fields that reference it for aggregations
+func (p *ClassParser) getRelationshipLabel(structure *Struct, kind RelationshipKind, to string) string {
+	if kind != RelationshipAggregates {
+		return ""
+	}
+	var names []string
+	for _, field := range structure.Fields {
+		for _, t := range field.ReferencedTypes {
+			if t == to {
+				names = append(names, field.Name)
+				break
+			}
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Implementations returns the sorted types that implement the given interface. Names can be fully qualified, like
+// pkg.Type, or bare names when only one parsed type has that name.
+func (p *ClassParser) Implementations(interfaceName string) ([]string, error) {
+	return p.getRelatedTypes(interfaceName, true, RelationshipImplements)
+}
+
+// Implements returns the sorted interfaces implemented by the given type. See Implementations for the valid names.
+func (p *ClassParser) Implements(typeName string) ([]string, error) {
+	return p.getRelatedTypes(typeName, false, RelationshipImplements)
+}
+
+// UsedBy returns the sorted types that embed the given type or have fields of it. See Implementations for the valid
+// names.
+func (p *ClassParser) UsedBy(typeName string) ([]string, error) {
+	return p.getRelatedTypes(typeName, true, RelationshipComposes, RelationshipAggregates)
+}
+
+// getRelatedTypes returns the types at the other end of the relationships of the given kinds that start from the
+// given type, or end in it if incoming is set
+func (p *ClassParser) getRelatedTypes(typeName string, incoming bool, kinds ...RelationshipKind) ([]string, error) {
+	fullName, err := p.resolveTypeName(typeName)
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]struct{}{}
+	for _, relationship := range p.Relationships() {
+		from, to := relationship.From, relationship.To
+		if incoming {
+			from, to = to, from
+		}
+		if from != fullName {
+			continue
+		}
+		for _, kind := range kinds {
+			if relationship.Kind == kind {
+				found[to] = struct{}{}
+			}
+		}
+	}
+	result := []string{}
+	for t := range found {
+		result = append(result, t)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// resolveTypeName returns the fully qualified name of the parsed type with the given name. The name can be fully
+// qualified, qualified with the last element of the import path of its package or bare if it is unique.
+func (p *ClassParser) resolveTypeName(typeName string) (string, error) {
+	p.Finalize()
+	matches := p.findTypeNames(typeName)
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("unknown type %s", typeName)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("%s is ambiguous, it can be %s", typeName, strings.Join(matches, ", "))
+}
+
+// findTypeNames returns the sorted fully qualified names of the parsed types with the given name, see
+// resolveTypeName. A fully qualified name only matches its type. It does not find the relationships so it can be
+// called while finalizing.
+func (p *ClassParser) findTypeNames(typeName string) []string {
+	var matches []string
+	for pack, structures := range p.structure {
+		for name := range structures {
+			fullName := getFullTypeName(pack, name)
+			if fullName == typeName {
+				return []string{fullName}
+			}
+			if name == typeName || getFullTypeName(getLastPathElements(pack, 1), name) == typeName {
+				matches = append(matches, fullName)
+			}
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
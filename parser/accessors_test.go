@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestAccessors(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/connectionlabels"},
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if packages := parser.Packages(); !reflect.DeepEqual(packages, []string{"connectionlabels"}) {
+		t.Errorf("Expected the connectionlabels package, got %v", packages)
+	}
+	var names []string
+	for _, st := range parser.Structs("connectionlabels") {
+		names = append(names, st.Name+" "+st.Type)
+	}
+	expectedNames := []string{"AbstractInterface interface", "AliasOfInt type", "ImplementsAbstractInterface class"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("Expected structs %v, got %v", expectedNames, names)
+	}
+	if structs := parser.Structs("missing"); structs != nil {
+		t.Errorf("Expected no structs for a missing package, got %v", structs)
+	}
+	expectedRelationships := []Relationship{
+		{Kind: RelationshipAggregates, From: "connectionlabels.ImplementsAbstractInterface", To: "connectionlabels.AbstractInterface", Label: "PublicUse"},
+		{Kind: RelationshipImplements, From: "connectionlabels.ImplementsAbstractInterface", To: "connectionlabels.AbstractInterface"},
+		{Kind: RelationshipComposes, From: "connectionlabels.ImplementsAbstractInterface", To: "connectionlabels.AliasOfInt"},
+	}
+	if relationships := parser.Relationships(); !reflect.DeepEqual(relationships, expectedRelationships) {
+		t.Errorf("Expected relationships %v, got %v", expectedRelationships, relationships)
+	}
+}
+
+func TestRelationshipQueries(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/queries", "../testingsupport/connectionlabels"},
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	tt := []struct {
+		Name          string
+		Query         func(string) ([]string, error)
+		TypeName      string
+		Expected      []string
+		ExpectedError string
+	}{
+		{
+			Name:     "Implementations",
+			Query:    parser.Implementations,
+			TypeName: "vault.Reader",
+			Expected: []string{"vault.Cache", "vault.Env", "vault.Memory"},
+		},
+		{
+			Name:     "Implementations of an embedding interface",
+			Query:    parser.Implementations,
+			TypeName: "ReadWriter",
+			Expected: []string{"vault.Memory"},
+		},
+		{
+			Name:     "Implements",
+			Query:    parser.Implements,
+			TypeName: "Memory",
+			Expected: []string{"vault.ReadWriter", "vault.Reader", "vault.Writer"},
+		},
+		{
+			Name:     "Implements nothing",
+			Query:    parser.Implements,
+			TypeName: "Entry",
+			Expected: []string{},
+		},
+		{
+			Name:     "Used by",
+			Query:    parser.UsedBy,
+			TypeName: "Entry",
+			Expected: []string{"vault.Audit", "vault.Cache"},
+		},
+		{
+			Name:     "Used by embedding",
+			Query:    parser.UsedBy,
+			TypeName: "vault.Reader",
+			Expected: []string{"vault.Cache"},
+		},
+		{
+			Name:          "Unknown type",
+			Query:         parser.UsedBy,
+			TypeName:      "Vault",
+			ExpectedError: "unknown type Vault",
+		},
+		{
+			Name:          "Wrong package",
+			Query:         parser.UsedBy,
+			TypeName:      "connectionlabels.Entry",
+			ExpectedError: "unknown type connectionlabels.Entry",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			result, err := tc.Query(tc.TypeName)
+			if tc.ExpectedError != "" {
+				if err == nil || err.Error() != tc.ExpectedError {
+					t.Fatalf("Expected error %s but got %v", tc.ExpectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			if !reflect.DeepEqual(result, tc.Expected) {
+				t.Errorf("Expected %v, got %v", tc.Expected, result)
+			}
+		})
+	}
+}
+
+func TestResolveAmbiguousTypeName(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/importpaths"},
+		Recursive:        true,
+		ImportPaths:      true,
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expectedError := "models.User is ambiguous, it can be " + importPathsModule + "/a/models.User, " + importPathsModule + "/b/models.User"
+	if _, err := parser.UsedBy("models.User"); err == nil || err.Error() != expectedError {
+		t.Errorf("Expected error %s but got %v", expectedError, err)
+	}
+	if _, err := parser.UsedBy(importPathsModule + "/a/models.User"); err != nil {
+		t.Errorf("Expected no error but got %s", err.Error())
+	}
+}
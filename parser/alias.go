@@ -1,6 +1,9 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 //Alias defines a type that is an alias for some other type
 type Alias struct {
@@ -20,6 +23,16 @@ func getNewAlias(name, packageName, aliasOf string) *Alias {
 	}
 }
 
+// getRenamedType returns the type an alias points to when it has to be renamed in the diagram because it contains
+// dots, like types of other packages. It returns an empty string otherwise.
+func (a *Alias) getRenamedType() string {
+	aliasType := strings.TrimPrefix(a.Name, a.PackageName+".")
+	if aliasType == a.Name || !strings.Contains(aliasType, ".") {
+		return ""
+	}
+	return aliasType
+}
+
 //AliasSlice implement the sort.Interface interface to allow for proper sorting of an alias slice
 type AliasSlice []Alias
 
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+)
+
+// addAnonymousStructClass adds the given field of the struct with the anonymous struct it uses, directly or through
+// pointers, slices and arrays, turned into a class named after both, like Post.Meta, that the struct is composed of. It
+// returns false, so the field is added with its anonymous struct inline, unless the AnonymousStructClasses option is
+// set, or if the field has no anonymous struct, declares several names, belongs to a generic type or is nested in
+// too many anonymous structs.
+func (p *ClassParser) addAnonymousStructClass(st *Struct, f *ast.Field, depth int) bool {
+	if !p.options.AnonymousStructClasses || depth >= maxAnonymousStructDepth || len(f.Names) != 1 || len(st.TypeParameters) > 0 {
+		return false
+	}
+	fieldName := f.Names[0].Name
+	label := fmt.Sprintf("%s.%s", st.getLabel(), fieldName)
+	structType, fieldType := getAnonymousStruct(f.Type, label)
+	if structType == nil {
+		return false
+	}
+	name := fmt.Sprintf("%s_%s", st.Name, fieldName)
+	_, fullType := getAnonymousStruct(f.Type, getFullTypeName(st.PackageName, name))
+	anonymous := p.getOrCreateStruct(st.PackageName, name)
+	anonymous.Type = "class"
+	anonymous.Label = label
+	anonymous.Position = p.getPosition(structType.Pos())
+	p.addStructFields(st.PackageName, name, structType, depth+1)
+	st.AddToComposition(name)
+	st.Fields = append(st.Fields, &Field{
+		Name:     fieldName,
+		Type:     fieldType,
+		FullType: fullType,
+		Tag:      getFieldTag(f),
+	})
+	return true
+}
+
+// getAnonymousStruct returns the anonymous struct used by the given type directly or through pointers, slices and arrays,
+// and the type with the anonymous struct replaced by the given name. It returns nil if there is no anonymous struct.
+func getAnonymousStruct(exp ast.Expr, name string) (*ast.StructType, string) {
+	switch v := unparen(exp).(type) {
+	case *ast.StructType:
+		return v, name
+	case *ast.StarExpr:
+		structType, t := getAnonymousStruct(v.X, name)
+		return structType, "*" + t
+	case *ast.ArrayType:
+		structType, t := getAnonymousStruct(v.Elt, name)
+		return structType, getArrayPrefix(v) + t
+	}
+	return nil, ""
+}
@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"runtime"
+	"strings"
+)
+
+// BuildContext selects the files of a directory that are parsed like the go tool does for the given platform and
+// build tags. Files whose name ends in another GOOS or GOARCH, like conn_windows.go, and files whose //go:build or
+// // +build constraints are not satisfied are skipped.
+type BuildContext struct {
+	// GOOS is the target operating system. It defaults to runtime.GOOS
+	GOOS string
+	// GOARCH is the target architecture. It defaults to runtime.GOARCH
+	GOARCH string
+	// Tags are the build tags that are set, like the ones given to go build -tags
+	Tags []string
+}
+
+// knownOS are the values of GOOS that can be used as file name suffixes
+var knownOS = map[string]struct{}{
+	"aix": {}, "android": {}, "darwin": {}, "dragonfly": {}, "freebsd": {}, "hurd": {}, "illumos": {}, "ios": {},
+	"js": {}, "linux": {}, "nacl": {}, "netbsd": {}, "openbsd": {}, "plan9": {}, "solaris": {}, "wasip1": {},
+	"windows": {}, "zos": {},
+}
+
+// unixOS are the values of GOOS satisfying the unix build tag
+var unixOS = map[string]struct{}{
+	"aix": {}, "android": {}, "darwin": {}, "dragonfly": {}, "freebsd": {}, "hurd": {}, "illumos": {}, "ios": {},
+	"linux": {}, "netbsd": {}, "openbsd": {}, "solaris": {},
+}
+
+// knownArch are the values of GOARCH that can be used as file name suffixes
+var knownArch = map[string]struct{}{
+	"386": {}, "amd64": {}, "amd64p32": {}, "arm": {}, "armbe": {}, "arm64": {}, "arm64be": {}, "loong64": {},
+	"mips": {}, "mipsle": {}, "mips64": {}, "mips64le": {}, "mips64p32": {}, "mips64p32le": {}, "ppc": {},
+	"ppc64": {}, "ppc64le": {}, "riscv": {}, "riscv64": {}, "s390": {}, "s390x": {}, "sparc": {}, "sparc64": {},
+	"wasm": {},
+}
+
+// getGOOS returns the target operating system of the build context
+func (c *BuildContext) getGOOS() string {
+	if c.GOOS == "" {
+		return runtime.GOOS
+	}
+	return c.GOOS
+}
+
+// getGOARCH returns the target architecture of the build context
+func (c *BuildContext) getGOARCH() string {
+	if c.GOARCH == "" {
+		return runtime.GOARCH
+	}
+	return c.GOARCH
+}
+
+// matchFile returns true if the file with the given name, parsed into f, is part of the build. Every file matches a
+// nil build context.
+func (c *BuildContext) matchFile(name string, f *ast.File) bool {
+	if c == nil {
+		return true
+	}
+	return c.matchFileName(name) && c.matchConstraints(f)
+}
+
+// matchFileName returns true unless the given file name ends in a GOOS or a GOARCH other than the ones of the build
+// context, like name_GOOS.go, name_GOARCH.go or name_GOOS_GOARCH.go
+func (c *BuildContext) matchFileName(name string) bool {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".go"), "_test")
+	elements := strings.Split(name, "_")
+	if len(elements) < 2 {
+		return true
+	}
+	last := elements[len(elements)-1]
+	if len(elements) >= 3 {
+		if _, ok := knownOS[elements[len(elements)-2]]; ok {
+			if _, ok := knownArch[last]; ok {
+				return c.matchTag(elements[len(elements)-2]) && c.matchTag(last)
+			}
+		}
+	}
+	if _, ok := knownOS[last]; ok {
+		return c.matchTag(last)
+	}
+	if _, ok := knownArch[last]; ok {
+		return c.matchTag(last)
+	}
+	return true
+}
+
+// matchConstraints returns true if the build constraints written before the package clause of the file are
+// satisfied. Files with a //go:build line ignore their // +build lines like the go tool does.
+func (c *BuildContext) matchConstraints(f *ast.File) bool {
+	var goBuild constraint.Expr
+	var plusBuild []constraint.Expr
+	for _, commentGroup := range f.Comments {
+		if commentGroup.Pos() > f.Package {
+			break
+		}
+		for _, comment := range commentGroup.List {
+			switch {
+			case constraint.IsGoBuild(comment.Text):
+				goBuild, _ = constraint.Parse(comment.Text)
+			case constraint.IsPlusBuild(comment.Text):
+				if expr, err := constraint.Parse(comment.Text); err == nil {
+					plusBuild = append(plusBuild, expr)
+				}
+			}
+		}
+	}
+	if goBuild != nil {
+		return goBuild.Eval(c.matchTag)
+	}
+	for _, expr := range plusBuild {
+		if !expr.Eval(c.matchTag) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTag returns true if the given build tag is satisfied by the build context. Besides GOOS, GOARCH and the
+// tags, the gc compiler, every Go release tag and the tags implied by GOOS, like unix, are satisfied.
+func (c *BuildContext) matchTag(tag string) bool {
+	goos := c.getGOOS()
+	switch {
+	case tag == goos, tag == c.getGOARCH(), tag == "gc", strings.HasPrefix(tag, "go1."):
+		return true
+	case tag == "unix":
+		_, ok := unixOS[goos]
+		return ok
+	case tag == "linux" && goos == "android", tag == "darwin" && goos == "ios", tag == "solaris" && goos == "illumos":
+		return true
+	}
+	for _, t := range c.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestBuildContext(t *testing.T) {
+	tt := []struct {
+		Name             string
+		BuildContext     *BuildContext
+		ExpectedTypes    []string
+		ExpectedFields   []string
+		ExpectedMethods  []string
+		ExpectedWarnings int
+	}{
+		{
+			Name:             "Every file",
+			ExpectedTypes:    []string{"Conn", "Dialer", "Legacy", "Tracer"},
+			ExpectedFields:   []string{"FD"},
+			ExpectedMethods:  []string{"Close", "Flush"},
+			ExpectedWarnings: 6,
+		},
+		{
+			Name:            "Linux",
+			BuildContext:    &BuildContext{GOOS: "linux", GOARCH: "amd64"},
+			ExpectedTypes:   []string{"Conn", "Dialer", "Legacy"},
+			ExpectedFields:  []string{"FD"},
+			ExpectedMethods: []string{"Close"},
+		},
+		{
+			Name:            "Linux on another architecture",
+			BuildContext:    &BuildContext{GOOS: "linux", GOARCH: "arm64"},
+			ExpectedTypes:   []string{"Conn", "Dialer"},
+			ExpectedFields:  []string{"FD"},
+			ExpectedMethods: []string{"Close"},
+		},
+		{
+			Name:            "Windows",
+			BuildContext:    &BuildContext{GOOS: "windows", GOARCH: "amd64"},
+			ExpectedTypes:   []string{"Conn", "Dialer"},
+			ExpectedFields:  []string{"Handle"},
+			ExpectedMethods: []string{"Close", "Flush"},
+		},
+		{
+			Name:            "Other platforms with tags",
+			BuildContext:    &BuildContext{GOOS: "darwin", GOARCH: "arm64", Tags: []string{"debug"}},
+			ExpectedTypes:   []string{"Conn", "Dialer", "Tracer"},
+			ExpectedFields:  []string{"Name"},
+			ExpectedMethods: []string{"Close"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:   afero.NewOsFs(),
+				Directories:  []string{"../testingsupport/platforms"},
+				BuildContext: tc.BuildContext,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			types := []string{}
+			for name := range parser.structure["platforms"] {
+				types = append(types, name)
+			}
+			sort.Strings(types)
+			if !reflect.DeepEqual(types, tc.ExpectedTypes) {
+				t.Errorf("Expected the types %v, got %v", tc.ExpectedTypes, types)
+			}
+			conn := parser.structure["platforms"]["Conn"]
+			fields, methods := []string{}, []string{}
+			for _, field := range conn.Fields {
+				fields = append(fields, field.Name)
+			}
+			for _, method := range conn.Functions {
+				methods = append(methods, method.Name)
+			}
+			if !reflect.DeepEqual(fields, tc.ExpectedFields) || !reflect.DeepEqual(methods, tc.ExpectedMethods) {
+				t.Errorf("Expected Conn to have %v and %v, got %v and %v", tc.ExpectedFields, tc.ExpectedMethods, fields, methods)
+			}
+			if len(conn.Constructors) != 1 {
+				t.Errorf("Expected a single constructor, got %d", len(conn.Constructors))
+			}
+			warnings := parser.Warnings()
+			if len(warnings) != tc.ExpectedWarnings {
+				t.Fatalf("Expected %d warnings, got %v", tc.ExpectedWarnings, warnings)
+			}
+			if len(warnings) > 0 && !strings.Contains(warnings[0].Error(), "conn_other.go:6:6: platforms.Conn is declared again, keeping the declaration of ") {
+				t.Errorf("Expected a warning for Conn, got %s", warnings[0].Error())
+			}
+		})
+	}
+}
+
+func TestMatchBuildContext(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Context  *BuildContext
+		FileName string
+		Tag      string
+		Expected bool
+	}{
+		{Name: "No suffix", Context: &BuildContext{GOOS: "linux"}, FileName: "conn.go", Expected: true},
+		{Name: "OS suffix", Context: &BuildContext{GOOS: "linux"}, FileName: "conn_windows.go", Expected: false},
+		{Name: "Test file", Context: &BuildContext{GOOS: "windows"}, FileName: "conn_windows_test.go", Expected: true},
+		{Name: "OS and architecture", Context: &BuildContext{GOOS: "linux", GOARCH: "arm64"}, FileName: "conn_linux_amd64.go", Expected: false},
+		{Name: "Only the OS", Context: &BuildContext{GOOS: "linux", GOARCH: "arm64"}, FileName: "linux.go", Expected: true},
+		{Name: "Unknown suffix", Context: &BuildContext{GOOS: "linux"}, FileName: "conn_pool.go", Expected: true},
+		{Name: "Unix", Context: &BuildContext{GOOS: "darwin"}, Tag: "unix", Expected: true},
+		{Name: "Not unix", Context: &BuildContext{GOOS: "windows"}, Tag: "unix", Expected: false},
+		{Name: "Android is linux", Context: &BuildContext{GOOS: "android"}, Tag: "linux", Expected: true},
+		{Name: "Release", Context: &BuildContext{GOOS: "linux"}, Tag: "go1.18", Expected: true},
+		{Name: "Missing tag", Context: &BuildContext{GOOS: "linux", Tags: []string{"debug"}}, Tag: "integration", Expected: false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			result := tc.Context.matchTag(tc.Tag)
+			if tc.FileName != "" {
+				result = tc.Context.matchFileName(tc.FileName)
+			}
+			if result != tc.Expected {
+				t.Errorf("Expected %t, got %t", tc.Expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,409 @@
+package parser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// cacheVersion is written into the cache files and must be increased whenever the extracted types change, like
+// when a field is added to Struct, so the entries written by older versions are not used
+const cacheVersion = 3
+
+// Cache keeps the types extracted from every parsed directory and reuses them while the directory does not change,
+// see ClassDiagramOptions.Cache. An entry is only used if the hash of the names and contents of the go files of the
+// directory and of the options used to parse it is the same, so adding, removing, renaming or editing a go file
+// parses the directory again. Single files given in ClassDiagramOptions.Files are never cached.
+//
+// A Cache can be shared by parsers running concurrently. The cache returned by NewCache only lives in memory while
+// the one returned by OpenCache can be saved into its file to be used by the next process.
+type Cache struct {
+	path    string
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+	// hits counts the directories taken from the cache
+	hits int
+}
+
+// cacheEntry is the hash of a directory and the types extracted from it, encoded so every parser gets its own copy
+type cacheEntry struct {
+	Hash       string          `json:"hash"`
+	Extraction json.RawMessage `json:"extraction"`
+}
+
+// cacheFile is the content of the file of a Cache
+type cacheFile struct {
+	Version int                    `json:"version"`
+	Entries map[string]*cacheEntry `json:"entries"`
+}
+
+// directoryExtraction holds what parsing the packages of a single directory adds into a ClassParser, see
+// mergeExtraction
+type directoryExtraction struct {
+	Structure         map[string]map[string]*Struct `json:"structure"`
+	Interfaces        []string                      `json:"interfaces"`
+	Structs           []string                      `json:"structs"`
+	Aliases           map[string]*Alias             `json:"aliases"`
+	RenamedStructs    map[string]map[string]string  `json:"renamedStructs"`
+	Functions         []*Function                   `json:"functions"`
+	Constants         map[string][]string           `json:"constants"`
+	FunctionPositions map[string]token.Position     `json:"functionPositions"`
+	DotImports        map[string][]string           `json:"dotImports"`
+	// ParseWarnings are the errors of the files that could not be parsed and Warnings the declarations found again
+	ParseWarnings []string `json:"parseWarnings"`
+	Warnings      []string `json:"warnings"`
+}
+
+// NewCache returns an empty cache that only lives in memory, useful to parse the same directories again and again
+// like a server does
+func NewCache() *Cache {
+	return &Cache{entries: map[string]*cacheEntry{}}
+}
+
+// OpenCache returns the cache saved into the given file, see Save. The cache is empty if the file does not exist or
+// was written by another version. An error is only returned if the file can not be read.
+func OpenCache(path string) (*Cache, error) {
+	cache := NewCache()
+	cache.path = path
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	file := &cacheFile{}
+	// A damaged file is as good as a missing one, the directories are parsed again
+	if err := json.Unmarshal(content, file); err == nil && file.Version == cacheVersion && file.Entries != nil {
+		cache.entries = file.Entries
+	}
+	return cache, nil
+}
+
+// DefaultCachePath returns the file of the cache in the cache directory of the user, like
+// ~/.cache/goplantuml/cache.json on Linux
+func DefaultCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goplantuml", "cache.json"), nil
+}
+
+// Save writes the cache into the file it was opened from, creating its directory if needed. The entries of the
+// directories that do not exist anymore are dropped. It does nothing for the caches returned by NewCache.
+func (c *Cache) Save() error {
+	if c.path == "" {
+		return nil
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key := range c.entries {
+		if _, err := os.Stat(key); errors.Is(err, os.ErrNotExist) {
+			delete(c.entries, key)
+		}
+	}
+	content, err := json.Marshal(&cacheFile{Version: cacheVersion, Entries: c.entries})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	// Writing into another file first keeps the previous cache if the process is interrupted
+	temporary, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+	if _, err := temporary.Write(content); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), c.path)
+}
+
+// get returns a copy of the types extracted from the directory with the given key if it still has the given hash
+func (c *Cache) get(key string, hash string) (*directoryExtraction, bool) {
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	if ok && entry.Hash == hash {
+		c.hits++
+	}
+	c.mutex.Unlock()
+	if !ok || entry.Hash != hash {
+		return nil, false
+	}
+	extraction := &directoryExtraction{}
+	if err := json.Unmarshal(entry.Extraction, extraction); err != nil {
+		return nil, false
+	}
+	return extraction, true
+}
+
+// put keeps the types extracted from the directory with the given key and hash, replacing the previous ones
+func (c *Cache) put(key string, hash string, extraction *directoryExtraction) {
+	encoded, err := json.Marshal(extraction)
+	if err != nil {
+		// The directory is parsed again next time
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[key] = &cacheEntry{Hash: hash, Extraction: encoded}
+}
+
+// parseCachedDirectory sets the types extracted from the given directory, taken from the cache of the options if the
+// directory did not change since it was cached. It only reads the options of the ClassParser so it can be called
+// concurrently.
+func (p *ClassParser) parseCachedDirectory(ctx context.Context, directory *parsedDirectory) {
+	extractor := p.newExtractor()
+	key := getPathKey(directory.path)
+	hash, err := extractor.getDirectoryHash(directory.path)
+	if err != nil {
+		directory.err = err
+		return
+	}
+	if extraction, ok := p.options.Cache.get(key, hash); ok {
+		directory.extraction = extraction
+		for _, warning := range extraction.ParseWarnings {
+			directory.warnings = append(directory.warnings, errors.New(warning))
+		}
+		return
+	}
+	fileSet := token.NewFileSet()
+	packages, warnings, err := parseDirectoryFiles(ctx, fileSet, directory.path, p.options.BuildContext, p.options.IncludeTests)
+	if err != nil {
+		directory.err = err
+		return
+	}
+	extractor.parsePackages(fileSet, packages)
+	directory.extraction = extractor.getExtraction(warnings)
+	directory.warnings = warnings
+	p.options.Cache.put(key, hash, directory.extraction)
+}
+
+// newExtractor returns a ClassParser without parsed code and with the same options, to extract the types of a
+// single directory
+func (p *ClassParser) newExtractor() *ClassParser {
+	return &ClassParser{
+		structure:         map[string]map[string]*Struct{},
+		allInterfaces:     map[string]struct{}{},
+		allStructs:        map[string]struct{}{},
+		allAliases:        map[string]*Alias{},
+		allRenamedStructs: map[string]map[string]string{},
+		options:           p.options,
+		packageNames:      p.packageNames,
+	}
+}
+
+// getDirectoryHash returns the hash of the given directory, made of the names and contents of its go files and of
+// the options that change the types extracted from them. With the ImportPaths option the import path of the
+// directory is part of it too, since it is the name of its packages, and the declared names of the parsed packages
+// are part of it without it, since they are the names of their aliased imports.
+func (p *ClassParser) getDirectoryHash(directoryPath string) (string, error) {
+	entries, err := os.ReadDir(directoryPath)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%t %t %t %t %t %t\x00", directoryPath, p.options.ImportPaths, p.options.IncludeGenerated, p.options.IncludeTests, p.options.AggregateChannels, p.options.AnonymousStructClasses, p.options.Verbose)
+	if p.options.ImportPaths {
+		fmt.Fprintf(hash, "%s\x00", p.getImportPath(directoryPath))
+	}
+	packageNames := make([]string, 0, len(p.packageNames))
+	for importPath, name := range p.packageNames {
+		packageNames = append(packageNames, importPath+"="+name)
+	}
+	sort.Strings(packageNames)
+	fmt.Fprintf(hash, "%s\x00", strings.Join(packageNames, ","))
+	if buildContext := p.options.BuildContext; buildContext != nil {
+		tags := append([]string{}, buildContext.Tags...)
+		sort.Strings(tags)
+		fmt.Fprintf(hash, "%s %s %s\x00", buildContext.getGOOS(), buildContext.getGOARCH(), strings.Join(tags, ","))
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		if err := writeFileHash(hash, filepath.Join(directoryPath, entry.Name())); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// writeFileHash writes the name and the content of the given file into the hash
+func writeFileHash(hash io.Writer, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// The size keeps the content of a file from being taken for the name of the next one
+	fmt.Fprintf(hash, "%s\x00%d\x00", filepath.Base(filePath), info.Size())
+	_, err = io.Copy(hash, f)
+	return err
+}
+
+// getExtraction returns the types extracted by this ClassParser, which parsed a single directory with the given
+// parse warnings
+func (p *ClassParser) getExtraction(parseWarnings []error) *directoryExtraction {
+	extraction := &directoryExtraction{
+		Structure:         p.structure,
+		Interfaces:        getSortedSet(p.allInterfaces),
+		Structs:           getSortedSet(p.allStructs),
+		Aliases:           p.allAliases,
+		RenamedStructs:    p.allRenamedStructs,
+		Functions:         p.allFunctions,
+		Constants:         p.allConstants,
+		FunctionPositions: p.functionPositions,
+		DotImports:        p.dotImports,
+	}
+	for _, warning := range parseWarnings {
+		extraction.ParseWarnings = append(extraction.ParseWarnings, warning.Error())
+	}
+	for _, warning := range p.warnings {
+		extraction.Warnings = append(extraction.Warnings, warning.Error())
+	}
+	return extraction
+}
+
+// getSortedSet returns the sorted elements of the given set
+func getSortedSet(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeExtraction adds the types extracted from a directory into the structure with the same result as parsing the
+// directory right away. Types of a package already parsed from another directory get the fields and methods of
+// the directory, and its declaration if it has one.
+func (p *ClassParser) mergeExtraction(extraction *directoryExtraction) {
+	p.mergeStructure(extraction.Structure)
+	// An interface declared in the directory is not taken for a struct anymore, unless the directory gives it methods
+	for _, name := range extraction.Interfaces {
+		p.allInterfaces[name] = struct{}{}
+		delete(p.allStructs, name)
+	}
+	for _, name := range extraction.Structs {
+		p.allStructs[name] = struct{}{}
+	}
+	for name, alias := range extraction.Aliases {
+		p.allAliases[name] = alias
+	}
+	for pack, renamed := range extraction.RenamedStructs {
+		if _, ok := p.allRenamedStructs[pack]; !ok {
+			p.allRenamedStructs[pack] = map[string]string{}
+		}
+		for renamedClass, renamedType := range renamed {
+			p.allRenamedStructs[pack][renamedClass] = renamedType
+		}
+	}
+	p.allFunctions = append(p.allFunctions, extraction.Functions...)
+	p.mergeConstants(extraction.Constants)
+	for name, position := range extraction.FunctionPositions {
+		if p.functionPositions == nil {
+			p.functionPositions = map[string]token.Position{}
+		}
+		p.functionPositions[name] = position
+	}
+	for fileName, dotImports := range extraction.DotImports {
+		if p.dotImports == nil {
+			p.dotImports = map[string][]string{}
+		}
+		p.dotImports[fileName] = dotImports
+	}
+	for _, warning := range extraction.Warnings {
+		p.warnings = append(p.warnings, errors.New(warning))
+	}
+}
+
+// mergeStructure adds the given types into the structure, merging the ones of a package already parsed from
+// another directory
+func (p *ClassParser) mergeStructure(structure map[string]map[string]*Struct) {
+	for pack, structures := range structure {
+		if _, ok := p.structure[pack]; !ok {
+			p.structure[pack] = map[string]*Struct{}
+		}
+		for name, st := range structures {
+			if existing, ok := p.structure[pack][name]; ok {
+				existing.merge(st)
+			} else {
+				p.structure[pack][name] = st
+			}
+		}
+	}
+}
+
+// mergeConstants adds the given enum values keyed by the full name of their type, skipping the ones already found
+// in another directory
+func (p *ClassParser) mergeConstants(constants map[string][]string) {
+	for fullName, values := range constants {
+		for _, value := range values {
+			if p.isEnumValue(fullName, value) {
+				continue
+			}
+			if p.allConstants == nil {
+				p.allConstants = map[string][]string{}
+			}
+			p.allConstants[fullName] = append(p.allConstants[fullName], value)
+		}
+	}
+}
+
+// merge adds the fields, methods and relationships of the same type parsed from another directory, which replaces
+// the declaration of the type if it has one. Types only given methods in the other directory have no position.
+func (st *Struct) merge(other *Struct) {
+	if other.Position.IsValid() {
+		st.Type = other.Type
+		st.Doc = other.Doc
+		st.Position = other.Position
+		if other.Label != "" {
+			st.Label = other.Label
+		}
+	}
+	st.TypeParameters = append(st.TypeParameters, other.TypeParameters...)
+	st.Fields = append(st.Fields, other.Fields...)
+	st.Functions = append(st.Functions, other.Functions...)
+	for _, types := range []struct{ to, from map[string]struct{} }{
+		{st.Composition, other.Composition},
+		{st.Extends, other.Extends},
+	} {
+		for t := range types.from {
+			st.addType(types.to, t)
+		}
+	}
+	for t := range other.Aggregations {
+		st.AddToAggregation(t)
+	}
+	for t := range other.PrivateAggregations {
+		st.addToPrivateAggregation(t)
+	}
+	for t, arguments := range other.ExtendsTypeArguments {
+		if st.ExtendsTypeArguments == nil {
+			st.ExtendsTypeArguments = map[string][]string{}
+		}
+		st.ExtendsTypeArguments[t] = arguments
+	}
+}
@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// parseWithCache parses the given directory recursively with the given options and cache and returns the rendered
+// diagram with its warnings
+func parseWithCache(t *testing.T, options ClassDiagramOptions, directory string, cache *Cache) (string, []string) {
+	t.Helper()
+	options.FileSystem = afero.NewOsFs()
+	options.Directories = []string{directory}
+	options.Recursive = true
+	options.Cache = cache
+	options.RenderingOptions = map[RenderingOption]interface{}{
+		RenderPrivateMembers: true,
+		RenderAggregations:   true,
+		RenderDependencies:   true,
+	}
+	parser, err := NewClassDiagramWithOptions(&options)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	warnings := []string{}
+	for _, warning := range parser.Warnings() {
+		warnings = append(warnings, warning.Error())
+	}
+	return parser.Render(), warnings
+}
+
+func TestCacheRendersTheSameDiagram(t *testing.T) {
+	tt := []struct {
+		Name    string
+		Options ClassDiagramOptions
+	}{
+		{Name: "Defaults"},
+		{Name: "Import paths", Options: ClassDiagramOptions{ImportPaths: true}},
+		{Name: "Anonymous structs and channels", Options: ClassDiagramOptions{AnonymousStructClasses: true, AggregateChannels: true}},
+		{Name: "Build context", Options: ClassDiagramOptions{BuildContext: &BuildContext{GOOS: "windows", GOARCH: "amd64"}}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			expected, expectedWarnings := parseWithCache(t, tc.Options, "../testingsupport", nil)
+			cache := NewCache()
+			for i, name := range []string{"added", "taken from"} {
+				result, warnings := parseWithCache(t, tc.Options, "../testingsupport", cache)
+				if result != expected {
+					t.Errorf("Expected the diagram with the types %s the cache to be\n%s\ngot\n%s", name, expected, result)
+				}
+				if !reflect.DeepEqual(warnings, expectedWarnings) {
+					t.Errorf("Expected the warnings with the types %s the cache to be %v, got %v", name, expectedWarnings, warnings)
+				}
+				if hits := cache.hits; hits != i*len(cache.entries) {
+					t.Errorf("Expected %d directories taken from the cache, got %d", i*len(cache.entries), hits)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "package app\n\ntype First struct{}\n")
+	write("b.go", "package app\n\ntype Second struct{}\n")
+	cache := NewCache()
+	tt := []struct {
+		Name     string
+		Change   func() error
+		Expected []string
+		Missing  []string
+	}{
+		{
+			Name:     "Unchanged",
+			Change:   func() error { return nil },
+			Expected: []string{"First", "Second", "b.go"},
+		},
+		{
+			Name:     "Edited file",
+			Change:   func() error { write("a.go", "package app\n\ntype Edited struct{}\n"); return nil },
+			Expected: []string{"Edited", "Second"},
+			Missing:  []string{"First"},
+		},
+		{
+			Name:     "Added file",
+			Change:   func() error { write("c.go", "package app\n\ntype Third struct{}\n"); return nil },
+			Expected: []string{"Edited", "Second", "Third"},
+		},
+		{
+			Name:     "Removed file",
+			Change:   func() error { return os.Remove(filepath.Join(dir, "c.go")) },
+			Expected: []string{"Edited", "Second"},
+			Missing:  []string{"Third"},
+		},
+		{
+			Name:     "Renamed file",
+			Change:   func() error { return os.Rename(filepath.Join(dir, "b.go"), filepath.Join(dir, "d.go")) },
+			Expected: []string{"Second", "d.go"},
+			Missing:  []string{"b.go"},
+		},
+	}
+	parseWithCache(t, ClassDiagramOptions{}, dir, cache)
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := tc.Change(); err != nil {
+				t.Fatal(err)
+			}
+			hits := cache.hits
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{dir},
+				Cache:            cache,
+				RenderingOptions: map[RenderingOption]interface{}{RenderLinkTemplate: "{path}"},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			if hit := cache.hits > hits; hit != (tc.Name == "Unchanged") {
+				t.Errorf("Expected the directory to be taken from the cache: %t, got %t", !hit, hit)
+			}
+			result := parser.Render()
+			for _, expected := range tc.Expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected %s in\n%s", expected, result)
+				}
+			}
+			for _, missing := range tc.Missing {
+				if strings.Contains(result, missing) {
+					t.Errorf("Expected no %s in\n%s", missing, result)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheOptions(t *testing.T) {
+	cache := NewCache()
+	inline, _ := parseWithCache(t, ClassDiagramOptions{}, "../testingsupport/anonymousstructs", cache)
+	classes, _ := parseWithCache(t, ClassDiagramOptions{AnonymousStructClasses: true}, "../testingsupport/anonymousstructs", cache)
+	if cache.hits != 0 {
+		t.Errorf("Expected the types extracted with other options not to be taken from the cache, got %d hits", cache.hits)
+	}
+	if inline == classes {
+		t.Errorf("Expected the AnonymousStructClasses option to change the diagram, got\n%s", classes)
+	}
+}
+
+func TestSaveCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goplantuml", "cache.json")
+	cache, err := OpenCache(path)
+	if err != nil {
+		t.Fatalf("Expected no error opening a missing cache but got %s", err.Error())
+	}
+	expected, _ := parseWithCache(t, ClassDiagramOptions{}, "../testingsupport/subfolder", cache)
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Expected no error saving the cache but got %s", err.Error())
+	}
+	saved, err := OpenCache(path)
+	if err != nil {
+		t.Fatalf("Expected no error opening the cache but got %s", err.Error())
+	}
+	result, _ := parseWithCache(t, ClassDiagramOptions{}, "../testingsupport/subfolder", saved)
+	if result != expected {
+		t.Errorf("Expected the diagram with the saved cache to be\n%s\ngot\n%s", expected, result)
+	}
+	if saved.hits == 0 {
+		t.Errorf("Expected the directories to be taken from the saved cache")
+	}
+	for name, content := range map[string]string{
+		"Corrupt file":  "{",
+		"Other version": `{"version": 0, "entries": {"a": {"hash": "b", "extraction": {}}}}`,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cache, err := OpenCache(path)
+		if err != nil {
+			t.Errorf("%s: expected no error but got %s", name, err.Error())
+		} else if len(cache.entries) != 0 {
+			t.Errorf("%s: expected an empty cache, got %d entries", name, len(cache.entries))
+		}
+	}
+	if err := NewCache().Save(); err != nil {
+		t.Errorf("Expected saving a cache without a file to do nothing, got %s", err.Error())
+	}
+}
@@ -6,7 +6,8 @@ function.
 Pass the directory where the .go files are and the parser will analyze the code and build a structure
 containing the information it needs to Render the class diagram.
 
-call the Render() function and this will return a string with the class diagram.
+call the Render() function and this will return a string with the class diagram, or call RenderTo(w) to
+write the class diagram directly into an io.Writer.
 
 See github.com/jfeliu007/goplantuml/cmd/goplantuml/main.go for a command that uses this functions and outputs the text to
 the console.
@@ -15,15 +16,24 @@ the console.
 package parser
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
+	"go/scanner"
 	"go/token"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"unicode"
 
 	"github.com/spf13/afero"
@@ -33,6 +43,8 @@ import (
 // adding new lines
 type LineStringBuilder struct {
 	strings.Builder
+	// indentation is added to the depth of every line, to nest the lines of a block like together
+	indentation int
 }
 
 const tab = "    "
@@ -42,22 +54,118 @@ const extends = `"extends"`
 const aggregates = `"uses"`
 const aliasOf = `"alias of"`
 
-// WriteLineWithDepth will write the given text with added tabs at the beginning into the string builder.
+// indentations holds the prefixes of the lines of the usual depths so they are not built for every line
+var indentations = [...]string{"", tab, tab + tab, tab + tab + tab, tab + tab + tab + tab}
+
+// WriteLineWithDepth will write the given text with added tabs at the beginning into the string builder. Empty
+// lines are written without tabs.
 func (lsb *LineStringBuilder) WriteLineWithDepth(depth int, str string) {
-	lsb.WriteString(strings.Repeat(tab, depth))
-	lsb.WriteString(str)
-	lsb.WriteString("\n")
+	if str != "" {
+		depth += lsb.indentation
+		if depth < len(indentations) {
+			lsb.WriteString(indentations[depth])
+		} else {
+			lsb.WriteString(strings.Repeat(tab, depth))
+		}
+		lsb.WriteString(str)
+	}
+	lsb.WriteByte('\n')
 }
 
-// ClassDiagramOptions will provide a way for callers of the NewClassDiagramFs() function to pass all the necessary arguments.
+// ClassDiagramOptions will provide a way for callers of the NewClassDiagramWithOptions() function to pass all the
+// necessary arguments. New settings are added as fields here so the constructors never need to change.
+//
+// All the options are used while parsing except for RenderingOptions, which only affect the Render() output and
+// can be changed later with SetRenderingOptions() without parsing the code again.
 type ClassDiagramOptions struct {
-	FileSystem         afero.Fs
-	Directories        []string
+	// FileSystem is used to walk the directories when Recursive is set
+	FileSystem afero.Fs
+	// Directories holds the directories to parse
+	Directories []string
+	// Files holds single go files to parse besides the ones in Directories
+	Files []string
+	// IgnoredDirectories are skipped, with all their subdirectories, when walking recursively. Entries are paths or
+	// glob patterns matched against the slash separated path of each directory relative to the walked directory.
+	// Entries without a slash match directory names at any depth too, and exact paths are matched as they are
 	IgnoredDirectories []string
-	RenderingOptions   map[RenderingOption]interface{}
-	Recursive          bool
+	// RenderingOptions are the initial rendering options, see SetRenderingOptions()
+	RenderingOptions map[RenderingOption]interface{}
+	// Recursive walks all the subdirectories of Directories. Hidden directories are always skipped
+	Recursive bool
+	// IncludeVendor walks vendor directories, which are skipped by default
+	IncludeVendor bool
+	// IncludeTestdata walks testdata directories, which are skipped by default
+	IncludeTestdata bool
+	// FollowSymlinks walks the symbolic links to directories found when Recursive is set, which are skipped by
+	// default. Every directory is parsed once, no matter how many links lead to it
+	FollowSymlinks bool
+	// IncludeGenerated parses files marked as generated, which are skipped by default
+	IncludeGenerated bool
+	// IncludeTests parses the _test.go files, which are skipped by default. The types they declare are rendered with
+	// the test stereotype and the external test packages, like foo_test, get their own namespace
+	IncludeTests bool
+	// IgnorePromotedMethods only uses the methods declared on a struct to find the interfaces it implements
+	IgnorePromotedMethods bool
+	// Workers is the number of directories parsed concurrently. It defaults to runtime.NumCPU()
+	Workers int
+	// BuildContext only parses the files of Directories that are part of the build for its platform and tags. Every
+	// file is parsed when it is nil, and the types, methods and functions declared again in other files of a
+	// package, like foo_linux.go and foo_windows.go, keep their first declaration and are reported in Warnings()
+	BuildContext *BuildContext
+	// Include is a regular expression matched against package.TypeName. Only the types that match are kept
+	Include string
+	// Exclude is a regular expression matched against package.TypeName. The types that match are removed after
+	// applying Include. Relationships with removed types are removed as well
+	Exclude string
+	// Roots limits the diagram to the neighborhood of the given types, the types related to them in any direction by
+	// any kind of relationship, see Depth. Names are resolved like in ClassParser.Implementations and the other types
+	// are left out after applying Include and Exclude. The neighborhood is found again when more code is added
+	Roots []string
+	// Depth is the number of relationships followed from the Roots. Every type connected to them is kept when it is 0
+	Depth int
+	// IgnoreConstructors does not attach package level functions to the types they construct, see findConstructors()
+	IgnoreConstructors bool
+	// IncludeFactories attaches any package level function returning a type of its package to that type, even when
+	// it returns other values too
+	IncludeFactories bool
+	// ImportPaths keys the packages by their import path, found with the go.mod file of their module, instead of
+	// their name so different packages with the same name are not merged. See RenderNamespaceSegments
+	ImportPaths bool
+	// Strict returns the error of the first file that can not be parsed in Directories or Files instead of skipping
+	// it. Errors in the directories found walking recursively are never returned, see ClassParser.Warnings()
+	Strict bool
+	// AggregateChannels adds aggregations to the types sent through the channels of the struct fields
+	AggregateChannels bool
+	// MethodSet is the method set used to find the interfaces implemented by every type, see MethodSet
+	MethodSet MethodSet
+	// AnonymousStructClasses renders the anonymous structs of the struct fields as classes named after the struct
+	// and the field, like Post.Meta, composed by the struct instead of rendering them inline
+	AnonymousStructClasses bool
+	// Cache reuses the types extracted from the directories that did not change since they were parsed with the
+	// same options, and keeps the ones extracted now. Nothing is cached when it is nil, see Cache
+	Cache *Cache
+	// IncludeEmptyInterfaces adds the realizations of the interfaces without methods, which every type implements.
+	// They are skipped by default so the diagram does not get an arrow from every class to them
+	IncludeEmptyInterfaces bool
+	// Verbose adds a warning with the position and the kind of every type expression that can not be rendered, which
+	// is written as ?unknown? in the diagram
+	Verbose bool
 }
 
+// MethodSet selects the methods of a type that are used to find the interfaces it implements
+type MethodSet int
+
+const (
+	// PointerMethodSet uses the methods with value and pointer receivers, which is the method set of *T. It is the
+	// default since types with pointer receivers are mostly used through pointers
+	PointerMethodSet MethodSet = iota
+	// ValueMethodSet only uses the methods with value receivers, which is the method set of T
+	ValueMethodSet
+	// BothMethodSets uses the method set of *T like PointerMethodSet and records the interfaces only *T implements
+	// in Struct.PointerImplements. Their realizations are rendered with a *T label
+	BothMethodSets
+)
+
 // RenderingOptions will allow the class parser to optionally enebale or disable the things to render.
 type RenderingOptions struct {
 	Title                   string
@@ -71,6 +179,37 @@ type RenderingOptions struct {
 	ConnectionLabels        bool
 	AggregatePrivateMembers bool
 	PrivateMembers          bool
+	PrivateFields           bool
+	PrivateMethods          bool
+	LinkTemplate            string
+	ExportedOnly            bool
+	DocNotes                bool
+	DocNotesLength          int
+	NamespaceSegments       int
+	Functions               bool
+	NestedNamespaces        bool
+	NamespaceSeparator      string
+	Tags                    bool
+	TagKey                  string
+	Dependencies            bool
+	PackageColors           map[string]string
+	AutoColors              bool
+	HideOrphans             bool
+	Preamble                []string
+	Header                  string
+	Footer                  string
+	ShortTypeNames          bool
+	ExternalTypeNames       ExternalTypeNames
+	ExternalTypes           ExternalTypes
+	GroupImplementations    bool
+	EmbeddedFields          bool
+	PointerAssociations     bool
+	MaxTypeLength           int
+	AbstractMethods         bool
+	MemberOrder             MemberOrder
+	FieldLabels             FieldLabels
+	PointerReceivers        bool
+	Flat                    bool
 }
 
 const aliasComplexNameComment = "'This class was created so that we can correctly have an alias pointing to this name. Since it contains dots that can break namespaces"
@@ -106,30 +245,222 @@ const (
 	// AggregatePrivateMembers is to be used in the SetRenderingOptions argument as the key to the map, when value is true, it will connect aggregations with private members
 	AggregatePrivateMembers
 
-	// RenderPrivateMembers is used if private members (fields, methods) should be rendered
+	// RenderPrivateMembers is used if private members (fields, methods) should be rendered. Setting it will set
+	// both RenderPrivateFields and RenderPrivateMethods to the same value
 	RenderPrivateMembers
+
+	// RenderPrivateFields is used if private fields should be rendered
+	RenderPrivateFields
+
+	// RenderPrivateMethods is used if private methods should be rendered. Private methods are always taken into
+	// account to find interface implementations even if they are not rendered
+	RenderPrivateMethods
+
+	// RenderLinkTemplate is a URL template used to link every class to the file where it is declared. {path} is
+	// replaced by the path of the file relative to the parsed directory and {line} by the line of the declaration.
+	// No links are rendered when it is empty
+	RenderLinkTemplate
+
+	// RenderExportedOnly renders only the exported types and members when its value is true. Relationships with
+	// unexported types are dropped and the exported members promoted from unexported embedded types are rendered
+	// in the types that embed them
+	RenderExportedOnly
+
+	// RenderDocNotes renders the first sentence of the doc comment of every type as a note on top of it when its
+	// value is true
+	RenderDocNotes
+
+	// RenderDocNotesLength renders the first given number of characters of the doc comments instead of their first
+	// sentence. It is only used with RenderDocNotes
+	RenderDocNotesLength
+
+	// RenderNamespaceSegments shortens the namespaces to the given number of trailing elements of their import
+	// path. Namespaces that would collide keep more elements. 0 renders the full import paths
+	RenderNamespaceSegments
+
+	// RenderFunctions renders the package level functions of every package as static methods of a class named
+	// after the package when its value is true. Constructors are only rendered in the types they construct
+	RenderFunctions
+
+	// RenderNestedNamespaces renders a namespace for every element of the import paths of the packages, nested in
+	// the namespace of their parent, when its value is true
+	RenderNestedNamespaces
+
+	// RenderNamespaceSeparator is the separator of the nested namespaces (see RenderNestedNamespaces). The PlantUML
+	// default "." is used when it is empty
+	RenderNamespaceSeparator
+
+	// RenderTags appends the tags of the struct fields to their lines when its value is true
+	RenderTags
+
+	// RenderTagKey only appends the value of the given key of the struct field tags, for example json. The whole
+	// tags are appended when it is empty. It is only used with RenderTags
+	RenderTagKey
+
+	// RenderDependencies renders a dependency from every type to the parsed types used in the parameters and return
+	// values of its methods when its value is true
+	RenderDependencies
+
+	// RenderPackageColors is a map of package names to the background colors of their namespaces, like #DDEEFF or
+	// LightBlue. Packages can also be given by the name rendered for their namespace
+	RenderPackageColors
+
+	// RenderAutoColors gives every namespace a light background color picked from the name of its package when its
+	// value is true. Colors given in RenderPackageColors take precedence
+	RenderAutoColors
+
+	// RenderHideOrphans leaves out of the diagram the types without fields, methods and rendered relationships
+	// when its value is true. ClassParser.Orphans() lists them
+	RenderHideOrphans
+
+	// RenderPreamble is a list of lines, like skinparam or !include directives, written as they are right after
+	// @startuml
+	RenderPreamble
+
+	// RenderHeader is the header written on the top right corner of every page of the diagram unless empty.
+	// Multi-line values are written as a header block
+	RenderHeader
+
+	// RenderFooter is the footer written on the bottom of every page of the diagram unless empty. Multi-line
+	// values are written as a footer block
+	RenderFooter
+
+	// RenderShortTypeNames writes the types of fields and methods qualified with an import path, like
+	// github.com/aws/aws-sdk-go/service/s3.Client, qualified with their package name instead, like s3.Client
+	RenderShortTypeNames
+
+	// RenderExternalTypeNames is an ExternalTypeNames value that selects how the types of the packages that were
+	// not parsed are written in fields and methods
+	RenderExternalTypeNames
+
+	// RenderExternalTypes is an ExternalTypes value that selects how the relationships to the types of the packages
+	// that were not parsed, like sync.Mutex, are rendered
+	RenderExternalTypes
+
+	// RenderGroupImplementations renders every interface and the types of its package that implement it in a
+	// together block so they are laid out near each other. A type implementing several interfaces joins the group of
+	// the first one in the order of their names
+	RenderGroupImplementations
+
+	// RenderEmbeddedFields lists the embedded types among the fields of the types that embed them, like
+	// + «embed» *BaseHandler, besides rendering them as compositions
+	RenderEmbeddedFields
+
+	// RenderPointerAssociations renders the fields holding a single pointer to a type, like parent *Node, as
+	// associations with the 0..1 multiplicity labeled with the name of the field instead of aggregations. Only used
+	// when aggregations are rendered
+	RenderPointerAssociations
+
+	// RenderMaxTypeLength is the maximum number of characters of the types written in fields and methods. Longer
+	// types are written without their package qualifiers and, if still too long, with an ellipsis in their middle.
+	// No limit is applied when it is 0
+	RenderMaxTypeLength
+
+	// RenderAbstractMethods marks the methods of the interfaces with the {abstract} modifier, which PlantUML writes
+	// in italics, so interfaces are told apart from classes at a glance. It is set by default
+	RenderAbstractMethods
+
+	// RenderMemberOrder is a MemberOrder value that selects the order in which the fields and methods of every class
+	// are written
+	RenderMemberOrder
+
+	// RenderFieldLabels is a FieldLabels value that selects how the aggregations are labeled with the names of the
+	// fields that reference the aggregated types
+	RenderFieldLabels
+
+	// RenderPointerReceivers marks the methods declared on a pointer receiver with «ptr» after their return values, so
+	// the methods that only the pointer to a type has are told apart. Methods of interfaces never get the marker
+	RenderPointerReceivers
+
+	// RenderFlat writes the classes without the namespaces of their packages, declared with their package qualified
+	// names like class "parser.Struct" as parser_Struct, for the renderers that do not support namespaces. The
+	// relationships reference the aliases of the classes
+	RenderFlat
 )
 
 // RenderingOption is an alias for an it so it is easier to use it as options in a map (see SetRenderingOptions(map[RenderingOption]bool) error)
 type RenderingOption int
 
 // ClassParser contains the structure of the parsed files. The structure is a map of package_names that contains
-// a map of structure_names -> Structs
+// a map of structure_names -> Structs. The zero value can not be used, create it with NewClassParser or one of the
+// NewClassDiagram functions.
 type ClassParser struct {
-	renderingOptions   *RenderingOptions
-	structure          map[string]map[string]*Struct
-	currentPackageName string
-	allInterfaces      map[string]struct{}
-	allStructs         map[string]struct{}
-	allImports         map[string]string
-	allAliases         map[string]*Alias
-	allRenamedStructs  map[string]map[string]string
+	renderingOptions  *RenderingOptions
+	structure         map[string]map[string]*Struct
+	allInterfaces     map[string]struct{}
+	allStructs        map[string]struct{}
+	currentImports    map[string]string
+	currentFileSet    *token.FileSet
+	allAliases        map[string]*Alias
+	allRenamedStructs map[string]map[string]string
+	allFunctions      []*Function
+	allConstants      map[string][]string
+	modules           map[string]*goModule
+	warnings          []error
+	namespaces        map[string]string
+	orphans           map[string]struct{}
+	// renderedRelationships are the pairs of classes connected by the diagram being rendered, see
+	// isDuplicateRelationship()
+	renderedRelationships map[string]struct{}
+	options               ClassDiagramOptions
+	include               *regexp.Regexp
+	exclude               *regexp.Regexp
+	// parsedPaths are the directories and files already parsed, see getPathKey()
+	parsedPaths map[string]struct{}
+	// finalized is false while there is parsed code whose relationships were not found yet, see Finalize()
+	finalized bool
+	// functionPositions are the positions of the parsed package level functions, see isRedeclaredFunction()
+	functionPositions map[string]token.Position
+	// packageNames are the import paths of the parsed packages whose name does not follow the naming convention,
+	// mapped to their declared names, see findPackageNames()
+	packageNames map[string]string
+	// neighborhood are the types kept by the Roots option, nil without roots, see updateNeighborhood()
+	neighborhood map[string]struct{}
+	// dotImports are the packages dot imported by the parsed files, by file name, see resolveDotImports()
+	dotImports map[string][]string
+	// droppedRelationships are the relationships left out by the last resolveDotImports() with the warnings about them
+	droppedRelationships map[*Struct]*droppedRelationships
+	dotImportWarnings    []error
+	// mu serializes the calls that add code into the structure and find the relationships, see AddDirectory()
+	mu sync.Mutex
 }
 
 // NewClassDiagramWithOptions returns a new classParser with which can Render the class diagram of
 // files in the given directory passed in the ClassDiargamOptions. This will also alow for different types of FileSystems
 // Passed since it is part of the ClassDiagramOptions as well.
 func NewClassDiagramWithOptions(options *ClassDiagramOptions) (*ClassParser, error) {
+	return NewClassDiagramCtx(context.Background(), options)
+}
+
+// NewClassDiagramCtx is NewClassDiagramWithOptions with a context. Walking and parsing stop between directories and
+// between files once the context is done, and its error is returned wrapped with the path being processed.
+func NewClassDiagramCtx(ctx context.Context, options *ClassDiagramOptions) (*ClassParser, error) {
+	classParser, err := NewClassParser(options)
+	if err != nil {
+		return nil, err
+	}
+	directories, err := classParser.getDirectoriesToParse(ctx, classParser.options.Directories, classParser.options.Files)
+	if err != nil {
+		return nil, err
+	}
+	if err := classParser.mergeDirectories(ctx, directories); err != nil {
+		return nil, err
+	}
+	classParser.Finalize()
+	if err := classParser.checkRoots(); err != nil {
+		return nil, err
+	}
+	return classParser, nil
+}
+
+// NewClassParser returns a ClassParser without any parsed code, ignoring the Directories and Files of the options.
+// Code is added with AddDirectory and ParseFile. The relationships among the parsed types are found by Finalize or
+// by the first method that needs them, like Render. Nil options are the zero ClassDiagramOptions, and the directories
+// are read from the disk when the options have no FileSystem.
+func NewClassParser(options *ClassDiagramOptions) (*ClassParser, error) {
+	if options == nil {
+		options = &ClassDiagramOptions{}
+	}
 	classParser := &ClassParser{
 		renderingOptions: &RenderingOptions{
 			Aggregations:     false,
@@ -141,59 +472,102 @@ func NewClassDiagramWithOptions(options *ClassDiagramOptions) (*ClassParser, err
 			ConnectionLabels: false,
 			Title:            "",
 			Notes:            "",
+			AbstractMethods:  true,
 		},
 		structure:         make(map[string]map[string]*Struct),
 		allInterfaces:     make(map[string]struct{}),
 		allStructs:        make(map[string]struct{}),
-		allImports:        make(map[string]string),
 		allAliases:        make(map[string]*Alias),
 		allRenamedStructs: make(map[string]map[string]string),
+		parsedPaths:       map[string]struct{}{},
+		options:           *options,
 	}
-	ignoreDirectoryMap := map[string]struct{}{}
-	for _, dir := range options.IgnoredDirectories {
-		ignoreDirectoryMap[dir] = struct{}{}
+	if classParser.options.FileSystem == nil {
+		classParser.options.FileSystem = afero.NewOsFs()
 	}
-	for _, directoryPath := range options.Directories {
-		if options.Recursive {
-			err := afero.Walk(options.FileSystem, directoryPath, func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-				if info.IsDir() {
-					if strings.HasPrefix(info.Name(), ".") || info.Name() == "vendor" {
-						return filepath.SkipDir
-					}
-					if _, ok := ignoreDirectoryMap[path]; ok {
-						return filepath.SkipDir
-					}
-					classParser.parseDirectory(path)
-				}
-				return nil
-			})
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			err := classParser.parseDirectory(directoryPath)
-			if err != nil {
-				return nil, err
-			}
-		}
+	if err := classParser.compileFilters(); err != nil {
+		return nil, err
+	}
+	if err := classParser.SetRenderingOptions(options.RenderingOptions); err != nil {
+		return nil, err
 	}
+	return classParser, nil
+}
 
-	for s := range classParser.allStructs {
-		st := classParser.getStruct(s)
-		if st != nil {
-			for i := range classParser.allInterfaces {
-				inter := classParser.getStruct(i)
-				if st.ImplementsInterface(inter) {
-					st.AddToExtends(i)
-				}
+// AddDirectory parses the given directory, and the directories under it if the Recursive option is set, into the
+// structure. Directories that were already parsed are skipped. It can be called after rendering, the relationships
+// are found again for the next render. AddDirectory, ParseFile and ParseSource can be called from several goroutines,
+// the code is added one call at a time, but not while the parser is rendering.
+func (p *ClassParser) AddDirectory(path string) error {
+	return p.AddDirectoryCtx(context.Background(), path)
+}
+
+// AddDirectoryCtx is AddDirectory with a context, see NewClassDiagramCtx. The directories that were not parsed when
+// the context was done can be added again.
+func (p *ClassParser) AddDirectoryCtx(ctx context.Context, path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	directories, err := p.getDirectoriesToParse(ctx, []string{path}, nil)
+	if err != nil {
+		return err
+	}
+	return p.mergeDirectories(ctx, directories)
+}
+
+// Finalize finds the relationships among all the parsed types, like the interfaces they implement. It does nothing
+// if no code was parsed since the last call. Rendering calls it so it only needs to be called explicitly to use the
+// parsed structure right away.
+func (p *ClassParser) Finalize() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finalize()
+}
+
+// finalize is Finalize for the callers already holding the lock
+func (p *ClassParser) finalize() {
+	if p.finalized {
+		return
+	}
+	p.resolveDotImports()
+	p.findImplementations()
+	p.findConstructors()
+	p.findEnums()
+	p.filterTypes()
+	p.updateNeighborhood()
+	p.finalized = true
+}
+
+// mergeDirectories parses the given directories and merges them into the structure. The directories that are not
+// merged when the context is done, or because one of them fails, are no longer marked as parsed.
+func (p *ClassParser) mergeDirectories(ctx context.Context, directories []*parsedDirectory) error {
+	p.findPackageNames(directories)
+	p.parseDirectories(ctx, directories)
+	// Merging the parsed directories in order keeps the result identical no matter how many workers are used
+	for i, directory := range directories {
+		if err := ctx.Err(); err != nil {
+			p.forgetDirectories(directories[i:])
+			return fmt.Errorf("%s: %w", directory.path, err)
+		}
+		if directory.err != nil {
+			if directory.ignoreErrors {
+				continue
 			}
+			p.forgetDirectories(directories[i:])
+			return directory.err
+		}
+		if len(directory.warnings) > 0 && p.options.Strict && !directory.ignoreErrors {
+			p.forgetDirectories(directories[i:])
+			return directory.warnings[0]
 		}
+		p.warnings = append(p.warnings, directory.warnings...)
+		if directory.extraction != nil {
+			p.mergeExtraction(directory.extraction)
+		} else {
+			p.parsePackages(directory.fileSet, directory.packages)
+		}
+		p.finalized = false
 	}
-	classParser.SetRenderingOptions(options.RenderingOptions)
-	return classParser, nil
+	return nil
 }
 
 // NewClassDiagram returns a new classParser with which can Render the class diagram of
@@ -209,64 +583,510 @@ func NewClassDiagram(directoryPaths []string, ignoreDirectories []string, recurs
 	return NewClassDiagramWithOptions(options)
 }
 
-// parse the given ast.Package into the ClassParser structure
+// parse the given ast.Package into the ClassParser structure. The types of every file are parsed before the
+// functions so methods are attached to types of a known kind, whatever the order of the files
 func (p *ClassParser) parsePackage(node ast.Node) {
 	pack := node.(*ast.Package)
-	p.currentPackageName = pack.Name
-	_, ok := p.structure[p.currentPackageName]
+	packageName := p.getPackageKey(pack)
+	_, ok := p.structure[packageName]
 	if !ok {
-		p.structure[p.currentPackageName] = make(map[string]*Struct)
+		p.structure[packageName] = make(map[string]*Struct)
 	}
+	files := p.getPackageFiles(pack)
+	for _, f := range files {
+		p.parseFileDeclarations(f, packageName, false)
+	}
+	for _, f := range files {
+		p.parseFileDeclarations(f, packageName, true)
+	}
+}
+
+// getPackageFiles returns the files of the package sorted by name, without tests and generated files unless the
+// IncludeTests and IncludeGenerated options are set
+func (p *ClassParser) getPackageFiles(pack *ast.Package) []*ast.File {
 	var sortedFiles []string
 	for fileName := range pack.Files {
 		sortedFiles = append(sortedFiles, fileName)
 	}
 	sort.Strings(sortedFiles)
+	files := []*ast.File{}
 	for _, fileName := range sortedFiles {
+		if !p.options.IncludeTests && isTestFile(fileName) {
+			continue
+		}
+		f := pack.Files[fileName]
+		if !p.options.IncludeGenerated && isGenerated(f) {
+			continue
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// getImports returns the map of import aliases -> package names of the given file. Aliases are only valid in the
+// file that declares them so each file gets its own map.
+func (p *ClassParser) getImports(f *ast.File) map[string]string {
+	imports := map[string]string{}
+	for _, impt := range f.Imports {
+		if impt.Name != nil {
+			imports[impt.Name.Name] = p.resolveImportedPackageName(strings.Trim(impt.Path.Value, `"`))
+		}
+	}
+	return imports
+}
 
-		if !strings.HasSuffix(fileName, "_test.go") {
-			f := pack.Files[fileName]
-			for _, d := range f.Imports {
-				p.parseImports(d)
+// majorVersionRegexp matches the major version suffix of an import path (e.g. v2 in github.com/foo/bar/v2)
+var majorVersionRegexp = regexp.MustCompile(`^v[0-9]+$`)
+
+// getImportedPackageName returns the package name for the given import path following the convention of naming
+// packages after the last element of the path. Major version suffixes and the go- prefix are skipped and the name
+// ends at the first character that is not valid in an identifier, so go-yaml.v3 becomes yaml.
+func getImportedPackageName(importPath string) string {
+	splitPath := strings.Split(importPath, "/")
+	name := splitPath[len(splitPath)-1]
+	if len(splitPath) > 1 && majorVersionRegexp.MatchString(name) {
+		name = splitPath[len(splitPath)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := invalidIdentifierRegexp.FindStringIndex(name); i != nil {
+		name = name[:i[0]]
+	}
+	return name
+}
+
+// findImplementations adds the interfaces implemented by each one of the parsed structs. Every type implements the
+// interfaces without methods, like type Marker interface{}, so they are skipped unless the IncludeEmptyInterfaces
+// option is set. Constraint interfaces with type terms are always skipped.
+func (p *ClassParser) findImplementations() {
+	for s := range p.allStructs {
+		st := p.getStruct(s)
+		if st != nil {
+			pointerMethodSet := p.getMethodSet(s, map[string]struct{}{}, true)
+			valueMethodSet := p.getMethodSet(s, map[string]struct{}{}, false)
+			for i := range p.allInterfaces {
+				inter, ok := p.getInterfaceMethodSet(i, map[string]struct{}{})
+				switch {
+				case !ok, len(inter.TypeTerms) > 0:
+					// Constraints can only be satisfied by type arguments, types do not implement them
+				case len(inter.Functions) == 0:
+					p.addImplementation(st, i, p.options.IncludeEmptyInterfaces, p.options.IncludeEmptyInterfaces)
+				default:
+					p.addImplementation(st, i, valueMethodSet.ImplementsInterface(inter), pointerMethodSet.ImplementsInterface(inter))
+				}
 			}
-			for _, d := range f.Decls {
-				p.parseFileDeclarations(d)
+		}
+	}
+}
+
+// addImplementation adds the interface to the implementations of the struct according to the MethodSet option, given
+// whether the value and the pointer method sets of the struct implement it
+func (p *ClassParser) addImplementation(st *Struct, inter string, byValue bool, byPointer bool) {
+	switch {
+	case p.options.MethodSet == ValueMethodSet:
+		if byValue {
+			st.AddToImplements(inter)
+		}
+	case p.options.MethodSet == BothMethodSets && byPointer && !byValue:
+		st.addToPointerImplements(inter)
+	case byPointer:
+		st.AddToImplements(inter)
+	}
+}
+
+// ParseFile parses a single go file into the structure and looks for interface implementations again. Methods
+// declared in other files of the same package are not known unless those files are parsed too.
+func (p *ClassParser) ParseFile(filePath string) error {
+	fileSet := token.NewFileSet()
+	packages, err := parseGoFile(fileSet, filePath)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.parsePackages(fileSet, packages)
+	p.finalized = false
+	p.finalize()
+	return nil
+}
+
+// parsedDirectory holds the packages found in a directory, or in a single file if isFile is set. Errors reading
+// the directories found while walking recursively are ignored. Warnings hold the errors of the files that could not
+// be parsed and were skipped. Directories taken from the cache, or added into it, have the extracted types in
+// extraction instead of packages.
+type parsedDirectory struct {
+	path         string
+	isFile       bool
+	ignoreErrors bool
+	fileSet      *token.FileSet
+	packages     map[string]*ast.Package
+	warnings     []error
+	err          error
+	extraction   *directoryExtraction
+}
+
+// getDirectoriesToParse returns all the given directories and files that need to be parsed in the order in which
+// they have to be merged into the structure. Directories given more than once, or found again walking an overlapping
+// directory, and files of parsed directories are only parsed once.
+func (p *ClassParser) getDirectoriesToParse(ctx context.Context, directoryPaths []string, filePaths []string) ([]*parsedDirectory, error) {
+	directories := []*parsedDirectory{}
+	if p.parsedPaths == nil {
+		p.parsedPaths = map[string]struct{}{}
+	}
+	seen := p.parsedPaths
+	add := func(directory *parsedDirectory) {
+		key := getPathKey(directory.path)
+		if _, ok := seen[key]; ok {
+			return
+		}
+		if _, ok := seen[getPathKey(filepath.Dir(directory.path))]; ok && directory.isFile {
+			return
+		}
+		seen[key] = struct{}{}
+		directories = append(directories, directory)
+	}
+	for _, directoryPath := range directoryPaths {
+		if !p.options.Recursive {
+			add(&parsedDirectory{path: directoryPath})
+			continue
+		}
+		err := p.walkDirectory(ctx, p.options.FileSystem, directoryPath, func(path string) {
+			add(&parsedDirectory{path: path, ignoreErrors: true})
+		})
+		if err != nil {
+			p.forgetDirectories(directories)
+			return nil, err
+		}
+	}
+	for _, filePath := range filePaths {
+		add(&parsedDirectory{path: filePath, isFile: true})
+	}
+	return directories, nil
+}
+
+// forgetDirectories removes the given directories from the parsed ones so they can be added again
+func (p *ClassParser) forgetDirectories(directories []*parsedDirectory) {
+	for _, directory := range directories {
+		delete(p.parsedPaths, getPathKey(directory.path))
+	}
+}
+
+// getPathKey returns the absolute and clean version of the given path, with its symbolic links resolved if it exists
+// on disk, used to find paths given more than once or directories reached through different links
+func getPathKey(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
+
+// parseDirectories parses the go files of the given directories that match the build context using a pool of
+// workers. runtime.NumCPU() workers are used if the Workers option is not a positive number. The directories taken
+// by the workers once the context is done get its error instead of being parsed.
+func (p *ClassParser) parseDirectories(ctx context.Context, directories []*parsedDirectory) {
+	workers := p.options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	jobs := make(chan *parsedDirectory)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for directory := range jobs {
+				if err := ctx.Err(); err != nil {
+					directory.err = fmt.Errorf("%s: %w", directory.path, err)
+					continue
+				}
+				p.parseDirectory(ctx, directory)
 			}
+		}()
+	}
+	for _, directory := range directories {
+		jobs <- directory
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// parseDirectory parses the go files of the given directory, or takes the types extracted from them from the cache.
+// It does not modify the ClassParser so it can be called concurrently.
+func (p *ClassParser) parseDirectory(ctx context.Context, directory *parsedDirectory) {
+	switch {
+	case directory.isFile:
+		directory.fileSet = token.NewFileSet()
+		directory.packages, directory.err = parseGoFile(directory.fileSet, directory.path)
+		if isSyntaxError(directory.err) {
+			directory.warnings, directory.err = []error{directory.err}, nil
 		}
+	case p.options.Cache != nil:
+		p.parseCachedDirectory(ctx, directory)
+	default:
+		directory.fileSet = token.NewFileSet()
+		directory.packages, directory.warnings, directory.err = parseDirectoryFiles(ctx, directory.fileSet, directory.path, p.options.BuildContext, p.options.IncludeTests)
 	}
 }
 
-func (p *ClassParser) parseImports(impt *ast.ImportSpec) {
-	if impt.Name != nil {
-		splitPath := strings.Split(impt.Path.Value, "/")
-		s := strings.Trim(splitPath[len(splitPath)-1], `"`)
-		p.allImports[impt.Name.Name] = s
+// WalkDirectory calls found for the given directory and for every directory under it that is parsed with the Recursive
+// option, in the FileSystem of the given options. The directories skipped by the other options, like
+// IgnoredDirectories and IncludeVendor, are skipped the same way, so tools can watch the directories that are parsed.
+func WalkDirectory(options *ClassDiagramOptions, root string, found func(dir string)) error {
+	p := &ClassParser{options: *options}
+	return p.walkDirectory(context.Background(), options.FileSystem, root, found)
+}
+
+// walkDirectory walks all the directories under the given root and calls found for each one of them. Hidden
+// directories, vendor and testdata directories are skipped as well as the ignored ones. The root directory itself is
+// never skipped. Symbolic links to directories are only walked with the FollowSymlinks option, and every directory is
+// only walked once, keyed by its resolved path, so cycles of links end. The walk stops with the error of the context
+// once it is done.
+func (p *ClassParser) walkDirectory(ctx context.Context, fs afero.Fs, root string, found func(path string)) error {
+	// Missing roots report the error of lstat like the other walked paths, but the root is followed even if it is a
+	// symbolic link since it was given explicitly
+	if lstater, ok := fs.(afero.Lstater); ok {
+		if _, _, err := lstater.LstatIfPossible(root); err != nil {
+			return err
+		}
+	}
+	info, err := fs.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return nil
 	}
+	return p.walkSubdirectories(ctx, fs, root, root, map[string]struct{}{}, found)
 }
 
-func (p *ClassParser) parseDirectory(directoryPath string) error {
-	fs := token.NewFileSet()
-	result, err := parser.ParseDir(fs, directoryPath, nil, 0)
+// walkSubdirectories calls found for the given directory, found walking root, and walks its subdirectories in the
+// order of their names. The directories in visited are skipped.
+func (p *ClassParser) walkSubdirectories(ctx context.Context, fs afero.Fs, root string, dir string, visited map[string]struct{}, found func(path string)) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s: %w", dir, err)
+	}
+	key := getPathKey(dir)
+	if _, ok := visited[key]; ok {
+		return nil
+	}
+	visited[key] = struct{}{}
+	found(dir)
+	// The entries are not followed if they are symbolic links
+	entries, err := afero.ReadDir(fs, dir)
 	if err != nil {
 		return err
 	}
-	for _, v := range result {
-		p.parsePackage(v)
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.Mode()&os.ModeSymlink != 0 {
+			if !p.options.FollowSymlinks {
+				continue
+			}
+			// Broken links and links to files are skipped
+			if info, err := fs.Stat(path); err != nil || !info.IsDir() {
+				continue
+			}
+		} else if !entry.IsDir() {
+			continue
+		}
+		if p.isSkippedDirectory(entry.Name()) {
+			continue
+		}
+		ignored, err := p.isIgnoredDirectory(root, path)
+		if err != nil {
+			return err
+		}
+		if ignored {
+			continue
+		}
+		if err := p.walkSubdirectories(ctx, fs, root, path, visited, found); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// parse the given declaration looking for classes, interfaces, or member functions
-func (p *ClassParser) parseFileDeclarations(node ast.Decl) {
-	switch decl := node.(type) {
-	case *ast.GenDecl:
-		p.handleGenDecl(decl)
-	case *ast.FuncDecl:
-		p.handleFuncDecl(decl)
+// isIgnoredDirectory returns true if the given directory, found walking root, matches one of the IgnoredDirectories.
+// An error is returned for malformed patterns.
+func (p *ClassParser) isIgnoredDirectory(root string, dir string) (bool, error) {
+	relativePath := ""
+	if rel, err := filepath.Rel(root, dir); err == nil {
+		relativePath = filepath.ToSlash(rel)
+	}
+	for _, ignored := range p.options.IgnoredDirectories {
+		if filepath.Clean(ignored) == filepath.Clean(dir) {
+			return true, nil
+		}
+		pattern := path.Clean(filepath.ToSlash(ignored))
+		names := []string{relativePath}
+		if !strings.Contains(pattern, "/") {
+			names = append(names, filepath.Base(dir))
+		}
+		for _, name := range names {
+			matched, err := path.Match(pattern, name)
+			if err != nil {
+				return false, fmt.Errorf("invalid ignored directory %s: %w", ignored, err)
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// isSkippedDirectory returns true for directories that are not walked when parsing recursively
+func (p *ClassParser) isSkippedDirectory(name string) bool {
+	switch {
+	case strings.HasPrefix(name, "."):
+		return true
+	case name == "vendor":
+		return !p.options.IncludeVendor
+	case name == "testdata":
+		return !p.options.IncludeTestdata
+	}
+	return false
+}
+
+// generatedCodeRegexp matches the comment that marks generated files. See https://golang.org/s/generatedcode
+var generatedCodeRegexp = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// isTestFile returns true if the given file name is the name of a test file
+func isTestFile(fileName string) bool {
+	return strings.HasSuffix(fileName, "_test.go")
+}
+
+// isGenerated returns true if the file contains the standard comment of generated files before the package clause
+func isGenerated(f *ast.File) bool {
+	for _, commentGroup := range f.Comments {
+		if commentGroup.Pos() > f.Package {
+			return false
+		}
+		for _, comment := range commentGroup.List {
+			if generatedCodeRegexp.MatchString(comment.Text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseDirectoryFiles parses the go files of the given directory except for the files that do not match the build
+// context and, unless includeTests is set, the test files. The files that can not be parsed are skipped and their errors returned as warnings. It
+// does not modify the ClassParser so it can be called concurrently. It stops with the error of the context, wrapped
+// with the file that was about to be parsed, once the context is done.
+func parseDirectoryFiles(ctx context.Context, fileSet *token.FileSet, directoryPath string, buildContext *BuildContext, includeTests bool) (map[string]*ast.Package, []error, error) {
+	entries, err := os.ReadDir(directoryPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	packages := map[string]*ast.Package{}
+	var warnings []error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || (!includeTests && isTestFile(entry.Name())) {
+			continue
+		}
+		filePath := filepath.Join(directoryPath, entry.Name())
+		if err := ctx.Err(); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		f, err := parser.ParseFile(fileSet, filePath, nil, parser.ParseComments)
+		if err != nil {
+			warnings = append(warnings, err)
+			continue
+		}
+		if !buildContext.matchFile(entry.Name(), f) {
+			continue
+		}
+		addFileToPackages(packages, filePath, f)
+	}
+	return packages, warnings, nil
+}
+
+// parseGoFile parses a single go file and returns it as the only file of its package. It does not modify the
+// ClassParser so it can be called concurrently.
+func parseGoFile(fileSet *token.FileSet, filePath string) (map[string]*ast.Package, error) {
+	f, err := parser.ParseFile(fileSet, filePath, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	packages := map[string]*ast.Package{}
+	addFileToPackages(packages, filePath, f)
+	return packages, nil
+}
+
+// addFileToPackages adds the given parsed file into the package it belongs to
+func addFileToPackages(packages map[string]*ast.Package, filePath string, f *ast.File) {
+	pack, ok := packages[f.Name.Name]
+	if !ok {
+		pack = &ast.Package{
+			Name:  f.Name.Name,
+			Files: map[string]*ast.File{},
+		}
+		packages[f.Name.Name] = pack
+	}
+	pack.Files[filePath] = f
+}
+
+// isSyntaxError returns true if the given error was returned parsing a file that could be read
+func isSyntaxError(err error) bool {
+	var errorList scanner.ErrorList
+	return errors.As(err, &errorList)
+}
+
+// Warnings returns the errors of the files that could not be parsed and were skipped, see the Strict option, and the
+// declarations skipped because they were declared again in another file of the directory, see BuildContext, and the
+// relationships that are not rendered because a dot imported package was not parsed
+func (p *ClassParser) Warnings() []error {
+	return append(append([]error{}, p.warnings...), p.dotImportWarnings...)
+}
+
+// parsePackages adds the given packages into the structure sorted by package name. The file set is the one used
+// to parse the packages and it is needed to know the position of the declarations
+func (p *ClassParser) parsePackages(fileSet *token.FileSet, packages map[string]*ast.Package) {
+	p.currentFileSet = fileSet
+	packageNames := []string{}
+	for name := range packages {
+		packageNames = append(packageNames, name)
+	}
+	sort.Strings(packageNames)
+	for _, name := range packageNames {
+		p.parsePackage(packages[name])
+	}
+}
+
+// parse the declarations of the given file of the package with the given name looking for classes, interfaces and
+// constants, or for member and package level functions if functions is set
+func (p *ClassParser) parseFileDeclarations(f *ast.File, packageName string, functions bool) {
+	if p.options.ImportPaths {
+		p.currentImports = getImportPaths(f)
+	} else {
+		p.currentImports = p.getImports(f)
+	}
+	if !functions {
+		p.addDotImports(f)
+	}
+	if p.options.Verbose && !functions {
+		p.warnUnknownTypes(f)
+	}
+	for _, node := range f.Decls {
+		switch decl := node.(type) {
+		case *ast.GenDecl:
+			if !functions {
+				p.handleGenDecl(decl, packageName)
+			}
+		case *ast.FuncDecl:
+			if functions {
+				p.handleFuncDecl(decl, packageName)
+			}
+		}
 	}
 }
 
-func (p *ClassParser) handleFuncDecl(decl *ast.FuncDecl) {
+func (p *ClassParser) handleFuncDecl(decl *ast.FuncDecl, packageName string) {
 
 	if decl.Recv != nil {
 		if decl.Recv.List == nil {
@@ -274,17 +1094,21 @@ func (p *ClassParser) handleFuncDecl(decl *ast.FuncDecl) {
 		}
 
 		// Only get in when the function is defined for a structure. Global functions are not needed for class diagram
-		theType, _ := getFieldType(decl.Recv.List[0].Type, p.allImports)
+		theType, _ := getFieldType(getReceiverBaseType(decl.Recv.List[0].Type), p.currentImports)
 		theType = replacePackageConstant(theType, "")
 		if theType[0] == "*"[0] {
 			theType = theType[1:]
 		}
-		structure := p.getOrCreateStruct(theType)
+		structure := p.getOrCreateStruct(packageName, theType)
+		if p.isRedeclaredMethod(structure, decl.Name.Name, decl.Pos()) {
+			return
+		}
 		if structure.Type == "" {
+			// The type is not declared in the parsed files of the package, like when a single file is parsed
 			structure.Type = "class"
 		}
 
-		fullName := fmt.Sprintf("%s.%s", p.currentPackageName, theType)
+		fullName := fmt.Sprintf("%s.%s", packageName, theType)
 		p.allStructs[fullName] = struct{}{}
 		structure.AddMethod(&ast.Field{
 			Names:   []*ast.Ident{decl.Name},
@@ -292,99 +1116,227 @@ func (p *ClassParser) handleFuncDecl(decl *ast.FuncDecl) {
 			Type:    decl.Type,
 			Tag:     nil,
 			Comment: nil,
-		}, p.allImports)
+		}, p.currentImports)
+		method := structure.Functions[len(structure.Functions)-1]
+		method.Position = p.getPosition(decl.Pos())
+		_, method.PointerReceiver = unparen(decl.Recv.List[0].Type).(*ast.StarExpr)
+	} else if decl.Name.Name != "init" && !p.isRedeclaredFunction(packageName, decl.Name.Name, decl.Pos()) {
+		// Package level functions are kept until all the types are known, see findConstructors()
+		function := getFunction(decl.Type, decl.Name.Name, p.currentImports, packageName)
+		function.Position = p.getPosition(decl.Pos())
+		p.allFunctions = append(p.allFunctions, function)
+	}
+}
+
+// warnUnknownTypes adds a warning for every type expression of the type declarations and of the function signatures
+// of the given file that can not be rendered
+func (p *ClassParser) warnUnknownTypes(f *ast.File) {
+	var nodes []ast.Node
+	for _, node := range f.Decls {
+		switch decl := node.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+					nodes = append(nodes, typeSpec)
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv != nil {
+				nodes = append(nodes, decl.Recv)
+			}
+			nodes = append(nodes, decl.Type)
+		}
+	}
+	for _, node := range nodes {
+		for _, unknown := range findUnknownTypes(node) {
+			p.warnings = append(p.warnings, fmt.Errorf("%s: unknown type expression %T rendered as %s", p.getPosition(unknown.Pos()), unknown, unknownType))
+		}
+	}
+}
+
+// getPosition returns the position of the given node in the file being parsed
+func (p *ClassParser) getPosition(pos token.Pos) token.Position {
+	if p.currentFileSet == nil {
+		return token.Position{}
 	}
+	return p.currentFileSet.Position(pos)
+}
+
+func handleGenDecStructType(p *ClassParser, packageName string, typeName string, c *ast.StructType) {
+	p.addStructFields(packageName, typeName, c, 1)
 }
 
-func handleGenDecStructType(p *ClassParser, typeName string, c *ast.StructType) {
+// addStructFields adds the fields of the given struct type, nested in the given number of anonymous structs
+// including itself, to the struct with the given name of the given package
+func (p *ClassParser) addStructFields(packageName string, typeName string, c *ast.StructType, depth int) {
 	for _, f := range c.Fields.List {
-		p.getOrCreateStruct(typeName).AddField(f, p.allImports)
+		st := p.getOrCreateStruct(packageName, typeName)
+		if !p.addAnonymousStructClass(st, f, depth) {
+			st.AddField(f, p.currentImports)
+		}
+		// The fields declared together are the last ones added, in the same order as their names. Embedded types
+		// add a single field
+		if len(f.Names) == 0 {
+			st.Fields[len(st.Fields)-1].Position = p.getPosition(f.Type.Pos())
+		}
+		for i, name := range f.Names {
+			st.Fields[len(st.Fields)-len(f.Names)+i].Position = p.getPosition(name.Pos())
+		}
+		if p.options.AggregateChannels {
+			st.addChannelAggregations(f, p.currentImports)
+		}
 	}
 }
 
-func handleGenDecInterfaceType(p *ClassParser, typeName string, c *ast.InterfaceType) {
+func handleGenDecInterfaceType(p *ClassParser, packageName string, typeName string, c *ast.InterfaceType) {
 	for _, f := range c.Methods.List {
-		switch t := f.Type.(type) {
+		if isTypeTerm(unparen(f.Type)) {
+			p.getOrCreateStruct(packageName, typeName).addTypeTerm(f.Type, p.currentImports)
+			continue
+		}
+		switch t := unparen(f.Type).(type) {
 		case *ast.FuncType:
-			p.getOrCreateStruct(typeName).AddMethod(f, p.allImports)
+			st := p.getOrCreateStruct(packageName, typeName)
+			st.AddMethod(f, p.currentImports)
+			st.Functions[len(st.Functions)-1].Position = p.getPosition(f.Pos())
 			break
-		case *ast.Ident:
-			f, _ := getFieldType(t, p.allImports)
-			st := p.getOrCreateStruct(typeName)
+		case *ast.Ident, *ast.SelectorExpr:
+			// Embedded interfaces extend the interface that embeds them
+			f, _ := getFieldType(t, p.currentImports)
+			st := p.getOrCreateStruct(packageName, typeName)
 			f = replacePackageConstant(f, st.PackageName)
-			st.AddToComposition(f)
+			st.AddToExtends(f)
 			break
+		case *ast.IndexExpr:
+			p.addGenericExtends(packageName, typeName, t.X, []ast.Expr{t.Index})
+		case *ast.IndexListExpr:
+			p.addGenericExtends(packageName, typeName, t.X, t.Indices)
 		}
 	}
 }
 
-func (p *ClassParser) handleGenDecl(decl *ast.GenDecl) {
+// addGenericExtends adds the extension of an embedded generic interface instantiated with the given type arguments,
+// which are used to find the methods it adds to the interface that embeds it
+func (p *ClassParser) addGenericExtends(packageName string, typeName string, genericType ast.Expr, typeArguments []ast.Expr) {
+	st := p.getOrCreateStruct(packageName, typeName)
+	f, _ := getFieldType(genericType, p.currentImports)
+	f = replacePackageConstant(f, st.PackageName)
+	arguments := make([]string, 0, len(typeArguments))
+	for _, argument := range typeArguments {
+		a, _ := getFieldType(argument, p.currentImports)
+		arguments = append(arguments, replacePackageConstant(a, st.PackageName))
+	}
+	st.AddToExtends(f)
+	if st.ExtendsTypeArguments == nil {
+		st.ExtendsTypeArguments = map[string][]string{}
+	}
+	st.ExtendsTypeArguments[f] = arguments
+}
+
+func (p *ClassParser) handleGenDecl(decl *ast.GenDecl, packageName string) {
 	if decl.Specs == nil || len(decl.Specs) < 1 {
 		// This might be a type of General Declaration we do not know how to handle.
 		return
 	}
+	if decl.Tok == token.CONST {
+		p.handleConstDecl(decl, packageName)
+		return
+	}
 	for _, spec := range decl.Specs {
-		p.processSpec(spec)
+		doc := decl.Doc
+		if typeSpec, ok := spec.(*ast.TypeSpec); ok && (typeSpec.Doc != nil || decl.Lparen.IsValid()) {
+			// Grouped declarations have their own doc comment for each type
+			doc = typeSpec.Doc
+		}
+		p.processSpec(spec, doc, packageName)
 	}
 }
 
-func (p *ClassParser) processSpec(spec ast.Spec) {
+func (p *ClassParser) processSpec(spec ast.Spec, doc *ast.CommentGroup, packageName string) {
 	var typeName string
 	var alias *Alias
 	declarationType := "alias"
 	switch v := spec.(type) {
 	case *ast.TypeSpec:
 		typeName = v.Name.Name
-		switch c := v.Type.(type) {
+		if p.isRedeclaredType(packageName, typeName, v.Pos()) {
+			return
+		}
+		switch c := unparen(v.Type).(type) {
 		case *ast.StructType:
 			declarationType = "class"
-			handleGenDecStructType(p, typeName, c)
+			p.getOrCreateStruct(packageName, typeName).AddTypeParameters(v.TypeParams, p.currentImports)
+			handleGenDecStructType(p, packageName, typeName, c)
 		case *ast.InterfaceType:
 			declarationType = "interface"
-			handleGenDecInterfaceType(p, typeName, c)
+			p.getOrCreateStruct(packageName, typeName).AddTypeParameters(v.TypeParams, p.currentImports)
+			handleGenDecInterfaceType(p, packageName, typeName, c)
 		default:
-			basicType, _ := getFieldType(getBasicType(c), p.allImports)
+			// Named types like type UserID int64 can have methods while aliases like type Email = string can not
+			if !v.Assign.IsValid() {
+				declarationType = "type"
+			}
+			p.getOrCreateStruct(packageName, typeName).AddTypeParameters(v.TypeParams, p.currentImports)
+			basicType, _ := getFieldType(getBasicType(c), p.currentImports)
 
-			aliasType, _ := getFieldType(c, p.allImports)
+			aliasType, _ := getFieldType(c, p.currentImports)
 			aliasType = replacePackageConstant(aliasType, "")
-			if !isPrimitiveString(typeName) {
-				typeName = fmt.Sprintf("%s.%s", p.currentPackageName, typeName)
-			}
-			packageName := p.currentPackageName
+			aliasPackageName := packageName
 			if isPrimitiveString(basicType) {
-				packageName = builtinPackageName
+				aliasPackageName = builtinPackageName
 			}
-			alias = getNewAlias(fmt.Sprintf("%s.%s", packageName, aliasType), p.currentPackageName, typeName)
-
+			alias = getNewAlias(fmt.Sprintf("%s.%s", aliasPackageName, aliasType), packageName, fmt.Sprintf("%s.%s", packageName, typeName))
 		}
 	default:
 		// Not needed for class diagrams (Imports, global variables, regular functions, etc)
 		return
 	}
-	p.getOrCreateStruct(typeName).Type = declarationType
-	fullName := fmt.Sprintf("%s.%s", p.currentPackageName, typeName)
+	st := p.getOrCreateStruct(packageName, typeName)
+	st.Type = declarationType
+	st.Doc = strings.TrimSpace(doc.Text())
+	if p.currentFileSet != nil {
+		st.Position = p.currentFileSet.Position(spec.Pos())
+	}
+	fullName := fmt.Sprintf("%s.%s", packageName, typeName)
 	switch declarationType {
 	case "interface":
 		p.allInterfaces[fullName] = struct{}{}
+		// A method parsed in an earlier call, before the type was known, may have taken it for a struct
+		delete(p.allStructs, fullName)
 	case "class":
 		p.allStructs[fullName] = struct{}{}
-	case "alias":
-		p.allAliases[typeName] = alias
-		if strings.Count(alias.Name, ".") > 1 {
-			pack := strings.SplitN(alias.Name, ".", 2)
-			if _, ok := p.allRenamedStructs[pack[0]]; !ok {
-				p.allRenamedStructs[pack[0]] = map[string]string{}
+	case "alias", "type":
+		p.allAliases[fullName] = alias
+		if renamedType := alias.getRenamedType(); renamedType != "" {
+			if _, ok := p.allRenamedStructs[alias.PackageName]; !ok {
+				p.allRenamedStructs[alias.PackageName] = map[string]string{}
 			}
-			renamedClass := generateRenamedStructName(pack[1])
-			p.allRenamedStructs[pack[0]][renamedClass] = pack[1]
+			renamedClass := generateRenamedStructName(renamedType)
+			p.allRenamedStructs[alias.PackageName][renamedClass] = renamedType
 		}
 	}
 	return
 }
 
+// getReceiverBaseType removes the type arguments of generic receivers so that methods declared on *List[T]
+// are attached to the List structure
+func getReceiverBaseType(theType ast.Expr) ast.Expr {
+	theType = unparen(theType)
+	switch t := theType.(type) {
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: getReceiverBaseType(t.X)}
+	case *ast.IndexExpr:
+		return t.X
+	case *ast.IndexListExpr:
+		return t.X
+	}
+	return theType
+}
+
 // If this element is an array or a pointer, this function will return the type that is closer to these
 // two definitions. For example []***map[int] string will return map[int]string
 func getBasicType(theType ast.Expr) ast.Expr {
+	theType = unparen(theType)
 	switch t := theType.(type) {
 	case *ast.ArrayType:
 		return getBasicType(t.Elt)
@@ -402,46 +1354,147 @@ func getBasicType(theType ast.Expr) ast.Expr {
 
 // Render returns a string of the class diagram that this parser has generated.
 func (p *ClassParser) Render() string {
-	str := &LineStringBuilder{}
-	str.WriteLineWithDepth(0, "@startuml")
-	if p.renderingOptions.Title != "" {
-		str.WriteLineWithDepth(0, fmt.Sprintf(`title %s`, p.renderingOptions.Title))
-	}
-	if note := strings.TrimSpace(p.renderingOptions.Notes); note != "" {
-		str.WriteLineWithDepth(0, "legend")
-		str.WriteLineWithDepth(0, note)
-		str.WriteLineWithDepth(0, "end legend")
-	}
+	str := &strings.Builder{}
+	// Writing into a strings.Builder never fails
+	p.RenderTo(str)
+	return str.String()
+}
 
-	var packages []string
-	for pack := range p.structure {
-		packages = append(packages, pack)
+// writeTextBlock writes the given text after the keyword, like title or footer, unless it is empty. Texts with more
+// than one line are written in a block closed with end keyword since PlantUML only reads the first line otherwise.
+func writeTextBlock(str *LineStringBuilder, keyword string, text string) {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return
 	}
-	sort.Strings(packages)
-	for _, pack := range packages {
-		structures := p.structure[pack]
-		p.renderStructures(pack, structures, str)
-
+	if !strings.Contains(text, "\n") {
+		str.WriteLineWithDepth(0, fmt.Sprintf("%s %s", keyword, text))
+		return
 	}
-	if p.renderingOptions.Aliases {
-		p.renderAliases(str)
+	str.WriteLineWithDepth(0, keyword)
+	for _, line := range strings.Split(text, "\n") {
+		str.WriteLineWithDepth(0, line)
 	}
-	if !p.renderingOptions.Fields {
-		str.WriteLineWithDepth(0, "hide fields")
+	str.WriteLineWithDepth(0, "end "+keyword)
+}
+
+// RenderTo writes the class diagram that this parser has generated into the given writer. The diagram is
+// written one section at a time as it is produced, so it never needs to be held in memory all at once.
+// Any error returned by the writer is returned and stops the rendering.
+func (p *ClassParser) RenderTo(w io.Writer) error {
+	p.Finalize()
+	return p.renderDiagram(w, p.getSortedPackages(), p.renderingOptions.Title, false)
+}
+
+// RenderPackageTo writes the class diagram of the given package into the given writer like RenderTo. Only the types
+// of the package are declared but the relationships starting from them are rendered, even if they point at types
+// of other packages.
+func (p *ClassParser) RenderPackageTo(w io.Writer, pack string) error {
+	p.Finalize()
+	if _, ok := p.structure[pack]; !ok {
+		return fmt.Errorf("unknown package %s", pack)
+	}
+	return p.renderDiagram(w, []string{pack}, p.renderingOptions.Title, false)
+}
+
+// RenderPackage returns the class diagram of the given package, see RenderPackageTo
+func (p *ClassParser) RenderPackage(pack string) (string, error) {
+	str := &strings.Builder{}
+	if err := p.RenderPackageTo(str, pack); err != nil {
+		return "", err
+	}
+	return str.String(), nil
+}
+
+// getSortedPackages returns the sorted names of the parsed packages
+func (p *ClassParser) getSortedPackages() []string {
+	var packages []string
+	for pack := range p.structure {
+		packages = append(packages, pack)
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// renderDiagram writes the diagram of the given packages with the given title. The overview diagram only has the
+// types, without members, and the relationships among types of different packages.
+func (p *ClassParser) renderDiagram(w io.Writer, packages []string, title string, overview bool) error {
+	p.updateNamespaces()
+	p.updateOrphans()
+	p.renderedRelationships = map[string]struct{}{}
+	str := &LineStringBuilder{}
+	str.WriteLineWithDepth(0, "@startuml")
+	for _, line := range p.renderingOptions.Preamble {
+		str.WriteLineWithDepth(0, line)
 	}
-	if !p.renderingOptions.Methods {
-		str.WriteLineWithDepth(0, "hide methods")
+	if p.renderingOptions.Flat {
+		// The dots of the package qualified names must not create namespaces
+		str.WriteLineWithDepth(0, `set namespaceSeparator none`)
+	} else if separator := p.getNamespaceSeparator(); separator != "." {
+		str.WriteLineWithDepth(0, fmt.Sprintf(`set namespaceSeparator %s`, separator))
+	}
+	writeTextBlock(str, "title", title)
+	writeTextBlock(str, "header", p.renderingOptions.Header)
+	writeTextBlock(str, "footer", p.renderingOptions.Footer)
+	if note := strings.TrimSpace(p.renderingOptions.Notes); note != "" {
+		str.WriteLineWithDepth(0, "legend")
+		str.WriteLineWithDepth(0, note)
+		str.WriteLineWithDepth(0, "end legend")
+	}
+	if p.renderingOptions.ExternalTypes == StubExternalTypes {
+		p.renderExternalTypes(str, p.getExternalTypes(packages, overview))
+	}
+	if err := flushTo(w, str); err != nil {
+		return err
+	}
+
+	rendered := map[string]struct{}{}
+	for _, pack := range packages {
+		rendered[pack] = struct{}{}
+		structures := p.getStructures(pack)
+		if overview {
+			structures = getOverviewStructures(structures)
+		}
+		p.renderStructures(pack, structures, str)
+		if err := flushTo(w, str); err != nil {
+			return err
+		}
+	}
+	if p.renderingOptions.Aliases {
+		p.renderAliases(str, func(alias *Alias) bool {
+			if overview {
+				aliasOfPackage, _ := splitFullTypeName(alias.AliasOf)
+				return aliasOfPackage != alias.PackageName
+			}
+			_, ok := rendered[alias.PackageName]
+			return ok
+		})
+	}
+	if overview {
+		str.WriteLineWithDepth(0, "hide members")
+	} else {
+		if !p.renderingOptions.Fields {
+			str.WriteLineWithDepth(0, "hide fields")
+		}
+		if !p.renderingOptions.Methods {
+			str.WriteLineWithDepth(0, "hide methods")
+		}
 	}
 	str.WriteLineWithDepth(0, "@enduml")
-	return str.String()
+	return flushTo(w, str)
+}
+
+// flushTo writes the contents of the builder into the writer and resets the builder so it can be reused
+func flushTo(w io.Writer, str *LineStringBuilder) error {
+	_, err := io.WriteString(w, str.String())
+	str.Reset()
+	return err
 }
 
 func (p *ClassParser) renderStructures(pack string, structures map[string]*Struct, str *LineStringBuilder) {
-	if len(structures) > 0 {
-		composition := &LineStringBuilder{}
-		extends := &LineStringBuilder{}
-		aggregations := &LineStringBuilder{}
-		str.WriteLineWithDepth(0, fmt.Sprintf(`namespace %s {`, pack))
+	functions := p.getRenderedFunctions(pack)
+	if len(structures) > 0 || len(functions) > 0 {
+		p.startPackage(pack, str)
 
 		names := []string{}
 		for name := range structures {
@@ -450,10 +1503,18 @@ func (p *ClassParser) renderStructures(pack string, structures map[string]*Struc
 
 		sort.Strings(names)
 
-		for _, name := range names {
+		// The relationships are rendered after the namespace, in the order the types were rendered in
+		var renderedNames []string
+		var renderedStructures []*Struct
+		p.renderGroupedStructures(pack, structures, names, str, func(name string, str *LineStringBuilder) {
 			structure := structures[name]
-			p.renderStructure(structure, pack, name, str, composition, extends, aggregations)
-		}
+			if p.renderingOptions.ExportedOnly {
+				structure = p.getExportedStructure(structure)
+			}
+			p.renderStructure(structure, pack, name, str)
+			renderedNames = append(renderedNames, name)
+			renderedStructures = append(renderedStructures, structure)
+		})
 		var orderedRenamedStructs []string
 		for tempName := range p.allRenamedStructs[pack] {
 			orderedRenamedStructs = append(orderedRenamedStructs, tempName)
@@ -461,24 +1522,56 @@ func (p *ClassParser) renderStructures(pack string, structures map[string]*Struc
 		sort.Strings(orderedRenamedStructs)
 		for _, tempName := range orderedRenamedStructs {
 			name := p.allRenamedStructs[pack][tempName]
+			if p.renderingOptions.Flat {
+				tempName = p.getFlatName(getFullTypeName(pack, tempName))
+			}
 			str.WriteLineWithDepth(1, fmt.Sprintf(`class "%s" as %s {`, name, tempName))
 			str.WriteLineWithDepth(2, aliasComplexNameComment)
 			str.WriteLineWithDepth(1, "}")
 		}
-		str.WriteLineWithDepth(0, fmt.Sprintf(`}`))
-		if p.renderingOptions.Compositions {
-			str.WriteLineWithDepth(0, composition.String())
+		if len(functions) > 0 {
+			p.renderPackageFunctions(pack, functions, str)
 		}
-		if p.renderingOptions.Implementations {
-			str.WriteLineWithDepth(0, extends.String())
+		p.endPackage(str)
+		if p.renderingOptions.DocNotes {
+			p.renderDocNotes(pack, structures, names, str)
 		}
-		if p.renderingOptions.Aggregations {
-			str.WriteLineWithDepth(0, aggregations.String())
+		p.renderRelationships(renderedStructures, renderedNames, str)
+		p.renderPackageCreations(pack, functions, str)
+	}
+}
+
+// renderRelationships renders the compositions, then the extensions and realizations, the aggregations, the
+// interfaces created by the constructors and the dependencies of the given structures, each kind in the order of the
+// structures
+func (p *ClassParser) renderRelationships(structures []*Struct, names []string, str *LineStringBuilder) {
+	if p.renderingOptions.Compositions {
+		for i, structure := range structures {
+			p.renderCompositions(structure, names[i], str)
+		}
+	}
+	if p.renderingOptions.Implementations {
+		for i, structure := range structures {
+			p.renderExtends(structure, names[i], str)
+			p.renderImplements(structure, names[i], str)
+		}
+	}
+	if p.renderingOptions.Aggregations {
+		for i, structure := range structures {
+			p.renderAggregations(structure, names[i], str)
+		}
+	}
+	for i, structure := range structures {
+		p.renderCreations(structure, names[i], str)
+	}
+	if p.renderingOptions.Dependencies {
+		for i, structure := range structures {
+			p.renderDependencies(structure, names[i], str)
 		}
 	}
 }
 
-func (p *ClassParser) renderAliases(str *LineStringBuilder) {
+func (p *ClassParser) renderAliases(str *LineStringBuilder, isRendered func(alias *Alias) bool) {
 
 	aliasString := ""
 	if p.renderingOptions.ConnectionLabels {
@@ -486,88 +1579,208 @@ func (p *ClassParser) renderAliases(str *LineStringBuilder) {
 	}
 	orderedAliases := AliasSlice{}
 	for _, alias := range p.allAliases {
-		orderedAliases = append(orderedAliases, *alias)
+		if isRendered(alias) {
+			orderedAliases = append(orderedAliases, *alias)
+		}
 	}
 	sort.Sort(orderedAliases)
 	for _, alias := range orderedAliases {
 		aliasName := alias.Name
-		if strings.Count(alias.Name, ".") > 1 {
-			split := strings.SplitN(alias.Name, ".", 2)
-			if aliasRename, ok := p.allRenamedStructs[split[0]]; ok {
-				renamed := generateRenamedStructName(split[1])
+		if renamedType := alias.getRenamedType(); renamedType != "" {
+			if aliasRename, ok := p.allRenamedStructs[alias.PackageName]; ok {
+				renamed := generateRenamedStructName(renamedType)
 				if _, ok := aliasRename[renamed]; ok {
-					aliasName = fmt.Sprintf("%s.%s", split[0], renamed)
+					aliasName = fmt.Sprintf("%s.%s", alias.PackageName, renamed)
 				}
 			}
 		}
-		str.WriteLineWithDepth(0, fmt.Sprintf(`"%s" #.. %s"%s"`, aliasName, aliasString, alias.AliasOf))
+		if p.isHiddenType(alias.AliasOf) || p.isOutsideNeighborhood(alias.Name) {
+			continue
+		}
+		str.WriteLineWithDepth(0, fmt.Sprintf(`"%s" #.. %s"%s"`, p.getDisplayedName(aliasName), aliasString, p.getDisplayedName(alias.AliasOf)))
 	}
 }
 
-func (p *ClassParser) renderStructure(structure *Struct, pack string, name string, str *LineStringBuilder, composition *LineStringBuilder, extends *LineStringBuilder, aggregations *LineStringBuilder) {
+func (p *ClassParser) renderStructure(structure *Struct, pack string, name string, str *LineStringBuilder) {
+	kind := structure.Kind()
+	renderStructureType := kind.getKeyword()
+	sType := kind.getStereotype()
+	if kind == InterfaceKind && p.isConstraint(structure, getFullTypeName(pack, name)) {
+		sType = "<< constraint >>"
+	}
+	if structure.isTest() {
+		sType = strings.TrimSpace(sType + " << test >>")
+	}
+	link := p.getLink(structure)
+	label := name
+	if structure.Label != "" {
+		label = structure.Label
+	}
+	diagramName := getDiagramTypeName(name)
+	if p.renderingOptions.Flat {
+		label, diagramName = p.getFlatLabel(pack, label), p.getFlatName(getFullTypeName(pack, name))
+	}
+	if diagramName != label {
+		str.WriteLineWithDepth(1, fmt.Sprintf(`%s "%s%s" as %s %s%s {`, renderStructureType, label, getTypeParametersString(structure), diagramName, sType, link))
+	} else {
+		str.WriteLineWithDepth(1, fmt.Sprintf(`%s %s%s %s%s {`, renderStructureType, name, getTypeParametersString(structure), sType, link))
+	}
+	// Hidden members are not rendered at all so the diagram only has the types and their relationships
+	if p.renderingOptions.Fields {
+		p.renderEnumValues(structure, str)
+		p.renderTypeTerms(structure, str)
+		p.renderStructFields(structure, str)
+	}
+	if p.renderingOptions.Methods {
+		p.renderStructMethods(structure, str)
+	}
+	str.WriteLineWithDepth(1, `}`)
+}
 
-	privateFields := &LineStringBuilder{}
-	publicFields := &LineStringBuilder{}
-	privateMethods := &LineStringBuilder{}
-	publicMethods := &LineStringBuilder{}
-	sType := ""
-	renderStructureType := structure.Type
-	switch structure.Type {
-	case "class":
-		sType = "<< (S,Aquamarine) >>"
-	case "alias":
-		sType = "<< (T, #FF7700) >> "
-		renderStructureType = "class"
+// renderEnumValues renders the constants of an enum. Unexported constants are skipped when rendering exported
+// members only.
+func (p *ClassParser) renderEnumValues(structure *Struct, str *LineStringBuilder) {
+	for _, value := range structure.EnumValues {
+		if p.renderingOptions.ExportedOnly && isPrivate(value) {
+			continue
+		}
+		str.WriteLineWithDepth(2, value)
+	}
+}
+
+// renderTypeTerms renders the type terms of a constraint interface, one line each. PlantUML reads a tilde as an
+// escape character, and as the package private visibility at the start of a line, so it is doubled.
+func (p *ClassParser) renderTypeTerms(structure *Struct, str *LineStringBuilder) {
+	for _, term := range structure.TypeTerms {
+		str.WriteLineWithDepth(2, strings.ReplaceAll(term, "~", "~~"))
+	}
+}
+
+// isConstraint returns true if the given interface has type terms or embeds an interface that has them, so it can
+// only be used as a type constraint
+func (p *ClassParser) isConstraint(structure *Struct, fullName string) bool {
+	if len(structure.TypeTerms) > 0 {
+		return true
+	}
+	methodSet, ok := p.getInterfaceMethodSet(fullName, map[string]struct{}{})
+	return ok && len(methodSet.TypeTerms) > 0
+}
 
+// getLink returns the link to the declaration of the structure built with the LinkTemplate rendering option. It
+// returns an empty string if there is no template or the position of the structure is not known.
+func (p *ClassParser) getLink(structure *Struct) string {
+	if p.renderingOptions.LinkTemplate == "" || structure.Position.Filename == "" {
+		return ""
 	}
-	str.WriteLineWithDepth(1, fmt.Sprintf(`%s %s %s {`, renderStructureType, name, sType))
-	p.renderStructFields(structure, privateFields, publicFields)
-	p.renderStructMethods(structure, privateMethods, publicMethods)
-	p.renderCompositions(structure, name, composition)
-	p.renderExtends(structure, name, extends)
-	p.renderAggregations(structure, name, aggregations)
-	if privateFields.Len() > 0 {
-		str.WriteLineWithDepth(0, privateFields.String())
+	replacer := strings.NewReplacer(
+		"{path}", p.getRelativePath(structure.Position.Filename),
+		"{line}", strconv.Itoa(structure.Position.Line),
+	)
+	return fmt.Sprintf(" [[%s]]", replacer.Replace(p.renderingOptions.LinkTemplate))
+}
+
+// renderDocNotes renders the doc comment of the given structures as a note on top of each one of them. Types
+// without a doc comment do not get a note.
+func (p *ClassParser) renderDocNotes(pack string, structures map[string]*Struct, names []string, str *LineStringBuilder) {
+	for _, name := range names {
+		fullName := name
+		if !strings.Contains(name, ".") {
+			fullName = fmt.Sprintf("%s.%s", pack, name)
+		}
+		note := getDocNote(structures[name].Doc, p.renderingOptions.DocNotesLength)
+		if note == "" || !p.isRenderedType(fullName) {
+			continue
+		}
+		str.WriteLineWithDepth(0, fmt.Sprintf(`note top of %s`, p.getDisplayedName(fullName)))
+		str.WriteLineWithDepth(1, note)
+		str.WriteLineWithDepth(0, `end note`)
 	}
-	if publicFields.Len() > 0 {
-		str.WriteLineWithDepth(0, publicFields.String())
+}
+
+// creoleMarkupRegexp matches the character sequences PlantUML uses to format text
+var creoleMarkupRegexp = regexp.MustCompile(`([*/"_\-=~])([*/"_\-=~])`)
+
+// getDocNote returns the text of a note for the given doc comment in a single line. It is the first sentence of
+// the comment, or its first length characters if length is greater than 0, with the PlantUML markup escaped.
+func getDocNote(doc string, length int) string {
+	note := strings.Join(strings.Fields(doc), " ")
+	if length > 0 {
+		if runes := []rune(note); len(runes) > length {
+			note = string(runes[:length]) + "..."
+		}
+	} else if i := strings.Index(note, ". "); i >= 0 {
+		note = note[:i+1]
 	}
-	if privateMethods.Len() > 0 {
-		str.WriteLineWithDepth(0, privateMethods.String())
+	note = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(note)
+	return creoleMarkupRegexp.ReplaceAllString(note, "~$1~$2")
+}
+
+// getRelativePath returns the path of the given file relative to the parsed directory that contains it, with
+// forward slashes so links are the same on every platform. Files given on their own are relative to their directory
+func (p *ClassParser) getRelativePath(filePath string) string {
+	for _, root := range p.options.Directories {
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != ".." && !strings.HasPrefix(rel, "../") {
+			return rel
+		}
+	}
+	return filepath.Base(filePath)
+}
+
+// getTypeParametersString returns the type parameters of a generic structure using the PlantUML generics
+// syntax. For example <K comparable, V any>. It returns an empty string for structures that are not generic.
+func getTypeParametersString(structure *Struct) string {
+	if len(structure.TypeParameters) == 0 {
+		return ""
 	}
-	if publicMethods.Len() > 0 {
-		str.WriteLineWithDepth(0, publicMethods.String())
+	typeParameters := make([]string, 0, len(structure.TypeParameters))
+	for _, typeParameter := range structure.TypeParameters {
+		typeParameters = append(typeParameters, fmt.Sprintf("%s %s", typeParameter.Name, typeParameter.Type))
 	}
-	str.WriteLineWithDepth(1, fmt.Sprintf(`}`))
+	return fmt.Sprintf("<%s>", strings.Join(typeParameters, ", "))
 }
 
-func (p *ClassParser) renderCompositions(structure *Struct, name string, composition *LineStringBuilder) {
+func (p *ClassParser) renderCompositions(structure *Struct, name string, str *LineStringBuilder) {
 	orderedCompositions := []string{}
 
 	for c := range structure.Composition {
 		if !strings.Contains(c, ".") {
 			c = fmt.Sprintf("%s.%s", p.getPackageName(c, structure), c)
 		}
+		if p.isHiddenType(c) {
+			continue
+		}
+		from, to := p.getDisplayedName(c), p.getDisplayedName(structure.PackageName+"."+name)
+		if p.isDuplicateRelationship(from, to) {
+			continue
+		}
 		composedString := ""
 		if p.renderingOptions.ConnectionLabels {
 			composedString = extends
 		}
-		c = fmt.Sprintf(`"%s" *-- %s"%s.%s"`, c, composedString, structure.PackageName, name)
-		orderedCompositions = append(orderedCompositions, c)
+		orderedCompositions = append(orderedCompositions, `"`+from+`" *-- `+composedString+`"`+to+`"`)
 	}
 	sort.Strings(orderedCompositions)
 	for _, c := range orderedCompositions {
-		composition.WriteLineWithDepth(0, c)
+		str.WriteLineWithDepth(0, c)
 	}
 }
 
-func (p *ClassParser) renderAggregations(structure *Struct, name string, aggregations *LineStringBuilder) {
+func (p *ClassParser) renderAggregations(structure *Struct, name string, str *LineStringBuilder) {
 
-	aggregationMap := structure.Aggregations
-	if p.renderingOptions.AggregatePrivateMembers {
+	// Copy the aggregations so that rendering never modifies the parsed structure
+	aggregationMap := map[string]struct{}{}
+	for a := range structure.Aggregations {
+		aggregationMap[a] = struct{}{}
+	}
+	if p.renderingOptions.AggregatePrivateMembers && !p.renderingOptions.ExportedOnly {
 		p.updatePrivateAggregations(structure, aggregationMap)
 	}
-	p.renderAggregationMap(aggregationMap, structure, aggregations, name)
+	p.renderAggregationMap(aggregationMap, structure, str, name)
 }
 
 func (p *ClassParser) updatePrivateAggregations(structure *Struct, aggregationsMap map[string]struct{}) {
@@ -577,7 +1790,7 @@ func (p *ClassParser) updatePrivateAggregations(structure *Struct, aggregationsM
 	}
 }
 
-func (p *ClassParser) renderAggregationMap(aggregationMap map[string]struct{}, structure *Struct, aggregations *LineStringBuilder, name string) {
+func (p *ClassParser) renderAggregationMap(aggregationMap map[string]struct{}, structure *Struct, str *LineStringBuilder, name string) {
 	var orderedAggregations []string
 	for a := range aggregationMap {
 		orderedAggregations = append(orderedAggregations, a)
@@ -589,14 +1802,99 @@ func (p *ClassParser) renderAggregationMap(aggregationMap map[string]struct{}, s
 		if !strings.Contains(a, ".") {
 			a = fmt.Sprintf("%s.%s", p.getPackageName(a, structure), a)
 		}
+		if p.isHiddenType(a) {
+			continue
+		}
 		aggregationString := ""
 		if p.renderingOptions.ConnectionLabels {
 			aggregationString = aggregates
 		}
-		if p.getPackageName(a, structure) != builtinPackageName {
-			aggregations.WriteLineWithDepth(0, fmt.Sprintf(`"%s.%s"%s o-- "%s"`, structure.PackageName, name, aggregationString, a))
+		if p.getPackageName(a, structure) == builtinPackageName {
+			continue
+		}
+		from, to := `"`+p.getDisplayedName(structure.PackageName+"."+name)+`"`, `"`+p.getDisplayedName(a)+`"`
+		associations := p.getPointerAssociations(structure, a)
+		p.renderAggregation(structure, a, from+aggregationString, to, len(associations) == 0, str)
+		// Pointers to the type itself, like the next node of a linked list, are rendered as self associations
+		for _, fieldName := range associations {
+			str.WriteLineWithDepth(0, from+` --> "`+optionalMultiplicity+`" `+to+` : `+fieldName)
+		}
+	}
+}
+
+// renderAggregation renders the aggregation of the given type, labeled with the names of the fields that reference
+// it unless the FieldLabels option is NoFieldLabels. It is skipped when only associations reference the type, unless
+// required is set.
+func (p *ClassParser) renderAggregation(structure *Struct, aggregated string, from string, to string, required bool, str *LineStringBuilder) {
+	fields := p.getAggregatingFields(structure, aggregated)
+	if p.renderingOptions.FieldLabels == SplitFieldLabels && len(fields) > 0 {
+		for _, field := range fields {
+			str.WriteLineWithDepth(0, from+` o-- "`+field.Multiplicity+`" `+to+` : `+escapeCreole(field.Name))
+		}
+		return
+	}
+	aggregationMultiplicity := p.getAggregationMultiplicity(structure, aggregated)
+	if aggregationMultiplicity == "" && !required {
+		return
+	}
+	multiplicity := ""
+	if aggregationMultiplicity != "" {
+		multiplicity = fmt.Sprintf(`"%s" `, aggregationMultiplicity)
+	}
+	label := ""
+	if p.renderingOptions.FieldLabels == JoinedFieldLabels && len(fields) > 0 {
+		label = " : " + escapeCreole(getFieldNames(fields))
+	}
+	str.WriteLineWithDepth(0, from+` o-- `+multiplicity+to+label)
+}
+
+// multiplicityRanks orders the multiplicities from the narrowest to the widest
+var multiplicityRanks = map[string]int{
+	oneMultiplicity:      1,
+	optionalMultiplicity: 2,
+	manyMultiplicity:     3,
+}
+
+// getAggregationMultiplicity returns the widest multiplicity of the rendered fields of the structure that
+// reference the aggregated type. It returns an empty string if no field references it. The fields rendered as
+// associations are skipped, see getPointerAssociations.
+func (p *ClassParser) getAggregationMultiplicity(structure *Struct, aggregated string) string {
+	result := ""
+	for _, field := range p.getAggregatingFields(structure, aggregated) {
+		if multiplicityRanks[field.Multiplicity] > multiplicityRanks[result] {
+			result = field.Multiplicity
+		}
+	}
+	return result
+}
+
+// getPointerAssociations returns the names of the rendered fields of the structure that hold a single pointer to the
+// aggregated type when the RenderPointerAssociations option is set
+func (p *ClassParser) getPointerAssociations(structure *Struct, aggregated string) []string {
+	var result []string
+	for _, field := range structure.Fields {
+		if !p.isAggregatingField(field) || !p.isPointerAssociation(field) {
+			continue
+		}
+		for _, t := range field.ReferencedTypes {
+			if t == aggregated {
+				result = append(result, field.Name)
+				break
+			}
 		}
 	}
+	return result
+}
+
+// isAggregatingField returns true if the types referenced by the given field are rendered as aggregations, which
+// private fields only are with the AggregatePrivateMembers option
+func (p *ClassParser) isAggregatingField(field *Field) bool {
+	return !isPrivate(field.Name) || p.renderingOptions.AggregatePrivateMembers && !p.renderingOptions.ExportedOnly
+}
+
+// isPointerAssociation returns true if the given field is rendered as an association instead of an aggregation
+func (p *ClassParser) isPointerAssociation(field *Field) bool {
+	return p.renderingOptions.PointerAssociations && field.Multiplicity == optionalMultiplicity
 }
 
 func (p *ClassParser) getPackageName(t string, st *Struct) string {
@@ -607,137 +1905,702 @@ func (p *ClassParser) getPackageName(t string, st *Struct) string {
 	}
 	return packageName
 }
-func (p *ClassParser) renderExtends(structure *Struct, name string, extends *LineStringBuilder) {
+func (p *ClassParser) renderExtends(structure *Struct, name string, str *LineStringBuilder) {
 
 	orderedExtends := []string{}
 	for c := range structure.Extends {
 		if !strings.Contains(c, ".") {
 			c = fmt.Sprintf("%s.%s", structure.PackageName, c)
 		}
-		implementString := ""
+		if p.isHiddenType(c) {
+			continue
+		}
+		from, to := p.getDisplayedName(c), p.getDisplayedName(structure.PackageName+"."+name)
+		if p.isDuplicateRelationship(from, to) {
+			continue
+		}
+		extendString := ""
 		if p.renderingOptions.ConnectionLabels {
-			implementString = implements
+			extendString = extends
 		}
-		c = fmt.Sprintf(`"%s" <|-- %s"%s.%s"`, c, implementString, structure.PackageName, name)
-		orderedExtends = append(orderedExtends, c)
+		orderedExtends = append(orderedExtends, `"`+from+`" <|-- `+extendString+`"`+to+`"`)
 	}
 	sort.Strings(orderedExtends)
 	for _, c := range orderedExtends {
-		extends.WriteLineWithDepth(0, c)
+		str.WriteLineWithDepth(0, c)
 	}
 }
 
-func (p *ClassParser) renderStructMethods(structure *Struct, privateMethods *LineStringBuilder, publicMethods *LineStringBuilder) {
+// renderImplements renders the interfaces realized by the structure with the dashed realization arrow so they
+// can be told apart from extensions
+func (p *ClassParser) renderImplements(structure *Struct, name string, str *LineStringBuilder) {
 
-	for _, method := range structure.Functions {
-		accessModifier := "+"
-		if unicode.IsLower(rune(method.Name[0])) {
-			if !p.renderingOptions.PrivateMembers {
-				continue
-			}
+	orderedImplements := []string{}
+	for c := range structure.Implements {
+		pointerLabel := ""
+		if _, ok := structure.PointerImplements[c]; ok {
+			pointerLabel = fmt.Sprintf(" : *%s", name)
+		}
+		if !strings.Contains(c, ".") {
+			c = fmt.Sprintf("%s.%s", structure.PackageName, c)
+		}
+		if p.isHiddenType(c) {
+			continue
+		}
+		from, to := p.getDisplayedName(c), p.getDisplayedName(structure.PackageName+"."+name)
+		if p.isDuplicateRelationship(from, to) {
+			continue
+		}
+		implementString := ""
+		if p.renderingOptions.ConnectionLabels {
+			implementString = implements
+		}
+		orderedImplements = append(orderedImplements, `"`+from+`" <|.. `+implementString+`"`+to+`"`+pointerLabel)
+	}
+	sort.Strings(orderedImplements)
+	for _, c := range orderedImplements {
+		str.WriteLineWithDepth(0, c)
+	}
+}
 
-			accessModifier = "-"
+// renderStructMethods renders the private methods of the structure and then the public ones, or all of them in the
+// order they are declared with the SourceMemberOrder option. The methods of the interfaces are marked abstract unless
+// the RenderAbstractMethods option is unset
+func (p *ClassParser) renderStructMethods(structure *Struct, str *LineStringBuilder) {
+	modifier := ""
+	if structure.Kind() == InterfaceKind && p.renderingOptions.AbstractMethods {
+		modifier = "{abstract} "
+	}
+	if p.renderingOptions.MemberOrder == SourceMemberOrder {
+		for _, constructor := range getSourceOrderedFunctions(structure.Constructors) {
+			p.renderMethod(constructor, "{static} ", str)
 		}
-		parameterList := make([]string, 0)
-		for _, p := range method.Parameters {
-			parameterList = append(parameterList, fmt.Sprintf("%s %s", p.Name, p.Type))
+		for _, method := range getSourceOrderedFunctions(structure.Functions) {
+			p.renderMethod(method, modifier, str)
 		}
-		returnValues := ""
-		if len(method.ReturnValues) > 0 {
-			if len(method.ReturnValues) == 1 {
-				returnValues = method.ReturnValues[0]
-			} else {
-				returnValues = fmt.Sprintf("(%s)", strings.Join(method.ReturnValues, ", "))
-			}
+		return
+	}
+	for _, private := range []bool{true, false} {
+		// Constructors go first, marked with the static modifier since they are not called on an instance
+		p.renderMethods(structure.Constructors, "{static} ", private, str)
+		p.renderMethods(structure.Functions, modifier, private, str)
+	}
+}
+
+// renderMethods renders either the private or the public methods among the given ones
+func (p *ClassParser) renderMethods(methods []*Function, modifier string, private bool, str *LineStringBuilder) {
+	for _, method := range methods {
+		if isPrivate(method.Name) == private {
+			p.renderMethod(method, modifier, str)
+		}
+	}
+}
+
+func (p *ClassParser) renderMethod(method *Function, modifier string, str *LineStringBuilder) {
+	accessModifier := "+ "
+	if isPrivate(method.Name) {
+		if !p.renderingOptions.PrivateMethods || p.renderingOptions.ExportedOnly {
+			return
 		}
-		if accessModifier == "-" {
-			privateMethods.WriteLineWithDepth(2, fmt.Sprintf(`%s %s(%s) %s`, accessModifier, method.Name, strings.Join(parameterList, ", "), returnValues))
+
+		accessModifier = "- "
+	}
+	parameters := p.getParametersString(method)
+	returnValues := p.getReturnValuesType(method)
+	str.WriteLineWithDepth(2, p.addReceiverMarker(method, accessModifier+modifier+method.Name+"("+parameters+") "+returnValues))
+}
+
+// pointerReceiverMarker is written after the methods declared on a pointer receiver
+const pointerReceiverMarker = "«ptr»"
+
+// addReceiverMarker returns the given line of the method followed by the pointerReceiverMarker if the method is
+// declared on a pointer receiver and the RenderPointerReceivers option is set, or the line unchanged otherwise
+func (p *ClassParser) addReceiverMarker(method *Function, line string) string {
+	if !p.renderingOptions.PointerReceivers || !method.PointerReceiver {
+		return line
+	}
+	return strings.TrimRight(line, " ") + " " + pointerReceiverMarker
+}
+
+// getParametersString returns the parameters of the given method as written in the diagram. Unnamed parameters are
+// written with only their type and so are blank ones, named _, unless another parameter has a name.
+func (p *ClassParser) getParametersString(method *Function) string {
+	names := make([]string, 0, len(method.Parameters))
+	for _, parameter := range method.Parameters {
+		names = append(names, parameter.Name)
+	}
+	named := hasNames(names)
+	parameterList := make([]string, 0, len(method.Parameters))
+	for _, parameter := range method.Parameters {
+		if named && parameter.Name != "" {
+			parameterList = append(parameterList, fmt.Sprintf("%s %s", parameter.Name, p.getMemberType(parameter.Type)))
 		} else {
-			publicMethods.WriteLineWithDepth(2, fmt.Sprintf(`%s %s(%s) %s`, accessModifier, method.Name, strings.Join(parameterList, ", "), returnValues))
+			parameterList = append(parameterList, p.getMemberType(parameter.Type))
 		}
 	}
+	return strings.Join(parameterList, ", ")
 }
 
-func (p *ClassParser) renderStructFields(structure *Struct, privateFields *LineStringBuilder, publicFields *LineStringBuilder) {
-	for _, field := range structure.Fields {
-		accessModifier := "+"
-		if unicode.IsLower(rune(field.Name[0])) {
-			if !p.renderingOptions.PrivateMembers {
-				continue
+// hasNames returns true if any of the given parameter or return value names is neither empty nor blank
+func hasNames(names []string) bool {
+	for _, name := range names {
+		if name != "" && name != "_" {
+			return true
+		}
+	}
+	return false
+}
+
+// getReturnValuesString returns the return values of the given method the way they are written in go. A single
+// unnamed return value is not wrapped in parenthesis while named return values are rendered with their names. Return
+// values that are all blank, named _, are rendered like unnamed ones.
+func getReturnValuesString(method *Function) string {
+	if len(method.ReturnValues) == 0 {
+		return ""
+	}
+	if len(method.ReturnValueNames) == len(method.ReturnValues) && hasNames(method.ReturnValueNames) {
+		namedReturnValues := make([]string, 0, len(method.ReturnValues))
+		for i, returnValue := range method.ReturnValues {
+			namedReturnValues = append(namedReturnValues, fmt.Sprintf("%s %s", method.ReturnValueNames[i], returnValue))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(namedReturnValues, ", "))
+	}
+	if len(method.ReturnValues) == 1 {
+		return method.ReturnValues[0]
+	}
+	return fmt.Sprintf("(%s)", strings.Join(method.ReturnValues, ", "))
+}
+
+// renderStructFields renders the private fields of the structure and then the public ones, or all of them in the
+// order they are declared with the SourceMemberOrder option
+func (p *ClassParser) renderStructFields(structure *Struct, str *LineStringBuilder) {
+	privateFields := p.renderingOptions.PrivateFields && !p.renderingOptions.ExportedOnly
+	if p.renderingOptions.MemberOrder == SourceMemberOrder {
+		for _, field := range getSourceOrderedFields(structure.Fields) {
+			if !isPrivate(field.Name) {
+				p.renderField(field, "+ ", str)
+			} else if privateFields {
+				p.renderField(field, "- ", str)
 			}
+		}
+		return
+	}
+	if privateFields {
+		p.renderFields(structure.Fields, "- ", true, str)
+	}
+	p.renderFields(structure.Fields, "+ ", false, str)
+}
 
-			accessModifier = "-"
+// renderFields renders either the private or the public fields among the given ones with the given access modifier
+func (p *ClassParser) renderFields(fields []*Field, accessModifier string, private bool, str *LineStringBuilder) {
+	for _, field := range fields {
+		if isPrivate(field.Name) == private {
+			p.renderField(field, accessModifier, str)
 		}
-		if accessModifier == "-" {
-			privateFields.WriteLineWithDepth(2, fmt.Sprintf(`%s %s %s`, accessModifier, field.Name, field.Type))
-		} else {
-			publicFields.WriteLineWithDepth(2, fmt.Sprintf(`%s %s %s`, accessModifier, field.Name, field.Type))
+	}
+}
+
+// renderField renders the given field with the given access modifier. Embedded types are only rendered as
+// compositions unless the RenderEmbeddedFields option is set
+func (p *ClassParser) renderField(field *Field, accessModifier string, str *LineStringBuilder) {
+	if field.Embedded && !p.renderingOptions.EmbeddedFields {
+		return
+	}
+	tag := p.getRenderedTag(field)
+	fieldType := p.getMemberType(field.Type)
+	modifier := ""
+	if strings.Contains(fieldType+tag, "(") {
+		// PlantUML takes any member with parenthesis for a method unless it is marked as a field
+		modifier = "{field} "
+	}
+	str.WriteLineWithDepth(2, accessModifier+modifier+getFieldLabel(field)+" "+fieldType+tag)
+}
+
+// getFieldLabel returns what is written before the type of the given field, its name or the embed stereotype for
+// embedded types
+func getFieldLabel(field *Field) string {
+	if field.Embedded {
+		return "«embed»"
+	}
+	return field.Name
+}
+
+// getRenderedTag returns the tag of the given field, or the value of the RenderTagKey key in it, as it is appended
+// to the field line. It is empty if tags are not rendered or the field has no such tag.
+func (p *ClassParser) getRenderedTag(field *Field) string {
+	if !p.renderingOptions.Tags || field.Tag == "" {
+		return ""
+	}
+	tag := field.Tag
+	if p.renderingOptions.TagKey != "" {
+		value, ok := reflect.StructTag(tag).Lookup(p.renderingOptions.TagKey)
+		if !ok {
+			return ""
+		}
+		tag = value
+	}
+	return fmt.Sprintf(" «%s»", escapeCreole(strings.Join(strings.Fields(tag), " ")))
+}
+
+// creoleMarkups are the characters that PlantUML turns into text formatting when they are doubled, like ** for bold
+// or "" for monospaced text
+const creoleMarkups = `*/"-_`
+
+// escapeCreole escapes the doubled characters of the given text that PlantUML would take for text formatting
+func escapeCreole(text string) string {
+	result := &strings.Builder{}
+	for i, r := range text {
+		if i > 0 && strings.ContainsRune(creoleMarkups, r) && rune(text[i-1]) == r {
+			result.WriteRune('~')
 		}
+		result.WriteRune(r)
 	}
+	return result.String()
 }
 
-// Returns an initialized struct of the given name or returns the existing one if it was already created
-func (p *ClassParser) getOrCreateStruct(name string) *Struct {
-	result, ok := p.structure[p.currentPackageName][name]
+// Returns an initialized struct of the given name in the given package or returns the existing one if it was already
+// created
+func (p *ClassParser) getOrCreateStruct(packageName string, name string) *Struct {
+	result, ok := p.structure[packageName][name]
 	if !ok {
 		result = &Struct{
-			PackageName:         p.currentPackageName,
+			Name:                name,
+			PackageName:         packageName,
 			Functions:           make([]*Function, 0),
 			Fields:              make([]*Field, 0),
 			Type:                "",
 			Composition:         make(map[string]struct{}, 0),
 			Extends:             make(map[string]struct{}, 0),
+			Implements:          make(map[string]struct{}, 0),
 			Aggregations:        make(map[string]struct{}, 0),
 			PrivateAggregations: make(map[string]struct{}, 0),
 		}
-		p.structure[p.currentPackageName][name] = result
+		if _, ok := p.structure[packageName]; !ok {
+			p.structure[packageName] = make(map[string]*Struct)
+		}
+		p.structure[packageName][name] = result
 	}
 	return result
 }
 
-// Returns an existing struct only if it was created. nil otherwhise
+// Returns an existing struct only if it was created. nil otherwhise. Names without a package qualifier are never
+// found since there is no current package once the code is parsed
 func (p *ClassParser) getStruct(structName string) *Struct {
-	split := strings.SplitN(structName, ".", 2)
-	pack, ok := p.structure[split[0]]
+	packageName, name := splitFullTypeName(structName)
+	if packageName == "" || name == "" {
+		return nil
+	}
+	pack, ok := p.structure[packageName]
 	if !ok {
 		return nil
 	}
-	return pack[split[1]]
+	return pack[name]
+}
+
+// getMethodSet returns a struct holding the methods of the given struct including the ones promoted from the
+// types it embeds, unless the IgnorePromotedMethods option is set. Embedded types that were not parsed are ignored.
+// Methods with pointer receivers are only part of the method set of the pointer, including the ones promoted from
+// types embedded by value.
+func (p *ClassParser) getMethodSet(structName string, visited map[string]struct{}, pointer bool) *Struct {
+	st := p.getStruct(structName)
+	if st == nil {
+		return nil
+	}
+	if st.Kind() == InterfaceKind {
+		inter, _ := p.getInterfaceMethodSet(structName, visited)
+		return inter
+	}
+	methodSet := &Struct{}
+	for _, function := range st.Functions {
+		if pointer || !function.PointerReceiver {
+			methodSet.Functions = append(methodSet.Functions, function)
+		}
+	}
+	visited[structName] = struct{}{}
+	if p.options.IgnorePromotedMethods {
+		return methodSet
+	}
+	for embedded := range st.Composition {
+		embeddedPointer := pointer || st.isEmbeddedPointer(embedded)
+		embedded = getEmbeddedTypeName(embedded, st)
+		if _, ok := visited[embedded]; ok {
+			continue
+		}
+		if embeddedMethodSet := p.getMethodSet(embedded, visited, embeddedPointer); embeddedMethodSet != nil {
+			methodSet.Functions = append(methodSet.Functions, embeddedMethodSet.Functions...)
+		}
+	}
+	return methodSet
+}
+
+// getEmbeddedTypeName returns the fully qualified name of a type embedded in the given structure
+func getEmbeddedTypeName(embedded string, st *Struct) string {
+	// Embedded generic types are stored with their type arguments
+	embedded = strings.SplitN(embedded, "[", 2)[0]
+	if !strings.Contains(embedded, ".") {
+		embedded = fmt.Sprintf("%s.%s", st.PackageName, embedded)
+	}
+	return embedded
+}
+
+// isHiddenType returns true if the given fully qualified type is not rendered because it is unexported and the
+// ExportedOnly option is set, or because it belongs to a package that was not parsed and the ExternalTypes option is
+// DropExternalTypes, or because it is outside of the neighborhood of the Roots option. Types of packages that were not
+// parsed are hidden by no other option.
+func (p *ClassParser) isHiddenType(fullName string) bool {
+	if p.renderingOptions.ExternalTypes == DropExternalTypes && p.isExternalType(fullName) {
+		return true
+	}
+	if p.isOutsideNeighborhood(fullName) {
+		return true
+	}
+	if !p.renderingOptions.ExportedOnly {
+		return false
+	}
+	packageName, name := splitFullTypeName(fullName)
+	if packageName == "" {
+		return false
+	}
+	if _, ok := p.structure[packageName]; !ok {
+		return false
+	}
+	return isPrivate(name)
+}
+
+// isDuplicateRelationship records a composition, extension or realization from the given class to the given
+// class, as named in the diagram, and returns true if one of them was already rendered between the same classes.
+// The diagram has a single arrow for every pair so a struct embedding an interface is not shown realizing it too.
+func (p *ClassParser) isDuplicateRelationship(from string, to string) bool {
+	key := from + " " + to
+	if _, ok := p.renderedRelationships[key]; ok {
+		return true
+	}
+	if p.renderedRelationships == nil {
+		p.renderedRelationships = map[string]struct{}{}
+	}
+	p.renderedRelationships[key] = struct{}{}
+	return false
+}
+
+// isRenderedType returns true if the given fully qualified parsed type is rendered in the diagram, so it is neither
+// hidden by the ExportedOnly option nor left out by the HideOrphans option
+func (p *ClassParser) isRenderedType(fullName string) bool {
+	return !p.isHiddenType(fullName) && !p.isOrphan(fullName)
+}
+
+// getExportedStructure returns a copy of the structure that also holds the fields and methods promoted from the
+// unexported types it embeds, since those types are not rendered when the ExportedOnly option is set
+func (p *ClassParser) getExportedStructure(structure *Struct) *Struct {
+	result := *structure
+	result.Fields = append([]*Field{}, structure.Fields...)
+	result.Functions = append([]*Function{}, structure.Functions...)
+	result.Aggregations = map[string]struct{}{}
+	for a := range structure.Aggregations {
+		result.Aggregations[a] = struct{}{}
+	}
+	p.addPromotedMembers(&result, structure, map[*Struct]struct{}{structure: {}})
+	return &result
+}
+
+// addPromotedMembers adds into result the members of the unexported types embedded in the given structure that are
+// not already declared in it, going through the types embedded by those recursively
+func (p *ClassParser) addPromotedMembers(result *Struct, structure *Struct, visited map[*Struct]struct{}) {
+	embeddedNames := []string{}
+	for embedded := range structure.Composition {
+		embeddedNames = append(embeddedNames, getEmbeddedTypeName(embedded, structure))
+	}
+	for embedded := range structure.Extends {
+		embeddedNames = append(embeddedNames, getEmbeddedTypeName(embedded, structure))
+	}
+	sort.Strings(embeddedNames)
+	for _, embeddedName := range embeddedNames {
+		embedded := p.getStruct(embeddedName)
+		if embedded == nil || !p.isHiddenType(embeddedName) || p.isOutsideNeighborhood(embeddedName) {
+			continue
+		}
+		if _, ok := visited[embedded]; ok {
+			continue
+		}
+		visited[embedded] = struct{}{}
+		for _, field := range embedded.Fields {
+			if !hasField(result, field.Name) {
+				result.Fields = append(result.Fields, field)
+			}
+		}
+		for _, function := range embedded.Functions {
+			if !hasFunction(result, function.Name) {
+				result.Functions = append(result.Functions, function)
+			}
+		}
+		for a := range embedded.Aggregations {
+			result.Aggregations[a] = struct{}{}
+		}
+		p.addPromotedMembers(result, embedded, visited)
+	}
+}
+
+func hasField(st *Struct, name string) bool {
+	for _, field := range st.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFunction(st *Struct, name string) bool {
+	for _, function := range st.Functions {
+		if function.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
-// SetRenderingOptions Sets the rendering options for the Render() Function
+// getInterfaceMethodSet returns a struct holding all the methods and type terms of the given interface including the
+// ones of all the interfaces it embeds. The second return value is false if the interface or any of the interfaces
+// it embeds was not parsed, in which case its method set cannot be known.
+func (p *ClassParser) getInterfaceMethodSet(interfaceName string, visited map[string]struct{}) (*Struct, bool) {
+	inter := p.getStruct(interfaceName)
+	if inter == nil || inter.Kind() != InterfaceKind {
+		return nil, false
+	}
+	methodSet := &Struct{
+		Functions: append([]*Function{}, inter.Functions...),
+		TypeTerms: append([]string{}, inter.TypeTerms...),
+	}
+	visited[interfaceName] = struct{}{}
+	for embedded := range inter.Extends {
+		if _, ok := visited[embedded]; ok {
+			continue
+		}
+		embeddedMethodSet, ok := p.getInterfaceMethodSet(embedded, visited)
+		if !ok {
+			return nil, false
+		}
+		if typeArguments, ok := inter.ExtendsTypeArguments[embedded]; ok {
+			embeddedMethodSet.Functions = instantiateFunctions(embeddedMethodSet.Functions, p.getStruct(embedded), typeArguments)
+		}
+		methodSet.Functions = append(methodSet.Functions, embeddedMethodSet.Functions...)
+		methodSet.TypeTerms = append(methodSet.TypeTerms, embeddedMethodSet.TypeTerms...)
+	}
+	return methodSet, true
+}
+
+// renderingOptionSetters contains, for every RenderingOption, the function that sets its value in the RenderingOptions
+var renderingOptionSetters = map[RenderingOption]func(ro *RenderingOptions, val interface{}){
+	RenderAggregations:      func(ro *RenderingOptions, val interface{}) { ro.Aggregations = val.(bool) },
+	RenderAliases:           func(ro *RenderingOptions, val interface{}) { ro.Aliases = val.(bool) },
+	RenderCompositions:      func(ro *RenderingOptions, val interface{}) { ro.Compositions = val.(bool) },
+	RenderFields:            func(ro *RenderingOptions, val interface{}) { ro.Fields = val.(bool) },
+	RenderImplementations:   func(ro *RenderingOptions, val interface{}) { ro.Implementations = val.(bool) },
+	RenderMethods:           func(ro *RenderingOptions, val interface{}) { ro.Methods = val.(bool) },
+	RenderConnectionLabels:  func(ro *RenderingOptions, val interface{}) { ro.ConnectionLabels = val.(bool) },
+	RenderTitle:             func(ro *RenderingOptions, val interface{}) { ro.Title = val.(string) },
+	RenderNotes:             func(ro *RenderingOptions, val interface{}) { ro.Notes = val.(string) },
+	AggregatePrivateMembers: func(ro *RenderingOptions, val interface{}) { ro.AggregatePrivateMembers = val.(bool) },
+	RenderPrivateMembers: func(ro *RenderingOptions, val interface{}) {
+		ro.PrivateMembers = val.(bool)
+		ro.PrivateFields = val.(bool)
+		ro.PrivateMethods = val.(bool)
+	},
+	RenderPrivateFields:  func(ro *RenderingOptions, val interface{}) { ro.PrivateFields = val.(bool) },
+	RenderPrivateMethods: func(ro *RenderingOptions, val interface{}) { ro.PrivateMethods = val.(bool) },
+	RenderLinkTemplate:   func(ro *RenderingOptions, val interface{}) { ro.LinkTemplate = val.(string) },
+	RenderExportedOnly:   func(ro *RenderingOptions, val interface{}) { ro.ExportedOnly = val.(bool) },
+	RenderDocNotes:       func(ro *RenderingOptions, val interface{}) { ro.DocNotes = val.(bool) },
+	RenderDocNotesLength: func(ro *RenderingOptions, val interface{}) { ro.DocNotesLength = val.(int) },
+	RenderNamespaceSegments: func(ro *RenderingOptions, val interface{}) {
+		ro.NamespaceSegments = val.(int)
+	},
+	RenderFunctions: func(ro *RenderingOptions, val interface{}) { ro.Functions = val.(bool) },
+	RenderNestedNamespaces: func(ro *RenderingOptions, val interface{}) {
+		ro.NestedNamespaces = val.(bool)
+	},
+	RenderNamespaceSeparator: func(ro *RenderingOptions, val interface{}) {
+		ro.NamespaceSeparator = val.(string)
+	},
+	RenderTags:   func(ro *RenderingOptions, val interface{}) { ro.Tags = val.(bool) },
+	RenderTagKey: func(ro *RenderingOptions, val interface{}) { ro.TagKey = val.(string) },
+	RenderDependencies: func(ro *RenderingOptions, val interface{}) {
+		ro.Dependencies = val.(bool)
+	},
+	RenderPackageColors: func(ro *RenderingOptions, val interface{}) {
+		ro.PackageColors = val.(map[string]string)
+	},
+	RenderAutoColors:  func(ro *RenderingOptions, val interface{}) { ro.AutoColors = val.(bool) },
+	RenderHideOrphans: func(ro *RenderingOptions, val interface{}) { ro.HideOrphans = val.(bool) },
+	RenderPreamble:    func(ro *RenderingOptions, val interface{}) { ro.Preamble = val.([]string) },
+	RenderHeader:      func(ro *RenderingOptions, val interface{}) { ro.Header = val.(string) },
+	RenderFooter:      func(ro *RenderingOptions, val interface{}) { ro.Footer = val.(string) },
+	RenderShortTypeNames: func(ro *RenderingOptions, val interface{}) {
+		ro.ShortTypeNames = val.(bool)
+	},
+	RenderExternalTypeNames: func(ro *RenderingOptions, val interface{}) {
+		ro.ExternalTypeNames = val.(ExternalTypeNames)
+	},
+	RenderExternalTypes: func(ro *RenderingOptions, val interface{}) {
+		ro.ExternalTypes = val.(ExternalTypes)
+	},
+	RenderGroupImplementations: func(ro *RenderingOptions, val interface{}) {
+		ro.GroupImplementations = val.(bool)
+	},
+	RenderEmbeddedFields: func(ro *RenderingOptions, val interface{}) {
+		ro.EmbeddedFields = val.(bool)
+	},
+	RenderPointerAssociations: func(ro *RenderingOptions, val interface{}) {
+		ro.PointerAssociations = val.(bool)
+	},
+	RenderMaxTypeLength: func(ro *RenderingOptions, val interface{}) {
+		ro.MaxTypeLength = val.(int)
+	},
+	RenderAbstractMethods: func(ro *RenderingOptions, val interface{}) {
+		ro.AbstractMethods = val.(bool)
+	},
+	RenderMemberOrder: func(ro *RenderingOptions, val interface{}) {
+		ro.MemberOrder = val.(MemberOrder)
+	},
+	RenderFieldLabels: func(ro *RenderingOptions, val interface{}) {
+		ro.FieldLabels = val.(FieldLabels)
+	},
+	RenderPointerReceivers: func(ro *RenderingOptions, val interface{}) {
+		ro.PointerReceivers = val.(bool)
+	},
+	RenderFlat: func(ro *RenderingOptions, val interface{}) {
+		ro.Flat = val.(bool)
+	},
+}
+
+// SetRenderingOptions Sets the rendering options for the Render() Function. The options are applied in the order
+// they are declared so options that set several values (like RenderPrivateMembers) can be refined by more
+// specific ones (like RenderPrivateFields)
 func (p *ClassParser) SetRenderingOptions(ro map[RenderingOption]interface{}) error {
-	for option, val := range ro {
-		switch option {
-		case RenderAggregations:
-			p.renderingOptions.Aggregations = val.(bool)
-		case RenderAliases:
-			p.renderingOptions.Aliases = val.(bool)
-		case RenderCompositions:
-			p.renderingOptions.Compositions = val.(bool)
-		case RenderFields:
-			p.renderingOptions.Fields = val.(bool)
-		case RenderImplementations:
-			p.renderingOptions.Implementations = val.(bool)
-		case RenderMethods:
-			p.renderingOptions.Methods = val.(bool)
-		case RenderConnectionLabels:
-			p.renderingOptions.ConnectionLabels = val.(bool)
-		case RenderTitle:
-			p.renderingOptions.Title = val.(string)
-		case RenderNotes:
-			p.renderingOptions.Notes = val.(string)
-		case AggregatePrivateMembers:
-			p.renderingOptions.AggregatePrivateMembers = val.(bool)
-		case RenderPrivateMembers:
-			p.renderingOptions.PrivateMembers = val.(bool)
-		default:
+	orderedOptions := []int{}
+	for option := range ro {
+		orderedOptions = append(orderedOptions, int(option))
+	}
+	sort.Ints(orderedOptions)
+	for _, o := range orderedOptions {
+		option := RenderingOption(o)
+		setter, ok := renderingOptionSetters[option]
+		if !ok {
 			return fmt.Errorf("Invalid Rendering option %v", option)
 		}
-
+		setter(p.renderingOptions, ro[option])
 	}
 	return nil
 }
+
+// plantUMLKeywords are the words that PlantUML can mistake for a keyword when they are used as the name of a
+// namespace or a class
+var plantUMLKeywords = map[string]struct{}{
+	"abstract": {}, "annotation": {}, "as": {}, "circle": {}, "class": {}, "diamond": {}, "end": {}, "entity": {},
+	"enum": {}, "exception": {}, "footer": {}, "header": {}, "hide": {}, "interface": {}, "json": {}, "legend": {},
+	"map": {}, "metaclass": {}, "namespace": {}, "note": {}, "object": {}, "package": {}, "protocol": {},
+	"remove": {}, "set": {}, "show": {}, "skinparam": {}, "stereotype": {}, "struct": {}, "title": {},
+	"together": {},
+}
+
+// invalidIdentifierRegexp matches the characters that can not be used in PlantUML identifiers
+var invalidIdentifierRegexp = regexp.MustCompile(`[^\p{L}\p{N}_]`)
+
+// getDiagramPackageName returns the name used for the given package in the diagram. Characters that PlantUML does
+// not accept in identifiers are replaced by underscores and keywords get an underscore appended.
+func getDiagramPackageName(packageName string) string {
+	// Most package names are valid identifiers already and checking them is much cheaper than the replacement
+	if strings.IndexFunc(packageName, isInvalidIdentifierRune) >= 0 {
+		packageName = invalidIdentifierRegexp.ReplaceAllString(packageName, "_")
+	}
+	return getDiagramTypeName(packageName)
+}
+
+// isInvalidIdentifierRune returns true for the runes matched by invalidIdentifierRegexp
+func isInvalidIdentifierRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_'
+}
+
+// getDiagramTypeName returns the name used for the given type in the diagram. Type names are valid identifiers
+// already so only keywords need to be changed.
+func getDiagramTypeName(typeName string) string {
+	if _, ok := plantUMLKeywords[typeName]; ok {
+		return typeName + "_"
+	}
+	return typeName
+}
+
+// getDiagramName returns the name used in the diagram to reference the given fully qualified type so that
+// relationships point to the same node that getDiagramPackageName and getDiagramTypeName declare
+func getDiagramName(fullName string) string {
+	packageName, name := splitFullTypeName(fullName)
+	if packageName == "" {
+		return fullName
+	}
+	return getDiagramPackageName(packageName) + "." + getDiagramTypeName(name)
+}
+
+// getDisplayedPackageName returns the name used in the diagram for the given package, shortened with the
+// NamespaceSegments rendering option and split in one namespace per path element with the NestedNamespaces one
+func (p *ClassParser) getDisplayedPackageName(packageName string) string {
+	if namespace, ok := p.namespaces[packageName]; ok {
+		packageName = namespace
+	}
+	if !p.renderingOptions.NestedNamespaces {
+		return getDiagramPackageName(packageName)
+	}
+	elements := strings.Split(packageName, "/")
+	for i, element := range elements {
+		elements[i] = getDiagramPackageName(element)
+	}
+	return strings.Join(elements, p.getNamespaceSeparator())
+}
+
+// getNamespaceSeparator returns the separator PlantUML uses between a namespace and the namespaces or classes in it
+func (p *ClassParser) getNamespaceSeparator() string {
+	if !p.renderingOptions.NestedNamespaces || p.renderingOptions.NamespaceSeparator == "" {
+		return "."
+	}
+	return p.renderingOptions.NamespaceSeparator
+}
+
+// getDisplayedName returns the name used in the diagram to reference the given fully qualified type. Packages are
+// shortened like in getDisplayedPackageName so relationships point to the classes declared in the namespaces
+func (p *ClassParser) getDisplayedName(fullName string) string {
+	packageName, name := splitFullTypeName(fullName)
+	if packageName == "" {
+		return fullName
+	}
+	if p.renderingOptions.ExternalTypes == StubExternalTypes && p.isExternalType(fullName) {
+		return p.getExternalStubName(fullName)
+	}
+	if p.renderingOptions.Flat {
+		// The types of the packages that were not parsed are not declared so PlantUML creates them with their names
+		if _, ok := p.structure[packageName]; !ok {
+			return fullName
+		}
+		return p.getFlatName(fullName)
+	}
+	if p.renderingOptions.NestedNamespaces {
+		return p.getDisplayedPackageName(packageName) + p.getNamespaceSeparator() + getDiagramTypeName(name)
+	}
+	if namespace, ok := p.namespaces[packageName]; ok {
+		return getDiagramName(fmt.Sprintf("%s.%s", namespace, name))
+	}
+	return getDiagramName(fullName)
+}
+
+// updateNamespaces computes the namespaces of the parsed packages for the current rendering options
+func (p *ClassParser) updateNamespaces() {
+	p.namespaces = nil
+	if p.renderingOptions.NamespaceSegments <= 0 {
+		return
+	}
+	var packages []string
+	for pack := range p.structure {
+		packages = append(packages, pack)
+	}
+	p.namespaces = getShortNamespaces(packages, p.renderingOptions.NamespaceSegments)
+}
+
 func generateRenamedStructName(currentName string) string {
 	reg, _ := regexp.Compile("[^a-zA-Z0-9]+")
 	return reg.ReplaceAllString(currentName, "")
@@ -1,10 +1,23 @@
 package parser
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"go/ast"
+	"go/token"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/spf13/afero"
 )
 
 func TestLineBuilder(t *testing.T) {
@@ -14,7 +27,14 @@ func TestLineBuilder(t *testing.T) {
 	if s.String() != result {
 		t.Errorf("TestLineBuilder: Expected text to be %s got %s", result, s.String())
 	}
-
+	s.Reset()
+	s.indentation = 1
+	s.WriteLineWithDepth(5, "text")
+	s.WriteLineWithDepth(1, "")
+	result = strings.Repeat(tab, 6) + "text\n\n"
+	if s.String() != result {
+		t.Errorf("TestLineBuilder: Expected indented text without tabs in the empty line %q got %q", result, s.String())
+	}
 }
 
 func TestGetOrCreateStruct(t *testing.T) {
@@ -77,25 +97,26 @@ func TestGetOrCreateStruct(t *testing.T) {
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			parser := &ClassParser{
-				currentPackageName: tc.packageName,
-				structure:          make(map[string]map[string]*Struct),
-				allInterfaces:      make(map[string]struct{}),
-				allStructs:         make(map[string]struct{}),
+				structure:     make(map[string]map[string]*Struct),
+				allInterfaces: make(map[string]struct{}),
+				allStructs:    make(map[string]struct{}),
 			}
 			parser.structure[tc.packageName] = make(map[string]*Struct)
 			if tc.structure != nil {
 				parser.structure[tc.packageName][tc.structureName] = tc.structure
 			}
 
-			st := parser.getOrCreateStruct(tc.nameToLookFor)
+			st := parser.getOrCreateStruct(tc.packageName, tc.nameToLookFor)
 			if tc.expectedEmpty {
 				if !reflect.DeepEqual(st, &Struct{
-					PackageName:         parser.currentPackageName,
+					Name:                tc.nameToLookFor,
+					PackageName:         tc.packageName,
 					Functions:           make([]*Function, 0),
 					Fields:              make([]*Field, 0),
 					Type:                "",
 					Composition:         make(map[string]struct{}, 0),
 					Extends:             make(map[string]struct{}, 0),
+					Implements:          make(map[string]struct{}, 0),
 					Aggregations:        make(map[string]struct{}, 0),
 					PrivateAggregations: make(map[string]struct{}, 0),
 				}) {
@@ -154,6 +175,35 @@ func TestGetStruct(t *testing.T) {
 	}
 }
 
+func TestGetStructNames(t *testing.T) {
+	parser := getEmptyParser("main")
+	foo := &Struct{PackageName: "main", Type: "class"}
+	parser.structure["main"] = map[string]*Struct{"foo": foo}
+	parser.structure["other"] = map[string]*Struct{"bar": {PackageName: "other", Type: "class"}}
+	tt := []struct {
+		Name     string
+		Input    string
+		Expected *Struct
+	}{
+		{Name: "Qualified", Input: "main.foo", Expected: foo},
+		{Name: "Unqualified", Input: "foo", Expected: nil},
+		{Name: "Unqualified in other package", Input: "bar", Expected: nil},
+		{Name: "Unqualified equal to a package name", Input: "main", Expected: nil},
+		{Name: "Unknown package", Input: "wrong.foo", Expected: nil},
+		{Name: "Unknown struct", Input: "main.wrong", Expected: nil},
+		{Name: "Empty", Input: "", Expected: nil},
+		{Name: "Missing name", Input: "main.", Expected: nil},
+		{Name: "Missing package", Input: ".foo", Expected: nil},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if result := parser.getStruct(tc.Input); result != tc.Expected {
+				t.Errorf("Expected getStruct(%q) to be %v, got %v", tc.Input, tc.Expected, result)
+			}
+		})
+	}
+}
+
 func TestRenderStructFields(t *testing.T) {
 	parser := getEmptyParser("main")
 
@@ -169,14 +219,10 @@ func TestRenderStructFields(t *testing.T) {
 			},
 		},
 	}
-	privateFields := &LineStringBuilder{}
-	publicFields := &LineStringBuilder{}
-	parser.renderStructFields(st, privateFields, publicFields)
-	if privateFields.String() != "        - privateField int\n" {
-		t.Errorf("TestRenderStructFields: expected privateFields to be [        - privateField int\\n] got [%v]", privateFields.String())
-	}
-	if publicFields.String() != "        + PublicField string\n" {
-		t.Errorf("TestRenderStructFields: expected publicFields to be [        + PublicField int\\n] got [%v]", publicFields.String())
+	fields := &LineStringBuilder{}
+	parser.renderStructFields(st, fields)
+	if fields.String() != "        - privateField int\n        + PublicField string\n" {
+		t.Errorf("TestRenderStructFields: expected fields to be [        - privateField int\\n        + PublicField string\\n] got [%v]", fields.String())
 	}
 }
 
@@ -188,7 +234,7 @@ func TestRenderStructures(t *testing.T) {
 	lineB := &LineStringBuilder{}
 	parser := getEmptyParser("main")
 	parser.renderStructures("main", structMap, lineB)
-	expectedResult := "namespace main {\n    class MainClass << (S,Aquamarine) >> {\n        - privateField int\n\n        + PublicField error\n\n        - foo( int,  string) (error, int)\n\n        + Boo( string,  int) int\n\n    }\n}\n\"foopack.AnotherClass\" *-- \"main.MainClass\"\n\n\"main.NewClass\" <|-- \"main.MainClass\"\n\n"
+	expectedResult := "namespace main {\n    class MainClass << (S,Aquamarine) >> {\n        - privateField int\n        + PublicField error\n        - foo(int, string) (error, int)\n        + Boo(string, int) int\n    }\n}\n\"foopack.AnotherClass\" *-- \"main.MainClass\"\n\"main.NewClass\" <|-- \"main.MainClass\"\n"
 	if lineB.String() != expectedResult {
 		t.Errorf("TestRenderStructures: expected %s, got %s", expectedResult, lineB.String())
 	}
@@ -205,7 +251,7 @@ func TestRenderStructures(t *testing.T) {
 		RenderAggregations: true,
 	})
 	parser.renderStructures("main", structMap, lineB)
-	expectedResult = "namespace main {\n    class MainClass << (S,Aquamarine) >> {\n        - privateField int\n\n        + PublicField error\n\n        - foo( int,  string) (error, int)\n\n        + Boo( string,  int) int\n\n    }\n}\n\"foopack.AnotherClass\" *-- \"main.MainClass\"\n\n\"main.NewClass\" <|-- \"main.MainClass\"\n\n\"main.MainClass\" o-- \"main.File\"\n\n"
+	expectedResult = "namespace main {\n    class MainClass << (S,Aquamarine) >> {\n        - privateField int\n        + PublicField error\n        - foo(int, string) (error, int)\n        + Boo(string, int) int\n    }\n}\n\"foopack.AnotherClass\" *-- \"main.MainClass\"\n\"main.NewClass\" <|-- \"main.MainClass\"\n\"main.MainClass\" o-- \"main.File\"\n"
 	if lineB.String() != expectedResult {
 		t.Errorf("TestRenderStructures: expected %s, got %s", expectedResult, lineB.String())
 	}
@@ -217,7 +263,7 @@ func TestRenderStructures(t *testing.T) {
 		AggregatePrivateMembers: true,
 	})
 	parser.renderStructures("main", structMap, lineB)
-	expectedResult = "namespace main {\n    class MainClass << (S,Aquamarine) >> {\n        - privateField int\n\n        + PublicField error\n\n        - foo( int,  string) (error, int)\n\n        + Boo( string,  int) int\n\n    }\n}\n\"foopack.AnotherClass\" *-- \"main.MainClass\"\n\n\"main.NewClass\" <|-- \"main.MainClass\"\n\n\"main.MainClass\" o-- \"main.File\"\n\"main.MainClass\" o-- \"main.File2\"\n\n"
+	expectedResult = "namespace main {\n    class MainClass << (S,Aquamarine) >> {\n        - privateField int\n        + PublicField error\n        - foo(int, string) (error, int)\n        + Boo(string, int) int\n    }\n}\n\"foopack.AnotherClass\" *-- \"main.MainClass\"\n\"main.NewClass\" <|-- \"main.MainClass\"\n\"main.MainClass\" o-- \"main.File\"\n\"main.MainClass\" o-- \"main.File2\"\n"
 	if lineB.String() != expectedResult {
 		t.Errorf("TestRenderStructures: expected %s, got %s", expectedResult, lineB.String())
 	}
@@ -227,26 +273,11 @@ func TestRenderStructure(t *testing.T) {
 	parser := getEmptyParser("main")
 	st := getTestStruct()
 	lineBuilder := &LineStringBuilder{}
-	compositionBuilder := &LineStringBuilder{}
-	extendBuilder := &LineStringBuilder{}
-	aggregationsBuilder := &LineStringBuilder{}
-	parser.renderStructure(st, "main", "TestClass", lineBuilder, compositionBuilder, extendBuilder, aggregationsBuilder)
-	expectedLineBuilder := "    class TestClass << (S,Aquamarine) >> {\n        - privateField int\n\n        + PublicField error\n\n        - foo( int,  string) (error, int)\n\n        + Boo( string,  int) int\n\n    }\n"
+	parser.renderStructure(st, "main", "TestClass", lineBuilder)
+	expectedLineBuilder := "    class TestClass << (S,Aquamarine) >> {\n        - privateField int\n        + PublicField error\n        - foo(int, string) (error, int)\n        + Boo(string, int) int\n    }\n"
 	if lineBuilder.String() != expectedLineBuilder {
 		t.Errorf("TestRenderStructure: Expected lineBuilder [%s] got [%s]", expectedLineBuilder, lineBuilder.String())
 	}
-	expectedComposition := "\"foopack.AnotherClass\" *-- \"main.TestClass\"\n"
-	if compositionBuilder.String() != expectedComposition {
-		t.Errorf("TestRenderStructure: Expected compositionBuilder %s got %s", expectedComposition, compositionBuilder.String())
-	}
-	expectedExtends := "\"main.NewClass\" <|-- \"main.TestClass\"\n"
-	if extendBuilder.String() != expectedExtends {
-		t.Errorf("TestRenderStructure: Expected extendBuilder %s got %s", expectedExtends, extendBuilder.String())
-	}
-	expectedAggregations := ""
-	if aggregationsBuilder.String() != expectedAggregations {
-		t.Errorf("TestRenderStructure: Expected aggregationsBuilder %s got %s", expectedAggregations, aggregationsBuilder.String())
-	}
 }
 
 func getTestStruct() *Struct {
@@ -403,14 +434,10 @@ func TestRenderStructMethods(t *testing.T) {
 			},
 		},
 	}
-	privateFunctions := &LineStringBuilder{}
-	publicFunctions := &LineStringBuilder{}
-	parser.renderStructMethods(st, privateFunctions, publicFunctions)
-	if privateFunctions.String() != "        - foo( int,  string) (error, int)\n" {
-		t.Errorf("TestRenderStructMethods: expected privateFields to be [        - foo( int,  string) (error, int)\\n] got [%v]", privateFunctions.String())
-	}
-	if publicFunctions.String() != "        + Bar( int,  string) int\n" {
-		t.Errorf("TestRenderStructMethods: expected publicFields to be [        + Bar( int,  string) int\\n] got [%v]", publicFunctions.String())
+	functions := &LineStringBuilder{}
+	parser.renderStructMethods(st, functions)
+	if functions.String() != "        - foo(int, string) (error, int)\n        + Bar(int, string) int\n" {
+		t.Errorf("TestRenderStructMethods: expected functions to be [        - foo(int, string) (error, int)\\n        + Bar(int, string) int\\n] got [%v]", functions.String())
 	}
 }
 
@@ -424,13 +451,15 @@ func getEmptyParser(packageName string) *ClassParser {
 			Implementations: true,
 			Aliases:         true,
 			PrivateMembers:  true,
+			PrivateFields:   true,
+			PrivateMethods:  true,
 		},
-		currentPackageName: packageName,
-		structure:          make(map[string]map[string]*Struct),
-		allInterfaces:      make(map[string]struct{}),
-		allStructs:         make(map[string]struct{}),
-		allAliases:         make(map[string]*Alias),
-		allRenamedStructs:  make(map[string]map[string]string),
+		structure:         make(map[string]map[string]*Struct),
+		allInterfaces:     make(map[string]struct{}),
+		allStructs:        make(map[string]struct{}),
+		allAliases:        make(map[string]*Alias),
+		allRenamedStructs: make(map[string]map[string]string),
+		finalized:         true,
 	}
 	result.structure[packageName] = make(map[string]*Struct)
 	return result
@@ -489,6 +518,33 @@ func TestNewClassDiagram(t *testing.T) {
 					Type:   "interface",
 					Exists: true,
 				},
+				{
+					Name:   "skipped.Skipped",
+					Type:   "class",
+					Exists: false,
+				},
+				{
+					Name:   "hidden.Hidden",
+					Type:   "class",
+					Exists: false,
+				},
+			},
+		},
+		{
+			Name:          "Recursive from a hidden root",
+			ExpectedError: "",
+			Path:          "../testingsupport/.hidden",
+			Recursive:     true,
+			ExpectedStructs: []struct {
+				Name   string
+				Type   string
+				Exists bool
+			}{
+				{
+					Name:   "hidden.Hidden",
+					Type:   "class",
+					Exists: true,
+				},
 			},
 		},
 		{
@@ -601,6 +657,30 @@ func TestMultipleFolders(t *testing.T) {
 	}
 }
 
+func TestRenderMembersAndConnections(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/queries", "../testingsupport/enums", "../testingsupport/functions"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderPrivateMembers:       true,
+			RenderAggregations:         true,
+			RenderDependencies:         true,
+			RenderFunctions:            true,
+			RenderGroupImplementations: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("TestRenderMembersAndConnections: expected no errors, got %s", err.Error())
+	}
+	result, err := ioutil.ReadFile("../testingsupport/queries-enums-functions.puml")
+	if err != nil {
+		t.Fatalf("TestRenderMembersAndConnections: expected no errors reading testing file, got %s", err.Error())
+	}
+	if resultRender := parser.Render(); string(result) != resultRender {
+		t.Errorf("TestRenderMembersAndConnections: Expected renders to be the same as %s , but got %s", result, resultRender)
+	}
+}
+
 func TestIgnoreDirectories(t *testing.T) {
 
 	parser, err := NewClassDiagram([]string{"../testingsupport"}, []string{}, true)
@@ -627,6 +707,82 @@ func TestIgnoreDirectories(t *testing.T) {
 	}
 }
 
+func TestIgnoredDirectoryPatterns(t *testing.T) {
+	tt := []struct {
+		Name               string
+		IgnoredDirectories []string
+		ExpectedExists     map[string]bool
+		ExpectedError      bool
+	}{
+		{
+			Name:               "Relative path",
+			IgnoredDirectories: []string{"crosspackage/store"},
+			ExpectedExists: map[string]bool{
+				"store.Store":           false,
+				"domain.Repository":     true,
+				"subfolder2.Subfolder2": true,
+			},
+		},
+		{
+			Name:               "Relative path with a leading dot and a trailing slash",
+			IgnoredDirectories: []string{"./crosspackage/"},
+			ExpectedExists: map[string]bool{
+				"store.Store":           false,
+				"domain.Repository":     false,
+				"subfolder2.Subfolder2": true,
+			},
+		},
+		{
+			Name:               "Glob pattern",
+			IgnoredDirectories: []string{"subfolder*", "crosspackage/[ds]*"},
+			ExpectedExists: map[string]bool{
+				"store.Store":                   false,
+				"domain.Repository":             false,
+				"legacy.Entity":                 true,
+				"subfolder2.Subfolder2":         false,
+				"subfolder3.SubfolderInterface": false,
+			},
+		},
+		{
+			Name:               "Directory name at any depth",
+			IgnoredDirectories: []string{"models"},
+			ExpectedExists: map[string]bool{
+				"models.User": false,
+				"app.Service": true,
+			},
+		},
+		{
+			Name:               "Malformed pattern",
+			IgnoredDirectories: []string{"[models"},
+			ExpectedError:      true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:         afero.NewOsFs(),
+				Directories:        []string{"../testingsupport"},
+				IgnoredDirectories: tc.IgnoredDirectories,
+				Recursive:          true,
+			})
+			if tc.ExpectedError {
+				if err == nil {
+					t.Errorf("Expected an error for %v", tc.IgnoredDirectories)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			for name, exists := range tc.ExpectedExists {
+				if st := parser.getStruct(name); (st != nil) != exists {
+					t.Errorf("Expected %s to exist: %t, got %v", name, exists, st)
+				}
+			}
+		})
+	}
+}
+
 func TestRenderAggregations(t *testing.T) {
 	parser := getEmptyParser("main")
 	st := &Struct{
@@ -671,6 +827,8 @@ func TestSetRenderingOptions(t *testing.T) {
 		Implementations: true,
 		Aliases:         true,
 		PrivateMembers:  true,
+		PrivateFields:   true,
+		PrivateMethods:  true,
 	}
 	if !reflect.DeepEqual(parser.renderingOptions, emptyRenderingOptions) {
 		t.Errorf("TestRenderingOptions: expected renderingOptions to be %v got %v", emptyRenderingOptions, parser.renderingOptions)
@@ -707,17 +865,20 @@ func TestSetRenderingOptions(t *testing.T) {
 	}
 }
 
-func TestRenderCompositionFromInterfaces(t *testing.T) {
+func TestRenderExtendsFromInterfaces(t *testing.T) {
 
 	parser, err := NewClassDiagram([]string{"../testingsupport/subfolder"}, []string{}, false)
 
 	if err != nil {
-		t.Errorf("TestIgnoreDirectories: expected no errors, got %s", err.Error())
+		t.Errorf("TestRenderExtendsFromInterfaces: expected no errors, got %s", err.Error())
 		return
 	}
 	st := parser.getStruct("subfolder.test2")
-	if _, ok := st.Composition["subfolder.TestInterfaceAsField"]; !ok {
-		t.Errorf("TestRenderCompositionFromInterfaces: expected st to have a composition dependency to subfolder.TestInterfaceAsField")
+	if _, ok := st.Extends["subfolder.TestInterfaceAsField"]; !ok {
+		t.Errorf("TestRenderExtendsFromInterfaces: expected st to extend subfolder.TestInterfaceAsField")
+	}
+	if len(st.Composition) != 0 {
+		t.Errorf("TestRenderExtendsFromInterfaces: expected st to have no compositions, got %v", st.Composition)
 	}
 }
 
@@ -787,7 +948,7 @@ func TestGetBasic(t *testing.T) {
 					},
 				},
 			},
-			ExpecterResult: "<font color=blue>struct</font>{int, string}",
+			ExpecterResult: "<font color=blue>struct</font>{int; string}",
 		},
 		{
 			Name: "*int",
@@ -835,13 +996,9 @@ func TestRenderingOptions(t *testing.T) {
 namespace renderingoptions {
     class Test << (S,Aquamarine) >> {
         - integer int
-
         - function() 
-
     }
 }
-
-
 @enduml
 `,
 		}, {
@@ -854,14 +1011,44 @@ namespace renderingoptions {
 			ExpectedResult: `@startuml
 namespace renderingoptions {
     class Test << (S,Aquamarine) >> {
-        - integer int
-
         - function() 
-
     }
 }
-
-
+hide fields
+@enduml
+`,
+		},
+		{
+			Name:        "Hide Fields and Methods",
+			InputFolder: "../testingsupport/renderingoptions",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderFields:         false,
+				RenderMethods:        false,
+				RenderPrivateMembers: true,
+			},
+			ExpectedResult: `@startuml
+namespace renderingoptions {
+    class Test << (S,Aquamarine) >> {
+    }
+}
+hide fields
+hide methods
+@enduml
+`,
+		},
+		{
+			Name:        "Hide Fields and Private Methods",
+			InputFolder: "../testingsupport/renderingoptions",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderFields:         false,
+				RenderPrivateFields:  true,
+				RenderPrivateMethods: false,
+			},
+			ExpectedResult: `@startuml
+namespace renderingoptions {
+    class Test << (S,Aquamarine) >> {
+    }
+}
 hide fields
 @enduml
 `,
@@ -876,13 +1063,9 @@ hide fields
 namespace renderingoptions {
     class Test << (S,Aquamarine) >> {
         - integer int
-
         - function() 
-
     }
 }
-
-
 @enduml
 `,
 		}, {
@@ -896,13 +1079,8 @@ namespace renderingoptions {
 namespace renderingoptions {
     class Test << (S,Aquamarine) >> {
         - integer int
-
-        - function() 
-
     }
 }
-
-
 hide methods
 @enduml
 `,
@@ -915,8 +1093,35 @@ namespace renderingoptions {
     class Test << (S,Aquamarine) >> {
     }
 }
-
-
+@enduml
+`,
+		}, {
+			Name:        "Hide Private Fields",
+			InputFolder: "../testingsupport/renderingoptions",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderPrivateMethods: true,
+			},
+			ExpectedResult: `@startuml
+namespace renderingoptions {
+    class Test << (S,Aquamarine) >> {
+        - function() 
+    }
+}
+@enduml
+`,
+		}, {
+			Name:        "Hide Private Methods",
+			InputFolder: "../testingsupport/renderingoptions",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderPrivateMembers: true,
+				RenderPrivateMethods: false,
+			},
+			ExpectedResult: `@startuml
+namespace renderingoptions {
+    class Test << (S,Aquamarine) >> {
+        - integer int
+    }
+}
 @enduml
 `,
 		},
@@ -944,10 +1149,10 @@ func TestHandleGenDecl(t *testing.T) {
 			t.Errorf("TestHandleGenDecl: Expected no panic in this function when the Specs are empty or nil.")
 		}
 	}()
-	parser.handleGenDecl(&ast.GenDecl{})
+	parser.handleGenDecl(&ast.GenDecl{}, "main")
 	parser.handleGenDecl(&ast.GenDecl{
 		Specs: []ast.Spec{},
-	})
+	}, "main")
 }
 
 func TestConnectionLabelsRendering(t *testing.T) {
@@ -965,24 +1170,18 @@ func TestConnectionLabelsRendering(t *testing.T) {
 	expectedResult := `@startuml
 namespace connectionlabels {
     interface AbstractInterface  {
-        - interfaceFunction() bool
-
+        - {abstract} interfaceFunction() bool
+    }
+    class AliasOfInt << (T, #FF7700) type >> {
     }
     class ImplementsAbstractInterface << (S,Aquamarine) >> {
         + PublicUse AbstractInterface
-
         - interfaceFunction() bool
-
-    }
-    class connectionlabels.AliasOfInt << (T, #FF7700) >>  {
     }
 }
 "connectionlabels.AliasOfInt" *-- "extends""connectionlabels.ImplementsAbstractInterface"
-
-"connectionlabels.AbstractInterface" <|-- "implements""connectionlabels.ImplementsAbstractInterface"
-
-"connectionlabels.ImplementsAbstractInterface""uses" o-- "connectionlabels.AbstractInterface"
-
+"connectionlabels.AbstractInterface" <|.. "implements""connectionlabels.ImplementsAbstractInterface"
+"connectionlabels.ImplementsAbstractInterface""uses" o-- "1" "connectionlabels.AbstractInterface"
 "__builtin__.int" #.. "alias of""connectionlabels.AliasOfInt"
 @enduml
 `
@@ -1020,7 +1219,7 @@ func TestClassParser_handleFuncDecl(t *testing.T) {
 		Recv: &ast.FieldList{
 			List: nil,
 		},
-	})
+	}, "main")
 	if len(p.allStructs) != 0 {
 		t.Error("expecting no structs to be created")
 	}
@@ -1037,16 +1236,12 @@ func TestParametrizedTypeDeclarations(t *testing.T) {
 	expectedResult := `@startuml
 namespace parenthesizedtypedeclarations {
     interface Bar  {
-        + Bar() 
-
+        + {abstract} Bar() 
     }
     interface Foo  {
-        + Foo() 
-
+        + {abstract} Foo() 
     }
 }
-
-
 @enduml
 `
 	if result != expectedResult {
@@ -1069,12 +1264,3078 @@ namespace namedimports {
     }
 }
 "time.Duration" *-- "namedimports.MyType"
+@enduml
+`
+	if result != expectedResult {
+		t.Errorf("TestNamedImportsInAnonymousFields: expecting \n%s\n got \n%s\n", expectedResult, result)
+	}
+
+}
 
+func TestAliasedImportsOfPackagesNamedDifferently(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/aliasedimports"}, []string{}, true)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderFields:       false,
+		RenderAggregations: true,
+	})
+	result := parser.Render()
+	expected := `@startuml
+namespace app {
+    interface Repository  {
+    }
+    class Service << (S,Aquamarine) >> {
+    }
+}
+"database.Conn" *-- "app.Service"
+"store.Record" *-- "app.Service"
+"store.Querier" <|-- "app.Repository"
+"app.Service" o-- "1" "store.Record"
+namespace database {
+    class Conn << (S,Aquamarine) >> {
+    }
+}
+namespace store {
+    interface Querier  {
+        + {abstract} Query(q string) error
+    }
+    class Record << (S,Aquamarine) >> {
+    }
+}
+hide fields
+@enduml
+`
+	if result != expected {
+		t.Errorf("Expected the aliases to use the declared package names\n%s\ngot\n%s", expected, result)
+	}
+}
 
+func TestGroupedTypeDeclarations(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/groupedtypedeclarations"}, []string{}, false)
+	if err != nil {
+		t.Errorf("TestGroupedTypeDeclarations: expected no error but got %s", err.Error())
+		return
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{})
+	result := parser.Render()
+	expectedResult := `@startuml
+namespace groupedtypedeclarations {
+    class A << (S,Aquamarine) >> {
+        + Name string
+    }
+    class B << (S,Aquamarine) >> {
+        + Count int
+    }
+    interface C  {
+        + {abstract} Do() error
+    }
+}
 @enduml
 `
 	if result != expectedResult {
-		t.Errorf("TestNamedImportsInAnonymousFields: expecting \n%s\n got \n%s\n", expectedResult, result)
+		t.Errorf("TestGroupedTypeDeclarations: expecting \n%s\n got \n%s\n", expectedResult, result)
+	}
+}
+
+func TestRenderIsDeterministic(t *testing.T) {
+	options := map[RenderingOption]interface{}{
+		RenderAggregations:      true,
+		AggregatePrivateMembers: true,
+		RenderPrivateMembers:    true,
+	}
+	firstParser, err := NewClassDiagram([]string{"../testingsupport"}, []string{}, true)
+	if err != nil {
+		t.Errorf("TestRenderIsDeterministic: expected no error but got %s", err.Error())
+		return
+	}
+	firstParser.SetRenderingOptions(options)
+	secondParser, err := NewClassDiagram([]string{"../testingsupport"}, []string{}, true)
+	if err != nil {
+		t.Errorf("TestRenderIsDeterministic: expected no error but got %s", err.Error())
+		return
+	}
+	secondParser.SetRenderingOptions(options)
+	first := firstParser.Render()
+	if second := secondParser.Render(); first != second {
+		t.Errorf("TestRenderIsDeterministic: expected two parsers of the same code to render the same, got \n%s\n and \n%s\n", first, second)
+	}
+	if again := firstParser.Render(); first != again {
+		t.Errorf("TestRenderIsDeterministic: expected rendering twice to produce the same result, got \n%s\n and \n%s\n", first, again)
+	}
+	withoutPrivate := map[RenderingOption]interface{}{
+		AggregatePrivateMembers: false,
+	}
+	freshParser, err := NewClassDiagram([]string{"../testingsupport"}, []string{}, true)
+	if err != nil {
+		t.Errorf("TestRenderIsDeterministic: expected no error but got %s", err.Error())
+		return
+	}
+	firstParser.SetRenderingOptions(withoutPrivate)
+	freshParser.SetRenderingOptions(options)
+	freshParser.SetRenderingOptions(withoutPrivate)
+	if first, fresh := firstParser.Render(), freshParser.Render(); first != fresh {
+		t.Errorf("TestRenderIsDeterministic: expected previous renders to not affect the result, got \n%s\n and \n%s\n", first, fresh)
+	}
+	firstParser.SetRenderingOptions(options)
+	if again := firstParser.Render(); first != again {
+		t.Errorf("TestRenderIsDeterministic: expected rendering with other options in between to produce the same result, got \n%s\n and \n%s\n", first, again)
+	}
+}
+
+type failingWriter struct {
+	writes int
+}
+
+func (fw *failingWriter) Write(b []byte) (int, error) {
+	fw.writes++
+	return 0, errors.New("write failed")
+}
+
+func TestRenderTo(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport"}, []string{}, false)
+	if err != nil {
+		t.Errorf("TestRenderTo: expected no errors, got %s", err.Error())
+		return
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderTitle:          "Test Title",
+		RenderNotes:          "Notes Example 1\nNotes Example 1 continues\nNotes Example 2",
+		RenderPrivateMembers: true,
+	})
+	buffer := &bytes.Buffer{}
+	if err := parser.RenderTo(buffer); err != nil {
+		t.Errorf("TestRenderTo: expected no errors, got %s", err.Error())
+	}
+	if buffer.String() != parser.Render() {
+		t.Errorf("TestRenderTo: expected RenderTo to write the same as Render, got %s", buffer.String())
+	}
+	writer := &failingWriter{}
+	err = parser.RenderTo(writer)
+	if err == nil || err.Error() != "write failed" {
+		t.Errorf("TestRenderTo: expected the writer error to be returned, got %v", err)
+	}
+	if writer.writes != 1 {
+		t.Errorf("TestRenderTo: expected rendering to stop after the first failed write, got %d writes", writer.writes)
 	}
+}
 
+func TestInterfaceComposition(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/interfacecomposition"}, []string{}, false)
+	if err != nil {
+		t.Errorf("TestInterfaceComposition: expected no error but got %s", err.Error())
+		return
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderConnectionLabels: true,
+	})
+	result := parser.Render()
+	expectedResult := `@startuml
+namespace interfacecomposition {
+    interface Closer  {
+        + {abstract} Close() error
+    }
+    interface ExternalReadCloser  {
+    }
+    class File << (S,Aquamarine) >> {
+        + Read(p []byte) (n int, err error)
+        + Close() error
+    }
+    class OnlyReader << (S,Aquamarine) >> {
+        + Read(p []byte) (n int, err error)
+    }
+    interface ReadCloser  {
+    }
+    interface Reader  {
+        + {abstract} Read(p []byte) (n int, err error)
+    }
+}
+"interfacecomposition.Closer" <|-- "extends""interfacecomposition.ExternalReadCloser"
+"io.Reader" <|-- "extends""interfacecomposition.ExternalReadCloser"
+"interfacecomposition.Closer" <|.. "implements""interfacecomposition.File"
+"interfacecomposition.ReadCloser" <|.. "implements""interfacecomposition.File"
+"interfacecomposition.Reader" <|.. "implements""interfacecomposition.File"
+"interfacecomposition.Reader" <|.. "implements""interfacecomposition.OnlyReader"
+"interfacecomposition.Closer" <|-- "extends""interfacecomposition.ReadCloser"
+"interfacecomposition.Reader" <|-- "extends""interfacecomposition.ReadCloser"
+@enduml
+`
+	if result != expectedResult {
+		t.Errorf("TestInterfaceComposition: expecting \n%s\n got \n%s\n", expectedResult, result)
+	}
+}
+
+func TestGetReturnValuesString(t *testing.T) {
+	tt := []struct {
+		Name           string
+		Function       *Function
+		ExpectedResult string
+	}{
+		{
+			Name:           "No return values",
+			Function:       &Function{},
+			ExpectedResult: "",
+		},
+		{
+			Name: "One return value",
+			Function: &Function{
+				ReturnValues: []string{"string"},
+			},
+			ExpectedResult: "string",
+		},
+		{
+			Name: "Multiple return values",
+			Function: &Function{
+				ReturnValues: []string{"int", "error"},
+			},
+			ExpectedResult: "(int, error)",
+		},
+		{
+			Name: "One named return value",
+			Function: &Function{
+				ReturnValues:     []string{"string"},
+				ReturnValueNames: []string{"name"},
+			},
+			ExpectedResult: "(name string)",
+		},
+		{
+			Name: "Multiple named return values",
+			Function: &Function{
+				ReturnValues:     []string{"int", "error"},
+				ReturnValueNames: []string{"n", "err"},
+			},
+			ExpectedResult: "(n int, err error)",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			result := getReturnValuesString(tc.Function)
+			if result != tc.ExpectedResult {
+				t.Errorf("Expected %s got %s", tc.ExpectedResult, result)
+			}
+		})
+	}
+}
+
+func TestRenderImplements(t *testing.T) {
+	parser := getEmptyParser("main")
+	st := &Struct{
+		PackageName: "main",
+		Implements: map[string]struct{}{
+			"foopack.AnotherInterface": {},
+			"Interface":                {},
+		},
+	}
+	implementsBuilder := &LineStringBuilder{}
+	parser.renderImplements(st, "TestClass", implementsBuilder)
+	expectedResult := "\"foopack.AnotherInterface\" <|.. \"main.TestClass\"\n\"main.Interface\" <|.. \"main.TestClass\"\n"
+	if implementsBuilder.String() != expectedResult {
+		t.Errorf("TestRenderImplements: Expected %s got %s", expectedResult, implementsBuilder.String())
+	}
+}
+
+func TestEmbeddingAndRealization(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/realization"}, []string{}, false)
+	if err != nil {
+		t.Errorf("TestEmbeddingAndRealization: expected no error but got %s", err.Error())
+		return
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{})
+	result := parser.Render()
+	expectedResult := `@startuml
+namespace realization {
+    class Base << (S,Aquamarine) >> {
+        + ID int
+    }
+    class Job << (S,Aquamarine) >> {
+        + Run() error
+    }
+    interface Runner  {
+        + {abstract} Run() error
+    }
+}
+"realization.Base" *-- "realization.Job"
+"realization.Runner" <|.. "realization.Job"
+@enduml
+`
+	if result != expectedResult {
+		t.Errorf("TestEmbeddingAndRealization: expecting \n%s\n got \n%s\n", expectedResult, result)
+	}
+}
+
+func TestAggregationMultiplicity(t *testing.T) {
+	tt := []struct {
+		Name             string
+		RenderingOptions map[RenderingOption]interface{}
+		ExpectedResult   string
+	}{
+		{
+			Name: "Public members",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderAggregations: true,
+				RenderFields:       false,
+			},
+			ExpectedResult: `"aggregations.Tree" o-- "0..1" "aggregations.Branch"
+"aggregations.Tree" o-- "1" "aggregations.Leaf"
+"aggregations.Tree" o-- "*" "aggregations.Node"
+`,
+		},
+		{
+			Name: "Private members",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderAggregations:      true,
+				AggregatePrivateMembers: true,
+				RenderFields:            false,
+			},
+			ExpectedResult: `"aggregations.Tree" o-- "0..1" "aggregations.Branch"
+"aggregations.Tree" o-- "1" "aggregations.Leaf"
+"aggregations.Tree" o-- "*" "aggregations.Node"
+"aggregations.Tree" o-- "*" "aggregations.Tree"
+`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagram([]string{"../testingsupport/aggregations"}, []string{}, false)
+			if err != nil {
+				t.Errorf("TestAggregationMultiplicity: expected no error but got %s", err.Error())
+				return
+			}
+			parser.SetRenderingOptions(tc.RenderingOptions)
+			result := parser.Render()
+			if !strings.Contains(result, tc.ExpectedResult) {
+				t.Errorf("TestAggregationMultiplicity: expected the result to contain \n%s\n got \n%s\n", tc.ExpectedResult, result)
+			}
+		})
+	}
+}
+
+func TestGenerics(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/generics"}, []string{}, false)
+	if err != nil {
+		t.Errorf("TestGenerics: expected no error but got %s", err.Error())
+		return
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderAggregations:      true,
+		AggregatePrivateMembers: true,
+		RenderPrivateMembers:    true,
+	})
+	result := parser.Render()
+	expectedResult := `@startuml
+namespace generics {
+    class Cache << (S,Aquamarine) >> {
+        + Entries Map[string, int]
+        + Keys List[string]
+    }
+    interface Getter<T any>  {
+        + {abstract} Get() T
+    }
+    class List<T any> << (S,Aquamarine) >> {
+        - items []T
+        + Push(v T) 
+    }
+    class Map<K comparable, V any> << (S,Aquamarine) >> {
+        - values <font color=blue>map</font>[K]V
+        + Get(k K) V
+    }
+}
+"generics.Cache" o-- "1" "generics.List"
+"generics.Cache" o-- "1" "generics.Map"
+@enduml
+`
+	if result != expectedResult {
+		t.Errorf("TestGenerics: expecting \n%s\n got \n%s\n", expectedResult, result)
+	}
+}
+
+func TestConstraintInterfaces(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/constraints"}, []string{}, false)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{})
+	result := parser.Render()
+	expected := `@startuml
+namespace constraints {
+    interface Bytes << constraint >> {
+        string | []byte
+    }
+    interface Code << constraint >> {
+        ~~int
+        + {abstract} Valid() bool
+    }
+    interface Float << constraint >> {
+        ~~float32 | ~~float64
+    }
+    interface Integer << constraint >> {
+        Signed | Unsigned
+    }
+    interface Key << constraint >> {
+        comparable
+        + {abstract} Key() string
+    }
+    interface Number << constraint >> {
+        Integer | Float
+    }
+    interface NumberKey << constraint >> {
+        + {abstract} Key() string
+    }
+    interface Signed << constraint >> {
+        ~~int | ~~int8 | ~~int16 | ~~int32 | ~~int64
+    }
+    class Stats<T Number> << (S,Aquamarine) >> {
+        + Values []T
+    }
+    class Status << (T, #FF7700) type >> {
+        + String() string
+        + Valid() bool
+        + Key() string
+    }
+    interface Unsigned << constraint >> {
+        ~~uint | ~~uint8 | ~~uint16 | ~~uint32 | ~~uint64
+    }
+    interface Validator  {
+        + {abstract} Valid() bool
+    }
+}
+"fmt.Stringer" <|-- "constraints.Code"
+"constraints.Number" <|-- "constraints.NumberKey"
+"constraints.Validator" <|.. "constraints.Status"
+"__builtin__.int" #.. "constraints.Status"
+@enduml
+`
+	if result != expected {
+		t.Errorf("Expected the constraints to list their type terms and to have no implementations\n%s\ngot\n%s", expected, result)
+	}
+	// Empty interfaces are implemented by every type but constraints are still skipped
+	parser, err = NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:             afero.NewOsFs(),
+		Directories:            []string{"../testingsupport/constraints"},
+		IncludeEmptyInterfaces: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if implementations, _ := parser.Implementations("constraints.Integer"); len(implementations) != 0 {
+		t.Errorf("Expected no implementations of the Integer constraint, got %v", implementations)
+	}
+}
+
+func TestRenderUnicodeMembers(t *testing.T) {
+	parser := getEmptyParser("main")
+	st := &Struct{
+		Fields: []*Field{
+			{
+				Name: "ñandú",
+				Type: "int",
+			},
+			{
+				Name: "Ñandú",
+				Type: "string",
+			},
+			{
+				Name: "_",
+				Type: "int",
+			},
+		},
+		Functions: []*Function{
+			{
+				Name: "Δέλτα",
+			},
+			{
+				Name: "δέλτα",
+			},
+		},
+	}
+	fields := &LineStringBuilder{}
+	parser.renderStructFields(st, fields)
+	if fields.String() != "        - ñandú int\n        - _ int\n        + Ñandú string\n" {
+		t.Errorf("TestRenderUnicodeMembers: unexpected fields [%v]", fields.String())
+	}
+	methods := &LineStringBuilder{}
+	parser.renderStructMethods(st, methods)
+	if methods.String() != "        - δέλτα() \n        + Δέλτα() \n" {
+		t.Errorf("TestRenderUnicodeMembers: unexpected methods [%v]", methods.String())
+	}
+}
+
+func TestSkippedDirectoriesAndFiles(t *testing.T) {
+	tt := []struct {
+		Name           string
+		Options        *ClassDiagramOptions
+		ExpectedExists map[string]bool
+	}{
+		{
+			Name: "Defaults",
+			Options: &ClassDiagramOptions{
+				Recursive: true,
+			},
+			ExpectedExists: map[string]bool{
+				"generatedfiles.Written":   true,
+				"generatedfiles.Generated": false,
+				"vendored.Vendored":        false,
+				"skipped.Skipped":          false,
+			},
+		},
+		{
+			Name: "Include everything",
+			Options: &ClassDiagramOptions{
+				Recursive:        true,
+				IncludeVendor:    true,
+				IncludeTestdata:  true,
+				IncludeGenerated: true,
+			},
+			ExpectedExists: map[string]bool{
+				"generatedfiles.Written":   true,
+				"generatedfiles.Generated": true,
+				"vendored.Vendored":        true,
+				"skipped.Skipped":          true,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			tc.Options.FileSystem = afero.NewOsFs()
+			tc.Options.Directories = []string{"../testingsupport"}
+			parser, err := NewClassDiagramWithOptions(tc.Options)
+			if err != nil {
+				t.Errorf("TestSkippedDirectoriesAndFiles: expected no error but got %s", err.Error())
+				return
+			}
+			for name, exists := range tc.ExpectedExists {
+				if st := parser.getStruct(name); (st != nil) != exists {
+					t.Errorf("TestSkippedDirectoriesAndFiles: expected %s to exist: %t, got %v", name, exists, st)
+				}
+			}
+		})
+	}
+}
+
+func TestCrossPackageImplements(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/crosspackage"}, []string{}, true)
+	if err != nil {
+		t.Errorf("TestCrossPackageImplements: expected no error but got %s", err.Error())
+		return
+	}
+	if _, ok := parser.getStruct("store.Store").Implements["domain.Repository"]; !ok {
+		t.Errorf("TestCrossPackageImplements: expected store.Store to implement domain.Repository")
+	}
+	if _, ok := parser.getStruct("store.LegacyStore").Implements["domain.Repository"]; ok {
+		t.Errorf("TestCrossPackageImplements: expected store.LegacyStore not to implement domain.Repository")
+	}
+	field := parser.getStruct("store.LegacyStore").Fields[0]
+	if field.Type != "[]legacy.Entity" {
+		t.Errorf("TestCrossPackageImplements: expected the import alias to be resolved to []legacy.Entity, got %s", field.Type)
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderFields:  false,
+		RenderMethods: false,
+	})
+	if result := parser.Render(); !strings.Contains(result, `"domain.Repository" <|.. "store.Store"`) {
+		t.Errorf("TestCrossPackageImplements: expected the realization arrow, got \n%s\n", result)
+	}
+}
+
+func TestGetImportedPackageName(t *testing.T) {
+	tt := []struct {
+		ImportPath     string
+		ExpectedResult string
+	}{
+		{ImportPath: "fmt", ExpectedResult: "fmt"},
+		{ImportPath: "go/ast", ExpectedResult: "ast"},
+		{ImportPath: "github.com/spf13/afero", ExpectedResult: "afero"},
+		{ImportPath: "github.com/foo/bar/v2", ExpectedResult: "bar"},
+		{ImportPath: "gopkg.in/yaml.v3", ExpectedResult: "yaml"},
+		{ImportPath: "github.com/foo/go-bar", ExpectedResult: "bar"},
+		{ImportPath: "github.com/foo/my-pkg", ExpectedResult: "my"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.ImportPath, func(t *testing.T) {
+			if result := getImportedPackageName(tc.ImportPath); result != tc.ExpectedResult {
+				t.Errorf("Expected %s got %s", tc.ExpectedResult, result)
+			}
+		})
+	}
+}
+
+func TestPromotedMethods(t *testing.T) {
+	tt := []struct {
+		Name                  string
+		IgnorePromotedMethods bool
+		ExpectedImplements    map[string]bool
+	}{
+		{
+			Name: "Promoted methods",
+			ExpectedImplements: map[string]bool{
+				"promotedmethods.BaseHandler":  false,
+				"promotedmethods.NamedHandler": true,
+				"promotedmethods.Server":       true,
+				"promotedmethods.Wrapper":      true,
+				"promotedmethods.Loop":         false,
+				"promotedmethods.Cycle":        false,
+			},
+		},
+		{
+			Name:                  "Ignore promoted methods",
+			IgnorePromotedMethods: true,
+			ExpectedImplements: map[string]bool{
+				"promotedmethods.BaseHandler":  false,
+				"promotedmethods.NamedHandler": false,
+				"promotedmethods.Server":       false,
+				"promotedmethods.Wrapper":      false,
+				"promotedmethods.Loop":         false,
+				"promotedmethods.Cycle":        false,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:            afero.NewOsFs(),
+				Directories:           []string{"../testingsupport/promotedmethods"},
+				RenderingOptions:      map[RenderingOption]interface{}{},
+				IgnorePromotedMethods: tc.IgnorePromotedMethods,
+			})
+			if err != nil {
+				t.Errorf("Expected no error but got %s", err.Error())
+				return
+			}
+			for name, expected := range tc.ExpectedImplements {
+				if _, ok := parser.getStruct(name).Implements["promotedmethods.Handler"]; ok != expected {
+					t.Errorf("Expected %s implementing promotedmethods.Handler to be %t, got %t", name, expected, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestMethodSets(t *testing.T) {
+	tt := []struct {
+		Name                      string
+		MethodSet                 MethodSet
+		ExpectedImplements        map[string][]string
+		ExpectedPointerImplements map[string][]string
+	}{
+		{
+			Name:      "Pointer method set",
+			MethodSet: PointerMethodSet,
+			ExpectedImplements: map[string][]string{
+				"methodsets.File":   {"methodsets.Reader", "methodsets.Writer"},
+				"methodsets.Buffer": {"methodsets.Reader", "methodsets.Writer"},
+				"methodsets.Stream": {"methodsets.Reader", "methodsets.Writer"},
+			},
+		},
+		{
+			Name:      "Value method set",
+			MethodSet: ValueMethodSet,
+			ExpectedImplements: map[string][]string{
+				"methodsets.File":   {"methodsets.Reader"},
+				"methodsets.Buffer": {"methodsets.Reader"},
+				"methodsets.Stream": {"methodsets.Reader", "methodsets.Writer"},
+			},
+		},
+		{
+			Name:      "Both method sets",
+			MethodSet: BothMethodSets,
+			ExpectedImplements: map[string][]string{
+				"methodsets.File":   {"methodsets.Reader", "methodsets.Writer"},
+				"methodsets.Buffer": {"methodsets.Reader", "methodsets.Writer"},
+				"methodsets.Stream": {"methodsets.Reader", "methodsets.Writer"},
+			},
+			ExpectedPointerImplements: map[string][]string{
+				"methodsets.File":   {"methodsets.Writer"},
+				"methodsets.Buffer": {"methodsets.Writer"},
+			},
+		},
+	}
+	getKeys := func(m map[string]struct{}) []string {
+		result := []string{}
+		for k := range m {
+			result = append(result, k)
+		}
+		sort.Strings(result)
+		return result
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{"../testingsupport/methodsets"},
+				RenderingOptions: map[RenderingOption]interface{}{},
+				MethodSet:        tc.MethodSet,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			for name, expected := range tc.ExpectedImplements {
+				st := parser.getStruct(name)
+				if implements := getKeys(st.Implements); !reflect.DeepEqual(implements, expected) {
+					t.Errorf("Expected %s to implement %v, got %v", name, expected, implements)
+				}
+				expectedPointer := tc.ExpectedPointerImplements[name]
+				if expectedPointer == nil {
+					expectedPointer = []string{}
+				}
+				if implements := getKeys(st.PointerImplements); !reflect.DeepEqual(implements, expectedPointer) {
+					t.Errorf("Expected only *%s to implement %v, got %v", name, expectedPointer, implements)
+				}
+			}
+		})
+	}
+}
+
+func TestEmptyInterfaces(t *testing.T) {
+	tt := []struct {
+		Name                   string
+		IncludeEmptyInterfaces bool
+		ExpectedImplements     map[string][]string
+	}{
+		{
+			Name: "Skipped by default",
+			ExpectedImplements: map[string][]string{
+				"emptyinterfaces.User":  {"emptyinterfaces.Named"},
+				"emptyinterfaces.Token": {},
+			},
+		},
+		{
+			Name:                   "Included",
+			IncludeEmptyInterfaces: true,
+			ExpectedImplements: map[string][]string{
+				"emptyinterfaces.User":  {"emptyinterfaces.Marker", "emptyinterfaces.Named", "emptyinterfaces.Tagged"},
+				"emptyinterfaces.Token": {"emptyinterfaces.Marker", "emptyinterfaces.Tagged"},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:             afero.NewOsFs(),
+				Directories:            []string{"../testingsupport/emptyinterfaces"},
+				RenderingOptions:       map[RenderingOption]interface{}{},
+				IncludeEmptyInterfaces: tc.IncludeEmptyInterfaces,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			for name, expected := range tc.ExpectedImplements {
+				implements := []string{}
+				for inter := range parser.getStruct(name).Implements {
+					implements = append(implements, inter)
+				}
+				sort.Strings(implements)
+				if !reflect.DeepEqual(implements, expected) {
+					t.Errorf("Expected %s to implement %v, got %v", name, expected, implements)
+				}
+			}
+			if rendered := strings.Contains(parser.Render(), `"emptyinterfaces.Marker" <|.. "emptyinterfaces.Token"`); rendered != tc.IncludeEmptyInterfaces {
+				t.Errorf("Expected the realizations of Marker to be rendered: %t, got %t", tc.IncludeEmptyInterfaces, rendered)
+			}
+		})
+	}
+}
+
+func TestRenderPointerImplements(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/methodsets"},
+		RenderingOptions: map[RenderingOption]interface{}{},
+		MethodSet:        BothMethodSets,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	for _, expected := range []string{
+		`"methodsets.Reader" <|.. "methodsets.File"` + "\n",
+		`"methodsets.Writer" <|.. "methodsets.File" : *File` + "\n",
+		`"methodsets.Writer" <|.. "methodsets.Buffer" : *Buffer` + "\n",
+		`"methodsets.Writer" <|.. "methodsets.Stream"` + "\n",
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected %s in\n%s", expected, result)
+		}
+	}
+}
+
+func TestAnonymousStructs(t *testing.T) {
+	tt := []struct {
+		Name                   string
+		AnonymousStructClasses bool
+		Expected               string
+	}{
+		{
+			Name: "Inline",
+			Expected: `@startuml
+namespace blog {
+    class Post << (S,Aquamarine) >> {
+        + Meta <font color=blue>struct</font>{Created time.Time; Updated time.Time; Author *<font color=blue>struct</font>{Name string; Contact <font color=blue>struct</font>{Email <font color=blue>struct</font>{...}}}}
+        + Tags []<font color=blue>struct</font>{Name string}
+        + Min <font color=blue>struct</font>{Value int}
+        + Max <font color=blue>struct</font>{Value int}
+    }
+}
+@enduml
+`,
+		},
+		{
+			Name:                   "Classes",
+			AnonymousStructClasses: true,
+			Expected: `@startuml
+namespace blog {
+    class Post << (S,Aquamarine) >> {
+        + Meta Post.Meta
+        + Tags []Post.Tags
+        + Min <font color=blue>struct</font>{Value int}
+        + Max <font color=blue>struct</font>{Value int}
+    }
+    class "Post.Meta" as Post_Meta << (S,Aquamarine) >> {
+        + Created time.Time
+        + Updated time.Time
+        + Author *Post.Meta.Author
+    }
+    class "Post.Meta.Author" as Post_Meta_Author << (S,Aquamarine) >> {
+        + Name string
+        + Contact <font color=blue>struct</font>{Email <font color=blue>struct</font>{Address string}}
+    }
+    class "Post.Tags" as Post_Tags << (S,Aquamarine) >> {
+        + Name string
+    }
+}
+"blog.Post_Meta" *-- "blog.Post"
+"blog.Post_Tags" *-- "blog.Post"
+"blog.Post_Meta_Author" *-- "blog.Post_Meta"
+@enduml
+`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:             afero.NewOsFs(),
+				Directories:            []string{"../testingsupport/anonymousstructs"},
+				RenderingOptions:       map[RenderingOption]interface{}{},
+				AnonymousStructClasses: tc.AnonymousStructClasses,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			if result := parser.Render(); result != tc.Expected {
+				t.Errorf("Expected\n%s\ngot\n%s", tc.Expected, result)
+			}
+		})
+	}
+}
+
+func TestRenderArrays(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/arrays"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderAggregations: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expected := `@startuml
+namespace board {
+    class Board << (S,Aquamarine) >> {
+        + Buffer [16]byte
+        + Matrix [4][4]float64
+        + Cells [size][size]*Cell
+        + Row *[size]Cell
+        + Free []Cell
+    }
+    class Cell << (S,Aquamarine) >> {
+        + Value int
+    }
+}
+"board.Board" o-- "*" "board.Cell"
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, result)
+	}
+}
+
+func TestInterfaceEmbedding(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/interfaceembedding"},
+		RenderingOptions: map[RenderingOption]interface{}{},
+		Recursive:        true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	for _, expected := range []string{
+		`"store.Reader" <|-- "app.Repository"`,
+		`"store.Writer" <|-- "app.Repository"`,
+		`"app.Getter" <|-- "app.Cache"`,
+		`"app.Pair" <|-- "app.Cache"`,
+		`"app.Repository" <|.. "app.Memory"`,
+		`"app.Cache" <|.. "app.Memory"`,
+	} {
+		if !strings.Contains(result, expected+"\n") {
+			t.Errorf("Expected %s in\n%s", expected, result)
+		}
+	}
+	for _, unexpected := range []string{
+		`"app.Cache" <|.. "app.Numbers"`,
+		`"app.Getter" <|.. "app.Memory"`,
+		"+ Reader",
+		"+ Getter",
+	} {
+		if strings.Contains(result, unexpected) {
+			t.Errorf("Expected no %s in\n%s", unexpected, result)
+		}
+	}
+}
+
+func TestRenderPreamble(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/arrays"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderTitle:    "Board",
+			RenderPreamble: []string{"skinparam monochrome true", "  skinparam shadowing false", "!include style.iuml"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expected := `@startuml
+skinparam monochrome true
+  skinparam shadowing false
+!include style.iuml
+title Board
+namespace board {
+`
+	if result := parser.Render(); !strings.HasPrefix(result, expected) {
+		t.Errorf("Expected the diagram to start with\n%s\ngot\n%s", expected, result)
+	}
+}
+
+func TestRenderTitleHeaderAndFooter(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/arrays"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderTitle:    "Board\r\ngenerated on 2006-01-02\n",
+			RenderHeader:   "Draft",
+			RenderFooter:   "Page footer",
+			RenderPreamble: []string{"skinparam monochrome true"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expected := `@startuml
+skinparam monochrome true
+title
+Board
+generated on 2006-01-02
+end title
+header Draft
+footer Page footer
+namespace board {
+`
+	if result := parser.Render(); !strings.HasPrefix(result, expected) {
+		t.Errorf("Expected the diagram to start with\n%s\ngot\n%s", expected, result)
+	}
+}
+
+func TestParallelParsing(t *testing.T) {
+	render := func(workers int) string {
+		parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+			FileSystem:       afero.NewOsFs(),
+			Directories:      []string{"../testingsupport", "../parser"},
+			RenderingOptions: map[RenderingOption]interface{}{},
+			Recursive:        true,
+			Workers:          workers,
+		})
+		if err != nil {
+			t.Fatalf("TestParallelParsing: expected no error but got %s", err.Error())
+		}
+		return parser.Render()
+	}
+	expectedResult := render(1)
+	for _, workers := range []int{0, 2, 16} {
+		if result := render(workers); result != expectedResult {
+			t.Errorf("TestParallelParsing: expected the result with %d workers to be equal to the sequential one, got \n%s\n", workers, result)
+		}
+	}
+}
+
+func TestOverlappingDirectories(t *testing.T) {
+	render := func(directories []string, files []string) string {
+		parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+			FileSystem:       afero.NewOsFs(),
+			Directories:      directories,
+			Files:            files,
+			RenderingOptions: map[RenderingOption]interface{}{},
+			Recursive:        true,
+		})
+		if err != nil {
+			t.Fatalf("TestOverlappingDirectories: expected no error but got %s", err.Error())
+		}
+		return parser.Render()
+	}
+	expectedResult := render([]string{"../testingsupport/importpaths"}, nil)
+	result := render(
+		[]string{"../testingsupport/importpaths/a", "../testingsupport/importpaths", "../testingsupport/importpaths/./a/"},
+		[]string{"../testingsupport/importpaths/app/app.go"},
+	)
+	if result != expectedResult {
+		t.Errorf("TestOverlappingDirectories: expected every directory to be parsed once, got \n%s\n", result)
+	}
+}
+
+func TestAddDirectory(t *testing.T) {
+	options := &ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		RenderingOptions: map[RenderingOption]interface{}{},
+		Recursive:        true,
+		ImportPaths:      true,
+	}
+	parser, err := NewClassParser(options)
+	if err != nil {
+		t.Fatalf("TestAddDirectory: expected no error but got %s", err.Error())
+	}
+	if err := parser.AddDirectory("../testingsupport/importpaths/app"); err != nil {
+		t.Fatalf("TestAddDirectory: expected no error but got %s", err.Error())
+	}
+	service := parser.Structs(importPathsModule + "/app")
+	if len(service) != 1 || len(service[0].Implements) != 0 {
+		t.Fatalf("TestAddDirectory: expected the Service without implementations, got %v", service)
+	}
+	parser.Render()
+	// Adding code after rendering finds the relationships again
+	for _, directory := range []string{"../testingsupport/importpaths/a", "../testingsupport/importpaths/app"} {
+		if err := parser.AddDirectory(directory); err != nil {
+			t.Fatalf("TestAddDirectory: expected no error but got %s", err.Error())
+		}
+	}
+	options.Directories = []string{"../testingsupport/importpaths/app", "../testingsupport/importpaths/a"}
+	expected, err := NewClassDiagramWithOptions(options)
+	if err != nil {
+		t.Fatalf("TestAddDirectory: expected no error but got %s", err.Error())
+	}
+	if result := parser.Render(); result != expected.Render() {
+		t.Errorf("TestAddDirectory: expected \n%s\ngot\n%s", expected.Render(), result)
+	}
+	if _, ok := parser.Structs(importPathsModule + "/app")[0].Implements[importPathsModule+"/a/models.Store"]; !ok {
+		t.Errorf("TestAddDirectory: expected the Service to implement the Store")
+	}
+	if err := parser.AddDirectory("../testingsupport/missing"); err == nil {
+		t.Errorf("TestAddDirectory: expected an error for a missing directory")
+	}
+}
+
+func TestNewClassDiagramCtxCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, recursive := range []bool{true, false} {
+		start := time.Now()
+		_, err := NewClassDiagramCtx(ctx, &ClassDiagramOptions{
+			FileSystem:  afero.NewOsFs(),
+			Directories: []string{"../testingsupport"},
+			Recursive:   recursive,
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected the context error, got %v", err)
+		}
+		if !strings.HasPrefix(err.Error(), "../testingsupport") {
+			t.Errorf("Expected the error to start with the parsed path, got %s", err.Error())
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected the canceled parse to stop right away, it took %s", elapsed)
+		}
+	}
+	if _, _, err := parseDirectoryFiles(ctx, token.NewFileSet(), "../testingsupport/subfolder", nil, false); !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), ".go") {
+		t.Errorf("Expected the context error with the file about to be parsed, got %v", err)
+	}
+}
+
+func TestAddDirectoryCtx(t *testing.T) {
+	parser, err := NewClassParser(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		RenderingOptions: map[RenderingOption]interface{}{},
+		Recursive:        true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := parser.AddDirectoryCtx(ctx, "../testingsupport/crosspackage"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the context error, got %v", err)
+	}
+	if packages := parser.Packages(); len(packages) != 0 {
+		t.Errorf("Expected nothing to be parsed, got %v", packages)
+	}
+	// The directories that were not parsed can be added again
+	if err := parser.AddDirectoryCtx(context.Background(), "../testingsupport/crosspackage"); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if packages := parser.Packages(); !reflect.DeepEqual(packages, []string{"domain", "legacy", "store"}) {
+		t.Errorf("Expected the packages of crosspackage, got %v", packages)
+	}
+}
+
+func TestNewClassParserNilOptions(t *testing.T) {
+	parser, err := NewClassParser(nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if err := parser.AddDirectory("../testingsupport/maps"); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if result := parser.Render(); !strings.Contains(result, "class Index") {
+		t.Errorf("Expected the directory to be parsed with the default options, got\n%s", result)
+	}
+}
+
+func TestAddDirectoryRetry(t *testing.T) {
+	parser, err := NewClassParser(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		RenderingOptions: map[RenderingOption]interface{}{},
+		Strict:           true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing")
+	if err := parser.AddDirectory(missing); err == nil {
+		t.Fatalf("Expected an error for a missing directory")
+	}
+	if err := os.Mkdir(missing, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(missing, "group.go"), []byte("package models\n\ntype Group struct{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.AddDirectory(missing); err != nil {
+		t.Fatalf("Expected the directory to be parsed once it exists, got %s", err.Error())
+	}
+	broken := filepath.Join(dir, "user.go")
+	if err := os.WriteFile(broken, []byte("package models\n\ntype User struct {\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.AddDirectory(dir); err == nil {
+		t.Fatalf("Expected the syntax error with the Strict option")
+	}
+	if err := os.WriteFile(broken, []byte("package models\n\ntype User struct{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.AddDirectory(dir); err != nil {
+		t.Fatalf("Expected the fixed directory to be parsed, got %s", err.Error())
+	}
+	result := parser.Render()
+	if !strings.Contains(result, "class User") || !strings.Contains(result, "class Group") {
+		t.Errorf("Expected the directories that failed to be parsed when added again, got\n%s", result)
+	}
+}
+
+func BenchmarkNewClassDiagram(b *testing.B) {
+	tt := []struct {
+		Name    string
+		Workers int
+	}{
+		{Name: "Sequential", Workers: 1},
+		{Name: fmt.Sprintf("%d workers", runtime.NumCPU()), Workers: 0},
+	}
+	for _, tc := range tt {
+		workers := tc.Workers
+		b.Run(tc.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+					FileSystem:       afero.NewOsFs(),
+					Directories:      []string{"../"},
+					RenderingOptions: map[RenderingOption]interface{}{},
+					Recursive:        true,
+					IncludeVendor:    true,
+					Workers:          workers,
+				})
+				if err != nil {
+					b.Fatalf("BenchmarkNewClassDiagram: expected no error but got %s", err.Error())
+				}
+			}
+		})
+	}
+}
+
+// writeSyntheticModel writes the given number of packages with the given number of types each into dir and returns
+// their directories. Every type has fields, methods, an embedded type and an aggregation and every other one
+// implements the interface of its package.
+func writeSyntheticModel(b *testing.B, dir string, packages int, types int) []string {
+	var directories []string
+	for i := 0; i < packages; i++ {
+		pack := fmt.Sprintf("package%d", i)
+		source := &strings.Builder{}
+		fmt.Fprintf(source, "package %s\n\ntype Handler interface {\n\tHandle(input string) (int, error)\n}\n", pack)
+		for j := 0; j < types; j++ {
+			fmt.Fprintf(source, "\ntype Type%d struct {\n", j)
+			if j > 0 {
+				fmt.Fprintf(source, "\tType%d\n", j-1)
+			}
+			fmt.Fprintf(source, "\tName string\n\tcount int\n\tNext *Type%d\n\tItems []map[string]*Type%d\n}\n", (j+1)%types, (j+2)%types)
+			fmt.Fprintf(source, "\nfunc (t *Type%d) Get(key string, values ...int) (*Type%d, error) {\n\treturn nil, nil\n}\n", j, (j+3)%types)
+			fmt.Fprintf(source, "\nfunc (t *Type%d) reset() {}\n", j)
+			if j%2 == 0 {
+				fmt.Fprintf(source, "\nfunc (t *Type%d) Handle(input string) (int, error) {\n\treturn 0, nil\n}\n", j)
+			}
+		}
+		directory := filepath.Join(dir, pack)
+		if err := os.Mkdir(directory, 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(directory, "types.go"), []byte(source.String()), 0644); err != nil {
+			b.Fatal(err)
+		}
+		directories = append(directories, directory)
+	}
+	return directories
+}
+
+func BenchmarkRender(b *testing.B) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: writeSyntheticModel(b, b.TempDir(), 10, 100),
+	})
+	if err != nil {
+		b.Fatalf("BenchmarkRender: expected no error but got %s", err.Error())
+	}
+	tt := []struct {
+		Name             string
+		RenderingOptions map[RenderingOption]interface{}
+	}{
+		{Name: "Defaults", RenderingOptions: map[RenderingOption]interface{}{}},
+		{Name: "All members and connections", RenderingOptions: map[RenderingOption]interface{}{
+			RenderPrivateMembers: true,
+			RenderAggregations:   true,
+			RenderDependencies:   true,
+		}},
+	}
+	for _, tc := range tt {
+		renderingOptions := tc.RenderingOptions
+		b.Run(tc.Name, func(b *testing.B) {
+			if err := parser.SetRenderingOptions(renderingOptions); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := parser.RenderTo(ioutil.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestClassDiagramOptionsCombinations(t *testing.T) {
+	tt := []struct {
+		Name             string
+		Options          *ClassDiagramOptions
+		ExpectedStructs  map[string]bool
+		ExpectedInRender []string
+		ExpectedError    bool
+	}{
+		{
+			Name: "Recursive with ignored directories and hidden fields",
+			Options: &ClassDiagramOptions{
+				Directories:        []string{"../testingsupport"},
+				IgnoredDirectories: []string{"../testingsupport/subfolder2"},
+				Recursive:          true,
+				RenderingOptions: map[RenderingOption]interface{}{
+					RenderFields: false,
+				},
+			},
+			ExpectedStructs: map[string]bool{
+				"subfolder.test2":       true,
+				"subfolder2.Subfolder2": false,
+			},
+			ExpectedInRender: []string{"hide fields"},
+		},
+		{
+			Name: "Not recursive with a title and without methods",
+			Options: &ClassDiagramOptions{
+				Directories: []string{"../testingsupport/realization"},
+				RenderingOptions: map[RenderingOption]interface{}{
+					RenderMethods: false,
+					RenderTitle:   "Realization",
+				},
+			},
+			ExpectedStructs: map[string]bool{
+				"realization.Job":  true,
+				"subfolder.test2":  false,
+				"realization.Base": true,
+			},
+			ExpectedInRender: []string{"title Realization", "hide methods"},
+		},
+		{
+			Name: "Invalid rendering option",
+			Options: &ClassDiagramOptions{
+				Directories: []string{"../testingsupport/realization"},
+				RenderingOptions: map[RenderingOption]interface{}{
+					RenderingOption(-1): true,
+				},
+			},
+			ExpectedError: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			tc.Options.FileSystem = afero.NewOsFs()
+			parser, err := NewClassDiagramWithOptions(tc.Options)
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("Expected error to be %t, got %v", tc.ExpectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			for name, exists := range tc.ExpectedStructs {
+				if st := parser.getStruct(name); (st != nil) != exists {
+					t.Errorf("Expected %s to exist to be %t", name, exists)
+				}
+			}
+			result := parser.Render()
+			for _, expected := range tc.ExpectedInRender {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected the result to contain %s, got \n%s\n", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderSanitizedNames(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/my-pkg"}, []string{}, false)
+	if err != nil {
+		t.Errorf("TestRenderSanitizedNames: expected no error but got %s", err.Error())
+		return
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderAggregations: true,
+	})
+	result := parser.Render()
+	expectedResult := `@startuml
+namespace note_ {
+    interface Renderer  {
+        + {abstract} Render() string
+    }
+    class "end" as end_ << (S,Aquamarine) >> {
+        + Items []*object
+    }
+    class "object" as object_ << (S,Aquamarine) >> {
+        + Render() string
+    }
+}
+"note_.Renderer" <|.. "note_.object_"
+"note_.end_" o-- "*" "note_.object_"
+@enduml
+`
+	if result != expectedResult {
+		t.Errorf("TestRenderSanitizedNames: expecting \n%s\n got \n%s\n", expectedResult, result)
+	}
+}
+
+func TestGetDiagramName(t *testing.T) {
+	tt := []struct {
+		Input          string
+		ExpectedResult string
+	}{
+		{Input: "main.Foo", ExpectedResult: "main.Foo"},
+		{Input: "builtin.int", ExpectedResult: "builtin.int"},
+		{Input: "note.Foo", ExpectedResult: "note_.Foo"},
+		{Input: "main.end", ExpectedResult: "main.end_"},
+		{Input: "my-pkg.Foo", ExpectedResult: "my_pkg.Foo"},
+		{Input: "Foo", ExpectedResult: "Foo"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Input, func(t *testing.T) {
+			if result := getDiagramName(tc.Input); result != tc.ExpectedResult {
+				t.Errorf("Expected %s got %s", tc.ExpectedResult, result)
+			}
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/crosspackage/domain"},
+		Files:            []string{"../testingsupport/crosspackage/legacy/legacy.go"},
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("TestParseFile: expected no error but got %s", err.Error())
+	}
+	if parser.getStruct("legacy.Entity") == nil {
+		t.Errorf("TestParseFile: expected legacy.Entity to be parsed from the Files option")
+	}
+	if err := parser.ParseFile("../testingsupport/crosspackage/store/store.go"); err != nil {
+		t.Fatalf("TestParseFile: expected no error but got %s", err.Error())
+	}
+	if parser.getStruct("store.LegacyStore") != nil {
+		t.Errorf("TestParseFile: expected store.LegacyStore not to be parsed since it is declared in another file")
+	}
+	st := parser.getStruct("store.Store")
+	if st == nil {
+		t.Fatalf("TestParseFile: expected store.Store to be parsed")
+	}
+	if _, ok := st.Implements["domain.Repository"]; !ok {
+		t.Errorf("TestParseFile: expected store.Store to implement domain.Repository")
+	}
+	if err := parser.ParseFile("../testingsupport/crosspackage/store/missing.go"); err == nil {
+		t.Errorf("TestParseFile: expected an error parsing a file that does not exist")
+	}
+}
+
+func TestRenderLinks(t *testing.T) {
+	tt := []struct {
+		Name           string
+		Directories    []string
+		Recursive      bool
+		ExpectedResult []string
+	}{
+		{
+			Name:        "Parsed directory",
+			Directories: []string{"../testingsupport/realization"},
+			ExpectedResult: []string{
+				`    class Base << (S,Aquamarine) >> [[https://example.com/blob/main/realization.go#L9]] {`,
+				`    class Job << (S,Aquamarine) >> [[https://example.com/blob/main/realization.go#L14]] {`,
+				`    interface Runner  [[https://example.com/blob/main/realization.go#L4]] {`,
+			},
+		},
+		{
+			Name:        "Recursive",
+			Directories: []string{"../testingsupport"},
+			Recursive:   true,
+			ExpectedResult: []string{
+				`    class Base << (S,Aquamarine) >> [[https://example.com/blob/main/realization/realization.go#L9]] {`,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:  afero.NewOsFs(),
+				Directories: tc.Directories,
+				Recursive:   tc.Recursive,
+				RenderingOptions: map[RenderingOption]interface{}{
+					RenderLinkTemplate: "https://example.com/blob/main/{path}#L{line}",
+				},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			result := parser.Render()
+			for _, expected := range tc.ExpectedResult {
+				if !strings.Contains(result, expected+"\n") {
+					t.Errorf("Expected the result to contain \n%s\n got \n%s\n", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRelativePath(t *testing.T) {
+	parser := getEmptyParser("main")
+	parser.options.Directories = []string{"/repo/a", "/repo/b"}
+	tt := []struct {
+		Input          string
+		ExpectedResult string
+	}{
+		{Input: "/repo/a/file.go", ExpectedResult: "file.go"},
+		{Input: "/repo/b/sub/file.go", ExpectedResult: "sub/file.go"},
+		{Input: "/repo/..c/file.go", ExpectedResult: "file.go"},
+		{Input: "/other/file.go", ExpectedResult: "file.go"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Input, func(t *testing.T) {
+			if result := parser.getRelativePath(tc.Input); result != tc.ExpectedResult {
+				t.Errorf("Expected %s got %s", tc.ExpectedResult, result)
+			}
+		})
+	}
+}
+
+func TestRenderExportedOnly(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/exportedonly"}, []string{}, false)
+	if err != nil {
+		t.Errorf("TestRenderExportedOnly: expected no error but got %s", err.Error())
+		return
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderAggregations:      true,
+		AggregatePrivateMembers: true,
+		RenderExportedOnly:      true,
+	})
+	result := parser.Render()
+	expectedResult := `@startuml
+namespace exportedonly {
+    interface Closer  {
+        + {abstract} Close() error
+    }
+    class Config << (S,Aquamarine) >> {
+        + Path string
+    }
+    class Service << (S,Aquamarine) >> {
+        + Name string
+        + ID int
+        + Config *Config
+        + Start() error
+        + Close() error
+        + Log(message string) 
+    }
+}
+"exportedonly.Closer" <|.. "exportedonly.Service"
+"exportedonly.Service" o-- "0..1" "exportedonly.Config"
+@enduml
+`
+	if result != expectedResult {
+		t.Errorf("TestRenderExportedOnly: expecting \n%s\n got \n%s\n", expectedResult, result)
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderExportedOnly: false,
+	})
+	result = parser.Render()
+	for _, expected := range []string{"class base", "interface closer", `"exportedonly.closer" <|.. "exportedonly.Service"`} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("TestRenderExportedOnly: expected the result to contain %s when the option is not set, got \n%s\n", expected, result)
+		}
+	}
+}
+
+func TestFindConstructors(t *testing.T) {
+	tt := []struct {
+		Name                 string
+		IgnoreConstructors   bool
+		IncludeFactories     bool
+		ExpectedConstructors map[string][]string
+	}{
+		{
+			Name: "Default",
+			ExpectedConstructors: map[string][]string{
+				"Client": {"NewClient", "DefaultClient"},
+				"Config": nil,
+				"Reader": nil,
+				"List":   {"NewList"},
+			},
+		},
+		{
+			Name:             "Include factories",
+			IncludeFactories: true,
+			ExpectedConstructors: map[string][]string{
+				"Client": {"NewClient", "DefaultClient"},
+				"Config": {"ParseConfig"},
+				"Reader": nil,
+				"List":   {"NewList"},
+			},
+		},
+		{
+			Name:               "Ignore constructors",
+			IgnoreConstructors: true,
+			IncludeFactories:   true,
+			ExpectedConstructors: map[string][]string{
+				"Client": nil,
+				"Config": nil,
+				"Reader": nil,
+				"List":   nil,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:         afero.NewOsFs(),
+				Directories:        []string{"../testingsupport/constructors"},
+				IgnoreConstructors: tc.IgnoreConstructors,
+				IncludeFactories:   tc.IncludeFactories,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			for name, expected := range tc.ExpectedConstructors {
+				var constructors []string
+				for _, constructor := range parser.structure["constructors"][name].Constructors {
+					constructors = append(constructors, constructor.Name)
+				}
+				if !reflect.DeepEqual(constructors, expected) {
+					t.Errorf("Expected %s to have the constructors %v but got %v", name, expected, constructors)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderConstructors(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/constructors"}, []string{}, false)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	expectedLines := []string{
+		`        + {static} NewClient(address string) (*Client, error)`,
+		`        + {static} DefaultClient() *Client`,
+		`        + Connect() error`,
+		`        + {static} NewList() *List[T]`,
+	}
+	for _, expected := range expectedLines {
+		if !strings.Contains(result, expected+"\n") {
+			t.Errorf("Expected the result to contain \n%s\n got \n%s\n", expected, result)
+		}
+	}
+	if strings.Contains(result, "NewReader") {
+		t.Errorf("Expected NewReader not to be rendered, got \n%s\n", result)
+	}
+}
+
+func TestGetDocNote(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Doc      string
+		Length   int
+		Expected string
+	}{
+		{
+			Name:     "Empty",
+			Doc:      "",
+			Expected: "",
+		},
+		{
+			Name:     "First sentence",
+			Doc:      "Foo does things.\nIt does them well.",
+			Expected: "Foo does things.",
+		},
+		{
+			Name:     "No period",
+			Doc:      "Foo does things\nwell",
+			Expected: "Foo does things well",
+		},
+		{
+			Name:     "Version numbers",
+			Doc:      "Foo supports v1.2 only. Bar does not.",
+			Expected: "Foo supports v1.2 only.",
+		},
+		{
+			Name:     "Length",
+			Doc:      "Foo does things.\nIt does them well.",
+			Length:   20,
+			Expected: "Foo does things. It ...",
+		},
+		{
+			Name:     "Length longer than the comment",
+			Doc:      "Foo does things.",
+			Length:   20,
+			Expected: "Foo does things.",
+		},
+		{
+			Name:     "Escaped",
+			Doc:      "Foo is <b>bold</b> & **strong** -- see https://example.com",
+			Expected: "Foo is &lt;b&gt;bold&lt;/b&gt; &amp; ~*~*strong~*~* ~-~- see https:~/~/example.com",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if result := getDocNote(tc.Doc, tc.Length); result != tc.Expected {
+				t.Errorf("Expected %q, got %q", tc.Expected, result)
+			}
+		})
+	}
+}
+
+func TestRenderDocNotes(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/docnotes"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderDocNotes: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	expected := `}
+note top of docnotes.Documented
+    Documented has a doc comment that spans several lines.
+end note
+note top of docnotes.Grouped
+    Grouped uses &lt;b&gt;markup&lt;/b&gt; and ~*~*bold~*~* text.
+end note
+note top of docnotes.Identifier
+    Identifier is an alias
+end note
+`
+	if !strings.Contains(result, expected) {
+		t.Errorf("Expected the result to contain \n%s\n got \n%s\n", expected, result)
+	}
+	if strings.Contains(result, "note top of docnotes.Undocumented") {
+		t.Errorf("Expected no note for types without a doc comment, got \n%s\n", result)
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderDocNotes: false,
+	})
+	if result := parser.Render(); strings.Contains(result, "note top of") {
+		t.Errorf("Expected no notes when the option is disabled, got \n%s\n", result)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tt := []struct {
+		Name          string
+		Directories   []string
+		Files         []string
+		Recursive     bool
+		Strict        bool
+		ExpectedError bool
+	}{
+		{
+			Name:        "Directory",
+			Directories: []string{"../testingsupport/testdata/brokenfile"},
+		},
+		{
+			Name:          "Strict directory",
+			Directories:   []string{"../testingsupport/testdata/brokenfile"},
+			Strict:        true,
+			ExpectedError: true,
+		},
+		{
+			Name:  "File",
+			Files: []string{"../testingsupport/testdata/brokenfile/broken.go", "../testingsupport/testdata/brokenfile/good.go"},
+		},
+		{
+			Name:          "Strict file",
+			Files:         []string{"../testingsupport/testdata/brokenfile/broken.go", "../testingsupport/testdata/brokenfile/good.go"},
+			Strict:        true,
+			ExpectedError: true,
+		},
+		{
+			Name:        "Strict recursive",
+			Directories: []string{"../testingsupport/testdata"},
+			Recursive:   true,
+			Strict:      true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:      afero.NewOsFs(),
+				Directories:     tc.Directories,
+				Files:           tc.Files,
+				Recursive:       tc.Recursive,
+				IncludeTestdata: true,
+				Strict:          tc.Strict,
+			})
+			if tc.ExpectedError {
+				if err == nil {
+					t.Fatalf("Expected an error parsing the broken file")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			warnings := parser.Warnings()
+			if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "broken.go") {
+				t.Errorf("Expected a warning about broken.go, got %v", warnings)
+			}
+			if parser.getStruct("brokenfile.Parsed") == nil {
+				t.Errorf("Expected the types of the other files to be parsed")
+			}
+			if parser.getStruct("brokenfile.Broken") != nil {
+				t.Errorf("Expected the types of the broken file to be skipped")
+			}
+		})
+	}
+}
+
+func TestVerboseUnknownTypes(t *testing.T) {
+	sources := map[string]string{
+		"app/app.go": `package app
+
+type Number interface {
+	~int | ~float64
+}
+
+type Values[T ~int | string] struct {
+	First (int)
+	Rest  [4]int
+}
+
+func (v *Values[T]) Get() (*Values[T], (error)) {
+	return v, nil
+}
+`,
+	}
+	parser, err := NewClassDiagramFromSource(sources, &ClassDiagramOptions{Verbose: true})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if warnings := parser.Warnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for the known type expressions, got %v", warnings)
+	}
+	if result := parser.Render(); !strings.Contains(result, "+ First int") || !strings.Contains(result, "class Values<T ~int | string>") {
+		t.Errorf("Expected the parenthesized field type and the constraint of the type parameter, got\n%s", result)
+	}
+	parser = getEmptyParser("app")
+	parser.warnUnknownTypes(&ast.File{
+		Decls: []ast.Decl{
+			&ast.GenDecl{
+				Tok: token.TYPE,
+				Specs: []ast.Spec{
+					&ast.TypeSpec{
+						Name: &ast.Ident{Name: "Values"},
+						Type: &ast.StructType{
+							Fields: &ast.FieldList{
+								List: []*ast.Field{
+									{Names: []*ast.Ident{{Name: "First"}}, Type: &NoMatchField{Expr: &ast.Ident{Name: "Foo"}}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	expected := []string{
+		"-: unknown type expression *parser.NoMatchField rendered as ?unknown?",
+	}
+	var result []string
+	for _, warning := range parser.Warnings() {
+		result = append(result, warning.Error())
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected the warnings\n%v\ngot\n%v", expected, result)
+	}
+}
+
+func TestParenthesizedTypes(t *testing.T) {
+	sources := map[string]string{
+		"app/app.go": `package app
+
+type Item struct{}
+
+type Wrapper (struct {
+	Item  (*Item)
+	Items ([]Item)
+	Call  (func(int) (error))
+	Done  chan (<-chan Item)
+})
+
+type Named interface {
+	Name() string
+}
+
+type Getter interface {
+	(Named)
+	Get() (*Item)
+}
+
+type Status (int)
+
+const Active (Status) = 1
+
+func (w (*Wrapper)) Get() (*Item) {
+	return w.Item
+}
+
+func (w *Wrapper) Name() string {
+	return ""
+}
+
+func (w *Wrapper) Put(item (*Item), items ...(Item)) {
+}
+`,
+	}
+	parser, err := NewClassDiagramFromSource(sources, &ClassDiagramOptions{
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderAggregations:     true,
+			RenderPointerReceivers: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	expected := `    class Wrapper << (S,Aquamarine) >> {
+        + Item *Item
+        + Items []Item
+        + {field} Call <font color=blue>func</font>(int) error
+        + {field} Done <font color=blue>chan</font> (<-<font color=blue>chan</font> Item)
+        + Get() *Item «ptr»
+        + Name() string «ptr»
+        + Put(item *Item, items ...Item) «ptr»
+    }
+}
+"app.Named" <|-- "app.Getter"
+"app.Getter" <|.. "app.Wrapper"
+"app.Named" <|.. "app.Wrapper"
+"app.Wrapper" o-- "*" "app.Item"
+`
+	if !strings.Contains(result, expected) {
+		t.Errorf("Expected the parenthesized types to be unwrapped\n%s\ngot\n%s", expected, result)
+	}
+	if !strings.Contains(result, "    enum Status  {\n        Active\n    }") {
+		t.Errorf("Expected the constants of the parenthesized named type, got\n%s", result)
+	}
+}
+
+func TestRenderFuncFields(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/callbacks"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderPrivateMembers: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	tt := []struct {
+		Name     string
+		Expected string
+	}{
+		{
+			Name:     "Parameters and result",
+			Expected: `+ {field} Handler <font color=blue>func</font>(http.ResponseWriter, *http.Request) error`,
+		},
+		{
+			Name:     "No parameters nor results",
+			Expected: `+ {field} OnClose <font color=blue>func</font>()`,
+		},
+		{
+			Name:     "Named results",
+			Expected: `+ {field} Split <font color=blue>func</font>(string) (string, string, error)`,
+		},
+		{
+			Name:     "Variadic",
+			Expected: `+ {field} Log <font color=blue>func</font>(string, ...<font color=blue>interface</font>{})`,
+		},
+		{
+			Name:     "Parameters sharing a type",
+			Expected: `+ {field} Pair <font color=blue>func</font>(int, int) int`,
+		},
+		{
+			Name:     "Nested functions",
+			Expected: `+ {field} Middleware <font color=blue>func</font>(<font color=blue>func</font>(*Event) error) <font color=blue>func</font>(*Event) error`,
+		},
+		{
+			Name:     "Private",
+			Expected: `- {field} onEvent <font color=blue>func</font>(*Event)`,
+		},
+		{
+			Name:     "Method parameters",
+			Expected: `+ Subscribe(filter <font color=blue>func</font>(*Event) bool, handler <font color=blue>func</font>(*Event)) `,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if !strings.Contains(result, tc.Expected+"\n") {
+				t.Errorf("Expected the result to contain \n%s\n got \n%s\n", tc.Expected, result)
+			}
+		})
+	}
+}
+
+func TestRenderChannels(t *testing.T) {
+	tt := []struct {
+		Name              string
+		AggregateChannels bool
+		Expected          []string
+		NotExpected       []string
+	}{
+		{
+			Name: "Channels are not aggregated by default",
+			Expected: []string{
+				`+ Events <font color=blue>chan</font> *Event`,
+				`+ In <-<font color=blue>chan</font> []Event`,
+				`+ Out <font color=blue>chan</font><- Result`,
+				`- pending <font color=blue>chan</font> Event`,
+				`+ Run(done <-<font color=blue>chan</font> <font color=blue>struct</font>{}) <-<font color=blue>chan</font> Result`,
+			},
+			NotExpected: []string{
+				`o--`,
+			},
+		},
+		{
+			Name:              "AggregateChannels",
+			AggregateChannels: true,
+			Expected: []string{
+				`"channels.Worker" o-- "*" "channels.Event"`,
+				`"channels.Worker" o-- "*" "channels.Result"`,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:        afero.NewOsFs(),
+				Directories:       []string{"../testingsupport/channels"},
+				AggregateChannels: tc.AggregateChannels,
+				RenderingOptions: map[RenderingOption]interface{}{
+					RenderAggregations:   true,
+					RenderPrivateMembers: true,
+				},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			result := parser.Render()
+			for _, expected := range tc.Expected {
+				if !strings.Contains(result, expected+"\n") {
+					t.Errorf("Expected the result to contain \n%s\n got \n%s\n", expected, result)
+				}
+			}
+			for _, notExpected := range tc.NotExpected {
+				if strings.Contains(result, notExpected) {
+					t.Errorf("Expected the result not to contain \n%s\n got \n%s\n", notExpected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderVariadics(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/variadics"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderPrivateMembers: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	tt := []struct {
+		Name     string
+		Expected string
+	}{
+		{
+			Name:     "Empty interface",
+			Expected: `+ Infof(format string, args ...<font color=blue>interface</font>{}) `,
+		},
+		{
+			Name:     "Any",
+			Expected: `+ Debug(values ...any) `,
+		},
+		{
+			Name:     "Pointers to named types",
+			Expected: `+ Add(level int, entries ...*Entry) int`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if !strings.Contains(result, tc.Expected+"\n") {
+				t.Errorf("Expected the result to contain \n%s\n got \n%s\n", tc.Expected, result)
+			}
+		})
+	}
+}
+
+func TestRenderGroupedNames(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/groupednames"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderPrivateMembers: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	expected := []string{
+		"        - a *Vector\n        - b *Vector\n",
+		"        + X float64\n        + Y float64\n        + Z float64\n",
+		"        + Scale(x float64, y float64, z float64, factors ...float64) (length float64, angle float64)\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(result, e) {
+			t.Errorf("Expected the result to contain \n%s\n got \n%s\n", e, result)
+		}
+	}
+}
+
+func TestRenderNamedTypes(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/namedtypes"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderAggregations: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	expected := []string{
+		"    class UserID << (T, #FF7700) type >> {\n        + String() string\n",
+		"    class Email << (T, #FF7700) alias >> {\n    }\n",
+		"    class Stack << (T, #FF7700) type >> {\n        + Push(f Frame) \n",
+		"    class Index << (T, #FF7700) type >> {\n        + Get(name string) *Frame\n",
+		`"namedtypes.Stringer" <|.. "namedtypes.UserID"`,
+		`"namedtypes.User" o-- "1" "namedtypes.UserID"`,
+		`"namedtypes.User" o-- "1" "namedtypes.Email"`,
+		`"namedtypes.User" o-- "1" "namedtypes.Stack"`,
+		`"__builtin__.int64" #.. "namedtypes.UserID"`,
+		`"__builtin__.string" #.. "namedtypes.Email"`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(result, e) {
+			t.Errorf("Expected the result to contain \n%s\n got \n%s\n", e, result)
+		}
+	}
+	for _, name := range []string{"UserID", "Email", "Stack", "Index"} {
+		if count := strings.Count(result, fmt.Sprintf("class %s ", name)); count != 1 {
+			t.Errorf("Expected %s to be rendered once, got %d times in \n%s\n", name, count, result)
+		}
+	}
+}
+
+func TestMethodsBeforeTypes(t *testing.T) {
+	tt := []struct {
+		Name    string
+		Options *ClassDiagramOptions
+	}{
+		{
+			Name:    "Directory",
+			Options: &ClassDiagramOptions{Directories: []string{"../testingsupport/methodsfirst"}},
+		},
+		{
+			Name: "Files",
+			Options: &ClassDiagramOptions{Files: []string{
+				"../testingsupport/methodsfirst/a_methods.go",
+				"../testingsupport/methodsfirst/z_types.go",
+			}},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			tc.Options.FileSystem = afero.NewOsFs()
+			parser, err := NewClassDiagramWithOptions(tc.Options)
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			structures := parser.structure["methodsfirst"]
+			expected := map[string]struct {
+				Type    string
+				Methods int
+			}{
+				"Celsius":     {"type", 2},
+				"Readings":    {"type", 1},
+				"Sensor":      {"interface", 1},
+				"Thermometer": {"class", 1},
+			}
+			if len(structures) != len(expected) {
+				t.Errorf("Expected %d types, got %d", len(expected), len(structures))
+			}
+			for name, e := range expected {
+				st, ok := structures[name]
+				if !ok {
+					t.Errorf("Expected %s to be parsed", name)
+					continue
+				}
+				if st.Type != e.Type || len(st.Functions) != e.Methods {
+					t.Errorf("Expected %s to be a %s with %d methods, got a %s with %d", name, e.Type, e.Methods, st.Type, len(st.Functions))
+				}
+			}
+			if _, ok := parser.allStructs["methodsfirst.Sensor"]; ok {
+				t.Errorf("Expected the Sensor interface not to be taken for a struct")
+			}
+			if _, ok := structures["Thermometer"].Implements["methodsfirst.Sensor"]; !ok {
+				t.Errorf("Expected Thermometer to implement Sensor, got %v", structures["Thermometer"].Implements)
+			}
+		})
+	}
+}
+
+func TestDuplicateRelationships(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/duplicates"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	service := parser.structure["duplicates"]["Service"]
+	verbose := parser.structure["duplicates"]["Verbose"]
+	// The same types added again with their package
+	service.AddToComposition("*duplicates.Base")
+	service.AddToImplements("Logger")
+	verbose.AddToExtends("duplicates.Logger")
+	if len(service.Composition) != 2 || len(service.Implements) != 1 || len(verbose.Extends) != 1 {
+		t.Errorf("Expected the duplicates not to be added, got %v, %v and %v", service.Composition, service.Implements, verbose.Extends)
+	}
+	service.Composition["duplicates.Base"] = struct{}{}
+	result := parser.Render()
+	expected := map[string]int{
+		`"duplicates.Base" *-- "duplicates.Service"`:    1,
+		`"duplicates.Logger" *-- "duplicates.Service"`:  1,
+		`"duplicates.Logger" <|.. "duplicates.Service"`: 0,
+		`"duplicates.Logger" <|-- "duplicates.Verbose"`: 1,
+	}
+	for arrow, e := range expected {
+		if count := strings.Count(result, arrow); count != e {
+			t.Errorf("Expected %d arrows %s, got %d in\n%s", e, arrow, count, result)
+		}
+	}
+	dot := parser.RenderDOT()
+	if count := strings.Count(dot, `"duplicates.Logger" -> "duplicates.Service"`) + strings.Count(dot, `"duplicates.Service" -> "duplicates.Logger"`); count != 1 {
+		t.Errorf("Expected a single edge between Service and Logger, got %d in\n%s", count, dot)
+	}
+}
+
+func TestHiddenMembersKeepRelationships(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/namedtypes"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderFields:       false,
+			RenderMethods:      false,
+			RenderAggregations: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	for _, expected := range []string{"    class User << (S,Aquamarine) >> {\n    }\n", `"namedtypes.User" o-- "1" "namedtypes.UserID"`, `"namedtypes.Stringer" <|.. "namedtypes.UserID"`} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected the result to contain \n%s\n got \n%s\n", expected, result)
+		}
+	}
+	for _, notExpected := range []string{"+ ID UserID", "String() string"} {
+		if strings.Contains(result, notExpected) {
+			t.Errorf("Expected the result not to contain \n%s\n got \n%s\n", notExpected, result)
+		}
+	}
+}
+
+func TestRenderFunctions(t *testing.T) {
+	tt := []struct {
+		Name             string
+		RenderingOptions map[RenderingOption]interface{}
+		Expected         string
+	}{
+		{
+			Name:             "Not rendered by default",
+			RenderingOptions: map[RenderingOption]interface{}{},
+			Expected: `@startuml
+namespace stringsutil {
+    class Builder << (S,Aquamarine) >> {
+        + {static} NewBuilder() *Builder
+    }
+}
+@enduml
+`,
+		},
+		{
+			Name: "Functions",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderFunctions:      true,
+				RenderPrivateMembers: true,
+			},
+			Expected: `@startuml
+namespace stringsutil {
+    class Builder << (S,Aquamarine) >> {
+        - parts []string
+        + {static} NewBuilder() *Builder
+    }
+    class stringsutil << (F, #8FBC8F) functions >> {
+        - {static} padLeft(s string, n int) string
+        + {static} Reverse(s string) string
+        + {static} Join(sep string, parts ...string) (string, error)
+    }
+}
+@enduml
+`,
+		},
+		{
+			Name: "Exported functions",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderFunctions:    true,
+				RenderExportedOnly: true,
+			},
+			Expected: `@startuml
+namespace stringsutil {
+    class Builder << (S,Aquamarine) >> {
+        + {static} NewBuilder() *Builder
+    }
+    class stringsutil << (F, #8FBC8F) functions >> {
+        + {static} Reverse(s string) string
+        + {static} Join(sep string, parts ...string) (string, error)
+    }
+}
+@enduml
+`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{"../testingsupport/functions"},
+				RenderingOptions: tc.RenderingOptions,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			if result := parser.Render(); result != tc.Expected {
+				t.Errorf("Expected \n%s\n got \n%s\n", tc.Expected, result)
+			}
+		})
+	}
+}
+
+func TestRenderTags(t *testing.T) {
+	tt := []struct {
+		Name             string
+		RenderingOptions map[RenderingOption]interface{}
+		Expected         string
+	}{
+		{
+			Name:             "Not rendered by default",
+			RenderingOptions: map[RenderingOption]interface{}{},
+			Expected: `@startuml
+namespace tags {
+    class User << (S,Aquamarine) >> {
+        + ID int64
+        + Name string
+        + Email string
+        + Password string
+        + Comment string
+        + Notes string
+        + {field} Callback <font color=blue>func</font>()
+    }
+}
+@enduml
+`,
+		},
+		{
+			Name: "Tags",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderTags: true,
+			},
+			Expected: `@startuml
+namespace tags {
+    class User << (S,Aquamarine) >> {
+        + ID int64 «json:"id" db:"user_id"»
+        + Name string «json:"name,omitempty"»
+        + Email string «db:"email" validate:"required,email"»
+        + Password string «json:"-"»
+        + Comment string «json:"comment" default:"~"»
+        + Notes string
+        + {field} Callback <font color=blue>func</font>() «json:"-"»
+    }
+}
+@enduml
+`,
+		},
+		{
+			Name: "Tag key",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderTags:   true,
+				RenderTagKey: "json",
+			},
+			Expected: `@startuml
+namespace tags {
+    class User << (S,Aquamarine) >> {
+        + ID int64 «id»
+        + Name string «name,omitempty»
+        + Email string
+        + Password string «-»
+        + Comment string «comment»
+        + Notes string
+        + {field} Callback <font color=blue>func</font>() «-»
+    }
+}
+@enduml
+`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{"../testingsupport/tags"},
+				RenderingOptions: tc.RenderingOptions,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			if result := parser.Render(); result != tc.Expected {
+				t.Errorf("Expected \n%s\n got \n%s\n", tc.Expected, result)
+			}
+		})
+	}
+}
+
+func TestRenderEmbeddedFields(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/methodsets"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderEmbeddedFields:  true,
+			RenderImplementations: false,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expected := `@startuml
+namespace methodsets {
+    class Buffer << (S,Aquamarine) >> {
+        + «embed» File
+    }
+    class File << (S,Aquamarine) >> {
+        + Read() string
+        + Write(s string) 
+    }
+    interface Reader  {
+        + {abstract} Read() string
+    }
+    class Stream << (S,Aquamarine) >> {
+        + «embed» *File
+    }
+    interface Writer  {
+        + {abstract} Write(s string) 
+    }
+}
+"methodsets.File" *-- "methodsets.Buffer"
+"methodsets.File" *-- "methodsets.Stream"
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("Expected \n%s\n got \n%s\n", expected, result)
+	}
+	if dot := parser.RenderDOT(); !strings.Contains(dot, `+ «embed» *File\l`) {
+		t.Errorf("Expected the embedded field in the DOT record of Stream, got\n%s", dot)
+	}
+}
+
+func TestRenderPointerAssociations(t *testing.T) {
+	tt := []struct {
+		Name             string
+		RenderingOptions map[RenderingOption]interface{}
+		Expected         string
+	}{
+		{
+			Name: "Aggregations",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderAggregations:      true,
+				AggregatePrivateMembers: true,
+			},
+			Expected: `"associations.Node" o-- "*" "associations.Node"
+"associations.Node" o-- "0..1" "associations.Tree"
+"associations.Tree" o-- "1" "associations.Counter"
+"associations.Tree" o-- "*" "associations.Node"
+`,
+		},
+		{
+			Name: "Pointer associations",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderAggregations:        true,
+				AggregatePrivateMembers:   true,
+				RenderPointerAssociations: true,
+			},
+			Expected: `"associations.Node" o-- "*" "associations.Node"
+"associations.Node" --> "0..1" "associations.Node" : Parent
+"associations.Node" --> "0..1" "associations.Node" : Next
+"associations.Node" --> "0..1" "associations.Tree" : tree
+"associations.Tree" o-- "1" "associations.Counter"
+"associations.Tree" o-- "*" "associations.Node"
+"associations.Tree" --> "0..1" "associations.Node" : Root
+`,
+		},
+		{
+			Name: "Public pointer associations",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderAggregations:        true,
+				RenderPointerAssociations: true,
+			},
+			Expected: `"associations.Node" o-- "*" "associations.Node"
+"associations.Node" --> "0..1" "associations.Node" : Parent
+"associations.Node" --> "0..1" "associations.Node" : Next
+"associations.Tree" o-- "1" "associations.Counter"
+"associations.Tree" o-- "*" "associations.Node"
+"associations.Tree" --> "0..1" "associations.Node" : Root
+`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{"../testingsupport/associations"},
+				RenderingOptions: tc.RenderingOptions,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			result := parser.Render()
+			connections := result[strings.Index(result, "\n}\n")+3 : strings.LastIndex(result, "@enduml")]
+			if connections != tc.Expected {
+				t.Errorf("Expected the connections\n%s\ngot\n%s", tc.Expected, connections)
+			}
+		})
+	}
+}
+
+func TestEscapeCreole(t *testing.T) {
+	tt := []struct {
+		Input    string
+		Expected string
+	}{
+		{Input: `json:"name"`, Expected: `json:"name"`},
+		{Input: `default:""`, Expected: `default:"~"`},
+		{Input: `url:"http://host"`, Expected: `url:"http:/~/host"`},
+		{Input: `sql:"a__b***"`, Expected: `sql:"a_~_b*~*~*"`},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Input, func(t *testing.T) {
+			if result := escapeCreole(tc.Input); result != tc.Expected {
+				t.Errorf("Expected %s, got %s", tc.Expected, result)
+			}
+		})
+	}
+}
+
+func TestRenderAbstractMethods(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/queries"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	for _, e := range []string{
+		"    interface Reader  {\n        + {abstract} Read(key string) (string, error)\n    }\n",
+		"    class Env << (S,Aquamarine) >> {\n        + Read(key string) (string, error)\n",
+	} {
+		if !strings.Contains(result, e) {
+			t.Errorf("Expected the diagram to contain\n%s\ngot\n%s", e, result)
+		}
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderAbstractMethods: false})
+	if result := parser.Render(); strings.Contains(result, "{abstract}") {
+		t.Errorf("Expected no abstract methods without the option, got\n%s", result)
+	}
+}
+
+func TestAddDirectoryConcurrently(t *testing.T) {
+	options := &ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		RenderingOptions: map[RenderingOption]interface{}{RenderAggregations: true},
+		Recursive:        true,
+		ImportPaths:      true,
+	}
+	parser, err := NewClassParser(options)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	directories := []string{"../testingsupport/importpaths/app", "../testingsupport/importpaths/a", "../testingsupport/importpaths/b"}
+	errs := make(chan error, len(directories))
+	for _, directory := range directories {
+		go func(directory string) {
+			errs <- parser.AddDirectory(directory)
+		}(directory)
+	}
+	for range directories {
+		if err := <-errs; err != nil {
+			t.Fatalf("Expected no error but got %s", err.Error())
+		}
+	}
+	options.Directories = directories
+	expected, err := NewClassDiagramWithOptions(options)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if result := parser.Render(); result != expected.Render() {
+		t.Errorf("Expected the concurrent parsing to match the sequential one\n%s\ngot\n%s", expected.Render(), result)
+	}
+}
+
+func TestRenderMemberOrder(t *testing.T) {
+	tt := []struct {
+		Name     string
+		Order    MemberOrder
+		Expected string
+	}{
+		{
+			Name:  "Visibility",
+			Order: VisibilityMemberOrder,
+			Expected: `@startuml
+namespace memberorder {
+    class Account << (S,Aquamarine) >> {
+        - balance int
+        - history []int
+        + ID string
+        + Owner string
+        - record(amount int) 
+        - check() bool
+        + {static} NewAccount(owner string) *Account
+        + Deposit(amount int) 
+        + Balance() int
+    }
+}
+@enduml
+`,
+		},
+		{
+			Name:  "Source",
+			Order: SourceMemberOrder,
+			Expected: `@startuml
+namespace memberorder {
+    class Account << (S,Aquamarine) >> {
+        + ID string
+        - balance int
+        + Owner string
+        - history []int
+        + {static} NewAccount(owner string) *Account
+        + Deposit(amount int) 
+        - record(amount int) 
+        - check() bool
+        + Balance() int
+    }
+}
+@enduml
+`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{"../testingsupport/memberorder"},
+				RenderingOptions: map[RenderingOption]interface{}{RenderPrivateMembers: true, RenderMemberOrder: tc.Order},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			if result := parser.Render(); result != tc.Expected {
+				t.Errorf("Expected\n%s\ngot\n%s", tc.Expected, result)
+			}
+		})
+	}
+}
+
+func TestRenderCreations(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/creations"},
+		RenderingOptions: map[RenderingOption]interface{}{RenderFunctions: true, RenderDependencies: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	expected := `"creations.Server" ..> "creations.Store" : creates
+"creations.creations" ..> "creations.Cache" : creates
+"creations.creations" ..> "creations.Store" : creates
+@enduml
+`
+	if !strings.HasSuffix(result, expected) {
+		t.Errorf("Expected the diagram to end with\n%s\ngot\n%s", expected, result)
+	}
+	if strings.Contains(result, "depends on") || strings.Contains(result, `"creations.Server" ..> "creations.Store"`+"\n") {
+		t.Errorf("Expected no dependencies to the created interfaces, got\n%s", result)
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderFunctions: false})
+	result = parser.Render()
+	if strings.Contains(result, `"creations.creations"`) || !strings.Contains(result, `"creations.Server" ..> "creations.Store" : creates`) {
+		t.Errorf("Expected only the creations of the rendered classes, got\n%s", result)
+	}
+}
+
+func TestIncludeTests(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/testfiles"},
+		RenderingOptions: map[RenderingOption]interface{}{RenderPrivateMembers: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if parser.getStruct("testfiles.fakeLedger") != nil || parser.getStruct("testfiles_test.failingLedger") != nil {
+		t.Errorf("Expected the test files to be skipped by default")
+	}
+	parser, err = NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/testfiles"},
+		RenderingOptions: map[RenderingOption]interface{}{RenderPrivateMembers: true},
+		IncludeTests:     true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	for _, expected := range []string{
+		"    class Transfer << (S,Aquamarine) >> {\n",
+		"    class fakeLedger << (S,Aquamarine) >> << test >> {\n",
+		"namespace testfiles_test {\n    class failingLedger << (S,Aquamarine) >> << test >> {\n",
+		`"testfiles.Ledger" <|.. "testfiles.fakeLedger"`,
+		`"testfiles.Ledger" *-- "testfiles_test.failingLedger"`,
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected the diagram to contain\n%s\ngot\n%s", expected, result)
+		}
+	}
+}
+
+func TestSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	for path, src := range map[string]string{
+		"app/app.go":            "package app\n\ntype App struct{}\n",
+		"shared/models/user.go": "package models\n\ntype User struct{}\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// app/models leads to a directory outside of app and app/loop back to app itself
+	if err := os.Symlink(filepath.Join(dir, "shared", "models"), filepath.Join(dir, "app", "models")); err != nil {
+		t.Skipf("Symbolic links are not supported: %s", err.Error())
+	}
+	if err := os.Symlink(filepath.Join(dir, "app"), filepath.Join(dir, "app", "loop")); err != nil {
+		t.Fatal(err)
+	}
+	tt := []struct {
+		Name           string
+		FollowSymlinks bool
+		Directories    []string
+		ExpectedUser   bool
+	}{
+		{
+			Name:        "Links skipped by default",
+			Directories: []string{filepath.Join(dir, "app")},
+		},
+		{
+			Name:           "Links followed",
+			FollowSymlinks: true,
+			Directories:    []string{filepath.Join(dir, "app")},
+			ExpectedUser:   true,
+		},
+		{
+			Name:           "Same directory through two paths",
+			FollowSymlinks: true,
+			Directories:    []string{filepath.Join(dir, "app"), filepath.Join(dir, "shared")},
+			ExpectedUser:   true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      tc.Directories,
+				Recursive:        true,
+				FollowSymlinks:   tc.FollowSymlinks,
+				RenderingOptions: map[RenderingOption]interface{}{},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			result := parser.Render()
+			if count := strings.Count(result, "class App "); count != 1 {
+				t.Errorf("Expected app.App to be rendered once, got %d times\n%s", count, result)
+			}
+			if count := strings.Count(result, "class User "); (count == 1) != tc.ExpectedUser || count > 1 {
+				t.Errorf("Expected models.User to be rendered: %t, got %d times\n%s", tc.ExpectedUser, count, result)
+			}
+		})
+	}
+}
+
+func TestRenderFieldLabels(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/fieldlabels"},
+		RenderingOptions: map[RenderingOption]interface{}{RenderAggregations: true, RenderFieldLabels: JoinedFieldLabels},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	expected := `"fieldlabels.Embedded" *-- "fieldlabels.Customer"
+"fieldlabels.Customer" o-- "*" "fieldlabels.Address" : Billing, Shipping, Previous
+@enduml
+`
+	if !strings.HasSuffix(result, expected) {
+		t.Errorf("Expected the diagram to end with\n%s\ngot\n%s", expected, result)
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderFieldLabels: SplitFieldLabels})
+	result = parser.Render()
+	expected = `"fieldlabels.Embedded" *-- "fieldlabels.Customer"
+"fieldlabels.Customer" o-- "1" "fieldlabels.Address" : Billing
+"fieldlabels.Customer" o-- "0..1" "fieldlabels.Address" : Shipping
+"fieldlabels.Customer" o-- "*" "fieldlabels.Address" : Previous
+@enduml
+`
+	if !strings.HasSuffix(result, expected) {
+		t.Errorf("Expected the diagram to end with\n%s\ngot\n%s", expected, result)
+	}
+	result = parser.RenderDOT()
+	if !strings.Contains(result, `"fieldlabels.Customer" -> "fieldlabels.Address" [dir=back, arrowtail=odiamond, headlabel="Shipping"];`) {
+		t.Errorf("Expected an aggregation edge labeled with the Shipping field, got\n%s", result)
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderFieldLabels: NoFieldLabels})
+	if result = parser.Render(); !strings.Contains(result, `"fieldlabels.Customer" o-- "*" "fieldlabels.Address"`+"\n") {
+		t.Errorf("Expected no field labels, got\n%s", result)
+	}
+}
+
+func TestRenderPointerReceivers(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/methodsets"},
+		RenderingOptions: map[RenderingOption]interface{}{RenderPointerReceivers: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	expected := `    class File << (S,Aquamarine) >> {
+        + Read() string
+        + Write(s string) «ptr»
+    }
+`
+	if !strings.Contains(result, expected) {
+		t.Errorf("Expected the diagram to contain\n%s\ngot\n%s", expected, result)
+	}
+	if strings.Count(result, "«ptr»") != 1 {
+		t.Errorf("Expected only the method of File declared on a pointer receiver to be marked, got\n%s", result)
+	}
+	if result = parser.RenderDOT(); !strings.Contains(result, `+ Write(s string) «ptr»\l`) {
+		t.Errorf("Expected the method to be marked in the DOT output, got\n%s", result)
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderPointerReceivers: false})
+	if result = parser.Render(); strings.Contains(result, "«ptr»") {
+		t.Errorf("Expected no marker, got\n%s", result)
+	}
+}
+
+func TestRenderFlat(t *testing.T) {
+	tt := []struct {
+		Name        string
+		Directories []string
+		Options     map[RenderingOption]interface{}
+		Golden      string
+	}{
+		{
+			Name:        "Aliases and renamed types",
+			Directories: []string{"../testingsupport"},
+			Options: map[RenderingOption]interface{}{
+				RenderTitle:          "Test Title",
+				RenderNotes:          "Notes Example 1\nNotes Example 1 continues\nNotes Example 2",
+				RenderPrivateMembers: true,
+			},
+			Golden: "../testingsupport/testingsupport-flat.puml",
+		},
+		{
+			Name:        "Members and connections",
+			Directories: []string{"../testingsupport/queries", "../testingsupport/enums", "../testingsupport/functions"},
+			Options: map[RenderingOption]interface{}{
+				RenderPrivateMembers:       true,
+				RenderAggregations:         true,
+				RenderDependencies:         true,
+				RenderFunctions:            true,
+				RenderGroupImplementations: true,
+			},
+			Golden: "../testingsupport/queries-enums-functions-flat.puml",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			tc.Options[RenderFlat] = true
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      tc.Directories,
+				RenderingOptions: tc.Options,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			resultRender := parser.Render()
+			result, err := ioutil.ReadFile(tc.Golden)
+			if err != nil {
+				t.Fatalf("Expected no errors reading testing file, got %s", err.Error())
+			}
+			if string(result) != resultRender {
+				t.Errorf("Expected renders to be the same as %s , but got %s", result, resultRender)
+			}
+			if strings.Contains(resultRender, "namespace ") {
+				t.Errorf("Expected no namespaces, got %s", resultRender)
+			}
+		})
+	}
+}
+
+func TestDotImports(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/dotimports"}, []string{}, true)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderFields:       false,
+		RenderMethods:      false,
+		RenderAggregations: true,
+	})
+	result := parser.Render()
+	expected := `@startuml
+namespace app {
+    class Customer << (S,Aquamarine) >> {
+    }
+    class Phone << (S,Aquamarine) >> {
+    }
+}
+"model.Entity" *-- "app.Customer"
+"model.Locator" <|.. "app.Customer"
+"app.Customer" o-- "1" "app.Phone"
+"app.Customer" o-- "1" "model.Address"
+namespace model {
+    class Address << (S,Aquamarine) >> {
+    }
+    class Entity << (S,Aquamarine) >> {
+    }
+    interface Locator  {
+    }
+}
+hide fields
+hide methods
+@enduml
+`
+	if result != expected {
+		t.Errorf("Expected the dot imported types to belong to their package\n%s\ngot\n%s", expected, result)
+	}
+	if warnings := parser.Warnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
+
+	parser, err = NewClassDiagram([]string{"../testingsupport/dotimports/app"}, []string{}, false)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderAggregations: true,
+	})
+	result = parser.Render()
+	if strings.Contains(result, "app.Entity") || strings.Contains(result, "app.Address") {
+		t.Errorf("Expected no relationships to the types of the dot imported package that was not parsed, got\n%s", result)
+	}
+	if !strings.Contains(result, `"app.Customer" o-- "1" "app.Phone"`) {
+		t.Errorf("Expected the relationships to the types of the package, got\n%s", result)
+	}
+	expectedWarnings := []string{
+		"app.Customer to app.Address is not rendered, Address may be declared by the dot imported model",
+		"app.Customer to app.Entity is not rendered, Entity may be declared by the dot imported model",
+	}
+	warnings := parser.Warnings()
+	if len(warnings) != len(expectedWarnings) {
+		t.Fatalf("Expected the warnings %v, got %v", expectedWarnings, warnings)
+	}
+	for i, warning := range warnings {
+		if !strings.Contains(warning.Error(), expectedWarnings[i]) {
+			t.Errorf("Expected a warning containing %q, got %q", expectedWarnings[i], warning.Error())
+		}
+	}
+}
+
+func TestDotImportsAddDirectory(t *testing.T) {
+	parser, err := NewClassParser(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderFields:       false,
+		RenderMethods:      false,
+		RenderAggregations: true,
+	})
+	if err := parser.AddDirectory("../testingsupport/dotimports/app"); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	parser.Finalize()
+	if warnings := parser.Warnings(); len(warnings) != 2 {
+		t.Errorf("Expected the warnings of the dot imported package that was not parsed, got %v", warnings)
+	}
+	if err := parser.AddDirectory("../testingsupport/dotimports/model"); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	oneShot, err := NewClassDiagram([]string{"../testingsupport/dotimports"}, []string{}, true)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	oneShot.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderFields:       false,
+		RenderMethods:      false,
+		RenderAggregations: true,
+	})
+	expected := oneShot.Render()
+	result := &strings.Builder{}
+	if err := parser.RenderTo(result); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if result.String() != expected {
+		t.Errorf("Expected the relationships dropped before the dot imported package was added to be rendered\n%s\ngot\n%s", expected, result.String())
+	}
+	if warnings := parser.Warnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings once the dot imported package is added, got %v", warnings)
+	}
+}
+
+func TestRenderMapAggregations(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/maps"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderAggregations:  true,
+			RenderFieldLabels:   SplitFieldLabels,
+			RenderExternalTypes: DropExternalTypes,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	for _, field := range []string{
+		"+ ByKey <font color=blue>map</font>[Key]*Value",
+		"+ ByPointer <font color=blue>map</font>[*Key][]Value",
+		"+ Nested <font color=blue>map</font>[string]<font color=blue>map</font>[Key][]*Value",
+		"+ Pairs <font color=blue>map</font>[Pair[Key, string]]Value",
+		"+ Timeouts <font color=blue>map</font>[time.Duration]*Value",
+	} {
+		if !strings.Contains(result, field) {
+			t.Errorf("Expected the field %s, got\n%s", field, result)
+		}
+	}
+	expected := `"maps.Index" o-- "*" "maps.Key" : ByKey
+"maps.Index" o-- "*" "maps.Key" : ByPointer
+"maps.Index" o-- "*" "maps.Key" : Nested
+"maps.Index" o-- "*" "maps.Key" : Pairs
+"maps.Index" o-- "*" "maps.Key" : Counts
+"maps.Index" o-- "*" "maps.Pair" : Pairs
+"maps.Index" o-- "*" "maps.Value" : ByKey
+"maps.Index" o-- "*" "maps.Value" : ByPointer
+"maps.Index" o-- "*" "maps.Value" : Nested
+"maps.Index" o-- "*" "maps.Value" : Pairs
+"maps.Index" o-- "*" "maps.Value" : Timeouts
+@enduml
+`
+	if !strings.HasSuffix(result, expected) {
+		t.Errorf("Expected an aggregation to the key and value types of every map\n%s\ngot\n%s", expected, result)
+	}
 }
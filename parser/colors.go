@@ -0,0 +1,32 @@
+package parser
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// namespaceColorPalette are the light colors given to the namespaces with the AutoColors rendering option so the
+// classes and relationships on top of them are still readable
+var namespaceColorPalette = []string{
+	"#DDEEFF", "#FFEEDD", "#DDFFDD", "#FFDDEE", "#EEDDFF", "#FFFFDD", "#DDFFFF", "#EEEEEE",
+	"#FFE4C4", "#E0FFE0", "#E6E6FA", "#FFF0F5",
+}
+
+// getNamespaceColor returns the color of the namespace of the given package, with a leading space so it can be
+// appended to the namespace declaration. Colors given in PackageColors, by package name or by the name rendered for
+// the namespace, take precedence over the ones picked from the palette with AutoColors.
+func (p *ClassParser) getNamespaceColor(pack string) string {
+	color, ok := p.renderingOptions.PackageColors[pack]
+	if !ok {
+		color, ok = p.renderingOptions.PackageColors[p.getDisplayedPackageName(pack)]
+	}
+	if !ok && p.renderingOptions.AutoColors {
+		hash := fnv.New32a()
+		hash.Write([]byte(pack))
+		color = namespaceColorPalette[hash.Sum32()%uint32(len(namespaceColorPalette))]
+	}
+	if color == "" {
+		return ""
+	}
+	return " #" + strings.TrimPrefix(color, "#")
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"strings"
+)
+
+// findConstructors attaches the parsed package level functions to the types they construct. A function is a
+// constructor of a type declared in its package when it is named New followed by the type name and returns the
+// type first, or when the type is its only return value. With IncludeFactories any function returning the type
+// is attached. Interfaces never get constructors.
+func (p *ClassParser) findConstructors() {
+	for _, pack := range p.structure {
+		for _, st := range pack {
+			st.Constructors = nil
+		}
+	}
+	if p.options.IgnoreConstructors {
+		return
+	}
+	for _, function := range p.allFunctions {
+		if st := p.getConstructedType(function); st != nil {
+			st.Constructors = append(st.Constructors, function)
+		}
+	}
+}
+
+// getConstructedType returns the structure the given function constructs or nil if it is not a constructor
+func (p *ClassParser) getConstructedType(function *Function) *Struct {
+	for i, returnValue := range function.FullNameReturnValues {
+		st, name := p.getPackageType(returnValue, function.PackageName)
+		if st == nil {
+			continue
+		}
+		switch {
+		case p.options.IncludeFactories:
+			return st
+		case i == 0 && function.Name == "New"+name:
+			return st
+		case len(function.FullNameReturnValues) == 1:
+			return st
+		}
+	}
+	return nil
+}
+
+// getPackageType returns the non interface structure with the given full type name, or a pointer to it, and its
+// name when it is declared in the given package
+func (p *ClassParser) getPackageType(fullType string, packageName string) (*Struct, string) {
+	fullType = strings.TrimPrefix(fullType, "*")
+	if i := strings.Index(fullType, "["); i >= 0 {
+		fullType = fullType[:i]
+	}
+	name := strings.TrimPrefix(fullType, packageName+".")
+	if name == fullType {
+		return nil, ""
+	}
+	st, ok := p.structure[packageName][name]
+	if !ok || st.Kind() == InterfaceKind {
+		return nil, ""
+	}
+	return st, name
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// creates is the label of the connections from the constructors to the interfaces they return
+const creates = "creates"
+
+// getCreatedInterfaces returns the full names of the parsed interfaces returned by the given constructor. Package
+// level functions that are not attached to a type, since interfaces never get constructors, are only taken for
+// constructors when they are named New something and return the interface first, like func NewStore() Store.
+func (p *ClassParser) getCreatedInterfaces(function *Function, attached bool) []string {
+	if p.options.IgnoreConstructors {
+		return nil
+	}
+	returnValues := function.FullNameReturnValues
+	if !attached {
+		if !strings.HasPrefix(function.Name, "New") || len(returnValues) == 0 {
+			return nil
+		}
+		returnValues = returnValues[:1]
+	}
+	var result []string
+	for _, returnValue := range returnValues {
+		if _, ok := p.allInterfaces[returnValue]; ok && !p.isHiddenType(returnValue) {
+			result = append(result, returnValue)
+		}
+	}
+	return result
+}
+
+// getCreations returns the sorted connections from the given class, with the given full name, to the interfaces
+// created by the given functions
+func (p *ClassParser) getCreations(fullName string, functions []*Function, attached bool) []string {
+	seen := map[string]struct{}{}
+	var result []string
+	for _, function := range functions {
+		if isPrivate(function.Name) && (!p.renderingOptions.PrivateMethods || p.renderingOptions.ExportedOnly) {
+			continue
+		}
+		for _, inter := range p.getCreatedInterfaces(function, attached) {
+			if _, ok := seen[inter]; ok || inter == fullName {
+				continue
+			}
+			seen[inter] = struct{}{}
+			result = append(result, fmt.Sprintf(`"%s" ..> "%s" : %s`, p.getDisplayedName(fullName), p.getDisplayedName(inter), creates))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// renderCreations renders a dependency labeled creates from the structure to every parsed interface returned by its
+// rendered constructors, so the concrete types behind factories can be found
+func (p *ClassParser) renderCreations(structure *Struct, name string, str *LineStringBuilder) {
+	if !p.renderingOptions.Methods {
+		return
+	}
+	for _, creation := range p.getCreations(getFullTypeName(structure.PackageName, name), structure.Constructors, true) {
+		str.WriteLineWithDepth(0, creation)
+	}
+}
+
+// renderPackageCreations renders a dependency labeled creates from the class holding the given package level
+// functions to every parsed interface they create, see getCreatedInterfaces
+func (p *ClassParser) renderPackageCreations(pack string, functions []*Function, str *LineStringBuilder) {
+	fullName := getFullTypeName(pack, pack[strings.LastIndex(pack, "/")+1:])
+	for _, creation := range p.getCreations(fullName, functions, false) {
+		str.WriteLineWithDepth(0, creation)
+	}
+}
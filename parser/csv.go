@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// relationshipsHeader is the first row of the CSV and TSV outputs
+var relationshipsHeader = []string{"source_package", "source_type", "target_package", "target_type", "kind", "label"}
+
+// RenderCSVTo writes every relationship among the parsed types as a row of comma separated values, with the package
+// and the name of both types, the kind of the relationship and its label, see Relationships. The first row holds the
+// names of the columns and the values with commas or quotes are quoted.
+func (p *ClassParser) RenderCSVTo(w io.Writer) error {
+	return p.writeRelationships(w, ',')
+}
+
+// RenderTSVTo writes the same rows as RenderCSVTo separated by tabs
+func (p *ClassParser) RenderTSVTo(w io.Writer) error {
+	return p.writeRelationships(w, '\t')
+}
+
+// writeRelationships writes the header and a row for every relationship with the given separator. The rows are in
+// the order of Relationships so the output is always the same for the same code.
+func (p *ClassParser) writeRelationships(w io.Writer, separator rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = separator
+	if err := writer.Write(relationshipsHeader); err != nil {
+		return err
+	}
+	for _, relationship := range p.Relationships() {
+		fromPackage, fromName := splitFullTypeName(relationship.From)
+		toPackage, toName := splitFullTypeName(relationship.To)
+		row := []string{fromPackage, fromName, toPackage, toName, string(relationship.Kind), relationship.Label}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
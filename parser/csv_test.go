@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRenderCSV(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/dependencies", "../testingsupport/associations"},
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expected := `source_package,source_type,target_package,target_type,kind,label
+associations,Node,associations,Node,aggregates,"Parent,Next,Children"
+associations,Node,associations,Tree,aggregates,tree
+associations,Tree,associations,Counter,aggregates,Size
+associations,Tree,associations,Node,aggregates,"Root,Nodes"
+shop,Order,shop,Item,aggregates,Items
+shop,Repository,shop,Order,depends,
+shop,Service,shop,Customer,depends,
+shop,Service,shop,Invoice,depends,
+shop,Service,shop,Item,depends,
+shop,Service,shop,Order,depends,
+shop,Service,shop,Repository,aggregates,repo
+`
+	result := &bytes.Buffer{}
+	if err := parser.RenderCSVTo(result); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if result.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, result.String())
+	}
+	result.Reset()
+	if err := parser.RenderTSVTo(result); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	lines := strings.Split(result.String(), "\n")
+	if len(lines) != 13 || lines[1] != "associations\tNode\tassociations\tNode\taggregates\tParent,Next,Children" {
+		t.Errorf("Expected the same rows separated by tabs, got\n%s", result.String())
+	}
+}
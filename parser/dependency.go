@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// dependsOn is the label of the dependency connections
+const dependsOn = `"depends on"`
+
+// renderDependencies renders a dependency to every parsed type used in the parameters and return values of the
+// rendered methods and constructors of the structure. Types the structure is already connected to by a rendered
+// composition, extension, implementation or aggregation are skipped. Nothing is rendered unless the Dependencies
+// rendering option is set.
+func (p *ClassParser) renderDependencies(structure *Struct, name string, str *LineStringBuilder) {
+	if !p.renderingOptions.Dependencies {
+		return
+	}
+	fullName := getFullTypeName(structure.PackageName, name)
+	dependencyString := ""
+	if p.renderingOptions.ConnectionLabels {
+		dependencyString = dependsOn
+	}
+	orderedDependencies := []string{}
+	for _, t := range p.getDependencies(structure, name) {
+		orderedDependencies = append(orderedDependencies, fmt.Sprintf(`"%s" ..> %s"%s"`, p.getDisplayedName(fullName), dependencyString, p.getDisplayedName(t)))
+	}
+	sort.Strings(orderedDependencies)
+	for _, d := range orderedDependencies {
+		str.WriteLineWithDepth(0, d)
+	}
+}
+
+// getDependencies returns the full names of the types the structure gets a dependency to, see renderDependencies
+func (p *ClassParser) getDependencies(structure *Struct, name string) []string {
+	fullName := getFullTypeName(structure.PackageName, name)
+	connected := p.getRenderedConnections(structure)
+	seen := map[string]struct{}{}
+	result := []string{}
+	functions := append(append([]*Function{}, structure.Functions...), structure.Constructors...)
+	for _, function := range functions {
+		if isPrivate(function.Name) && (!p.renderingOptions.PrivateMethods || p.renderingOptions.ExportedOnly) {
+			continue
+		}
+		for _, t := range function.ReferencedTypes {
+			if _, ok := seen[t]; ok {
+				continue
+			}
+			seen[t] = struct{}{}
+			if _, ok := connected[t]; ok || t == fullName || !p.isDependency(structure, t) {
+				continue
+			}
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// getMethodDependencies returns the full names of the parsed types used in the parameters and return values of all
+// the methods and constructors of the structure whatever the rendering options are, except for the structure itself
+// and its type parameters
+func (p *ClassParser) getMethodDependencies(structure *Struct, name string) []string {
+	fullName := getFullTypeName(structure.PackageName, name)
+	seen := map[string]struct{}{fullName: {}}
+	result := []string{}
+	functions := append(append([]*Function{}, structure.Functions...), structure.Constructors...)
+	for _, function := range functions {
+		for _, t := range function.ReferencedTypes {
+			if _, ok := seen[t]; ok {
+				continue
+			}
+			seen[t] = struct{}{}
+			packageName, typeName := splitFullTypeName(t)
+			if packageName == structure.PackageName && structure.isTypeParameter(typeName) || p.getStruct(t) == nil {
+				continue
+			}
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// isDependency returns true if the given type, used in a method of the structure, is a rendered type of the parsed
+// packages and not one of the type parameters of the structure
+func (p *ClassParser) isDependency(structure *Struct, t string) bool {
+	packageName, typeName := splitFullTypeName(t)
+	if packageName == structure.PackageName && structure.isTypeParameter(typeName) {
+		return false
+	}
+	return p.getStruct(t) != nil && !p.isHiddenType(t)
+}
+
+// getRenderedConnections returns the full names of the types the structure is connected to by the rendered
+// compositions, extensions, implementations and aggregations, and the interfaces created by its constructors
+func (p *ClassParser) getRenderedConnections(structure *Struct) map[string]struct{} {
+	result := map[string]struct{}{}
+	add := func(types map[string]struct{}) {
+		for t := range types {
+			result[getEmbeddedTypeName(t, structure)] = struct{}{}
+		}
+	}
+	if p.renderingOptions.Compositions {
+		add(structure.Composition)
+	}
+	if p.renderingOptions.Implementations {
+		add(structure.Extends)
+		add(structure.Implements)
+	}
+	if p.renderingOptions.Aggregations {
+		add(structure.Aggregations)
+		if p.renderingOptions.AggregatePrivateMembers && !p.renderingOptions.ExportedOnly {
+			add(structure.PrivateAggregations)
+		}
+	}
+	if p.renderingOptions.Methods {
+		for _, constructor := range structure.Constructors {
+			for _, inter := range p.getCreatedInterfaces(constructor, true) {
+				result[inter] = struct{}{}
+			}
+		}
+	}
+	return result
+}
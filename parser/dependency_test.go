@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRenderDependencies(t *testing.T) {
+	tt := []struct {
+		Name             string
+		RenderingOptions map[RenderingOption]interface{}
+		Expected         []string
+	}{
+		{
+			Name:             "Not rendered by default",
+			RenderingOptions: map[RenderingOption]interface{}{},
+		},
+		{
+			Name: "Dependencies",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderDependencies:   true,
+				RenderPrivateMembers: true,
+			},
+			Expected: []string{
+				`"shop.Order" ..> "shop.Item"`,
+				`"shop.Repository" ..> "shop.Order"`,
+				`"shop.Service" ..> "shop.Customer"`,
+				`"shop.Service" ..> "shop.Invoice"`,
+				`"shop.Service" ..> "shop.Item"`,
+				`"shop.Service" ..> "shop.Order"`,
+				`"shop.Service" ..> "shop.Repository"`,
+			},
+		},
+		{
+			Name: "Aggregated types and private methods are skipped",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderDependencies:      true,
+				RenderAggregations:      true,
+				AggregatePrivateMembers: true,
+				RenderPrivateMethods:    false,
+				RenderConnectionLabels:  true,
+			},
+			Expected: []string{
+				`"shop.Repository" ..> "depends on""shop.Order"`,
+				`"shop.Service" ..> "depends on""shop.Customer"`,
+				`"shop.Service" ..> "depends on""shop.Invoice"`,
+				`"shop.Service" ..> "depends on""shop.Order"`,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{"../testingsupport/dependencies"},
+				RenderingOptions: tc.RenderingOptions,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			var result []string
+			for _, line := range strings.Split(parser.Render(), "\n") {
+				if strings.Contains(line, "..>") {
+					result = append(result, line)
+				}
+			}
+			if !reflect.DeepEqual(result, tc.Expected) {
+				t.Errorf("Expected the dependencies \n%s\n got \n%s\n", strings.Join(tc.Expected, "\n"), strings.Join(result, "\n"))
+			}
+		})
+	}
+}
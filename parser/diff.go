@@ -0,0 +1,379 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DiffStatus tells how a type, a member or a relationship changed between two parsed trees
+type DiffStatus string
+
+const (
+	// DiffUnchanged is something found in both trees
+	DiffUnchanged DiffStatus = "unchanged"
+	// DiffAdded is something only found in the new tree
+	DiffAdded DiffStatus = "added"
+	// DiffRemoved is something only found in the old tree
+	DiffRemoved DiffStatus = "removed"
+	// DiffChanged is a type found in both trees with different kinds or members
+	DiffChanged DiffStatus = "changed"
+)
+
+// MemberDiff is a field, method, constructor or enum value of a type. Signatures use fully qualified types and
+// leave out the names of parameters and return values so renaming them is not a change.
+type MemberDiff struct {
+	Kind      string
+	Signature string
+	Status    DiffStatus
+}
+
+// TypeDiff is a type of one or both trees. Members holds the members of the new tree in declaration order followed
+// by the removed ones. OldKind is only set when the kind of a changed type is different.
+type TypeDiff struct {
+	Package string
+	Name    string
+	Kind    string
+	OldKind string
+	Status  DiffStatus
+	Members []*MemberDiff
+}
+
+// RelationshipDiff is a relationship of one or both trees
+type RelationshipDiff struct {
+	Relationship
+	Status DiffStatus
+}
+
+// Diff is the structural difference between two parsed trees. It has all the types and relationships of both trees,
+// sorted by name, so it can be rendered as a whole.
+type Diff struct {
+	Types         []*TypeDiff
+	Relationships []*RelationshipDiff
+}
+
+// CompareClassDiagrams returns the structural difference between the old and the new parsed trees. Types are
+// matched by package and name, and members by kind and signature, so the order and formatting of the code does not
+// matter.
+func CompareClassDiagrams(oldParser *ClassParser, newParser *ClassParser) *Diff {
+	diff := &Diff{}
+	oldTypes := oldParser.getModelTypes()
+	newTypes := newParser.getModelTypes()
+	for _, fullName := range getSortedKeys(oldTypes, newTypes) {
+		oldType, inOld := oldTypes[fullName]
+		newType, inNew := newTypes[fullName]
+		pack, _ := splitFullTypeName(fullName)
+		switch {
+		case !inOld:
+			diff.Types = append(diff.Types, getTypeDiff(pack, newType, DiffAdded))
+		case !inNew:
+			diff.Types = append(diff.Types, getTypeDiff(pack, oldType, DiffRemoved))
+		default:
+			diff.Types = append(diff.Types, compareModelTypes(pack, oldType, newType))
+		}
+	}
+	oldRelationships := map[Relationship]struct{}{}
+	for _, r := range oldParser.getComparedRelationships() {
+		oldRelationships[r] = struct{}{}
+	}
+	newRelationships := map[Relationship]struct{}{}
+	for _, r := range newParser.getComparedRelationships() {
+		newRelationships[r] = struct{}{}
+		status := DiffAdded
+		if _, ok := oldRelationships[r]; ok {
+			status = DiffUnchanged
+		}
+		diff.Relationships = append(diff.Relationships, &RelationshipDiff{Relationship: r, Status: status})
+	}
+	for _, r := range oldParser.getComparedRelationships() {
+		if _, ok := newRelationships[r]; !ok {
+			diff.Relationships = append(diff.Relationships, &RelationshipDiff{Relationship: r, Status: DiffRemoved})
+		}
+	}
+	sort.SliceStable(diff.Relationships, func(i, j int) bool {
+		a, b := diff.Relationships[i], diff.Relationships[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+	return diff
+}
+
+// getComparedRelationships returns the relationships compared by CompareClassDiagrams. Dependencies and the fields of
+// aggregations are left out since they follow the members, which are compared already.
+func (p *ClassParser) getComparedRelationships() []Relationship {
+	var result []Relationship
+	for _, r := range p.Relationships() {
+		if r.Kind != RelationshipDepends {
+			r.Label = ""
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// HasChanges returns true if anything was added, removed or changed
+func (d *Diff) HasChanges() bool {
+	for _, t := range d.Types {
+		if t.Status != DiffUnchanged {
+			return true
+		}
+	}
+	for _, r := range d.Relationships {
+		if r.Status != DiffUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// getModelTypes returns the model of every parsed type by its fully qualified name
+func (p *ClassParser) getModelTypes() map[string]*ModelType {
+	p.Finalize()
+	result := map[string]*ModelType{}
+	for pack := range p.structure {
+		for name, structure := range p.getStructures(pack) {
+			result[getFullTypeName(pack, name)] = p.getModelType(structure, pack, name)
+		}
+	}
+	return result
+}
+
+// getSortedKeys returns the sorted union of the keys of the given maps
+func getSortedKeys(oldTypes map[string]*ModelType, newTypes map[string]*ModelType) []string {
+	keys := make([]string, 0, len(newTypes))
+	for key := range newTypes {
+		keys = append(keys, key)
+	}
+	for key := range oldTypes {
+		if _, ok := newTypes[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getTypeDiff returns a type found in one tree only, with all its members in the same status
+func getTypeDiff(pack string, modelType *ModelType, status DiffStatus) *TypeDiff {
+	result := &TypeDiff{Package: pack, Name: modelType.Name, Kind: modelType.Kind, Status: status}
+	for _, member := range getMembers(modelType) {
+		member.Status = status
+		result.Members = append(result.Members, member)
+	}
+	return result
+}
+
+// compareModelTypes returns the difference between two versions of the same type
+func compareModelTypes(pack string, oldType *ModelType, newType *ModelType) *TypeDiff {
+	result := &TypeDiff{Package: pack, Name: newType.Name, Kind: newType.Kind, Status: DiffUnchanged}
+	if oldType.Kind != newType.Kind {
+		result.OldKind = oldType.Kind
+		result.Status = DiffChanged
+	}
+	oldMembers := map[MemberDiff]struct{}{}
+	for _, member := range getMembers(oldType) {
+		oldMembers[*member] = struct{}{}
+	}
+	newMembers := map[MemberDiff]struct{}{}
+	for _, member := range getMembers(newType) {
+		newMembers[*member] = struct{}{}
+		if _, ok := oldMembers[*member]; !ok {
+			member.Status = DiffAdded
+			result.Status = DiffChanged
+		}
+		result.Members = append(result.Members, member)
+	}
+	for _, member := range getMembers(oldType) {
+		if _, ok := newMembers[*member]; !ok {
+			member.Status = DiffRemoved
+			result.Status = DiffChanged
+			result.Members = append(result.Members, member)
+		}
+	}
+	return result
+}
+
+// getMembers returns the unchanged members of the given type. Embedded fields are left out since they are
+// compared as compositions.
+func getMembers(modelType *ModelType) []*MemberDiff {
+	result := []*MemberDiff{}
+	add := func(kind string, signature string) {
+		result = append(result, &MemberDiff{Kind: kind, Signature: signature, Status: DiffUnchanged})
+	}
+	for _, value := range modelType.Values {
+		add("value", value)
+	}
+	for _, field := range modelType.Fields {
+		if !field.Embedded {
+			add("field", fmt.Sprintf("%s %s", field.Name, field.Type))
+		}
+	}
+	for _, constructor := range modelType.Constructors {
+		add("constructor", getMethodSignature(constructor))
+	}
+	for _, method := range modelType.Methods {
+		add("method", getMethodSignature(method))
+	}
+	return result
+}
+
+// getMethodSignature returns the name of the method followed by the types of its parameters and return values
+func getMethodSignature(method *ModelMethod) string {
+	parameters := make([]string, 0, len(method.Parameters))
+	for _, parameter := range method.Parameters {
+		parameters = append(parameters, parameter.Type)
+	}
+	signature := fmt.Sprintf("%s(%s)", method.Name, strings.Join(parameters, ", "))
+	switch len(method.ReturnValues) {
+	case 0:
+		return signature
+	case 1:
+		return fmt.Sprintf("%s %s", signature, method.ReturnValues[0].Type)
+	}
+	returnValues := make([]string, 0, len(method.ReturnValues))
+	for _, returnValue := range method.ReturnValues {
+		returnValues = append(returnValues, returnValue.Type)
+	}
+	return fmt.Sprintf("%s (%s)", signature, strings.Join(returnValues, ", "))
+}
+
+// diffMarks are the marks written before added, removed and changed lines in the text summary
+var diffMarks = map[DiffStatus]string{
+	DiffAdded:   "+",
+	DiffRemoved: "-",
+	DiffChanged: "~",
+}
+
+// WriteText writes a summary of the changes into w, one line per added, removed or changed type followed by its
+// added and removed members, and then one line per added or removed relationship. Nothing is written when there
+// are no changes.
+func (d *Diff) WriteText(w io.Writer) error {
+	str := &LineStringBuilder{}
+	for _, t := range d.Types {
+		if t.Status == DiffUnchanged {
+			continue
+		}
+		kind := t.Kind
+		if t.OldKind != "" {
+			kind = fmt.Sprintf("%s (was %s)", t.Kind, t.OldKind)
+		}
+		str.WriteLineWithDepth(0, fmt.Sprintf("%s %s %s", diffMarks[t.Status], kind, getFullTypeName(t.Package, t.Name)))
+		if t.Status != DiffChanged {
+			continue
+		}
+		for _, member := range t.Members {
+			if member.Status != DiffUnchanged {
+				str.WriteLineWithDepth(1, fmt.Sprintf("%s %s %s", diffMarks[member.Status], member.Kind, member.Signature))
+			}
+		}
+	}
+	for _, r := range d.Relationships {
+		if r.Status != DiffUnchanged {
+			str.WriteLineWithDepth(0, fmt.Sprintf("%s %s %s %s", diffMarks[r.Status], r.From, r.Kind, r.To))
+		}
+	}
+	_, err := io.WriteString(w, str.String())
+	return err
+}
+
+// diffColors are the colors of the added and removed members and relationships in the diagram
+var diffColors = map[DiffStatus]string{
+	DiffAdded:   "Green",
+	DiffRemoved: "Red",
+}
+
+// relationshipArrows are the head and the line of the arrow of every kind of relationship. Aggregations point
+// from the type that holds the other one like in the class diagram.
+var relationshipArrows = map[RelationshipKind][2]string{
+	RelationshipExtends:    {"<|", "-"},
+	RelationshipImplements: {"<|", "."},
+	RelationshipComposes:   {"*", "-"},
+	RelationshipAggregates: {"o", "-"},
+}
+
+// RenderTo writes a class diagram with all the types of both trees into w. Added types and members are green and
+// removed ones red and crossed out. Changed types are yellow.
+func (d *Diff) RenderTo(w io.Writer) error {
+	str := &LineStringBuilder{}
+	str.WriteLineWithDepth(0, "@startuml")
+	str.WriteLineWithDepth(0, "skinparam class {")
+	str.WriteLineWithDepth(1, "BackgroundColor<<added>> PaleGreen")
+	str.WriteLineWithDepth(1, "BackgroundColor<<removed>> MistyRose")
+	str.WriteLineWithDepth(1, "BackgroundColor<<changed>> LightYellow")
+	str.WriteLineWithDepth(0, "}")
+	pack := ""
+	for i, t := range d.Types {
+		if i == 0 || t.Package != pack {
+			if i > 0 {
+				str.WriteLineWithDepth(0, "}")
+			}
+			pack = t.Package
+			str.WriteLineWithDepth(0, fmt.Sprintf("namespace %s {", getDiagramPackageName(pack)))
+		}
+		renderTypeDiff(t, str)
+	}
+	if len(d.Types) > 0 {
+		str.WriteLineWithDepth(0, "}")
+	}
+	for _, r := range d.Relationships {
+		arrow := relationshipArrows[r.Kind]
+		line := arrow[1] + arrow[1]
+		if color, ok := diffColors[r.Status]; ok {
+			line = fmt.Sprintf("%s[#%s]%s", arrow[1], color, arrow[1])
+		}
+		if r.Kind == RelationshipAggregates {
+			str.WriteLineWithDepth(0, fmt.Sprintf(`"%s" %s%s "%s"`, getDiagramName(r.From), arrow[0], line, getDiagramName(r.To)))
+		} else {
+			str.WriteLineWithDepth(0, fmt.Sprintf(`"%s" %s%s "%s"`, getDiagramName(r.To), arrow[0], line, getDiagramName(r.From)))
+		}
+	}
+	str.WriteLineWithDepth(0, "@enduml")
+	_, err := io.WriteString(w, str.String())
+	return err
+}
+
+// renderTypeDiff renders a type of the diff diagram with its members
+func renderTypeDiff(t *TypeDiff, str *LineStringBuilder) {
+	kind := "class"
+	if t.Kind == "interface" {
+		kind = t.Kind
+	} else if len(t.Members) > 0 && t.Members[0].Kind == "value" {
+		kind = "enum"
+	}
+	stereotype := ""
+	if t.Status != DiffUnchanged {
+		stereotype = fmt.Sprintf(" <<%s>>", t.Status)
+	}
+	if diagramName := getDiagramTypeName(t.Name); diagramName != t.Name {
+		str.WriteLineWithDepth(1, fmt.Sprintf(`%s "%s" as %s%s {`, kind, t.Name, diagramName, stereotype))
+	} else {
+		str.WriteLineWithDepth(1, fmt.Sprintf(`%s %s%s {`, kind, t.Name, stereotype))
+	}
+	for _, member := range t.Members {
+		text := escapeCreole(member.Signature)
+		if color, ok := diffColors[member.Status]; ok && t.Status == DiffChanged {
+			if member.Status == DiffRemoved {
+				text = fmt.Sprintf("--%s--", text)
+			}
+			text = fmt.Sprintf("<color:%s>%s</color>", color, text)
+		}
+		switch {
+		case member.Kind == "constructor":
+			text = "{static} " + text
+		case member.Kind == "field" && strings.Contains(member.Signature, "("):
+			// PlantUML takes any member with parenthesis for a method unless it is marked as a field
+			text = "{field} " + text
+		}
+		if member.Kind != "value" {
+			text = fmt.Sprintf("%s %s", getAccessModifier(member.Signature), text)
+		}
+		str.WriteLineWithDepth(2, text)
+	}
+	str.WriteLineWithDepth(1, "}")
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func getDiffParsers(t *testing.T) (*ClassParser, *ClassParser) {
+	parsers := []*ClassParser{}
+	for _, dir := range []string{"../testingsupport/diff/old", "../testingsupport/diff/new"} {
+		parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+			FileSystem:  afero.NewOsFs(),
+			Directories: []string{dir},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error parsing %s but got %s", dir, err.Error())
+		}
+		parsers = append(parsers, parser)
+	}
+	return parsers[0], parsers[1]
+}
+
+func TestCompareClassDiagrams(t *testing.T) {
+	oldParser, newParser := getDiffParsers(t)
+	diff := CompareClassDiagrams(oldParser, newParser)
+	if !diff.HasChanges() {
+		t.Errorf("Expected the diff to have changes")
+	}
+	result := &strings.Builder{}
+	if err := diff.WriteText(result); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expected := `+ class shop.Customer
+- class shop.Legacy
+~ class shop.Order
+    + field Customer *shop.Customer
+    + method Cancel(string) error
+    - method Cancel() error
+~ interface shop.Repository
+    + method Delete(string) error
++ shop.Order aggregates shop.Customer
+- shop.Order composes shop.Entity
+`
+	if result.String() != expected {
+		t.Errorf("Expected \n%s\n got \n%s\n", expected, result.String())
+	}
+
+	unchanged := CompareClassDiagrams(newParser, newParser)
+	if unchanged.HasChanges() {
+		t.Errorf("Expected a tree compared with itself to have no changes")
+	}
+	result.Reset()
+	unchanged.WriteText(result)
+	if result.String() != "" {
+		t.Errorf("Expected an empty summary, got \n%s\n", result.String())
+	}
+}
+
+func TestRenderDiff(t *testing.T) {
+	oldParser, newParser := getDiffParsers(t)
+	result := &strings.Builder{}
+	if err := CompareClassDiagrams(oldParser, newParser).RenderTo(result); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expected := `@startuml
+skinparam class {
+    BackgroundColor<<added>> PaleGreen
+    BackgroundColor<<removed>> MistyRose
+    BackgroundColor<<changed>> LightYellow
+}
+namespace shop {
+    class Customer <<added>> {
+        + Name string
+    }
+    class Entity {
+        + ID string
+    }
+    class Legacy <<removed>> {
+    }
+    class Order <<changed>> {
+        + Items []string
+        - total int
+        + <color:Green>Customer *shop.Customer</color>
+        + <color:Green>Cancel(string) error</color>
+        + Total() int
+        + <color:Red>--Cancel() error--</color>
+    }
+    interface Repository <<changed>> {
+        + Save(*shop.Order) error
+        + <color:Green>Delete(string) error</color>
+    }
+}
+"shop.Order" o-[#Green]- "shop.Customer"
+"shop.Entity" *-[#Red]- "shop.Order"
+@enduml
+`
+	if result.String() != expected {
+		t.Errorf("Expected \n%s\n got \n%s\n", expected, result.String())
+	}
+}
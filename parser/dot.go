@@ -0,0 +1,291 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DOT edge attributes for each one of the relationships. They follow the PlantUML arrows: extensions and
+// implementations point to the parent with an empty arrow and the diamonds are drawn on the same end.
+const (
+	dotExtendsAttributes     = `arrowhead=empty`
+	dotImplementsAttributes  = `arrowhead=empty, style=dashed`
+	dotCompositionAttributes = `dir=back, arrowtail=diamond`
+	dotAggregationAttributes = `dir=back, arrowtail=odiamond`
+	dotAliasAttributes       = `arrowhead=open, style=dotted`
+)
+
+// RenderDOT returns the class diagram that this parser has generated as a Graphviz digraph
+func (p *ClassParser) RenderDOT() string {
+	str := &strings.Builder{}
+	// Writing into a strings.Builder never fails
+	p.RenderDOTTo(str)
+	return str.String()
+}
+
+// RenderDOTTo writes the class diagram that this parser has generated as a Graphviz digraph into the given writer.
+// Every type is a record node listing its fields and methods and every package is a cluster. The same rendering
+// options used by RenderTo() apply, except for notes.
+func (p *ClassParser) RenderDOTTo(w io.Writer) error {
+	p.Finalize()
+	p.updateNamespaces()
+	p.updateOrphans()
+	p.renderedRelationships = map[string]struct{}{}
+	str := &LineStringBuilder{}
+	str.WriteLineWithDepth(0, `digraph "classes" {`)
+	if p.renderingOptions.Title != "" {
+		str.WriteLineWithDepth(1, fmt.Sprintf(`label=%s;`, escapeDOTID(p.renderingOptions.Title)))
+		str.WriteLineWithDepth(1, `labelloc=t;`)
+	}
+	str.WriteLineWithDepth(1, `rankdir=BT;`)
+	str.WriteLineWithDepth(1, `node [shape=record];`)
+	if err := flushTo(w, str); err != nil {
+		return err
+	}
+
+	var packages []string
+	for pack := range p.structure {
+		packages = append(packages, pack)
+	}
+	sort.Strings(packages)
+	var edges []string
+	for _, pack := range packages {
+		edges = append(edges, p.renderDOTPackage(pack, str)...)
+		if err := flushTo(w, str); err != nil {
+			return err
+		}
+	}
+	if p.renderingOptions.Aliases {
+		edges = append(edges, p.getDOTAliasEdges()...)
+	}
+	sort.Strings(edges)
+	for _, edge := range edges {
+		str.WriteLineWithDepth(1, edge)
+	}
+	str.WriteLineWithDepth(0, "}")
+	return flushTo(w, str)
+}
+
+// renderDOTPackage renders the cluster of the given package and returns the edges of its types
+func (p *ClassParser) renderDOTPackage(pack string, str *LineStringBuilder) []string {
+	structures := p.getStructures(pack)
+	if len(structures) == 0 {
+		return nil
+	}
+	var names []string
+	for name := range structures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var edges []string
+	str.WriteLineWithDepth(1, fmt.Sprintf(`subgraph %s {`, escapeDOTID("cluster_"+pack)))
+	label := pack
+	if namespace, ok := p.namespaces[pack]; ok {
+		label = namespace
+	}
+	str.WriteLineWithDepth(2, fmt.Sprintf(`label=%s;`, escapeDOTID(label)))
+	for _, name := range names {
+		fullName := name
+		if !strings.Contains(name, ".") {
+			fullName = fmt.Sprintf("%s.%s", pack, name)
+		}
+		if !p.isRenderedType(fullName) {
+			continue
+		}
+		structure := structures[name]
+		if p.renderingOptions.ExportedOnly {
+			structure = p.getExportedStructure(structure)
+		}
+		record := p.getDOTRecord(structure, fullName, strings.TrimPrefix(name, pack+"."))
+		str.WriteLineWithDepth(2, fmt.Sprintf(`%s [label="{%s}"];`, escapeDOTID(fullName), strings.Join(record, "|")))
+		edges = append(edges, p.getDOTEdges(structure, pack, name, fullName)...)
+	}
+	str.WriteLineWithDepth(1, "}")
+	return edges
+}
+
+// getDOTRecord returns the sections of the record label of the given structure: its name, fields and methods
+func (p *ClassParser) getDOTRecord(structure *Struct, fullName string, name string) []string {
+	header := escapeDOTRecord(name + getPlainType(getTypeParametersString(structure)))
+	switch kind := structure.Kind(); {
+	case kind == InterfaceKind && p.isConstraint(structure, fullName):
+		header = fmt.Sprintf(`«constraint»\n%s`, header)
+	case kind != ClassKind:
+		header = fmt.Sprintf(`«%s»\n%s`, kind, header)
+	}
+	record := []string{header}
+	if p.renderingOptions.Fields {
+		record = append(record, p.getDOTFields(structure))
+	}
+	if p.renderingOptions.Methods {
+		methods := ""
+		for _, constructor := range structure.Constructors {
+			methods += p.getDOTMethod(constructor, "static ")
+		}
+		for _, method := range structure.Functions {
+			methods += p.getDOTMethod(method, "")
+		}
+		record = append(record, methods)
+	}
+	return record
+}
+
+// getDOTFields returns the fields section of a record label, starting with the values of enums and the type terms
+// of constraints
+func (p *ClassParser) getDOTFields(structure *Struct) string {
+	fields := ""
+	for _, value := range structure.EnumValues {
+		if !p.renderingOptions.ExportedOnly || !isPrivate(value) {
+			fields += escapeDOTRecord(value) + `\l`
+		}
+	}
+	for _, term := range structure.TypeTerms {
+		fields += escapeDOTRecord(term) + `\l`
+	}
+	for _, field := range structure.Fields {
+		if field.Embedded && !p.renderingOptions.EmbeddedFields || isPrivate(field.Name) && (!p.renderingOptions.PrivateFields || p.renderingOptions.ExportedOnly) {
+			continue
+		}
+		fields += escapeDOTRecord(fmt.Sprintf("%s %s %s", getAccessModifier(field.Name), getFieldLabel(field), getPlainType(p.getMemberType(field.Type)))) + `\l`
+	}
+	return fields
+}
+
+// getDOTMethod returns the line of the given method in a record label or an empty string if it is not rendered
+func (p *ClassParser) getDOTMethod(method *Function, modifier string) string {
+	if isPrivate(method.Name) && (!p.renderingOptions.PrivateMethods || p.renderingOptions.ExportedOnly) {
+		return ""
+	}
+	line := fmt.Sprintf("%s %s%s(%s) %s", getAccessModifier(method.Name), modifier, method.Name, p.getParametersString(method), p.getReturnValuesType(method))
+	return escapeDOTRecord(p.addReceiverMarker(method, strings.TrimSpace(getPlainType(line)))) + `\l`
+}
+
+// getDOTEdges returns the edges that start in the given structure for the relationships enabled in the rendering
+// options. Edges to hidden types are skipped.
+func (p *ClassParser) getDOTEdges(structure *Struct, pack string, name string, fullName string) []string {
+	var edges []string
+	addEdges := func(targets []string, attributes string, label string, reversed bool) {
+		if p.renderingOptions.ConnectionLabels {
+			attributes = fmt.Sprintf("%s, label=%s", attributes, label)
+		}
+		for _, target := range targets {
+			if p.isHiddenType(target) {
+				continue
+			}
+			// Aggregations are not compositions, extensions nor realizations so they are never duplicates of them
+			if label != aggregates && p.isDuplicateRelationship(target, fullName) {
+				continue
+			}
+			from, to := fullName, target
+			if reversed {
+				from, to = target, fullName
+			}
+			edges = append(edges, fmt.Sprintf(`%s -> %s [%s];`, escapeDOTID(from), escapeDOTID(to), attributes))
+		}
+	}
+	modelType := p.getModelType(structure, pack, name)
+	if p.renderingOptions.Compositions {
+		addEdges(modelType.Compositions, dotCompositionAttributes, extends, true)
+	}
+	if p.renderingOptions.Implementations {
+		addEdges(modelType.Extends, dotExtendsAttributes, extends, false)
+		addEdges(modelType.Implements, dotImplementsAttributes, implements, false)
+	}
+	if p.renderingOptions.Aggregations {
+		aggregations := modelType.Aggregations
+		if p.renderingOptions.AggregatePrivateMembers && !p.renderingOptions.ExportedOnly {
+			aggregations = append(aggregations, modelType.PrivateAggregations...)
+		}
+		for i, a := range aggregations {
+			if !strings.Contains(a, ".") {
+				aggregations[i] = fmt.Sprintf("%s.%s", p.getPackageName(a, structure), a)
+			}
+		}
+		p.addDOTAggregationEdges(structure, aggregations, addEdges)
+	}
+	return edges
+}
+
+// addDOTAggregationEdges adds the edges to the given aggregated types with addEdges, labeled with the names of the
+// fields that reference them unless the FieldLabels option is NoFieldLabels
+func (p *ClassParser) addDOTAggregationEdges(structure *Struct, aggregations []string, addEdges func(targets []string, attributes string, label string, reversed bool)) {
+	if p.renderingOptions.FieldLabels == NoFieldLabels {
+		addEdges(aggregations, dotAggregationAttributes, aggregates, false)
+		return
+	}
+	for _, a := range aggregations {
+		for _, names := range p.getDOTFieldLabels(structure, a) {
+			attributes := dotAggregationAttributes
+			if names != "" {
+				attributes = fmt.Sprintf("%s, headlabel=%s", attributes, escapeDOTID(names))
+			}
+			addEdges([]string{a}, attributes, aggregates, false)
+		}
+	}
+}
+
+// getDOTFieldLabels returns the labels of the aggregation edges from the structure to the aggregated type, one for
+// every field with the SplitFieldLabels option or the joined names of the fields otherwise. Aggregations that are not
+// made by any rendered field get a single edge without names.
+func (p *ClassParser) getDOTFieldLabels(structure *Struct, aggregated string) []string {
+	fields := p.getAggregatingFields(structure, aggregated)
+	if len(fields) == 0 {
+		return []string{""}
+	}
+	if p.renderingOptions.FieldLabels == JoinedFieldLabels {
+		return []string{getFieldNames(fields)}
+	}
+	result := []string{}
+	for _, field := range fields {
+		result = append(result, field.Name)
+	}
+	return result
+}
+
+// getDOTAliasEdges returns the edges from every alias to the type it is an alias of. Notice that the Name of an
+// Alias is the original type while AliasOf is the alias.
+func (p *ClassParser) getDOTAliasEdges() []string {
+	attributes := dotAliasAttributes
+	if p.renderingOptions.ConnectionLabels {
+		attributes = fmt.Sprintf("%s, label=%s", attributes, aliasOf)
+	}
+	var edges []string
+	for _, alias := range p.allAliases {
+		if p.isHiddenType(alias.Name) || p.isHiddenType(alias.AliasOf) {
+			continue
+		}
+		edges = append(edges, fmt.Sprintf(`%s -> %s [%s];`, escapeDOTID(alias.AliasOf), escapeDOTID(alias.Name), attributes))
+	}
+	return edges
+}
+
+// getAccessModifier returns the UML visibility of a member with the given name
+func getAccessModifier(name string) string {
+	if isPrivate(name) {
+		return "-"
+	}
+	return "+"
+}
+
+// escapeDOTID returns the given identifier as a quoted DOT string
+func escapeDOTID(id string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(id) + `"`
+}
+
+// dotRecordReplacer escapes the characters that have a meaning in DOT record labels
+var dotRecordReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`|`, `\|`,
+	`<`, `\<`,
+	`>`, `\>`,
+)
+
+// escapeDOTRecord escapes a field of a DOT record label
+func escapeDOTRecord(text string) string {
+	return dotRecordReplacer.Replace(text)
+}
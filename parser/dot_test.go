@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRenderDOT(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/connectionlabels"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderAggregations:      true,
+			RenderAliases:           true,
+			RenderConnectionLabels:  true,
+			AggregatePrivateMembers: true,
+			RenderPrivateMembers:    true,
+			RenderTitle:             "Connection labels",
+		},
+	})
+	if err != nil {
+		t.Fatalf("TestRenderDOT: expected no error but got %s", err.Error())
+	}
+	expectedResult, err := ioutil.ReadFile("../testingsupport/connectionlabels.dot")
+	if err != nil {
+		t.Fatalf("TestRenderDOT: expected no error reading the expected result but got %s", err.Error())
+	}
+	result := parser.RenderDOT()
+	if result != string(expectedResult) {
+		t.Errorf("TestRenderDOT: expecting \n%s\n got \n%s\n", expectedResult, result)
+	}
+	for i := 0; i < 10; i++ {
+		if parser.RenderDOT() != result {
+			t.Fatalf("TestRenderDOT: expected the result to be the same every time it is rendered")
+		}
+	}
+}
+
+func TestRenderDOTHidden(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/exportedonly"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderExportedOnly: true,
+			RenderFields:       false,
+			RenderMethods:      false,
+		},
+	})
+	if err != nil {
+		t.Fatalf("TestRenderDOTHidden: expected no error but got %s", err.Error())
+	}
+	result := parser.RenderDOT()
+	for _, line := range strings.Split(result, "\n") {
+		if strings.Contains(line, `[label="{`) && strings.Contains(line, "|") {
+			t.Errorf("TestRenderDOTHidden: expected no fields or methods, got %s", line)
+		}
+		if strings.Contains(line, `"exportedonly.engine"`) {
+			t.Errorf("TestRenderDOTHidden: expected unexported types to be hidden, got %s", line)
+		}
+	}
+}
+
+func TestRenderDOTToError(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/connectionlabels"}, []string{}, false)
+	if err != nil {
+		t.Fatalf("TestRenderDOTToError: expected no error but got %s", err.Error())
+	}
+	writer := &failingWriter{}
+	if err := parser.RenderDOTTo(writer); err == nil || err.Error() != "write failed" {
+		t.Errorf("TestRenderDOTToError: expected the writer error to be returned, got %v", err)
+	}
+	if writer.writes != 1 {
+		t.Errorf("TestRenderDOTToError: expected rendering to stop after the first failed write, got %d writes", writer.writes)
+	}
+	buffer := &bytes.Buffer{}
+	if err := parser.RenderDOTTo(buffer); err != nil {
+		t.Errorf("TestRenderDOTToError: expected no error but got %s", err.Error())
+	}
+	if buffer.String() != parser.RenderDOT() {
+		t.Errorf("TestRenderDOTToError: expected RenderDOTTo and RenderDOT to return the same diagram")
+	}
+}
+
+func TestEscapeDOT(t *testing.T) {
+	if result := escapeDOTID(`a"b\c`); result != `"a\"b\\c"` {
+		t.Errorf("TestEscapeDOT: expected the identifier to be escaped, got %s", result)
+	}
+	if result := escapeDOTRecord(`+ Get() map[string]<-chan struct{}|"x"`); result != `+ Get() map[string]\<-chan struct\{\}\|\"x\"` {
+		t.Errorf("TestEscapeDOT: expected the record to be escaped, got %s", result)
+	}
+}
@@ -0,0 +1,281 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A file with import . "example.com/pkg" references the exported types of the package without a qualifier, so they
+// are taken for types of the package of the file while parsing it. Once the directories are parsed, the names the
+// package does not declare are qualified with the dot imported package declaring them, see resolveDotImports(). The
+// relationships to the names that may be declared by a dot imported package that was not parsed are dropped with a
+// warning instead of pointing at a class of the wrong package. They are kept aside and resolved again by the next
+// finalize, so the package can still be added, see AddDirectory().
+
+// droppedRelationships are the relationships of a type that were dropped by resolveDotImports, by kind
+type droppedRelationships struct {
+	composition         map[string]struct{}
+	extends             map[string]struct{}
+	aggregations        map[string]struct{}
+	privateAggregations map[string]struct{}
+}
+
+// getDotImports returns the packages dot imported by the given file, named like the packages of the structure
+func (p *ClassParser) getDotImports(f *ast.File) []string {
+	var dotImports []string
+	for _, impt := range f.Imports {
+		if impt.Name == nil || impt.Name.Name != "." {
+			continue
+		}
+		importPath := strings.Trim(impt.Path.Value, `"`)
+		if p.options.ImportPaths {
+			dotImports = append(dotImports, importPath)
+		} else {
+			dotImports = append(dotImports, p.resolveImportedPackageName(importPath))
+		}
+	}
+	return dotImports
+}
+
+// addDotImports keeps the packages dot imported by the given file by its file name
+func (p *ClassParser) addDotImports(f *ast.File) {
+	dotImports := p.getDotImports(f)
+	if len(dotImports) == 0 {
+		return
+	}
+	if p.dotImports == nil {
+		p.dotImports = map[string][]string{}
+	}
+	p.dotImports[p.getPosition(f.Pos()).Filename] = dotImports
+}
+
+// resolveDotImports qualifies the types referenced by the files with dot imports with the dot imported packages
+// declaring them, in the fields, methods and relationships of the types and in the package level functions. The
+// relationships dropped by the previous call are restored first and the warnings about them are replaced.
+func (p *ClassParser) resolveDotImports() {
+	if len(p.dotImports) == 0 {
+		return
+	}
+	dropped := p.droppedRelationships
+	p.droppedRelationships = map[*Struct]*droppedRelationships{}
+	p.dotImportWarnings = nil
+	expressions := map[string]*regexp.Regexp{}
+	getResolver := func(pack string, dotImports []string) *dotImportResolver {
+		if _, ok := expressions[pack]; !ok {
+			expressions[pack] = regexp.MustCompile(`(^|[^\p{L}\p{N}_./-])` + regexp.QuoteMeta(pack) + `\.([\p{L}_][\p{L}\p{N}_]*)`)
+		}
+		return &dotImportResolver{
+			p:          p,
+			pack:       pack,
+			qualified:  expressions[pack],
+			dotImports: dotImports,
+			unresolved: map[string][]string{},
+		}
+	}
+	packages := make([]string, 0, len(p.structure))
+	for pack := range p.structure {
+		packages = append(packages, pack)
+	}
+	sort.Strings(packages)
+	for _, pack := range packages {
+		names := make([]string, 0, len(p.structure[pack]))
+		for name := range p.structure[pack] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			st := p.structure[pack][name]
+			if relationships, ok := dropped[st]; ok {
+				relationships.restore(st)
+			}
+			r := getResolver(pack, p.dotImports[st.Position.Filename])
+			r.st = st
+			r.resolveStruct()
+		}
+	}
+	for _, function := range p.allFunctions {
+		if dotImports := p.dotImports[function.Position.Filename]; len(dotImports) > 0 {
+			getResolver(function.PackageName, dotImports).resolveFunction(function)
+		}
+	}
+}
+
+// dotImportResolver qualifies the names of a package referenced by a file with the dot imported packages of the file
+// declaring them
+type dotImportResolver struct {
+	p    *ClassParser
+	pack string
+	// qualified matches the names qualified with the package, with the character before them
+	qualified  *regexp.Regexp
+	dotImports []string
+	// st is the type being resolved, whose type parameters are never resolved
+	st *Struct
+	// unresolved are the full names that may be declared by the dot imported packages that were not parsed, mapped
+	// to these packages
+	unresolved map[string][]string
+}
+
+// resolve returns the given type with the names qualified with the package that the package does not declare
+// qualified with the dot imported package declaring them instead
+func (r *dotImportResolver) resolve(t string) string {
+	if len(r.dotImports) == 0 {
+		return t
+	}
+	return r.qualified.ReplaceAllStringFunc(t, func(match string) string {
+		submatches := r.qualified.FindStringSubmatch(match)
+		if pack := r.getDeclaringPackage(submatches[2]); pack != r.pack {
+			return submatches[1] + pack + "." + submatches[2]
+		}
+		return match
+	})
+}
+
+// getDeclaringPackage returns the dot imported package declaring the given name. The package of the resolver is
+// returned if it declares the name itself or if no dot imported package does, in which case the name is unresolved
+// if some dot imported package was not parsed.
+func (r *dotImportResolver) getDeclaringPackage(name string) string {
+	if _, ok := r.p.structure[r.pack][name]; ok || (r.st != nil && r.st.isTypeParameter(name)) {
+		return r.pack
+	}
+	var unparsed []string
+	for _, dotImport := range r.dotImports {
+		structures, ok := r.p.structure[dotImport]
+		if !ok {
+			unparsed = append(unparsed, dotImport)
+			continue
+		}
+		if _, ok := structures[name]; ok {
+			return dotImport
+		}
+	}
+	if len(unparsed) > 0 {
+		r.unresolved[getFullTypeName(r.pack, name)] = unparsed
+	}
+	return r.pack
+}
+
+// resolveStruct resolves the fields, methods and relationships of the type of the resolver. The relationships to
+// unresolved names are dropped with a warning, and kept to be restored by the next resolveDotImports.
+func (r *dotImportResolver) resolveStruct() {
+	st := r.st
+	for _, field := range st.Fields {
+		r.resolveField(field)
+	}
+	kept := &droppedRelationships{}
+	dropped := map[string]struct{}{}
+	st.Composition, kept.composition = r.resolveRelationships(st.Composition, dropped)
+	st.Extends, kept.extends = r.resolveRelationships(st.Extends, dropped)
+	st.Aggregations, kept.aggregations = r.resolveRelationships(st.Aggregations, dropped)
+	st.PrivateAggregations, kept.privateAggregations = r.resolveRelationships(st.PrivateAggregations, dropped)
+	if len(dropped) > 0 {
+		r.p.droppedRelationships[st] = kept
+	}
+	if len(st.ExtendsTypeArguments) > 0 {
+		typeArguments := map[string][]string{}
+		for extended, arguments := range st.ExtendsTypeArguments {
+			for i, argument := range arguments {
+				arguments[i] = r.resolve(argument)
+			}
+			typeArguments[r.resolve(extended)] = arguments
+		}
+		st.ExtendsTypeArguments = typeArguments
+	}
+	r.warnDropped(dropped)
+	dotImports := r.dotImports
+	for _, method := range st.Functions {
+		// Methods can be declared in other files than their type, the methods of interfaces have no position
+		if method.Position.IsValid() {
+			r.dotImports = r.p.dotImports[method.Position.Filename]
+		}
+		r.resolveFunction(method)
+		r.dotImports = dotImports
+	}
+}
+
+// resolveField resolves the full type and the referenced types of the given field
+func (r *dotImportResolver) resolveField(field *Field) {
+	field.FullType = r.resolve(field.FullType)
+	for i, t := range field.ReferencedTypes {
+		field.ReferencedTypes[i] = r.resolve(t)
+	}
+}
+
+// resolveFunction resolves the types of the parameters and return values of the given function
+func (r *dotImportResolver) resolveFunction(function *Function) {
+	for _, parameter := range function.Parameters {
+		r.resolveField(parameter)
+	}
+	for i, t := range function.FullNameReturnValues {
+		function.FullNameReturnValues[i] = r.resolve(t)
+	}
+	for i, t := range function.ReferencedTypes {
+		function.ReferencedTypes[i] = r.resolve(t)
+	}
+}
+
+// resolveRelationships returns the given relationships of the type of the resolver with their types resolved, and
+// the ones to unresolved names, which are left out and whose names are added to the dropped ones.
+func (r *dotImportResolver) resolveRelationships(relationships map[string]struct{}, dropped map[string]struct{}) (map[string]struct{}, map[string]struct{}) {
+	if len(relationships) == 0 || len(r.dotImports) == 0 {
+		return relationships, nil
+	}
+	resolved := map[string]struct{}{}
+	var unresolved map[string]struct{}
+	for relationship := range relationships {
+		fullName := relationship
+		if !strings.Contains(strings.SplitN(relationship, "[", 2)[0], ".") {
+			// Compositions of the types of the same package are not qualified
+			fullName = fmt.Sprintf("%s.%s", r.pack, relationship)
+		}
+		resolvedName := r.resolve(fullName)
+		if _, ok := r.unresolved[getEmbeddedTypeName(fullName, r.st)]; ok {
+			dropped[getEmbeddedTypeName(fullName, r.st)] = struct{}{}
+			if unresolved == nil {
+				unresolved = map[string]struct{}{}
+			}
+			unresolved[relationship] = struct{}{}
+			continue
+		}
+		if resolvedName == fullName {
+			resolved[relationship] = struct{}{}
+		} else {
+			resolved[resolvedName] = struct{}{}
+		}
+	}
+	return resolved, unresolved
+}
+
+// restore adds the dropped relationships back into the relationships of the given type
+func (d *droppedRelationships) restore(st *Struct) {
+	restore := func(relationships map[string]struct{}, dropped map[string]struct{}) map[string]struct{} {
+		if len(dropped) == 0 {
+			return relationships
+		}
+		if relationships == nil {
+			relationships = map[string]struct{}{}
+		}
+		for relationship := range dropped {
+			relationships[relationship] = struct{}{}
+		}
+		return relationships
+	}
+	st.Composition = restore(st.Composition, d.composition)
+	st.Extends = restore(st.Extends, d.extends)
+	st.Aggregations = restore(st.Aggregations, d.aggregations)
+	st.PrivateAggregations = restore(st.PrivateAggregations, d.privateAggregations)
+}
+
+// warnDropped adds a warning for every dropped relationship of the type of the resolver
+func (r *dotImportResolver) warnDropped(dropped map[string]struct{}) {
+	names := make([]string, 0, len(dropped))
+	for name := range dropped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.p.dotImportWarnings = append(r.p.dotImportWarnings, fmt.Errorf("%s: the relationship of %s to %s is not rendered, %s may be declared by the dot imported %s that was not parsed", r.st.Position, getFullTypeName(r.pack, r.st.Name), name, name[len(r.pack)+1:], strings.Join(r.unresolved[name], ", ")))
+	}
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+)
+
+// handleConstDecl keeps the names of the constants declared with a named type of the given package, usually with
+// iota, so the type can be rendered as an enum. Constants without a type or value repeat the ones of the previous
+// constant in the same block as the Go specification says. Blank constants are skipped.
+func (p *ClassParser) handleConstDecl(decl *ast.GenDecl, packageName string) {
+	typeName := ""
+	for _, spec := range decl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		switch {
+		case valueSpec.Type != nil:
+			typeName = ""
+			if ident, ok := unparen(valueSpec.Type).(*ast.Ident); ok && !isPrimitive(ident) {
+				typeName = ident.Name
+			}
+		case len(valueSpec.Values) > 0:
+			// Untyped constants, even if they are converted like Status(1), are not part of the enum
+			typeName = ""
+		}
+		if typeName == "" {
+			continue
+		}
+		fullName := fmt.Sprintf("%s.%s", packageName, typeName)
+		for _, name := range valueSpec.Names {
+			if name.Name == "_" || p.isEnumValue(fullName, name.Name) {
+				// Platform specific files can declare the same values
+				continue
+			}
+			if p.allConstants == nil {
+				p.allConstants = map[string][]string{}
+			}
+			p.allConstants[fullName] = append(p.allConstants[fullName], name.Name)
+		}
+	}
+}
+
+// isEnumValue returns true if the given constant was already found for the given type
+func (p *ClassParser) isEnumValue(fullName string, name string) bool {
+	for _, value := range p.allConstants[fullName] {
+		if value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// findEnums sets the values of the named types that have constants declared with them. Only named types declared
+// in the parsed code, like type Status int, can be enums.
+func (p *ClassParser) findEnums() {
+	for _, pack := range p.structure {
+		for _, st := range pack {
+			st.EnumValues = nil
+		}
+	}
+	for fullName, values := range p.allConstants {
+		if st := p.getStruct(fullName); st != nil && st.Type == "type" {
+			st.EnumValues = append([]string{}, values...)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestFindEnums(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/enums"},
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	tt := []struct {
+		Name     string
+		Expected []string
+	}{
+		{
+			Name:     "enums.Status",
+			Expected: []string{"StatusUnknown", "StatusActive", "StatusSuspended", "statusDeleted"},
+		},
+		{
+			Name:     "enums.State",
+			Expected: []string{"StateOpen", "StateClosed"},
+		},
+		{
+			Name: "enums.Level",
+		},
+		{
+			Name: "enums.Account",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if values := parser.getStruct(tc.Name).EnumValues; !reflect.DeepEqual(values, tc.Expected) {
+				t.Errorf("Expected values %v got %v", tc.Expected, values)
+			}
+		})
+	}
+}
+
+func TestRenderEnums(t *testing.T) {
+	tt := []struct {
+		Name         string
+		ExportedOnly bool
+		Expected     []string
+		NotExpected  []string
+	}{
+		{
+			Name: "Enums",
+			Expected: []string{
+				"    enum Status  {\n        StatusUnknown\n        StatusActive\n        StatusSuspended\n        statusDeleted\n        + String() string\n",
+				"    enum State  {\n        StateOpen\n        StateClosed\n    }\n",
+				"    class Level << (T, #FF7700) type >> {\n",
+				`"enums.Account" o-- "1" "enums.Status"`,
+			},
+			NotExpected: []string{"MaxRetries", "Converted"},
+		},
+		{
+			Name:         "Exported only",
+			ExportedOnly: true,
+			Expected: []string{
+				"    enum Status  {\n        StatusUnknown\n        StatusActive\n        StatusSuspended\n",
+			},
+			NotExpected: []string{"statusDeleted"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:  afero.NewOsFs(),
+				Directories: []string{"../testingsupport/enums"},
+				RenderingOptions: map[RenderingOption]interface{}{
+					RenderAggregations: true,
+					RenderExportedOnly: tc.ExportedOnly,
+				},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			result := parser.Render()
+			for _, expected := range tc.Expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected the result to contain \n%s\n got \n%s\n", expected, result)
+				}
+			}
+			for _, notExpected := range tc.NotExpected {
+				if strings.Contains(result, notExpected) {
+					t.Errorf("Expected the result not to contain \n%s\n got \n%s\n", notExpected, result)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Model is a serializable representation of everything the ClassParser found. Packages and types are sorted by
+// name while fields and methods keep the order in which they were declared in the source code.
+type Model struct {
+	Packages []*ModelPackage `json:"packages" yaml:"packages"`
+}
+
+// ModelPackage holds the types declared in a package
+type ModelPackage struct {
+	Name  string       `json:"name" yaml:"name"`
+	Types []*ModelType `json:"types" yaml:"types"`
+}
+
+// ModelType is a struct, interface, named type or alias declaration. Values holds the constants of enums and TypeTerms
+// the type set elements of constraint interfaces, like ~int | ~float64. All the relationships use fully qualified
+// type names. File is the path of the file where the type is declared relative to the parsed directory and Line is
+// the line of the declaration.
+type ModelType struct {
+	Name           string         `json:"name" yaml:"name"`
+	Kind           string         `json:"kind" yaml:"kind"`
+	File           string         `json:"file,omitempty" yaml:"file,omitempty"`
+	Line           int            `json:"line,omitempty" yaml:"line,omitempty"`
+	AliasOf        string         `json:"aliasOf,omitempty" yaml:"aliasOf,omitempty"`
+	Values         []string       `json:"values,omitempty" yaml:"values,omitempty"`
+	TypeTerms      []string       `json:"typeTerms,omitempty" yaml:"typeTerms,omitempty"`
+	TypeParameters []*ModelField  `json:"typeParameters,omitempty" yaml:"typeParameters,omitempty"`
+	Fields         []*ModelField  `json:"fields,omitempty" yaml:"fields,omitempty"`
+	Methods        []*ModelMethod `json:"methods,omitempty" yaml:"methods,omitempty"`
+	Constructors   []*ModelMethod `json:"constructors,omitempty" yaml:"constructors,omitempty"`
+	Extends        []string       `json:"extends,omitempty" yaml:"extends,omitempty"`
+	Implements     []string       `json:"implements,omitempty" yaml:"implements,omitempty"`
+	Compositions   []string       `json:"compositions,omitempty" yaml:"compositions,omitempty"`
+	Aggregations   []string       `json:"aggregations,omitempty" yaml:"aggregations,omitempty"`
+	// PrivateAggregations are the aggregations made through private fields
+	PrivateAggregations []string `json:"privateAggregations,omitempty" yaml:"privateAggregations,omitempty"`
+	// PointerImplements are the interfaces in Implements that only the pointer to the type implements
+	PointerImplements []string `json:"pointerImplements,omitempty" yaml:"pointerImplements,omitempty"`
+}
+
+// ModelField is a field, a parameter or a type parameter. Types are written in plain Go syntax. Only struct fields
+// have a position.
+type ModelField struct {
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+	Type     string `json:"type" yaml:"type"`
+	Tag      string `json:"tag,omitempty" yaml:"tag,omitempty"`
+	Embedded bool   `json:"embedded,omitempty" yaml:"embedded,omitempty"`
+	File     string `json:"file,omitempty" yaml:"file,omitempty"`
+	Line     int    `json:"line,omitempty" yaml:"line,omitempty"`
+}
+
+// ModelMethod is a method with its parameters and return values
+type ModelMethod struct {
+	Name            string        `json:"name" yaml:"name"`
+	PointerReceiver bool          `json:"pointerReceiver,omitempty" yaml:"pointerReceiver,omitempty"`
+	File            string        `json:"file,omitempty" yaml:"file,omitempty"`
+	Line            int           `json:"line,omitempty" yaml:"line,omitempty"`
+	Parameters      []*ModelField `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	ReturnValues    []*ModelField `json:"returnValues,omitempty" yaml:"returnValues,omitempty"`
+}
+
+// ExportJSON returns the parsed structure as indented JSON. See Model for the exported format.
+func (p *ClassParser) ExportJSON() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	// Types like <-chan int are easier to read without escaping
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(p.Model()); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// ExportYAML returns the parsed structure as YAML, with the same keys as ExportJSON(). See Model for the exported
+// format.
+func (p *ClassParser) ExportYAML() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(p.Model()); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Model returns a serializable representation of the parsed structure
+func (p *ClassParser) Model() *Model {
+	p.Finalize()
+	model := &Model{
+		Packages: []*ModelPackage{},
+	}
+	var packages []string
+	for pack := range p.structure {
+		packages = append(packages, pack)
+	}
+	sort.Strings(packages)
+	for _, pack := range packages {
+		modelPackage := &ModelPackage{
+			Name:  pack,
+			Types: []*ModelType{},
+		}
+		structures := p.getStructures(pack)
+		var names []string
+		for name := range structures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			modelPackage.Types = append(modelPackage.Types, p.getModelType(structures[name], pack, name))
+		}
+		model.Packages = append(model.Packages, modelPackage)
+	}
+	return model
+}
+
+func (p *ClassParser) getModelType(structure *Struct, pack string, name string) *ModelType {
+	modelType := &ModelType{
+		Name: strings.TrimPrefix(name, pack+"."),
+		Kind: structure.Type,
+	}
+	modelType.File, modelType.Line = p.getModelPosition(structure.Position)
+	if alias, ok := p.allAliases[getFullTypeName(pack, name)]; ok {
+		modelType.AliasOf = alias.Name
+	}
+	modelType.Values = structure.EnumValues
+	modelType.TypeTerms = structure.TypeTerms
+	for _, typeParameter := range structure.TypeParameters {
+		modelType.TypeParameters = append(modelType.TypeParameters, &ModelField{
+			Name: typeParameter.Name,
+			Type: getPlainType(typeParameter.Type),
+		})
+	}
+	for _, field := range structure.Fields {
+		modelField := &ModelField{
+			Name:     field.Name,
+			Type:     getPlainType(field.FullType),
+			Tag:      field.Tag,
+			Embedded: field.Embedded,
+		}
+		modelField.File, modelField.Line = p.getModelPosition(field.Position)
+		modelType.Fields = append(modelType.Fields, modelField)
+	}
+	for _, function := range structure.Functions {
+		modelType.Methods = append(modelType.Methods, p.getModelMethod(function))
+	}
+	for _, constructor := range structure.Constructors {
+		modelType.Constructors = append(modelType.Constructors, p.getModelMethod(constructor))
+	}
+	for c := range structure.Composition {
+		if !strings.Contains(c, ".") {
+			c = fmt.Sprintf("%s.%s", p.getPackageName(c, structure), c)
+		}
+		modelType.Compositions = append(modelType.Compositions, c)
+	}
+	for e := range structure.Extends {
+		if !strings.Contains(e, ".") {
+			e = fmt.Sprintf("%s.%s", structure.PackageName, e)
+		}
+		modelType.Extends = append(modelType.Extends, e)
+	}
+	for i := range structure.Implements {
+		modelType.Implements = append(modelType.Implements, i)
+	}
+	for i := range structure.PointerImplements {
+		modelType.PointerImplements = append(modelType.PointerImplements, i)
+	}
+	for a := range structure.Aggregations {
+		modelType.Aggregations = append(modelType.Aggregations, a)
+	}
+	for a := range structure.PrivateAggregations {
+		modelType.PrivateAggregations = append(modelType.PrivateAggregations, a)
+	}
+	if p.neighborhood != nil {
+		p.filterModelRelationships(modelType, structure)
+	}
+	sort.Strings(modelType.Compositions)
+	sort.Strings(modelType.Extends)
+	sort.Strings(modelType.Implements)
+	sort.Strings(modelType.PointerImplements)
+	sort.Strings(modelType.Aggregations)
+	sort.Strings(modelType.PrivateAggregations)
+	return modelType
+}
+
+func (p *ClassParser) getModelMethod(function *Function) *ModelMethod {
+	method := &ModelMethod{
+		Name:            function.Name,
+		PointerReceiver: function.PointerReceiver,
+	}
+	method.File, method.Line = p.getModelPosition(function.Position)
+	for _, parameter := range function.Parameters {
+		method.Parameters = append(method.Parameters, &ModelField{
+			Name: parameter.Name,
+			Type: getPlainType(parameter.FullType),
+		})
+	}
+	for i, returnValue := range function.FullNameReturnValues {
+		modelReturnValue := &ModelField{
+			Type: getPlainType(returnValue),
+		}
+		if i < len(function.ReturnValueNames) {
+			modelReturnValue.Name = function.ReturnValueNames[i]
+		}
+		method.ReturnValues = append(method.ReturnValues, modelReturnValue)
+	}
+	return method
+}
+
+// getModelPosition returns the path, relative to the parsed directory, and the line of the given position. The path
+// is empty if the position is not known.
+func (p *ClassParser) getModelPosition(position token.Position) (string, int) {
+	if position.Filename == "" {
+		return "", 0
+	}
+	return p.getRelativePath(position.Filename), position.Line
+}
+
+// fontTagRegexp matches the font tags used to highlight keywords in the rendered types
+var fontTagRegexp = regexp.MustCompile(`</?font[^>]*>`)
+
+// getPlainType removes the PlantUML formatting from the given type
+func getPlainType(t string) string {
+	return fontTagRegexp.ReplaceAllString(t, "")
+}
@@ -0,0 +1,192 @@
+package parser
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestExportJSON(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/crosspackage"}, []string{}, true)
+	if err != nil {
+		t.Errorf("TestExportJSON: expected no error but got %s", err.Error())
+		return
+	}
+	result, err := parser.ExportJSON()
+	if err != nil {
+		t.Errorf("TestExportJSON: expected no error but got %s", err.Error())
+		return
+	}
+	expectedResult, err := ioutil.ReadFile("../testingsupport/crosspackage.json")
+	if err != nil {
+		t.Errorf("TestExportJSON: expected no error reading the expected result but got %s", err.Error())
+		return
+	}
+	if string(result) != string(expectedResult) {
+		t.Errorf("TestExportJSON: expecting \n%s\n got \n%s\n", expectedResult, result)
+	}
+	model := &Model{}
+	if err := json.Unmarshal(result, model); err != nil {
+		t.Errorf("TestExportJSON: expected no error unmarshaling the result but got %s", err.Error())
+		return
+	}
+	if !reflect.DeepEqual(model, parser.Model()) {
+		t.Errorf("TestExportJSON: expected the unmarshaled model to be equal to the parsed one")
+	}
+}
+
+func TestExportYAML(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/crosspackage"}, []string{}, true)
+	if err != nil {
+		t.Fatalf("TestExportYAML: expected no error but got %s", err.Error())
+	}
+	result, err := parser.ExportYAML()
+	if err != nil {
+		t.Fatalf("TestExportYAML: expected no error but got %s", err.Error())
+	}
+	model := &Model{}
+	if err := yaml.Unmarshal(result, model); err != nil {
+		t.Fatalf("TestExportYAML: expected no error unmarshaling the result but got %s", err.Error())
+	}
+	if !reflect.DeepEqual(model, parser.Model()) {
+		t.Errorf("TestExportYAML: expected the unmarshaled model to be equal to the parsed one, got\n%s", result)
+	}
+	// The YAML keys are the JSON ones, so both formats have the same schema
+	var fromYAML, fromJSON interface{}
+	if err := yaml.Unmarshal(result, &fromYAML); err != nil {
+		t.Fatalf("TestExportYAML: expected no error unmarshaling the result but got %s", err.Error())
+	}
+	exported, err := parser.ExportJSON()
+	if err != nil {
+		t.Fatalf("TestExportYAML: expected no error but got %s", err.Error())
+	}
+	if err := json.Unmarshal(exported, &fromJSON); err != nil {
+		t.Fatalf("TestExportYAML: expected no error unmarshaling the JSON export but got %s", err.Error())
+	}
+	normalized, err := json.Marshal(fromYAML)
+	if err != nil {
+		t.Fatalf("TestExportYAML: expected no error marshaling the YAML export as JSON but got %s", err.Error())
+	}
+	var fromNormalized interface{}
+	json.Unmarshal(normalized, &fromNormalized)
+	if !reflect.DeepEqual(fromNormalized, fromJSON) {
+		t.Errorf("TestExportYAML: expected the YAML export to have the keys and values of the JSON one, got\n%s", result)
+	}
+}
+
+func TestExportJSONGenerics(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/generics"}, []string{}, false)
+	if err != nil {
+		t.Errorf("TestExportJSONGenerics: expected no error but got %s", err.Error())
+		return
+	}
+	for _, modelType := range parser.Model().Packages[0].Types {
+		if modelType.Name != "Map" {
+			continue
+		}
+		expectedTypeParameters := []*ModelField{
+			{Name: "K", Type: "comparable"},
+			{Name: "V", Type: "any"},
+		}
+		if !reflect.DeepEqual(modelType.TypeParameters, expectedTypeParameters) {
+			t.Errorf("TestExportJSONGenerics: expected %v got %v", expectedTypeParameters, modelType.TypeParameters)
+		}
+		return
+	}
+	t.Errorf("TestExportJSONGenerics: expected to find the Map type")
+}
+
+func TestGetPlainType(t *testing.T) {
+	tt := []struct {
+		Input          string
+		ExpectedResult string
+	}{
+		{Input: "int", ExpectedResult: "int"},
+		{Input: "<font color=blue>map</font>[string]*main.Foo", ExpectedResult: "map[string]*main.Foo"},
+		{Input: "<font color=blue>func</font>(<font color=blue>chan</font> int) error", ExpectedResult: "func(chan int) error"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Input, func(t *testing.T) {
+			if result := getPlainType(tc.Input); result != tc.ExpectedResult {
+				t.Errorf("Expected %s got %s", tc.ExpectedResult, result)
+			}
+		})
+	}
+}
+
+func TestPositions(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/positions"}, []string{}, false)
+	if err != nil {
+		t.Fatalf("TestPositions: expected no error but got %s", err.Error())
+	}
+	node := parser.structure["positions"]["Node"]
+	if node == nil {
+		t.Fatalf("TestPositions: expected the Node to be parsed, got %v", parser.structure)
+	}
+	type fieldPosition struct {
+		Name     string
+		Embedded bool
+		Line     int
+	}
+	expectedFields := []fieldPosition{
+		{Name: "Base", Embedded: true, Line: 10},
+		{Name: "Other", Embedded: true, Line: 11},
+		{Name: "X", Line: 12},
+		{Name: "Y", Line: 12},
+		{Name: "Label", Line: 13},
+	}
+	fields := []fieldPosition{}
+	for _, field := range node.Fields {
+		fields = append(fields, fieldPosition{Name: field.Name, Embedded: field.Embedded, Line: field.Position.Line})
+		if filepath.Base(field.Position.Filename) != "positions.go" {
+			t.Errorf("TestPositions: expected %s to be declared in positions.go, got %s", field.Name, field.Position.Filename)
+		}
+	}
+	if !reflect.DeepEqual(fields, expectedFields) {
+		t.Errorf("TestPositions: expected the fields %v, got %v", expectedFields, fields)
+	}
+	type methodPosition struct {
+		Name            string
+		PointerReceiver bool
+		Line            int
+	}
+	expectedMethods := []methodPosition{
+		{Name: "Move", PointerReceiver: true, Line: 22},
+		{Name: "Position", Line: 27},
+	}
+	methods := []methodPosition{}
+	for _, method := range node.Functions {
+		methods = append(methods, methodPosition{Name: method.Name, PointerReceiver: method.PointerReceiver, Line: method.Position.Line})
+	}
+	if !reflect.DeepEqual(methods, expectedMethods) {
+		t.Errorf("TestPositions: expected the methods %v, got %v", expectedMethods, methods)
+	}
+	if line := node.Constructors[0].Position.Line; line != 17 {
+		t.Errorf("TestPositions: expected the constructor to be declared in line 17, got %d", line)
+	}
+	if line := parser.structure["positions"]["Mover"].Functions[0].Position.Line; line != 36 {
+		t.Errorf("TestPositions: expected the interface method to be declared in line 36, got %d", line)
+	}
+}
+
+func TestExportJSONPositions(t *testing.T) {
+	parser, err := NewClassDiagram([]string{"../testingsupport/positions"}, []string{}, false)
+	if err != nil {
+		t.Fatalf("TestExportJSONPositions: expected no error but got %s", err.Error())
+	}
+	modelNode := parser.Model().Packages[0].Types[2]
+	if modelNode.Name != "Node" || modelNode.File != "positions.go" || modelNode.Line != 9 {
+		t.Errorf("TestExportJSONPositions: expected the Node to be exported with positions.go:9, got %s %s:%d", modelNode.Name, modelNode.File, modelNode.Line)
+	}
+	expectedField := &ModelField{Name: "Base", Type: "positions.Base", Embedded: true, File: "positions.go", Line: 10}
+	if !reflect.DeepEqual(modelNode.Fields[0], expectedField) {
+		t.Errorf("TestExportJSONPositions: expected the exported field %v, got %v", expectedField, modelNode.Fields[0])
+	}
+	if method := modelNode.Methods[0]; !method.PointerReceiver || method.File != "positions.go" || method.Line != 22 {
+		t.Errorf("TestExportJSONPositions: expected the exported method to have a pointer receiver in positions.go:22, got %v", method)
+	}
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// ExternalTypes selects how the relationships to the types of the packages that were not parsed are rendered
+type ExternalTypes int
+
+const (
+	// ImplicitExternalTypes renders the relationships and lets PlantUML create the classes they point to. It is the
+	// default
+	ImplicitExternalTypes ExternalTypes = iota
+	// DropExternalTypes does not render the relationships to external types
+	DropExternalTypes
+	// StubExternalTypes declares a placeholder class with the external stereotype for every external type in the
+	// external namespace, once for the whole diagram, so the relationships point to declared classes
+	StubExternalTypes
+)
+
+// externalNamespace is the namespace of the placeholder classes of the StubExternalTypes option
+const externalNamespace = "external"
+
+// externalStubNameRegexp matches the characters that can not be part of the name of a placeholder class
+var externalStubNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// isExternalType returns true if the given fully qualified type belongs to a package that was not parsed
+func (p *ClassParser) isExternalType(fullName string) bool {
+	packageName, _ := splitFullTypeName(fullName)
+	if packageName == "" || packageName == builtinPackageName {
+		return false
+	}
+	_, parsed := p.structure[packageName]
+	return !parsed
+}
+
+// getExternalStubName returns the name of the placeholder class of the given external type in the diagram
+func (p *ClassParser) getExternalStubName(fullName string) string {
+	separator := p.getNamespaceSeparator()
+	if p.renderingOptions.Flat {
+		separator = "_"
+	}
+	return externalNamespace + separator + externalStubNameRegexp.ReplaceAllString(fullName, "_")
+}
+
+// getExternalTypes returns the sorted external types that the rendered relationships of the given packages point to.
+// Aliases of external types are not needed since they are rendered as classes of the package of the alias.
+func (p *ClassParser) getExternalTypes(packages []string, overview bool) []string {
+	found := map[string]struct{}{}
+	for _, pack := range packages {
+		structures := p.structure[pack]
+		if overview {
+			structures = getOverviewStructures(structures)
+		}
+		for name, structure := range structures {
+			if !p.isRenderedType(getFullTypeName(pack, name)) {
+				continue
+			}
+			for t := range p.getRenderedConnections(structure) {
+				if p.isExternalType(t) {
+					found[t] = struct{}{}
+				}
+			}
+		}
+	}
+	result := []string{}
+	for t := range found {
+		result = append(result, t)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// renderExternalTypes renders the placeholder classes of the given external types in the external namespace
+func (p *ClassParser) renderExternalTypes(str *LineStringBuilder, types []string) {
+	if len(types) == 0 {
+		return
+	}
+	if p.renderingOptions.Flat {
+		for _, t := range types {
+			str.WriteLineWithDepth(0, fmt.Sprintf(`class "%s" as %s <<external>>`, t, p.getExternalStubName(t)))
+		}
+		return
+	}
+	str.WriteLineWithDepth(0, fmt.Sprintf(`namespace %s {`, externalNamespace))
+	for _, t := range types {
+		str.WriteLineWithDepth(1, fmt.Sprintf(`class "%s" as %s <<external>>`, t, externalStubNameRegexp.ReplaceAllString(t, "_")))
+	}
+	str.WriteLineWithDepth(0, `}`)
+}
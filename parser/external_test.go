@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestExternalTypes(t *testing.T) {
+	tt := []struct {
+		Name          string
+		ExternalTypes ExternalTypes
+		Expected      []string
+		Unexpected    []string
+	}{
+		{
+			Name:          "Implicit",
+			ExternalTypes: ImplicitExternalTypes,
+			Expected: []string{
+				`"sync.Mutex" *-- "externalstubs.Cache"`,
+				`"externalstubs.Cache" o-- "0..1" "http.Client"`,
+			},
+			Unexpected: []string{"namespace external {"},
+		},
+		{
+			Name:          "Drop",
+			ExternalTypes: DropExternalTypes,
+			Expected: []string{
+				`"externalstubs.Cache" o-- "*" "externalstubs.Entry"`,
+				"+ Client *http.Client",
+			},
+			Unexpected: []string{"sync.Mutex", `"http.Client"`, "time.Time\"", "namespace external {"},
+		},
+		{
+			Name:          "Stub",
+			ExternalTypes: StubExternalTypes,
+			Expected: []string{
+				`namespace external {
+    class "http.Client" as http_Client <<external>>
+    class "http.Response" as http_Response <<external>>
+    class "sync.Mutex" as sync_Mutex <<external>>
+    class "time.Time" as time_Time <<external>>
+}`,
+				`"external.sync_Mutex" *-- "externalstubs.Cache"`,
+				`"externalstubs.Cache" o-- "0..1" "external.http_Client"`,
+				`"externalstubs.Entry" o-- "1" "external.time_Time"`,
+				`"externalstubs.Cache" o-- "*" "externalstubs.Entry"`,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:  afero.NewOsFs(),
+				Directories: []string{"../testingsupport/externalstubs"},
+				RenderingOptions: map[RenderingOption]interface{}{
+					RenderAggregations:  true,
+					RenderExternalTypes: tc.ExternalTypes,
+				},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			result := parser.Render()
+			for _, e := range tc.Expected {
+				if !strings.Contains(result, e) {
+					t.Errorf("Expected the diagram to contain %s, got\n%s", e, result)
+				}
+			}
+			for _, u := range tc.Unexpected {
+				if strings.Contains(result, u) {
+					t.Errorf("Expected the diagram not to contain %s, got\n%s", u, result)
+				}
+			}
+		})
+	}
+}
+
+func TestExternalTypesAreDeclaredOnce(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/externalstubs"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderAggregations:  true,
+			RenderExternalTypes: StubExternalTypes,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	if count := strings.Count(result, `class "http.Client" as http_Client <<external>>`); count != 1 {
+		t.Errorf("Expected http.Client to be declared once, got %d in\n%s", count, result)
+	}
+	if count := strings.Count(result, `"external.http_Client"`); count != 2 {
+		t.Errorf("Expected both Cache and Fetcher to aggregate http.Client, got %d in\n%s", count, result)
+	}
+}
@@ -5,21 +5,54 @@ import (
 	"strings"
 
 	"go/ast"
+	"go/token"
+	"go/types"
 )
 
 const packageConstant = "{packageName}"
 
-//Field can hold the name and type of any field
+//Field can hold the name and type of any field. ReferencedTypes contains the full names of the non primitive
+//types the field refers to and Multiplicity is "*" when the field holds a collection (slice, array, map...) of them.
+//Tag is the unquoted tag of struct fields, Embedded is true for the embedded types of structs and Position is where
+//struct fields are declared
 type Field struct {
-	Name     string
-	Type     string
-	FullType string
+	Name            string
+	Type            string
+	FullType        string
+	Multiplicity    string
+	ReferencedTypes []string
+	Tag             string
+	Embedded        bool
+	Position        token.Position
+}
+
+const (
+	oneMultiplicity      = "1"
+	optionalMultiplicity = "0..1"
+	manyMultiplicity     = "*"
+)
+
+// getMultiplicity returns the multiplicity of the types referenced by the given expression. Slices, arrays, maps,
+// channels and variadic parameters hold many elements, pointers hold zero or one and anything else holds one.
+func getMultiplicity(exp ast.Expr) string {
+	switch v := exp.(type) {
+	case *ast.StarExpr:
+		if getMultiplicity(v.X) == manyMultiplicity {
+			return manyMultiplicity
+		}
+		return optionalMultiplicity
+	case *ast.ParenExpr:
+		return getMultiplicity(v.X)
+	case *ast.ArrayType, *ast.MapType, *ast.Ellipsis, *ast.ChanType:
+		return manyMultiplicity
+	}
+	return oneMultiplicity
 }
 
 //Returns a string representation of the given expression if it was recognized.
 //Refer to the implementation to see the different string representations.
 func getFieldType(exp ast.Expr, aliases map[string]string) (string, []string) {
-	switch v := exp.(type) {
+	switch v := unparen(exp).(type) {
 	case *ast.Ident:
 		return getIdent(v, aliases)
 	case *ast.ArrayType:
@@ -40,8 +73,66 @@ func getFieldType(exp ast.Expr, aliases map[string]string) (string, []string) {
 		return getFuncType(v, aliases)
 	case *ast.Ellipsis:
 		return getEllipsis(v, aliases)
+	case *ast.IndexExpr:
+		return getIndexExpr(v, aliases)
+	case *ast.IndexListExpr:
+		return getIndexListExpr(v, aliases)
+	case *ast.UnaryExpr:
+		return getUnaryExpr(v, aliases)
+	case *ast.BinaryExpr:
+		return getBinaryExpr(v, aliases)
+	}
+	return unknownType, []string{}
+}
+
+// unparen returns the given expression without the parentheses around it, like (func(int) error) or (*Foo)
+func unparen(exp ast.Expr) ast.Expr {
+	for {
+		paren, ok := exp.(*ast.ParenExpr)
+		if !ok {
+			return exp
+		}
+		exp = paren.X
 	}
-	return "", []string{}
+}
+
+// unknownType is rendered for the type expressions getFieldType does not know, see findUnknownTypes
+const unknownType = "?unknown?"
+
+// getUnaryExpr returns the representation of an approximation element of a type set, like ~int
+func getUnaryExpr(v *ast.UnaryExpr, aliases map[string]string) (string, []string) {
+	t, fundamentalTypes := getFieldType(v.X, aliases)
+	return v.Op.String() + t, fundamentalTypes
+}
+
+// getBinaryExpr returns the representation of a union of the elements of a type set, like ~int | ~float64
+func getBinaryExpr(v *ast.BinaryExpr, aliases map[string]string) (string, []string) {
+	t1, f1 := getFieldType(v.X, aliases)
+	t2, f2 := getFieldType(v.Y, aliases)
+	return fmt.Sprintf("%s %s %s", t1, v.Op.String(), t2), append(f1, f2...)
+}
+
+// findUnknownTypes returns the parts of the types in the given node that getFieldType renders as unknownType. The
+// lengths of the arrays and the names and tags of the fields are not types so they are not looked at.
+func findUnknownTypes(node ast.Node) []ast.Expr {
+	var unknown []ast.Expr
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch v := n.(type) {
+		case *ast.ArrayType:
+			unknown = append(unknown, findUnknownTypes(v.Elt)...)
+			return false
+		case *ast.Field:
+			unknown = append(unknown, findUnknownTypes(v.Type)...)
+			return false
+		case ast.Expr:
+			if t, _ := getFieldType(v, nil); t == unknownType {
+				unknown = append(unknown, v)
+				return false
+			}
+		}
+		return true
+	})
+	return unknown
 }
 
 func getIdent(v *ast.Ident, aliases map[string]string) (string, []string) {
@@ -53,9 +144,18 @@ func getIdent(v *ast.Ident, aliases map[string]string) (string, []string) {
 	return t, []string{t}
 }
 
+// getArrayType returns the representation of a slice or of an array keeping its length, like [16]byte
 func getArrayType(v *ast.ArrayType, aliases map[string]string) (string, []string) {
 	t, fundamentalTypes := getFieldType(v.Elt, aliases)
-	return fmt.Sprintf("[]%s", t), fundamentalTypes
+	return getArrayPrefix(v) + t, fundamentalTypes
+}
+
+// getArrayPrefix returns [] for slices and the length of arrays between brackets, as written in the code, for arrays
+func getArrayPrefix(v *ast.ArrayType) string {
+	if v.Len == nil {
+		return "[]"
+	}
+	return fmt.Sprintf("[%s]", types.ExprString(v.Len))
 }
 
 func getSelectorExp(v *ast.SelectorExpr, aliases map[string]string) (string, []string) {
@@ -81,20 +181,83 @@ func getStarExp(v *ast.StarExpr, aliases map[string]string) (string, []string) {
 	return fmt.Sprintf("*%s", t), f
 }
 
+// getChanType returns the representation of a channel keeping its direction. The types sent through channels are
+// not returned as fundamental types so they are not aggregated, see getChannelTypes()
 func getChanType(v *ast.ChanType, aliases map[string]string) (string, []string) {
 
-	t, f := getFieldType(v.Value, aliases)
-	return fmt.Sprintf("<font color=blue>chan</font> %s", t), f
+	t, _ := getFieldType(v.Value, aliases)
+	switch v.Dir {
+	case ast.SEND:
+		return fmt.Sprintf("<font color=blue>chan</font><- %s", t), []string{}
+	case ast.RECV:
+		return fmt.Sprintf("<-<font color=blue>chan</font> %s", t), []string{}
+	}
+	if value, ok := unparen(v.Value).(*ast.ChanType); ok && value.Dir == ast.RECV {
+		// chan <-chan T would be read as chan<- chan T
+		t = fmt.Sprintf("(%s)", t)
+	}
+	return fmt.Sprintf("<font color=blue>chan</font> %s", t), []string{}
+}
+
+// getChannelTypes returns the fundamental types sent through the channels of the given expression. Channels in
+// function signatures and inline structs or interfaces are not taken into account.
+func getChannelTypes(exp ast.Expr, aliases map[string]string) []string {
+	var result []string
+	ast.Inspect(exp, func(n ast.Node) bool {
+		switch v := n.(type) {
+		case *ast.FuncType, *ast.StructType, *ast.InterfaceType:
+			return false
+		case *ast.ChanType:
+			_, f := getFieldType(v.Value, aliases)
+			result = append(result, f...)
+		}
+		return true
+	})
+	return result
 }
 
+// maxAnonymousStructDepth is how many anonymous structs nested in each other are rendered. The ones nested deeper are
+// rendered as struct{...}
+const maxAnonymousStructDepth = 3
+
+// getStructType returns the compact, single line, representation of an anonymous struct with the names and types of
+// its fields, like struct{Created time.Time; Updated time.Time}
 func getStructType(v *ast.StructType, aliases map[string]string) (string, []string) {
+	return getNestedStructType(v, aliases, 1), []string{}
+}
 
+// getNestedStructType returns the representation of an anonymous struct nested in the given number of anonymous
+// structs, including itself
+func getNestedStructType(v *ast.StructType, aliases map[string]string, depth int) string {
+	if depth > maxAnonymousStructDepth {
+		return "<font color=blue>struct</font>{...}"
+	}
 	fieldList := make([]string, 0)
 	for _, field := range v.Fields.List {
-		t, _ := getFieldType(field.Type, aliases)
-		fieldList = append(fieldList, t)
+		t := getStructFieldType(field.Type, aliases, depth)
+		if len(field.Names) == 0 {
+			fieldList = append(fieldList, t)
+		}
+		for _, name := range field.Names {
+			fieldList = append(fieldList, fmt.Sprintf("%s %s", name.Name, t))
+		}
+	}
+	return fmt.Sprintf("<font color=blue>struct</font>{%s}", strings.Join(fieldList, "; "))
+}
+
+// getStructFieldType returns the type of a field of an anonymous struct nested in the given number of anonymous
+// structs, keeping track of the depth of the anonymous structs used directly or through pointers, slices and arrays
+func getStructFieldType(exp ast.Expr, aliases map[string]string, depth int) string {
+	switch v := unparen(exp).(type) {
+	case *ast.StructType:
+		return getNestedStructType(v, aliases, depth+1)
+	case *ast.StarExpr:
+		return "*" + getStructFieldType(v.X, aliases, depth)
+	case *ast.ArrayType:
+		return getArrayPrefix(v) + getStructFieldType(v.Elt, aliases, depth)
 	}
-	return fmt.Sprintf("<font color=blue>struct</font>{%s}", strings.Join(fieldList, ", ")), []string{}
+	t, _ := getFieldType(exp, aliases)
+	return t
 }
 
 func getInterfaceType(v *ast.InterfaceType, aliases map[string]string) (string, []string) {
@@ -111,6 +274,7 @@ func getInterfaceType(v *ast.InterfaceType, aliases map[string]string) (string,
 	return fmt.Sprintf("<font color=blue>interface</font>{%s}", strings.Join(methods, "; ")), []string{}
 }
 
+// getFuncType returns the compact signature of a function type, without parameter and return value names
 func getFuncType(v *ast.FuncType, aliases map[string]string) (string, []string) {
 
 	function := getFunction(v, "", aliases, "")
@@ -119,21 +283,42 @@ func getFuncType(v *ast.FuncType, aliases map[string]string) (string, []string)
 		params = append(params, pa.Type)
 	}
 	returns := ""
-	returnList := make([]string, 0)
-	for _, re := range function.ReturnValues {
-		returnList = append(returnList, re)
+	switch len(function.ReturnValues) {
+	case 0:
+	case 1:
+		returns = " " + function.ReturnValues[0]
+	default:
+		returns = fmt.Sprintf(" (%s)", strings.Join(function.ReturnValues, ", "))
 	}
-	if len(returnList) > 1 {
-		returns = fmt.Sprintf("(%s)", strings.Join(returnList, ", "))
-	} else {
-		returns = strings.Join(returnList, "")
-	}
-	return fmt.Sprintf("<font color=blue>func</font>(%s) %s", strings.Join(params, ", "), returns), []string{}
+	return fmt.Sprintf("<font color=blue>func</font>(%s)%s", strings.Join(params, ", "), returns), []string{}
 }
 
+// getEllipsis returns the representation of a variadic parameter. Its element types are returned as fundamental
+// types the same way slices do.
 func getEllipsis(v *ast.Ellipsis, aliases map[string]string) (string, []string) {
-	t, _ := getFieldType(v.Elt, aliases)
-	return fmt.Sprintf("...%s", t), []string{}
+	t, fundamentalTypes := getFieldType(v.Elt, aliases)
+	return fmt.Sprintf("...%s", t), fundamentalTypes
+}
+
+// getIndexExpr returns the representation of a generic type instantiated with a single type argument
+func getIndexExpr(v *ast.IndexExpr, aliases map[string]string) (string, []string) {
+	return getGenericType(v.X, []ast.Expr{v.Index}, aliases)
+}
+
+// getIndexListExpr returns the representation of a generic type instantiated with several type arguments
+func getIndexListExpr(v *ast.IndexListExpr, aliases map[string]string) (string, []string) {
+	return getGenericType(v.X, v.Indices, aliases)
+}
+
+func getGenericType(genericType ast.Expr, typeArguments []ast.Expr, aliases map[string]string) (string, []string) {
+	t, fundamentalTypes := getFieldType(genericType, aliases)
+	argumentList := make([]string, 0, len(typeArguments))
+	for _, argument := range typeArguments {
+		a, f := getFieldType(argument, aliases)
+		argumentList = append(argumentList, a)
+		fundamentalTypes = append(fundamentalTypes, f...)
+	}
+	return fmt.Sprintf("%s[%s]", t, strings.Join(argumentList, ", ")), fundamentalTypes
 }
 
 var globalPrimitives = map[string]struct{}{
@@ -157,6 +342,7 @@ var globalPrimitives = map[string]struct{}{
 	"complex64":   {},
 	"complex128":  {},
 	"error":       {},
+	"any":         {},
 	"*bool":       {},
 	"*string":     {},
 	"*int":        {},
@@ -188,9 +374,15 @@ func isPrimitiveString(t string) bool {
 	return ok
 }
 
+// isPrivate returns true if the given identifier is not exported. Only identifiers starting with an upper case
+// letter are exported so names starting with other characters like _, and empty names, are private.
+func isPrivate(name string) bool {
+	return !token.IsExported(name)
+}
+
 func replacePackageConstant(field, packageName string) string {
 	if packageName != "" {
 		packageName = fmt.Sprintf("%s.", packageName)
 	}
-	return strings.Replace(field, packageConstant, packageName, 1)
+	return strings.ReplaceAll(field, packageConstant, packageName)
 }
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"go/ast"
+	"go/parser"
+	"go/token"
 )
 
 type NoMatchField struct {
@@ -45,6 +47,50 @@ func TestGetFieldType(t *testing.T) {
 			},
 			ExpectedFundamentalTypes: []string{},
 		},
+		{
+			Name:           "Test *ast.ArrayType with a length",
+			ExpectedResult: "[16]byte",
+			InputField: &ast.ArrayType{
+				Len: &ast.BasicLit{Kind: token.INT, Value: "16"},
+				Elt: &ast.Ident{
+					Name: "byte",
+				},
+			},
+			ExpectedFundamentalTypes: []string{},
+		},
+		{
+			Name:           "Test multidimensional *ast.ArrayType",
+			ExpectedResult: "[4][4]float64",
+			InputField: &ast.ArrayType{
+				Len: &ast.BasicLit{Kind: token.INT, Value: "4"},
+				Elt: &ast.ArrayType{
+					Len: &ast.BasicLit{Kind: token.INT, Value: "4"},
+					Elt: &ast.Ident{
+						Name: "float64",
+					},
+				},
+			},
+			ExpectedFundamentalTypes: []string{},
+		},
+		{
+			Name:           "Test pointer to *ast.ArrayType with a constant length",
+			ExpectedResult: fmt.Sprintf("*[size * 2]*%sTestClass", packageConstant),
+			InputField: &ast.StarExpr{
+				X: &ast.ArrayType{
+					Len: &ast.BinaryExpr{
+						X:  &ast.Ident{Name: "size"},
+						Op: token.MUL,
+						Y:  &ast.BasicLit{Kind: token.INT, Value: "2"},
+					},
+					Elt: &ast.StarExpr{
+						X: &ast.Ident{
+							Name: "TestClass",
+						},
+					},
+				},
+			},
+			ExpectedFundamentalTypes: []string{fmt.Sprintf("%s%s", packageConstant, "TestClass")},
+		},
 		{
 			Name:           "Test *ast.SelectorExpr",
 			ExpectedResult: "goplantuml.TestClass",
@@ -91,9 +137,86 @@ func TestGetFieldType(t *testing.T) {
 			},
 			ExpectedFundamentalTypes: []string{},
 		},
+		{
+			Name:           "Test receive only *ast.ChanType",
+			ExpectedResult: "<-<font color=blue>chan</font> string",
+			InputField: &ast.ChanType{
+				Dir: ast.RECV,
+				Value: &ast.Ident{
+					Name: "string",
+				},
+			},
+			ExpectedFundamentalTypes: []string{},
+		},
+		{
+			Name:           "Test send only *ast.ChanType",
+			ExpectedResult: "<font color=blue>chan</font><- error",
+			InputField: &ast.ChanType{
+				Dir: ast.SEND,
+				Value: &ast.Ident{
+					Name: "error",
+				},
+			},
+			ExpectedFundamentalTypes: []string{},
+		},
+		{
+			Name:           "Test *ast.ChanType of pointers",
+			ExpectedResult: fmt.Sprintf("<font color=blue>chan</font> *%sTestClass", packageConstant),
+			InputField: &ast.ChanType{
+				Dir: ast.SEND | ast.RECV,
+				Value: &ast.StarExpr{
+					X: &ast.Ident{
+						Name: "TestClass",
+					},
+				},
+			},
+			ExpectedFundamentalTypes: []string{},
+		},
+		{
+			Name:           "Test slice of *ast.ChanType",
+			ExpectedResult: "[]<font color=blue>chan</font> int",
+			InputField: &ast.ArrayType{
+				Elt: &ast.ChanType{
+					Dir: ast.SEND | ast.RECV,
+					Value: &ast.Ident{
+						Name: "int",
+					},
+				},
+			},
+			ExpectedFundamentalTypes: []string{},
+		},
+		{
+			Name:           "Test *ast.ChanType of slices of pointers",
+			ExpectedResult: fmt.Sprintf("<font color=blue>chan</font> []*%sTestClass", packageConstant),
+			InputField: &ast.ChanType{
+				Dir: ast.SEND | ast.RECV,
+				Value: &ast.ArrayType{
+					Elt: &ast.StarExpr{
+						X: &ast.Ident{
+							Name: "TestClass",
+						},
+					},
+				},
+			},
+			ExpectedFundamentalTypes: []string{},
+		},
+		{
+			Name:           "Test *ast.ChanType of receive only channels",
+			ExpectedResult: "<font color=blue>chan</font> (<-<font color=blue>chan</font> int)",
+			InputField: &ast.ChanType{
+				Dir: ast.SEND | ast.RECV,
+				Value: &ast.ChanType{
+					Dir: ast.RECV,
+					Value: &ast.Ident{
+						Name: "int",
+					},
+				},
+			},
+			ExpectedFundamentalTypes: []string{},
+		},
 		{
 			Name:           "Test *ast.StructType",
-			ExpectedResult: "<font color=blue>struct</font>{int, string}",
+			ExpectedResult: "<font color=blue>struct</font>{int; string}",
 			InputField: &ast.StructType{
 				Fields: &ast.FieldList{
 					List: []*ast.Field{
@@ -195,6 +318,24 @@ func TestGetFieldType(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:                     "Test *ast.FuncType without results",
+			ExpectedResult:           "<font color=blue>func</font>(...string)",
+			ExpectedFundamentalTypes: []string{},
+			InputField: &ast.FuncType{
+				Params: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Type: &ast.Ellipsis{
+								Elt: &ast.Ident{
+									Name: "string",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:                     "Test *ast.FuncType with two results",
 			ExpectedResult:           "<font color=blue>func</font>(*FooComposed) (*FooComposed, *string)",
@@ -240,7 +381,7 @@ func TestGetFieldType(t *testing.T) {
 		},
 		{
 			Name:                     "Test not match field type",
-			ExpectedResult:           "",
+			ExpectedResult:           unknownType,
 			ExpectedFundamentalTypes: []string{},
 			InputField:               &NoMatchField{},
 		},
@@ -254,6 +395,108 @@ func TestGetFieldType(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:                     "Test *ast.Ellipsis of any",
+			ExpectedResult:           "...any",
+			ExpectedFundamentalTypes: []string{},
+			InputField: &ast.Ellipsis{
+				Elt: &ast.Ident{
+					Name: "any",
+				},
+			},
+		},
+		{
+			Name:                     "Test *ast.Ellipsis of pointers to named types",
+			ExpectedResult:           fmt.Sprintf("...*%sTestClass", packageConstant),
+			ExpectedFundamentalTypes: []string{fmt.Sprintf("%sTestClass", packageConstant)},
+			InputField: &ast.Ellipsis{
+				Elt: &ast.StarExpr{
+					X: &ast.Ident{
+						Name: "TestClass",
+					},
+				},
+			},
+		},
+		{
+			Name:                     "Test *ast.IndexExpr",
+			ExpectedResult:           fmt.Sprintf("%sList[int]", packageConstant),
+			ExpectedFundamentalTypes: []string{fmt.Sprintf("%sList", packageConstant)},
+			InputField: &ast.IndexExpr{
+				X: &ast.Ident{
+					Name: "List",
+				},
+				Index: &ast.Ident{
+					Name: "int",
+				},
+			},
+		},
+		{
+			Name:                     "Test *ast.IndexListExpr",
+			ExpectedResult:           fmt.Sprintf("goplantuml.Map[string, %sFoo]", packageConstant),
+			ExpectedFundamentalTypes: []string{"goplantuml.Map", fmt.Sprintf("%sFoo", packageConstant)},
+			InputField: &ast.IndexListExpr{
+				X: &ast.SelectorExpr{
+					X: &ast.Ident{
+						Name: "puml",
+					},
+					Sel: &ast.Ident{
+						Name: "Map",
+					},
+				},
+				Indices: []ast.Expr{
+					&ast.Ident{
+						Name: "string",
+					},
+					&ast.Ident{
+						Name: "Foo",
+					},
+				},
+			},
+		},
+		{
+			Name:                     "Test type set union",
+			ExpectedResult:           "~int | goplantuml.Number",
+			ExpectedFundamentalTypes: []string{"goplantuml.Number"},
+			InputField: &ast.BinaryExpr{
+				X: &ast.UnaryExpr{
+					Op: token.TILDE,
+					X:  &ast.Ident{Name: "int"},
+				},
+				Op: token.OR,
+				Y: &ast.SelectorExpr{
+					X:   &ast.Ident{Name: "puml"},
+					Sel: &ast.Ident{Name: "Number"},
+				},
+			},
+		},
+		{
+			Name:                     "Test *ast.ParenExpr",
+			ExpectedResult:           fmt.Sprintf("*%sTestClass", packageConstant),
+			ExpectedFundamentalTypes: []string{fmt.Sprintf("%sTestClass", packageConstant)},
+			InputField: &ast.ParenExpr{
+				X: &ast.StarExpr{
+					X: &ast.ParenExpr{
+						X: &ast.Ident{
+							Name: "TestClass",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "Test *ast.ChanType of a parenthesized receive only channel",
+			ExpectedResult:           "<font color=blue>chan</font> (<-<font color=blue>chan</font> int)",
+			ExpectedFundamentalTypes: []string{},
+			InputField: &ast.ChanType{
+				Dir: ast.SEND | ast.RECV,
+				Value: &ast.ParenExpr{
+					X: &ast.ChanType{
+						Dir:   ast.RECV,
+						Value: &ast.Ident{Name: "int"},
+					},
+				},
+			},
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.Name, func(t *testing.T) {
@@ -272,8 +515,216 @@ func TestGetFieldType(t *testing.T) {
 	}
 }
 
+func TestGetMapType(t *testing.T) {
+	tt := []struct {
+		Name                     string
+		Input                    string
+		ExpectedResult           string
+		ExpectedFundamentalTypes []string
+	}{
+		{
+			Name:                     "Primitive key and value",
+			Input:                    "map[string]int",
+			ExpectedResult:           "<font color=blue>map</font>[string]int",
+			ExpectedFundamentalTypes: []string{},
+		},
+		{
+			Name:                     "Pointer value",
+			Input:                    "map[Key]*Value",
+			ExpectedResult:           "<font color=blue>map</font>[main.Key]*main.Value",
+			ExpectedFundamentalTypes: []string{"main.Key", "main.Value"},
+		},
+		{
+			Name:                     "Pointer key and slice value",
+			Input:                    "map[*Key][]Value",
+			ExpectedResult:           "<font color=blue>map</font>[*main.Key][]main.Value",
+			ExpectedFundamentalTypes: []string{"main.Key", "main.Value"},
+		},
+		{
+			Name:                     "Qualified key and value",
+			Input:                    "map[pkg.Key]*pkg.Value",
+			ExpectedResult:           "<font color=blue>map</font>[pkg.Key]*pkg.Value",
+			ExpectedFundamentalTypes: []string{"pkg.Key", "pkg.Value"},
+		},
+		{
+			Name:                     "Aliased import",
+			Input:                    "map[p.Key]p.Value",
+			ExpectedResult:           "<font color=blue>map</font>[pkg.Key]pkg.Value",
+			ExpectedFundamentalTypes: []string{"pkg.Key", "pkg.Value"},
+		},
+		{
+			Name:                     "Nested maps",
+			Input:                    "map[string]map[Key][]*Value",
+			ExpectedResult:           "<font color=blue>map</font>[string]<font color=blue>map</font>[main.Key][]*main.Value",
+			ExpectedFundamentalTypes: []string{"main.Key", "main.Value"},
+		},
+		{
+			Name:                     "Array key",
+			Input:                    "map[[2]Key]Value",
+			ExpectedResult:           "<font color=blue>map</font>[[2]main.Key]main.Value",
+			ExpectedFundamentalTypes: []string{"main.Key", "main.Value"},
+		},
+		{
+			Name:                     "Generic key",
+			Input:                    "map[Pair[Key, string]]Value",
+			ExpectedResult:           "<font color=blue>map</font>[main.Pair[main.Key, string]]main.Value",
+			ExpectedFundamentalTypes: []string{"main.Pair", "main.Key", "main.Value"},
+		},
+		{
+			Name:                     "Generic value with a map argument",
+			Input:                    "map[*pkg.Key][]*pkg.List[map[string]Value]",
+			ExpectedResult:           "<font color=blue>map</font>[*pkg.Key][]*pkg.List[<font color=blue>map</font>[string]main.Value]",
+			ExpectedFundamentalTypes: []string{"pkg.Key", "pkg.List", "main.Value"},
+		},
+		{
+			Name:                     "Pointer to a map",
+			Input:                    "*map[Key]Value",
+			ExpectedResult:           "*<font color=blue>map</font>[main.Key]main.Value",
+			ExpectedFundamentalTypes: []string{"main.Key", "main.Value"},
+		},
+		{
+			Name:                     "Slice of maps",
+			Input:                    "[]map[Key]Value",
+			ExpectedResult:           "[]<font color=blue>map</font>[main.Key]main.Value",
+			ExpectedFundamentalTypes: []string{"main.Key", "main.Value"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tc.Input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, fundamentalTypes := getFieldType(expr, map[string]string{"p": "pkg"})
+			if result = replacePackageConstant(result, "main"); result != tc.ExpectedResult {
+				t.Errorf("Expected %s got %s", tc.ExpectedResult, result)
+			}
+			resultTypes := []string{}
+			for _, fundamentalType := range fundamentalTypes {
+				resultTypes = append(resultTypes, replacePackageConstant(fundamentalType, "main"))
+			}
+			if !reflect.DeepEqual(resultTypes, tc.ExpectedFundamentalTypes) {
+				t.Errorf("Expected the fundamental types %v got %v", tc.ExpectedFundamentalTypes, resultTypes)
+			}
+		})
+	}
+}
+
 func TestIsPrimitiveStringPointer(t *testing.T) {
 	if !isPrimitiveString("*int") {
 		t.Errorf("TestIsPrimitiveStringPointer: expecting true, got false")
 	}
 }
+
+func TestUnparen(t *testing.T) {
+	ident := &ast.Ident{Name: "Foo"}
+	if result := unparen(&ast.ParenExpr{X: &ast.ParenExpr{X: ident}}); result != ident {
+		t.Errorf("Expected the parentheses to be removed, got %v", result)
+	}
+	star := &ast.StarExpr{X: &ast.ParenExpr{X: ident}}
+	if result := unparen(star); result != star {
+		t.Errorf("Expected only the outer parentheses to be removed, got %v", result)
+	}
+}
+
+func TestGetMultiplicity(t *testing.T) {
+	tt := []struct {
+		Name           string
+		Input          ast.Expr
+		ExpectedResult string
+	}{
+		{
+			Name:           "Ident",
+			Input:          &ast.Ident{Name: "Foo"},
+			ExpectedResult: "1",
+		},
+		{
+			Name:           "Selector",
+			Input:          &ast.SelectorExpr{X: &ast.Ident{Name: "pkg"}, Sel: &ast.Ident{Name: "Foo"}},
+			ExpectedResult: "1",
+		},
+		{
+			Name:           "Pointer",
+			Input:          &ast.StarExpr{X: &ast.Ident{Name: "Foo"}},
+			ExpectedResult: "0..1",
+		},
+		{
+			Name:           "Channel",
+			Input:          &ast.ChanType{Value: &ast.Ident{Name: "Foo"}},
+			ExpectedResult: "*",
+		},
+		{
+			Name:           "Slice",
+			Input:          &ast.ArrayType{Elt: &ast.Ident{Name: "Foo"}},
+			ExpectedResult: "*",
+		},
+		{
+			Name:           "Pointer to slice",
+			Input:          &ast.StarExpr{X: &ast.ArrayType{Elt: &ast.Ident{Name: "Foo"}}},
+			ExpectedResult: "*",
+		},
+		{
+			Name:           "Map",
+			Input:          &ast.MapType{Key: &ast.Ident{Name: "string"}, Value: &ast.Ident{Name: "Foo"}},
+			ExpectedResult: "*",
+		},
+		{
+			Name:           "Variadic",
+			Input:          &ast.Ellipsis{Elt: &ast.Ident{Name: "Foo"}},
+			ExpectedResult: "*",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if result := getMultiplicity(tc.Input); result != tc.ExpectedResult {
+				t.Errorf("Expected %s got %s", tc.ExpectedResult, result)
+			}
+		})
+	}
+}
+
+func TestIsPrivate(t *testing.T) {
+	tt := []struct {
+		Name           string
+		ExpectedResult bool
+	}{
+		{Name: "Exported", ExpectedResult: false},
+		{Name: "unexported", ExpectedResult: true},
+		{Name: "Ñandú", ExpectedResult: false},
+		{Name: "ñandú", ExpectedResult: true},
+		{Name: "Δέλτα", ExpectedResult: false},
+		{Name: "名前", ExpectedResult: true},
+		{Name: "_Underscore", ExpectedResult: true},
+		{Name: "", ExpectedResult: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if result := isPrivate(tc.Name); result != tc.ExpectedResult {
+				t.Errorf("Expected isPrivate(%q) to be %t, got %t", tc.Name, tc.ExpectedResult, result)
+			}
+		})
+	}
+}
+
+func TestFindUnknownTypes(t *testing.T) {
+	unknown := &NoMatchField{}
+	node := &ast.StructType{
+		Fields: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Names: []*ast.Ident{{Name: "Values"}},
+					// The length of an array is not a type
+					Type: &ast.ArrayType{Len: &ast.BasicLit{Kind: token.INT, Value: "4"}, Elt: unknown},
+					Tag:  &ast.BasicLit{Kind: token.STRING, Value: "`json:\"values\"`"},
+				},
+				{
+					Names: []*ast.Ident{{Name: "Size"}},
+					Type:  &ast.Ident{Name: "int"},
+				},
+			},
+		},
+	}
+	if result := findUnknownTypes(node); !reflect.DeepEqual(result, []ast.Expr{unknown}) {
+		t.Errorf("Expected only the element of the array to be unknown, got %v", result)
+	}
+}
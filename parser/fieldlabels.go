@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"strings"
+)
+
+// FieldLabels selects how the aggregations are labeled with the names of the fields that reference the aggregated
+// type, which tells apart the fields of a struct that have the same type
+type FieldLabels int
+
+const (
+	// NoFieldLabels renders the aggregations without the names of the fields. It is the default
+	NoFieldLabels FieldLabels = iota
+	// JoinedFieldLabels renders a single aggregation for every aggregated type labeled with the comma separated names
+	// of all the fields that reference it
+	JoinedFieldLabels
+	// SplitFieldLabels renders an aggregation for every field, labeled with its name and with its own multiplicity
+	SplitFieldLabels
+)
+
+// getAggregatingFields returns the rendered fields of the structure that reference the aggregated type in the order
+// they are declared. The fields rendered as associations are skipped, see getPointerAssociations.
+func (p *ClassParser) getAggregatingFields(structure *Struct, aggregated string) []*Field {
+	var result []*Field
+	for _, field := range structure.Fields {
+		if !p.isAggregatingField(field) || p.isPointerAssociation(field) {
+			continue
+		}
+		for _, t := range field.ReferencedTypes {
+			if t == aggregated {
+				result = append(result, field)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// getFieldNames returns the comma separated names of the given fields
+func getFieldNames(fields []*Field) string {
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+	return strings.Join(names, ", ")
+}
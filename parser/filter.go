@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// compileFilters compiles the Include and Exclude options
+func (p *ClassParser) compileFilters() error {
+	var err error
+	if p.options.Include != "" {
+		if p.include, err = regexp.Compile(p.options.Include); err != nil {
+			return fmt.Errorf("invalid include expression: %w", err)
+		}
+	}
+	if p.options.Exclude != "" {
+		if p.exclude, err = regexp.Compile(p.options.Exclude); err != nil {
+			return fmt.Errorf("invalid exclude expression: %w", err)
+		}
+	}
+	return nil
+}
+
+// isFilteredOut returns true if the given fully qualified type does not match the include expression or matches
+// the exclude one
+func (p *ClassParser) isFilteredOut(fullName string) bool {
+	if p.include != nil && !p.include.MatchString(fullName) {
+		return true
+	}
+	return p.exclude != nil && p.exclude.MatchString(fullName)
+}
+
+// filterTypes removes the types filtered out by the Include and Exclude options from the parsed structure together
+// with every relationship that references them. It is applied on the parsed structure so every output benefits.
+func (p *ClassParser) filterTypes() {
+	if p.include == nil && p.exclude == nil {
+		return
+	}
+	p.removeTypes(p.isFilteredOut)
+}
+
+// removeTypes removes the types whose fully qualified name is matched by the given function from the parsed
+// structure together with every relationship that references them
+func (p *ClassParser) removeTypes(matches func(fullName string) bool) {
+	removed := map[string]struct{}{}
+	for pack, structures := range p.structure {
+		for name := range structures {
+			fullName := getFullTypeName(pack, name)
+			if matches(fullName) {
+				removed[fullName] = struct{}{}
+				delete(structures, name)
+				delete(p.allStructs, fullName)
+				delete(p.allInterfaces, fullName)
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	for _, structures := range p.structure {
+		for _, st := range structures {
+			removeReferences(st.Composition, removed, st)
+			removeReferences(st.Extends, removed, st)
+			removeReferences(st.Implements, removed, st)
+			removeReferences(st.PointerImplements, removed, st)
+			removeReferences(st.Aggregations, removed, st)
+			removeReferences(st.PrivateAggregations, removed, st)
+		}
+	}
+	for name, alias := range p.allAliases {
+		_, aliasRemoved := removed[alias.AliasOf]
+		_, aliasedRemoved := removed[alias.Name]
+		if aliasRemoved || aliasedRemoved {
+			delete(p.allAliases, name)
+		}
+	}
+}
+
+// getFullTypeName returns package.TypeName for the given structure name unless it is qualified already
+func getFullTypeName(pack string, name string) string {
+	if strings.HasPrefix(name, pack+".") {
+		return name
+	}
+	return fmt.Sprintf("%s.%s", pack, name)
+}
+
+// removeReferences deletes from the given relationships the ones pointing at removed types
+func removeReferences(relationships map[string]struct{}, removed map[string]struct{}, st *Struct) {
+	for r := range relationships {
+		if _, ok := removed[getEmbeddedTypeName(r, st)]; ok {
+			delete(relationships, r)
+		}
+	}
+}
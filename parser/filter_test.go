@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestFilterTypes(t *testing.T) {
+	tt := []struct {
+		Name              string
+		Directory         string
+		Include           string
+		Exclude           string
+		ExpectedTypes     []string
+		ExpectedRelations map[string][]string
+		ExpectedError     bool
+	}{
+		{
+			Name:          "No filters",
+			Directory:     "../testingsupport/crosspackage",
+			ExpectedTypes: []string{"domain.Entity", "domain.Repository", "legacy.Entity", "store.LegacyStore", "store.Store"},
+		},
+		{
+			Name:          "Include",
+			Directory:     "../testingsupport/crosspackage",
+			Include:       `^domain\.`,
+			ExpectedTypes: []string{"domain.Entity", "domain.Repository"},
+		},
+		{
+			Name:          "Exclude",
+			Directory:     "../testingsupport/crosspackage",
+			Exclude:       `^store\.`,
+			ExpectedTypes: []string{"domain.Entity", "domain.Repository", "legacy.Entity"},
+		},
+		{
+			Name:          "Include is applied before exclude",
+			Directory:     "../testingsupport/crosspackage",
+			Include:       `^(domain|store)\.`,
+			Exclude:       `Entity$`,
+			ExpectedTypes: []string{"domain.Repository", "store.LegacyStore", "store.Store"},
+			ExpectedRelations: map[string][]string{
+				"store.Store implements":           {"domain.Repository"},
+				"store.Store private aggregations": {},
+			},
+		},
+		{
+			Name:          "Extends chain",
+			Directory:     "../testingsupport/interfacecomposition",
+			Exclude:       `\.Reader$`,
+			ExpectedTypes: []string{"interfacecomposition.Closer", "interfacecomposition.ExternalReadCloser", "interfacecomposition.File", "interfacecomposition.OnlyReader", "interfacecomposition.ReadCloser"},
+			ExpectedRelations: map[string][]string{
+				"interfacecomposition.ReadCloser extends": {"interfacecomposition.Closer"},
+				"interfacecomposition.File implements":    {"interfacecomposition.Closer", "interfacecomposition.ReadCloser"},
+			},
+		},
+		{
+			Name:          "Invalid expression",
+			Directory:     "../testingsupport/crosspackage",
+			Include:       `(`,
+			ExpectedError: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{tc.Directory},
+				Recursive:        true,
+				RenderingOptions: map[RenderingOption]interface{}{},
+				Include:          tc.Include,
+				Exclude:          tc.Exclude,
+			})
+			if (err != nil) != tc.ExpectedError {
+				t.Fatalf("Expected error to be %t, got %v", tc.ExpectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			types := []string{}
+			for pack, structures := range parser.structure {
+				for name := range structures {
+					types = append(types, getFullTypeName(pack, name))
+				}
+			}
+			sort.Strings(types)
+			if !reflect.DeepEqual(types, tc.ExpectedTypes) {
+				t.Errorf("Expected types %v, got %v", tc.ExpectedTypes, types)
+			}
+			for key, expected := range tc.ExpectedRelations {
+				split := strings.SplitN(key, " ", 2)
+				st := parser.getStruct(split[0])
+				relationships := map[string]map[string]struct{}{
+					"implements":           st.Implements,
+					"extends":              st.Extends,
+					"private aggregations": st.PrivateAggregations,
+				}[split[1]]
+				result := []string{}
+				for r := range relationships {
+					result = append(result, r)
+				}
+				sort.Strings(result)
+				if !reflect.DeepEqual(result, expected) {
+					t.Errorf("Expected %s to be %v, got %v", key, expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterTypesRender(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/interfacecomposition"},
+		RenderingOptions: map[RenderingOption]interface{}{},
+		Exclude:          `\.Reader$`,
+	})
+	if err != nil {
+		t.Fatalf("TestFilterTypesRender: expected no error but got %s", err.Error())
+	}
+	if result := parser.Render(); strings.Contains(result, "interfacecomposition.Reader") {
+		t.Errorf("TestFilterTypesRender: expected excluded types not to be rendered, got \n%s\n", result)
+	}
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// getFlatPackageName returns the name of the given package written in the flat diagrams, shortened with the
+// NamespaceSegments rendering option
+func (p *ClassParser) getFlatPackageName(packageName string) string {
+	if namespace, ok := p.namespaces[packageName]; ok {
+		return namespace
+	}
+	return packageName
+}
+
+// getFlatName returns the identifier of the class of the given fully qualified type in the flat diagrams, made of its
+// package and its name joined by an underscore, like parser_Struct
+func (p *ClassParser) getFlatName(fullName string) string {
+	packageName, name := splitFullTypeName(fullName)
+	if packageName == "" {
+		return getDiagramPackageName(fullName)
+	}
+	return getDiagramPackageName(p.getFlatPackageName(packageName) + "_" + name)
+}
+
+// getFlatLabel returns the name written for a class of the given package with the given label in the flat diagrams,
+// its package qualified label like parser.Struct
+func (p *ClassParser) getFlatLabel(pack string, label string) string {
+	return fmt.Sprintf("%s.%s", p.getFlatPackageName(pack), label)
+}
+
+// startPackage writes the opening line of the namespace of the given package, or nothing in the flat diagrams where
+// the classes of the package are written one level less indented instead
+func (p *ClassParser) startPackage(pack string, str *LineStringBuilder) {
+	if p.renderingOptions.Flat {
+		str.indentation--
+		return
+	}
+	str.WriteLineWithDepth(0, fmt.Sprintf(`namespace %s%s {`, p.getDisplayedPackageName(pack), p.getNamespaceColor(pack)))
+}
+
+// endPackage writes the closing line of the namespace started with startPackage
+func (p *ClassParser) endPackage(str *LineStringBuilder) {
+	if p.renderingOptions.Flat {
+		str.indentation++
+		return
+	}
+	str.WriteLineWithDepth(0, `}`)
+}
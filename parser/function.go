@@ -2,16 +2,24 @@ package parser
 
 import (
 	"go/ast"
+	"go/token"
 	"reflect"
+	"regexp"
 )
 
-//Function holds the signature of a function with name, Parameters and Return values
+//Function holds the signature of a function with name, Parameters and Return values. ReferencedTypes contains the
+//full names of the non primitive types used by its parameters and return values. Position is where the function is
+//declared and PointerReceiver is true for the methods declared on a pointer receiver
 type Function struct {
 	Name                 string
 	Parameters           []*Field
 	ReturnValues         []string
 	PackageName          string
 	FullNameReturnValues []string
+	ReturnValueNames     []string
+	ReferencedTypes      []string
+	Position             token.Position
+	PointerReceiver      bool
 }
 
 //SignturesAreEqual Returns true if the two functions have the same signature (parameter names are not checked)
@@ -43,7 +51,8 @@ func getFunction(f *ast.FuncType, name string, aliases map[string]string, packag
 	params := f.Params
 	if params != nil {
 		for _, pa := range params.List {
-			theType, _ := getFieldType(pa.Type, aliases)
+			theType, fundamentalTypes := getFieldType(pa.Type, aliases)
+			function.addReferencedTypes(fundamentalTypes)
 			if pa.Names != nil {
 				if pa.Names != nil {
 					for _, fieldName := range pa.Names {
@@ -67,17 +76,66 @@ func getFunction(f *ast.FuncType, name string, aliases map[string]string, packag
 	results := f.Results
 	if results != nil {
 		for _, pa := range results.List {
-			theType, _ := getFieldType(pa.Type, aliases)
-			count := 1
+			theType, fundamentalTypes := getFieldType(pa.Type, aliases)
+			function.addReferencedTypes(fundamentalTypes)
+			names := []string{""}
 			if pa.Names != nil {
-				count = len(pa.Names)
+				names = make([]string, 0, len(pa.Names))
+				for _, returnName := range pa.Names {
+					names = append(names, returnName.Name)
+				}
 			}
-			for count > 0 {
-				count--
+			for _, returnName := range names {
 				function.ReturnValues = append(function.ReturnValues, replacePackageConstant(theType, ""))
 				function.FullNameReturnValues = append(function.FullNameReturnValues, replacePackageConstant(theType, packageName))
+				if returnName != "" {
+					// Go does not allow mixing named and unnamed return values so either all of them have a name or none
+					function.ReturnValueNames = append(function.ReturnValueNames, returnName)
+				}
 			}
 		}
 	}
 	return function
 }
+
+// addReferencedTypes adds the given fundamental types of a parameter or a return value to the ReferencedTypes
+func (f *Function) addReferencedTypes(fundamentalTypes []string) {
+	for _, t := range fundamentalTypes {
+		f.ReferencedTypes = append(f.ReferencedTypes, replacePackageConstant(t, f.PackageName))
+	}
+}
+
+// instantiateFunctions returns copies of the functions of the given generic type with its type parameters replaced
+// by the given full type arguments in the full types of their parameters and return values
+func instantiateFunctions(functions []*Function, generic *Struct, typeArguments []string) []*Function {
+	replacements := map[*regexp.Regexp]string{}
+	for i, typeParameter := range generic.TypeParameters {
+		if i >= len(typeArguments) {
+			break
+		}
+		fullName := regexp.QuoteMeta(getFullTypeName(generic.PackageName, typeParameter.Name))
+		replacements[regexp.MustCompile(`(^|[^\w.])`+fullName+`\b`)] = "${1}" + typeArguments[i]
+	}
+	instantiate := func(t string) string {
+		for typeParameter, typeArgument := range replacements {
+			t = typeParameter.ReplaceAllString(t, typeArgument)
+		}
+		return t
+	}
+	result := make([]*Function, 0, len(functions))
+	for _, function := range functions {
+		instance := *function
+		instance.Parameters = make([]*Field, 0, len(function.Parameters))
+		for _, parameter := range function.Parameters {
+			instanceParameter := *parameter
+			instanceParameter.FullType = instantiate(parameter.FullType)
+			instance.Parameters = append(instance.Parameters, &instanceParameter)
+		}
+		instance.FullNameReturnValues = make([]string, 0, len(function.FullNameReturnValues))
+		for _, returnValue := range function.FullNameReturnValues {
+			instance.FullNameReturnValues = append(instance.FullNameReturnValues, instantiate(returnValue))
+		}
+		result = append(result, &instance)
+	}
+	return result
+}
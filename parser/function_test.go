@@ -2,7 +2,9 @@ package parser
 
 import (
 	"go/ast"
+	"go/parser"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -103,6 +105,47 @@ func TestGetFunction(t *testing.T) {
 			},
 			FunctionName: "TestFunction",
 		},
+		{
+			Name: "Function with named return values",
+			Func: &ast.FuncType{
+				Results: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Names: []*ast.Ident{
+								{
+									Name: "a",
+								},
+								{
+									Name: "b",
+								},
+							},
+							Type: &ast.Ident{
+								Name: "int",
+							},
+						},
+						{
+							Names: []*ast.Ident{
+								{
+									Name: "err",
+								},
+							},
+							Type: &ast.Ident{
+								Name: "error",
+							},
+						},
+					},
+				},
+			},
+			ExpectedResult: &Function{
+				Name:                 "TestFunction",
+				PackageName:          "main",
+				Parameters:           []*Field{},
+				ReturnValues:         []string{"int", "int", "error"},
+				FullNameReturnValues: []string{"int", "int", "error"},
+				ReturnValueNames:     []string{"a", "b", "err"},
+			},
+			FunctionName: "TestFunction",
+		},
 	}
 
 	for _, tc := range tt {
@@ -118,3 +161,75 @@ func TestGetFunction(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderParameterNames(t *testing.T) {
+	tt := []struct {
+		Name      string
+		Signature string
+		Expected  string
+	}{
+		{
+			Name:      "Named parameters and results",
+			Signature: "func(id string, force bool) (user User, err error)",
+			Expected:  "+ Get(id string, force bool) (user User, err error)",
+		},
+		{
+			Name:      "Parameters declared together",
+			Signature: "func(a, b int) int",
+			Expected:  "+ Get(a int, b int) int",
+		},
+		{
+			Name:      "Unnamed parameters and results",
+			Signature: "func(string, bool) (User, error)",
+			Expected:  "+ Get(string, bool) (User, error)",
+		},
+		{
+			Name:      "Single unnamed parameter",
+			Signature: "func(context.Context)",
+			Expected:  "+ Get(context.Context) ",
+		},
+		{
+			Name:      "Blank and named parameters",
+			Signature: "func(_ string, force bool) error",
+			Expected:  "+ Get(_ string, force bool) error",
+		},
+		{
+			Name:      "Blank parameters and results",
+			Signature: "func(_ string, _ bool) (_ User, _ error)",
+			Expected:  "+ Get(string, bool) (User, error)",
+		},
+		{
+			Name:      "Unnamed variadic parameter",
+			Signature: "func(...interface{})",
+			Expected:  "+ Get(...<font color=blue>interface</font>{}) ",
+		},
+		{
+			Name:      "Unnamed function parameter",
+			Signature: "func(func(int) error) []string",
+			Expected:  "+ Get(<font color=blue>func</font>(int) error) []string",
+		},
+		{
+			Name:      "No parameters",
+			Signature: "func() (n int)",
+			Expected:  "+ Get() (n int)",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tc.Signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+			function := getFunction(expr.(*ast.FuncType), "Get", map[string]string{}, "main")
+			p := getEmptyParser("main")
+			str := &LineStringBuilder{}
+			p.renderMethod(function, "", str)
+			if result := strings.TrimSpace(str.String()); result != strings.TrimSpace(tc.Expected) {
+				t.Errorf("Expected %s, got %s", tc.Expected, result)
+			}
+			if result := p.getDOTMethod(function, ""); strings.Contains(result, "( ") || strings.Contains(result, ",  ") {
+				t.Errorf("Expected the DOT method to have no empty parameter names, got %s", result)
+			}
+		})
+	}
+}
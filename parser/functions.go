@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// getRenderedFunctions returns the package level functions of the given package that have to be rendered, in the
+// order in which they were declared. Constructors are left out since they are rendered in the types they construct.
+func (p *ClassParser) getRenderedFunctions(pack string) []*Function {
+	if !p.renderingOptions.Functions || !p.renderingOptions.Methods {
+		return nil
+	}
+	constructors := map[*Function]struct{}{}
+	for _, st := range p.structure[pack] {
+		for _, constructor := range st.Constructors {
+			constructors[constructor] = struct{}{}
+		}
+	}
+	var result []*Function
+	for _, function := range p.allFunctions {
+		if _, ok := constructors[function]; ok || function.PackageName != pack {
+			continue
+		}
+		if isPrivate(function.Name) && (!p.renderingOptions.PrivateMethods || p.renderingOptions.ExportedOnly) {
+			continue
+		}
+		result = append(result, function)
+	}
+	return result
+}
+
+// renderPackageFunctions renders the given functions as the static methods of a class named after the package
+func (p *ClassParser) renderPackageFunctions(pack string, functions []*Function, str *LineStringBuilder) {
+	name := pack[strings.LastIndex(pack, "/")+1:]
+	label, diagramName := name, getDiagramTypeName(name)
+	if p.renderingOptions.Flat {
+		label, diagramName = p.getFlatLabel(pack, name), p.getFlatName(getFullTypeName(pack, name))
+	}
+	if diagramName != label {
+		str.WriteLineWithDepth(1, fmt.Sprintf(`class "%s" as %s %s {`, label, diagramName, FunctionsKind.getStereotype()))
+	} else {
+		str.WriteLineWithDepth(1, fmt.Sprintf(`class %s %s {`, name, FunctionsKind.getStereotype()))
+	}
+	p.renderMethods(functions, "{static} ", true, str)
+	p.renderMethods(functions, "{static} ", false, str)
+	str.WriteLineWithDepth(1, "}")
+}
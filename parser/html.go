@@ -0,0 +1,180 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// HTMLOptions selects how RenderHTMLTo writes the report
+type HTMLOptions struct {
+	// Template writes the report from an HTMLReport. The DefaultHTMLTemplate is used if it is nil, see NewHTMLTemplate
+	Template *template.Template
+	// ServerBase is the PlantUML server rendering the diagram, for example https://www.plantuml.com/plantuml
+	ServerBase string
+	// InlineSVG fetches the diagram rendered as SVG from the server and embeds it in the report. Otherwise the report
+	// links the image with an img tag pointing at the server, so it only works while the server is reachable
+	InlineSVG bool
+}
+
+// HTMLReport is what the HTML templates are executed with. Either SVG or ImageURL is set. Source is the PlantUML text
+// of the diagram and Packages the types of the Model.
+type HTMLReport struct {
+	Title    string
+	SVG      template.HTML
+	ImageURL string
+	Source   string
+	Packages []*ModelPackage
+}
+
+// DefaultHTMLTemplate writes a single page with the diagram and an index of the types and their members that can be
+// searched. Clicking a type highlights its class in the embedded SVG.
+const DefaultHTMLTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { display: flex; margin: 0; font-family: sans-serif; }
+nav { width: 22em; height: 100vh; overflow-y: auto; padding: 0 1em; border-right: 1px solid #ccc; box-sizing: border-box; }
+main { flex: 1; height: 100vh; overflow: auto; padding: 1em; box-sizing: border-box; }
+nav input { width: 100%; margin: 1em 0; }
+nav h2 { font-size: 1em; margin: 1em 0 0.3em; }
+nav ul { list-style: none; padding: 0; margin: 0; }
+nav li.type { margin: 0.3em 0; }
+nav li.type > a { font-weight: bold; }
+nav li.member { font-family: monospace; font-size: 0.85em; color: #555; margin-left: 1em; }
+nav .kind { color: #888; font-size: 0.8em; }
+.highlighted rect, .highlighted path, .highlighted polygon { stroke: #d00 !important; stroke-width: 3 !important; }
+text.highlighted { fill: #d00 !important; font-weight: bold; }
+</style>
+</head>
+<body>
+<nav>
+<h1>{{.Title}}</h1>
+<input id="search" type="search" placeholder="Search types and members" oninput="search(this.value)">
+{{range .Packages}}<section class="package">
+<h2>{{.Name}}</h2>
+<ul>
+{{$package := .Name}}{{range .Types}}<li class="type" id="{{typeID $package .Name}}" data-name="{{.Name}}" data-search="{{$package}}.{{.Name}}">
+<a href="#{{typeID $package .Name}}" onclick="highlight(this.parentNode)">{{.Name}}</a> <span class="kind">{{.Kind}}</span>
+<ul>
+{{range .Values}}<li class="member" data-search="{{.}}">{{.}}</li>
+{{end}}{{range .Fields}}<li class="member" data-search="{{.Name}}">{{.Name}} {{.Type}}</li>
+{{end}}{{range .Constructors}}<li class="member" data-search="{{.Name}}">{{signature .}}</li>
+{{end}}{{range .Methods}}<li class="member" data-search="{{.Name}}">{{signature .}}</li>
+{{end}}</ul>
+</li>
+{{end}}</ul>
+</section>
+{{end}}</nav>
+<main id="diagram">
+{{if .SVG}}{{.SVG}}{{else}}<img src="{{.ImageURL}}" alt="{{.Title}}">{{end}}
+</main>
+<script>
+function search(query) {
+	query = query.toLowerCase();
+	document.querySelectorAll("nav li.type").forEach(function(type) {
+		var typeMatches = type.getAttribute("data-search").toLowerCase().indexOf(query) >= 0;
+		var found = typeMatches;
+		type.querySelectorAll("li.member").forEach(function(member) {
+			var matches = typeMatches || member.getAttribute("data-search").toLowerCase().indexOf(query) >= 0;
+			member.style.display = matches ? "" : "none";
+			found = found || matches;
+		});
+		type.style.display = found ? "" : "none";
+	});
+	document.querySelectorAll("nav section.package").forEach(function(section) {
+		var visible = Array.prototype.some.call(section.querySelectorAll("li.type"), function(type) {
+			return type.style.display !== "none";
+		});
+		section.style.display = visible ? "" : "none";
+	});
+}
+function highlight(type) {
+	document.querySelectorAll("#diagram .highlighted").forEach(function(element) {
+		element.classList.remove("highlighted");
+	});
+	var name = type.getAttribute("data-name");
+	var classes = Array.prototype.filter.call(document.querySelectorAll("#diagram svg text"), function(text) {
+		return text.textContent.trim() === name;
+	});
+	classes.forEach(function(text) {
+		var group = text.closest("g[id]") || text;
+		group.classList.add("highlighted");
+		text.classList.add("highlighted");
+	});
+	if (classes.length > 0) {
+		classes[0].scrollIntoView({block: "center", inline: "center"});
+	}
+}
+</script>
+</body>
+</html>
+`
+
+// htmlTemplateFuncs are the functions available in the HTML templates. typeID returns the id of the index entry of
+// a type given its package and name, and signature the signature of a method or constructor.
+var htmlTemplateFuncs = template.FuncMap{
+	"typeID":    getHTMLTypeID,
+	"signature": getMethodSignature,
+}
+
+// defaultHTMLTemplate is the parsed DefaultHTMLTemplate
+var defaultHTMLTemplate = template.Must(NewHTMLTemplate(DefaultHTMLTemplate))
+
+// NewHTMLTemplate parses the given text as an HTML template for RenderHTMLTo with the functions the
+// DefaultHTMLTemplate uses, typeID and signature
+func NewHTMLTemplate(text string) (*template.Template, error) {
+	return template.New("report").Funcs(htmlTemplateFuncs).Parse(text)
+}
+
+// invalidHTMLIDRegexp matches the characters that are replaced in the ids of the index entries
+var invalidHTMLIDRegexp = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// getHTMLTypeID returns the id of the index entry of the given type
+func getHTMLTypeID(pack string, name string) string {
+	return "type-" + invalidHTMLIDRegexp.ReplaceAllString(pack+"."+name, "_")
+}
+
+// xmlDeclarationRegexp matches the XML declaration the PlantUML servers write before the SVG element
+var xmlDeclarationRegexp = regexp.MustCompile(`^\s*<\?xml[^>]*\?>`)
+
+// RenderHTMLTo writes a single HTML file with the diagram and an index of the types of the Model into the given
+// writer. The diagram is rendered by the PlantUML server of the options, see HTMLOptions. The request to the server
+// is cancelled with ctx.
+func (p *ClassParser) RenderHTMLTo(ctx context.Context, w io.Writer, options *HTMLOptions) error {
+	text := p.Render()
+	title := strings.Join(strings.Fields(p.renderingOptions.Title), " ")
+	if title == "" {
+		title = defaultMarkdownTitle
+	}
+	report := &HTMLReport{
+		Title:    title,
+		Source:   text,
+		Packages: p.Model().Packages,
+	}
+	serverBase := strings.TrimSuffix(options.ServerBase, "/")
+	if options.InlineSVG {
+		svg := &bytes.Buffer{}
+		if err := RenderPlantUMLImage(ctx, serverBase, "svg", text, svg); err != nil {
+			return err
+		}
+		// The SVG comes from the PlantUML server the caller chose
+		report.SVG = template.HTML(xmlDeclarationRegexp.ReplaceAllString(svg.String(), ""))
+	} else {
+		encoded, err := EncodePlantUML(text)
+		if err != nil {
+			return err
+		}
+		report.ImageURL = serverBase + "/svg/" + encoded
+	}
+	tmpl := options.Template
+	if tmpl == nil {
+		tmpl = defaultHTMLTemplate
+	}
+	return tmpl.Execute(w, report)
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRenderHTMLTo(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/memberorder"},
+		RenderingOptions: map[RenderingOption]interface{}{RenderTitle: "Accounts"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/plantuml/svg" {
+			t.Errorf("Expected the SVG to be requested, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8" standalone="no"?><svg><text>Account</text></svg>`))
+	}))
+	defer server.Close()
+	tt := []struct {
+		Name       string
+		Options    *HTMLOptions
+		Expected   []string
+		Unexpected []string
+	}{
+		{
+			Name:    "Inline SVG",
+			Options: &HTMLOptions{ServerBase: server.URL + "/plantuml/", InlineSVG: true},
+			Expected: []string{
+				"<title>Accounts</title>",
+				`<main id="diagram">` + "\n<svg><text>Account</text></svg>\n</main>",
+				`<li class="type" id="type-memberorder_Account" data-name="Account" data-search="memberorder.Account">`,
+				`<li class="member" data-search="Owner">Owner string</li>`,
+				`<li class="member" data-search="NewAccount">NewAccount(string) *memberorder.Account</li>`,
+				`<li class="member" data-search="Deposit">Deposit(int)</li>`,
+			},
+			Unexpected: []string{"<?xml", "<img"},
+		},
+		{
+			Name:     "Image link",
+			Options:  &HTMLOptions{ServerBase: server.URL + "/plantuml"},
+			Expected: []string{`<img src="` + server.URL + `/plantuml/svg/`},
+		},
+		{
+			Name: "Template",
+			Options: &HTMLOptions{
+				ServerBase: server.URL,
+				Template:   mustHTMLTemplate(t, `{{range .Packages}}{{range .Types}}{{typeID "a/b" .Name}}{{end}}{{end}}`),
+			},
+			Expected: []string{"type-a_b_Account"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			result := &strings.Builder{}
+			if err := parser.RenderHTMLTo(context.Background(), result, tc.Options); err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			for _, e := range tc.Expected {
+				if !strings.Contains(result.String(), e) {
+					t.Errorf("Expected the report to contain %s, got\n%s", e, result.String())
+				}
+			}
+			for _, u := range tc.Unexpected {
+				if strings.Contains(result.String(), u) {
+					t.Errorf("Expected the report not to contain %s, got\n%s", u, result.String())
+				}
+			}
+		})
+	}
+}
+
+func mustHTMLTemplate(t *testing.T, text string) *template.Template {
+	tmpl, err := NewHTMLTemplate(text)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	return tmpl
+}
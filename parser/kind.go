@@ -0,0 +1,76 @@
+package parser
+
+// Kind is the kind of a parsed type, which selects how it is rendered. Struct.Type keeps the declared kind as a
+// string for compatibility, use Struct.Kind instead.
+type Kind int
+
+const (
+	// ClassKind is a struct, or a type only known by its methods
+	ClassKind Kind = iota
+	// InterfaceKind is an interface, including the constraints
+	InterfaceKind
+	// NamedTypeKind is a type declared with another type, like type UserID int64
+	NamedTypeKind
+	// AliasKind is an alias of another type, like type Email = string
+	AliasKind
+	// EnumKind is a named type with constants declared with it, like type Status int with StatusActive Status = 1
+	EnumKind
+	// FunctionsKind is the class holding the package level functions of a package, see RenderFunctions
+	FunctionsKind
+)
+
+// kindNames are the names of the kinds, which are the values of Struct.Type for the declared types
+var kindNames = map[Kind]string{
+	ClassKind:     "class",
+	InterfaceKind: "interface",
+	NamedTypeKind: "type",
+	AliasKind:     "alias",
+	EnumKind:      "enum",
+	FunctionsKind: "functions",
+}
+
+// kindStereotypes are the spots and stereotypes rendered after the names of the classes of every kind
+var kindStereotypes = map[Kind]string{
+	ClassKind:     "<< (S,Aquamarine) >>",
+	NamedTypeKind: "<< (T, #FF7700) type >>",
+	AliasKind:     "<< (T, #FF7700) alias >>",
+	FunctionsKind: "<< (F, #8FBC8F) functions >>",
+}
+
+// String returns the name of the kind, like class or enum
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// getKeyword returns the PlantUML keyword declaring the types of the kind
+func (k Kind) getKeyword() string {
+	switch k {
+	case InterfaceKind:
+		return "interface"
+	case EnumKind:
+		return "enum"
+	}
+	return "class"
+}
+
+// getStereotype returns the spot and stereotype of the types of the kind, empty for the kinds told apart by their
+// keyword
+func (k Kind) getStereotype() string {
+	return kindStereotypes[k]
+}
+
+// Kind returns the kind of the type. Named types are enums once their constants are found, see findEnums()
+func (st *Struct) Kind() Kind {
+	switch st.Type {
+	case "interface":
+		return InterfaceKind
+	case "alias":
+		return AliasKind
+	case "type":
+		if len(st.EnumValues) > 0 {
+			return EnumKind
+		}
+		return NamedTypeKind
+	}
+	return ClassKind
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStructKind(t *testing.T) {
+	tt := []struct {
+		Name         string
+		Struct       *Struct
+		ExpectedKind Kind
+		ExpectedName string
+	}{
+		{
+			Name:         "Struct",
+			Struct:       &Struct{Type: "class"},
+			ExpectedKind: ClassKind,
+			ExpectedName: "class",
+		},
+		{
+			Name:         "Type only known by its methods",
+			Struct:       &Struct{},
+			ExpectedKind: ClassKind,
+			ExpectedName: "class",
+		},
+		{
+			Name:         "Interface",
+			Struct:       &Struct{Type: "interface"},
+			ExpectedKind: InterfaceKind,
+			ExpectedName: "interface",
+		},
+		{
+			Name:         "Named type",
+			Struct:       &Struct{Type: "type"},
+			ExpectedKind: NamedTypeKind,
+			ExpectedName: "type",
+		},
+		{
+			Name:         "Alias",
+			Struct:       &Struct{Type: "alias"},
+			ExpectedKind: AliasKind,
+			ExpectedName: "alias",
+		},
+		{
+			Name:         "Enum",
+			Struct:       &Struct{Type: "type", EnumValues: []string{"Active", "Inactive"}},
+			ExpectedKind: EnumKind,
+			ExpectedName: "enum",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			kind := tc.Struct.Kind()
+			if kind != tc.ExpectedKind {
+				t.Errorf("Expected the kind %s, got %s", tc.ExpectedKind, kind)
+			}
+			if kind.String() != tc.ExpectedName {
+				t.Errorf("Expected the name %s, got %s", tc.ExpectedName, kind.String())
+			}
+		})
+	}
+}
+
+func TestRenderKinds(t *testing.T) {
+	sources := map[string]string{
+		"kinds/kinds.go": `package kinds
+
+type Account struct {
+	ID UserID
+}
+
+type Store interface {
+	Save(a *Account) error
+}
+
+type Number interface {
+	~int | ~float64
+}
+
+type UserID int64
+
+type Email = string
+
+type Status int
+
+const (
+	Active Status = iota
+	Inactive
+)
+
+func Open(path string) (Store, error) {
+	return nil, nil
+}
+`,
+	}
+	parser, err := NewClassDiagramFromSource(sources, &ClassDiagramOptions{
+		RenderingOptions: map[RenderingOption]interface{}{RenderFunctions: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	for _, declaration := range []string{
+		"class Account << (S,Aquamarine) >> {",
+		"interface Store  {",
+		"interface Number << constraint >> {",
+		"class UserID << (T, #FF7700) type >> {",
+		"class Email << (T, #FF7700) alias >> {",
+		"enum Status  {",
+		"class kinds << (F, #8FBC8F) functions >> {",
+	} {
+		if !strings.Contains(result, "    "+declaration+"\n") {
+			t.Errorf("Expected the declaration %s, got\n%s", declaration, result)
+		}
+	}
+	result = parser.RenderDOT()
+	for _, header := range []string{`{«interface»\nStore|`, `{«constraint»\nNumber|`, `{«type»\nUserID|`, `{«alias»\nEmail|`, `{«enum»\nStatus|`, `{Account|`} {
+		if !strings.Contains(result, header) {
+			t.Errorf("Expected the record header %s, got\n%s", header, result)
+		}
+	}
+}
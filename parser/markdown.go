@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// defaultMarkdownTitle is the heading of the Markdown document when the diagram has no title
+const defaultMarkdownTitle = "Class diagram"
+
+// RenderMarkdown returns the class diagram that this parser has generated as a Markdown document, see
+// RenderMarkdownTo
+func (p *ClassParser) RenderMarkdown() string {
+	str := &strings.Builder{}
+	// Writing into a strings.Builder never fails
+	p.RenderMarkdownTo(str)
+	return str.String()
+}
+
+// RenderMarkdownTo writes a Markdown document into the given writer with a table of contents and a section for every
+// package, headed by its import path, with a plantuml fenced block rendered like RenderPackageTo. The title of the
+// diagram is the heading of the document.
+func (p *ClassParser) RenderMarkdownTo(w io.Writer) error {
+	p.Finalize()
+	title := strings.Join(strings.Fields(p.renderingOptions.Title), " ")
+	if title == "" {
+		title = defaultMarkdownTitle
+	}
+	str := &LineStringBuilder{}
+	str.WriteLineWithDepth(0, "# "+title)
+	str.WriteLineWithDepth(0, "")
+	var packages, headings []string
+	for _, pack := range p.getSortedPackages() {
+		if len(p.getStructures(pack)) == 0 && len(p.getRenderedFunctions(pack)) == 0 {
+			continue
+		}
+		heading := p.getPackageHeading(pack)
+		packages = append(packages, pack)
+		headings = append(headings, heading)
+		str.WriteLineWithDepth(0, fmt.Sprintf("- [%s](#%s)", heading, getMarkdownAnchor(heading)))
+	}
+	for i, pack := range packages {
+		str.WriteLineWithDepth(0, "")
+		str.WriteLineWithDepth(0, "## "+headings[i])
+		str.WriteLineWithDepth(0, "")
+		str.WriteLineWithDepth(0, "```plantuml")
+		if err := flushTo(w, str); err != nil {
+			return err
+		}
+		if err := p.renderDiagram(w, []string{pack}, "", false); err != nil {
+			return err
+		}
+		str.WriteLineWithDepth(0, "```")
+	}
+	return flushTo(w, str)
+}
+
+// getPackageHeading returns the import path of the given package, found with the go.mod file of the first file
+// declaring its types, or its name if it is not part of a module
+func (p *ClassParser) getPackageHeading(pack string) string {
+	if p.options.ImportPaths {
+		return pack
+	}
+	var names []string
+	for name, st := range p.structure[pack] {
+		if st.Position.Filename != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return pack
+	}
+	sort.Strings(names)
+	if importPath := p.getImportPath(filepath.Dir(p.structure[pack][names[0]].Position.Filename)); importPath != "" {
+		return importPath
+	}
+	return pack
+}
+
+// getMarkdownAnchor returns the anchor generated for the given heading, lower case without punctuation and with
+// dashes instead of spaces
+func getMarkdownAnchor(heading string) string {
+	anchor := &strings.Builder{}
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			anchor.WriteRune(r)
+		case r == ' ':
+			anchor.WriteRune('-')
+		}
+	}
+	return anchor.String()
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/interfaceembedding"},
+		Recursive:        true,
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	const module = "github.com/jfeliu007/goplantuml/testingsupport/interfaceembedding"
+	result := parser.RenderMarkdown()
+	expectedHeader := `# Class diagram
+
+- [` + module + `/app](#githubcomjfeliu007goplantumltestingsupportinterfaceembeddingapp)
+- [` + module + `/store](#githubcomjfeliu007goplantumltestingsupportinterfaceembeddingstore)
+
+## ` + module + `/app
+
+` + "```plantuml\n@startuml\nnamespace app {\n"
+	if !strings.HasPrefix(result, expectedHeader) {
+		t.Fatalf("Expected the document to start with\n%s\ngot\n%s", expectedHeader, result)
+	}
+	sections := strings.Split(result, "\n## ")
+	if len(sections) != 3 {
+		t.Fatalf("Expected a section for each package, got\n%s", result)
+	}
+	app, store := sections[1], sections[2]
+	if !strings.Contains(app, `"store.Reader" <|.. "app.Memory"`) || strings.Contains(app, "namespace store") {
+		t.Errorf("Expected the app section to have its relationships with the store package but not its types, got\n%s", app)
+	}
+	if !strings.Contains(store, "interface Reader") || strings.Contains(store, "app.") {
+		t.Errorf("Expected the store section to only have the store types, got\n%s", store)
+	}
+	if !strings.HasSuffix(result, "@enduml\n```\n") {
+		t.Errorf("Expected the last fenced block to be closed, got\n%s", result)
+	}
+}
+
+func TestRenderPackageTo(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/interfaceembedding"},
+		Recursive:   true,
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderTitle: "Store",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := &strings.Builder{}
+	if err := parser.RenderPackageTo(result, "store"); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if !strings.HasPrefix(result.String(), "@startuml\ntitle Store\nnamespace store {\n") || strings.Contains(result.String(), "app") {
+		t.Errorf("Expected the diagram of the store package, got\n%s", result.String())
+	}
+	if err := parser.RenderPackageTo(result, "missing"); err == nil || err.Error() != "unknown package missing" {
+		t.Errorf("Expected an unknown package error, got %v", err)
+	}
+}
+
+func TestGetMarkdownAnchor(t *testing.T) {
+	tt := map[string]string{
+		"example.com/repo/models": "examplecomrepomodels",
+		"My Package_v2":           "my-package_v2",
+		"go-yaml.v3":              "go-yamlv3",
+	}
+	for heading, expected := range tt {
+		if result := getMarkdownAnchor(heading); result != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, heading, result)
+		}
+	}
+}
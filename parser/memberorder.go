@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"go/token"
+	"sort"
+)
+
+// MemberOrder selects the order in which the fields and methods of every class are written
+type MemberOrder int
+
+const (
+	// VisibilityMemberOrder writes the private fields, the public fields, the private methods and then the public
+	// methods, in the order of their declarations within each group. It is the default
+	VisibilityMemberOrder MemberOrder = iota
+	// SourceMemberOrder writes the fields and then the methods in the order they are declared. Methods declared in
+	// several files are sorted by the name of the file and then by their position in it
+	SourceMemberOrder
+)
+
+// getSourceOrderedFields returns the given fields sorted by their declaration positions
+func getSourceOrderedFields(fields []*Field) []*Field {
+	result := append([]*Field{}, fields...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return isBeforeInSource(result[i].Position, result[j].Position)
+	})
+	return result
+}
+
+// getSourceOrderedFunctions returns the given functions sorted by their declaration positions
+func getSourceOrderedFunctions(functions []*Function) []*Function {
+	result := append([]*Function{}, functions...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return isBeforeInSource(result[i].Position, result[j].Position)
+	})
+	return result
+}
+
+// isBeforeInSource returns true if the first position is in a file whose name sorts before the file of the second
+// one, or before it in the same file
+func isBeforeInSource(first token.Position, second token.Position) bool {
+	if first.Filename != second.Filename {
+		return first.Filename < second.Filename
+	}
+	return first.Offset < second.Offset
+}
@@ -0,0 +1,260 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// goModule is a go module found in the file system
+type goModule struct {
+	path string
+	root string
+}
+
+// getPackageKey returns the name used to key the given package in the structure. It is the package name unless the
+// ImportPaths option is set, in which case it is the import path of the package found with its go.mod file. Packages
+// that are not part of a module fall back to their name. External test packages, like foo_test, get the _test suffix
+// after their import path so they are not merged with the package they test.
+func (p *ClassParser) getPackageKey(pack *ast.Package) string {
+	if !p.options.ImportPaths {
+		return pack.Name
+	}
+	for fileName := range pack.Files {
+		if importPath := p.getImportPath(filepath.Dir(fileName)); importPath != "" {
+			if strings.HasSuffix(pack.Name, "_test") {
+				return importPath + "_test"
+			}
+			return importPath
+		}
+		break
+	}
+	return pack.Name
+}
+
+// getImportPath returns the import path of the package in the given directory or an empty string if it is not
+// part of a module. Packages in vendor directories get the import path they are vendored for.
+func (p *ClassParser) getImportPath(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	module := p.findModule(dir)
+	if module == nil {
+		return ""
+	}
+	rel, err := filepath.Rel(module.root, dir)
+	if err != nil {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		return module.path
+	}
+	if i := strings.LastIndex("/"+rel, "/vendor/"); i >= 0 {
+		return rel[i+len("vendor/"):]
+	}
+	return path.Join(module.path, rel)
+}
+
+// findModule returns the module that contains the given directory looking for the closest go.mod file. Results are
+// cached since all the directories of a module share it.
+func (p *ClassParser) findModule(dir string) *goModule {
+	if module, ok := p.modules[dir]; ok {
+		return module
+	}
+	fs := p.options.FileSystem
+	if fs == nil {
+		fs = afero.NewOsFs()
+	}
+	var module *goModule
+	if content, err := afero.ReadFile(fs, filepath.Join(dir, "go.mod")); err == nil {
+		if modulePath := getModulePath(content); modulePath != "" {
+			module = &goModule{path: modulePath, root: dir}
+		}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		module = p.findModule(parent)
+	}
+	if p.modules == nil {
+		p.modules = map[string]*goModule{}
+	}
+	p.modules[dir] = module
+	return module
+}
+
+// ModulePath returns the path of the module that contains the given directory, read from its closest go.mod file,
+// or an empty string if it is not part of a module
+func ModulePath(fs afero.Fs, dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	p := &ClassParser{options: ClassDiagramOptions{FileSystem: fs}}
+	if module := p.findModule(dir); module != nil {
+		return module.path
+	}
+	return ""
+}
+
+// getModulePath returns the module path declared in the given go.mod content
+func getModulePath(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(fields[1]); err == nil {
+			return unquoted
+		}
+		return fields[1]
+	}
+	return ""
+}
+
+// findPackageNames keeps the declared names of the given directories that are part of a module when they do not
+// follow the convention of naming packages after the last element of their import path, like a database directory
+// with package dbase in it, so the aliased imports of those packages use their real names. The ImportPaths option
+// uses the import paths as names so it does not need them.
+func (p *ClassParser) findPackageNames(directories []*parsedDirectory) {
+	if p.options.ImportPaths {
+		return
+	}
+	for _, directory := range directories {
+		dir := directory.path
+		if directory.isFile {
+			dir = filepath.Dir(dir)
+		}
+		importPath := p.getImportPath(dir)
+		if importPath == "" {
+			continue
+		}
+		name := getDeclaredPackageName(dir)
+		if name == "" || name == "main" || name == getImportedPackageName(importPath) {
+			continue
+		}
+		if p.packageNames == nil {
+			p.packageNames = map[string]string{}
+		}
+		p.packageNames[importPath] = name
+	}
+}
+
+// getDeclaredPackageName returns the name in the package clause of the first go file of the given directory that
+// is not a test file, or an empty string if there is none. Only the package clauses are parsed.
+func getDeclaredPackageName(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	fileSet := token.NewFileSet()
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		if f, err := parser.ParseFile(fileSet, filepath.Join(dir, entry.Name()), nil, parser.PackageClauseOnly); err == nil {
+			return f.Name.Name
+		}
+	}
+	return ""
+}
+
+// resolveImportedPackageName returns the name of the package with the given import path, its declared name if it
+// was parsed and does not follow the naming convention, see getImportedPackageName()
+func (p *ClassParser) resolveImportedPackageName(importPath string) string {
+	if name, ok := p.packageNames[importPath]; ok {
+		return name
+	}
+	return getImportedPackageName(importPath)
+}
+
+// getImportPaths returns the map of names used in the given file to refer to imported packages -> import paths.
+// Packages imported without a name are referred to by their package name, see getImportedPackageName().
+func getImportPaths(f *ast.File) map[string]string {
+	imports := map[string]string{}
+	for _, impt := range f.Imports {
+		importPath := strings.Trim(impt.Path.Value, `"`)
+		name := getImportedPackageName(importPath)
+		if impt.Name != nil {
+			name = impt.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		imports[name] = importPath
+	}
+	return imports
+}
+
+// splitFullTypeName returns the package and the name of the given fully qualified type. Packages can be import
+// paths with dots in them so the type name starts after the last dot of the last path element. Names without
+// a package qualifier return an empty package.
+func splitFullTypeName(fullName string) (string, string) {
+	base := fullName
+	// Type arguments can have qualified types of their own
+	if i := strings.Index(base, "["); i >= 0 {
+		base = base[:i]
+	}
+	i := strings.LastIndex(base, ".")
+	if i < 0 || i < strings.LastIndex(base, "/") {
+		return "", fullName
+	}
+	return fullName[:i], fullName[i+1:]
+}
+
+// getShortNamespaces returns the name of each one of the given packages shortened to its last segments path
+// elements. Packages whose shortened names collide keep more path elements until they are unique.
+func getShortNamespaces(packages []string, segments int) map[string]string {
+	result := map[string]string{}
+	for _, pack := range packages {
+		result[pack] = getLastPathElements(pack, segments)
+	}
+	for n := segments + 1; ; n++ {
+		byName := map[string][]string{}
+		for _, pack := range packages {
+			byName[result[pack]] = append(byName[result[pack]], pack)
+		}
+		var collisions []string
+		for _, colliding := range byName {
+			if len(colliding) > 1 {
+				collisions = append(collisions, colliding...)
+			}
+		}
+		if len(collisions) == 0 {
+			return result
+		}
+		changed := false
+		for _, pack := range collisions {
+			if name := getLastPathElements(pack, n); name != result[pack] {
+				result[pack] = name
+				changed = true
+			}
+		}
+		if !changed {
+			// The full import paths are the same so there is nothing left to add
+			return result
+		}
+	}
+}
+
+// getLastPathElements returns the last n elements of the given import path
+func getLastPathElements(importPath string, n int) string {
+	elements := strings.Split(importPath, "/")
+	if n <= 0 || n >= len(elements) {
+		return importPath
+	}
+	return strings.Join(elements[len(elements)-n:], "/")
+}
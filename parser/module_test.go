@@ -0,0 +1,266 @@
+package parser
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+const importPathsModule = "github.com/jfeliu007/goplantuml/testingsupport/importpaths"
+
+func TestImportPaths(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/importpaths"},
+		Recursive:   true,
+		ImportPaths: true,
+	})
+	if err != nil {
+		t.Fatalf("TestImportPaths: expected no error but got %s", err.Error())
+	}
+	for _, pack := range []string{"a/models", "b/models"} {
+		if _, ok := parser.structure[importPathsModule+"/"+pack]["User"]; !ok {
+			t.Errorf("TestImportPaths: expected %s to have its own User, got %v", pack, parser.structure)
+		}
+	}
+	service := parser.structure[importPathsModule+"/app"]["Service"]
+	if service == nil {
+		t.Fatalf("TestImportPaths: expected the Service to be found, got %v", parser.structure)
+	}
+	expectedComposition := map[string]struct{}{importPathsModule + "/a/models.User": {}}
+	if !reflect.DeepEqual(service.Composition, expectedComposition) {
+		t.Errorf("TestImportPaths: expected the compositions %v, got %v", expectedComposition, service.Composition)
+	}
+	expectedImplements := map[string]struct{}{importPathsModule + "/a/models.Store": {}}
+	if !reflect.DeepEqual(service.Implements, expectedImplements) {
+		t.Errorf("TestImportPaths: expected the implementations %v, got %v", expectedImplements, service.Implements)
+	}
+	expectedAggregations := map[string]struct{}{importPathsModule + "/b/models.User": {}}
+	if !reflect.DeepEqual(service.Aggregations, expectedAggregations) {
+		t.Errorf("TestImportPaths: expected the aggregations %v, got %v", expectedAggregations, service.Aggregations)
+	}
+}
+
+func TestRenderNamespaceSegments(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/importpaths"},
+		Recursive:   true,
+		ImportPaths: true,
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderAggregations:      true,
+			RenderNamespaceSegments: 1,
+		},
+	})
+	if err != nil {
+		t.Fatalf("TestRenderNamespaceSegments: expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	expectedLines := []string{
+		`namespace a_models {`,
+		`namespace b_models {`,
+		`namespace app {`,
+		`"a_models.User" *-- "app.Service"`,
+		`"a_models.Store" <|.. "app.Service"`,
+		`"app.Service" o-- "0..1" "b_models.User"`,
+	}
+	for _, expected := range expectedLines {
+		if !strings.Contains(result, expected+"\n") {
+			t.Errorf("TestRenderNamespaceSegments: expected the result to contain \n%s\n got \n%s\n", expected, result)
+		}
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderNamespaceSegments: 0,
+	})
+	result = parser.Render()
+	expected := `"github_com_jfeliu007_goplantuml_testingsupport_importpaths_a_models.User" *-- "github_com_jfeliu007_goplantuml_testingsupport_importpaths_app.Service"`
+	if !strings.Contains(result, expected+"\n") {
+		t.Errorf("TestRenderNamespaceSegments: expected the result to contain \n%s\n got \n%s\n", expected, result)
+	}
+}
+
+func TestRenderNestedNamespaces(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/importpaths"},
+		Recursive:   true,
+		ImportPaths: true,
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderAggregations:      true,
+			RenderNestedNamespaces:  true,
+			RenderNamespaceSegments: 2,
+		},
+	})
+	if err != nil {
+		t.Fatalf("TestRenderNestedNamespaces: expected no error but got %s", err.Error())
+	}
+	tt := []struct {
+		Name          string
+		Separator     string
+		ExpectedLines []string
+	}{
+		{
+			Name: "Default separator",
+			ExpectedLines: []string{
+				`@startuml`,
+				`namespace a.models {`,
+				`namespace importpaths.app {`,
+				`"a.models.User" *-- "importpaths.app.Service"`,
+				`"a.models.Store" <|.. "importpaths.app.Service"`,
+				`"importpaths.app.Service" o-- "0..1" "b.models.User"`,
+			},
+		},
+		{
+			Name:      "Custom separator",
+			Separator: "::",
+			ExpectedLines: []string{
+				`@startuml`,
+				`set namespaceSeparator ::`,
+				`namespace a::models {`,
+				`namespace importpaths::app {`,
+				`"a::models::User" *-- "importpaths::app::Service"`,
+				`"importpaths::app::Service" o-- "0..1" "b::models::User"`,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser.SetRenderingOptions(map[RenderingOption]interface{}{
+				RenderNamespaceSeparator: tc.Separator,
+			})
+			result := parser.Render()
+			for _, expected := range tc.ExpectedLines {
+				if !strings.Contains(result, expected+"\n") {
+					t.Errorf("Expected the result to contain \n%s\n got \n%s\n", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestGetImportPath(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/repo/go.mod", []byte("// The module\nmodule \"example.com/repo\" // comment\n\ngo 1.18\n"), 0644)
+	afero.WriteFile(fs, "/repo/nested/go.mod", []byte("module example.com/nested\n"), 0644)
+	parser := getEmptyParser("main")
+	parser.options.FileSystem = fs
+	tt := []struct {
+		Dir      string
+		Expected string
+	}{
+		{Dir: "/repo", Expected: "example.com/repo"},
+		{Dir: "/repo/internal/models", Expected: "example.com/repo/internal/models"},
+		{Dir: "/repo/vendor/github.com/foo/bar", Expected: "github.com/foo/bar"},
+		{Dir: "/repo/nested/models", Expected: "example.com/nested/models"},
+		{Dir: "/other", Expected: ""},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Dir, func(t *testing.T) {
+			if result := parser.getImportPath(filepath.FromSlash(tc.Dir)); result != tc.Expected {
+				t.Errorf("Expected %q, got %q", tc.Expected, result)
+			}
+		})
+	}
+}
+
+func TestModulePath(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/repo/go.mod", []byte("module example.com/repo\n"), 0644)
+	if result := ModulePath(fs, filepath.FromSlash("/repo/internal/models")); result != "example.com/repo" {
+		t.Errorf("Expected example.com/repo, got %q", result)
+	}
+	if result := ModulePath(fs, filepath.FromSlash("/other")); result != "" {
+		t.Errorf("Expected no module, got %q", result)
+	}
+}
+
+func TestSplitFullTypeName(t *testing.T) {
+	tt := []struct {
+		FullName        string
+		ExpectedPackage string
+		ExpectedName    string
+	}{
+		{FullName: "models.User", ExpectedPackage: "models", ExpectedName: "User"},
+		{FullName: "github.com/acme/app/models.User", ExpectedPackage: "github.com/acme/app/models", ExpectedName: "User"},
+		{FullName: "gopkg.in/yaml.v3.Node", ExpectedPackage: "gopkg.in/yaml.v3", ExpectedName: "Node"},
+		{FullName: "models.List[other.User]", ExpectedPackage: "models", ExpectedName: "List[other.User]"},
+		{FullName: "User", ExpectedPackage: "", ExpectedName: "User"},
+		{FullName: "github.com/acme/app", ExpectedPackage: "", ExpectedName: "github.com/acme/app"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.FullName, func(t *testing.T) {
+			pack, name := splitFullTypeName(tc.FullName)
+			if pack != tc.ExpectedPackage || name != tc.ExpectedName {
+				t.Errorf("Expected %q %q, got %q %q", tc.ExpectedPackage, tc.ExpectedName, pack, name)
+			}
+		})
+	}
+}
+
+func TestGetShortNamespaces(t *testing.T) {
+	packages := []string{
+		"example.com/app",
+		"example.com/app/a/models",
+		"example.com/app/b/models",
+		"example.com/app/internal/store",
+		"strings",
+	}
+	expected := map[string]string{
+		"example.com/app":                "app",
+		"example.com/app/a/models":       "a/models",
+		"example.com/app/b/models":       "b/models",
+		"example.com/app/internal/store": "store",
+		"strings":                        "strings",
+	}
+	if result := getShortNamespaces(packages, 1); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestRenderNamespaceColors(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/importpaths"},
+		Recursive:   true,
+		ImportPaths: true,
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderNestedNamespaces:  true,
+			RenderNamespaceSegments: 2,
+			RenderAutoColors:        true,
+			RenderPackageColors: map[string]string{
+				importPathsModule + "/a/models": "#FFEEDD",
+				"b.models":                      "LightBlue",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("TestRenderNamespaceColors: expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	for _, expected := range []string{`namespace a.models #FFEEDD {`, `namespace b.models #LightBlue {`} {
+		if !strings.Contains(result, expected+"\n") {
+			t.Errorf("TestRenderNamespaceColors: expected the result to contain \n%s\n got \n%s\n", expected, result)
+		}
+	}
+	autoColor := parser.getNamespaceColor(importPathsModule + "/app")
+	if !strings.Contains(result, "namespace importpaths.app"+autoColor+" {\n") {
+		t.Errorf("TestRenderNamespaceColors: expected the app namespace to have the color %s, got \n%s\n", autoColor, result)
+	}
+	found := false
+	for _, color := range namespaceColorPalette {
+		found = found || " "+color == autoColor
+	}
+	if !found {
+		t.Errorf("TestRenderNamespaceColors: expected the color %s to be picked from the palette", autoColor)
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderAutoColors:    false,
+		RenderPackageColors: map[string]string{},
+	})
+	if result := parser.Render(); !strings.Contains(result, "namespace importpaths.app {\n") {
+		t.Errorf("TestRenderNamespaceColors: expected the namespaces to have no color, got \n%s\n", result)
+	}
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// updateNeighborhood computes the types in the neighborhood of the Roots option from the whole parsed structure. The
+// types outside of it are kept in the structure so they can join the neighborhood when more code is added, they are
+// left out when rendering and exporting instead, see isOutsideNeighborhood. The roots that are not found are skipped,
+// see checkRoots.
+func (p *ClassParser) updateNeighborhood() {
+	p.neighborhood = nil
+	if len(p.options.Roots) == 0 {
+		return
+	}
+	p.neighborhood = p.getNeighborhood()
+}
+
+// isOutsideNeighborhood returns true if the given fully qualified type is a parsed type that is not in the
+// neighborhood of the Roots option. Types that were not parsed are never outside of it.
+func (p *ClassParser) isOutsideNeighborhood(fullName string) bool {
+	if p.neighborhood == nil {
+		return false
+	}
+	// Relationships to generic types are stored with their type arguments
+	fullName = strings.SplitN(fullName, "[", 2)[0]
+	if _, ok := p.neighborhood[fullName]; ok {
+		return false
+	}
+	return p.getStruct(fullName) != nil
+}
+
+// getStructures returns the parsed types of the given package that are in the neighborhood of the Roots option, which
+// are all of them without roots
+func (p *ClassParser) getStructures(pack string) map[string]*Struct {
+	structures := p.structure[pack]
+	if p.neighborhood == nil {
+		return structures
+	}
+	result := make(map[string]*Struct, len(structures))
+	for name, structure := range structures {
+		if !p.isOutsideNeighborhood(getFullTypeName(pack, name)) {
+			result[name] = structure
+		}
+	}
+	return result
+}
+
+// filterModelRelationships removes from the given model type of the given structure the relationships to the types
+// outside of the neighborhood of the Roots option
+func (p *ClassParser) filterModelRelationships(modelType *ModelType, structure *Struct) {
+	filter := func(types []string) []string {
+		var result []string
+		for _, t := range types {
+			fullName := t
+			if !strings.Contains(fullName, ".") {
+				fullName = fmt.Sprintf("%s.%s", p.getPackageName(t, structure), t)
+			}
+			if !p.isOutsideNeighborhood(fullName) {
+				result = append(result, t)
+			}
+		}
+		return result
+	}
+	modelType.Compositions = filter(modelType.Compositions)
+	modelType.Extends = filter(modelType.Extends)
+	modelType.Implements = filter(modelType.Implements)
+	modelType.PointerImplements = filter(modelType.PointerImplements)
+	modelType.Aggregations = filter(modelType.Aggregations)
+	modelType.PrivateAggregations = filter(modelType.PrivateAggregations)
+}
+
+// getNeighborhood returns the fully qualified names of the root types and of the parsed types reachable from them
+// through at most Depth relationships of any kind, followed in both directions. Types that were not parsed are not
+// followed, so two types are not neighbors because both have a field of the same external type.
+func (p *ClassParser) getNeighborhood() map[string]struct{} {
+	packages := make([]string, 0, len(p.structure))
+	for pack := range p.structure {
+		packages = append(packages, pack)
+	}
+	neighbors := map[string][]string{}
+	for _, relationship := range p.getRelationships(packages) {
+		neighbors[relationship.From] = append(neighbors[relationship.From], relationship.To)
+		neighbors[relationship.To] = append(neighbors[relationship.To], relationship.From)
+	}
+	neighborhood := map[string]struct{}{}
+	var current []string
+	for _, root := range p.options.Roots {
+		// Every type an ambiguous root can be is kept, checkRoots reports it
+		for _, fullName := range p.findTypeNames(root) {
+			if _, ok := neighborhood[fullName]; !ok {
+				neighborhood[fullName] = struct{}{}
+				current = append(current, fullName)
+			}
+		}
+	}
+	for depth := 0; len(current) > 0 && (p.options.Depth <= 0 || depth < p.options.Depth); depth++ {
+		var next []string
+		for _, fullName := range current {
+			for _, neighbor := range neighbors[fullName] {
+				if _, ok := neighborhood[neighbor]; ok || p.getStruct(neighbor) == nil {
+					continue
+				}
+				neighborhood[neighbor] = struct{}{}
+				next = append(next, neighbor)
+			}
+		}
+		current = next
+	}
+	return neighborhood
+}
+
+// checkRoots returns an error for the first type of the Roots option that is unknown or ambiguous
+func (p *ClassParser) checkRoots() error {
+	for _, root := range p.options.Roots {
+		if _, err := p.resolveTypeName(root); err != nil {
+			return fmt.Errorf("invalid root: %w", err)
+		}
+	}
+	return nil
+}
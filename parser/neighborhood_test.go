@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestNeighborhood(t *testing.T) {
+	tt := []struct {
+		Name          string
+		Roots         []string
+		Depth         int
+		Exclude       string
+		ExpectedTypes []string
+		ExpectedError string
+	}{
+		{
+			Name:          "One relationship away",
+			Roots:         []string{"domain.Repository"},
+			Depth:         1,
+			ExpectedTypes: []string{"domain.Entity", "domain.Repository", "store.Store"},
+		},
+		{
+			Name:          "Every connected type",
+			Roots:         []string{"LegacyStore"},
+			ExpectedTypes: []string{"legacy.Entity", "store.LegacyStore"},
+		},
+		{
+			Name:          "Several roots",
+			Roots:         []string{"store.LegacyStore", "domain.Entity"},
+			Depth:         1,
+			ExpectedTypes: []string{"domain.Entity", "domain.Repository", "legacy.Entity", "store.LegacyStore", "store.Store"},
+		},
+		{
+			Name:          "Filters are applied first",
+			Roots:         []string{"domain.Repository"},
+			Depth:         1,
+			Exclude:       `^store\.`,
+			ExpectedTypes: []string{"domain.Entity", "domain.Repository"},
+		},
+		{
+			Name:          "Ambiguous root",
+			Roots:         []string{"Entity"},
+			ExpectedError: "invalid root: Entity is ambiguous, it can be domain.Entity, legacy.Entity",
+		},
+		{
+			Name:          "Unknown root",
+			Roots:         []string{"store.Missing"},
+			ExpectedError: "invalid root: unknown type store.Missing",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{"../testingsupport/crosspackage"},
+				Recursive:        true,
+				RenderingOptions: map[RenderingOption]interface{}{},
+				Exclude:          tc.Exclude,
+				Roots:            tc.Roots,
+				Depth:            tc.Depth,
+			})
+			if tc.ExpectedError != "" {
+				if err == nil || err.Error() != tc.ExpectedError {
+					t.Fatalf("Expected the error %q, got %v", tc.ExpectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			types := []string{}
+			for _, pack := range parser.Packages() {
+				for _, structure := range parser.Structs(pack) {
+					types = append(types, getFullTypeName(pack, structure.Name))
+				}
+			}
+			sort.Strings(types)
+			if !reflect.DeepEqual(types, tc.ExpectedTypes) {
+				t.Errorf("Expected types %v, got %v", tc.ExpectedTypes, types)
+			}
+		})
+	}
+}
+
+func TestNeighborhoodRender(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/crosspackage"},
+		Recursive:   true,
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderAggregations:      true,
+			AggregatePrivateMembers: true,
+		},
+		Roots: []string{"store.LegacyStore"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	if strings.Contains(result, "domain.") || strings.Contains(result, "store.Store") {
+		t.Errorf("Expected only the neighborhood of the root to be rendered, got\n%s", result)
+	}
+	if !strings.Contains(result, `"store.LegacyStore" o-- "*" "legacy.Entity"`) {
+		t.Errorf("Expected the relationships of the neighborhood, got\n%s", result)
+	}
+}
+
+func TestNeighborhoodParseSource(t *testing.T) {
+	sources := map[string]string{
+		"a/a.go": "package a\n\ntype R struct{}\n\ntype X struct{}\n",
+		"b/b.go": "package b\n\nimport \"a\"\n\ntype Y struct {\n\ta.R\n\ta.X\n}\n",
+	}
+	options := &ClassDiagramOptions{
+		RenderingOptions: map[RenderingOption]interface{}{},
+		Roots:            []string{"a.R"},
+		Depth:            2,
+	}
+	oneShot, err := NewClassDiagramFromSource(sources, options)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expected := oneShot.Render()
+	if !strings.Contains(expected, "class X") || !strings.Contains(expected, `"a.X" *-- "b.Y"`) {
+		t.Fatalf("Expected the types reached through b.Y to be rendered, got\n%s", expected)
+	}
+	parser, err := NewClassParser(options)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if err := parser.ParseSource("a/a.go", []byte(sources["a/a.go"])); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if result := parser.Render(); strings.Contains(result, "class X") {
+		t.Errorf("Expected a.X to be outside of the neighborhood before b is parsed, got\n%s", result)
+	}
+	if err := parser.ParseSource("b/b.go", []byte(sources["b/b.go"])); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if result := parser.Render(); result != expected {
+		t.Errorf("Expected the types joining the neighborhood to be rendered once their code is added\n%s\ngot\n%s", expected, result)
+	}
+}
@@ -0,0 +1,93 @@
+package parser
+
+import "sort"
+
+// Orphans returns the full names of the parsed types that have no fields, no methods and no relationship rendered
+// with the current rendering options, sorted. They are the types left out by the HideOrphans rendering option.
+func (p *ClassParser) Orphans() []string {
+	p.Finalize()
+	result := []string{}
+	for orphan := range p.getOrphans() {
+		result = append(result, orphan)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// updateOrphans computes the types left out of the diagram for the current rendering options. It must be called
+// before rendering since the relationships of every type are needed to decide if a type is an orphan.
+func (p *ClassParser) updateOrphans() {
+	p.orphans = nil
+	if p.renderingOptions.HideOrphans {
+		p.orphans = p.getOrphans()
+	}
+}
+
+// isOrphan returns true if the given fully qualified type is left out of the diagram by the HideOrphans option
+func (p *ClassParser) isOrphan(fullName string) bool {
+	_, ok := p.orphans[fullName]
+	return ok
+}
+
+// getOrphans returns the rendered types without members and without any rendered relationship, either starting
+// or ending in them
+func (p *ClassParser) getOrphans() map[string]struct{} {
+	connected := map[string]struct{}{}
+	connect := func(from string, to string) {
+		if !p.isHiddenType(to) {
+			connected[from] = struct{}{}
+			connected[to] = struct{}{}
+		}
+	}
+	candidates := []string{}
+	for pack, structures := range p.structure {
+		for name, structure := range structures {
+			fullName := getFullTypeName(pack, name)
+			if p.isHiddenType(fullName) {
+				continue
+			}
+			if p.renderingOptions.ExportedOnly {
+				structure = p.getExportedStructure(structure)
+			}
+			if !hasMembers(structure) {
+				candidates = append(candidates, fullName)
+			}
+			for t := range p.getRenderedConnections(structure) {
+				connect(fullName, t)
+			}
+			if p.renderingOptions.Dependencies {
+				for _, t := range p.getDependencies(structure, name) {
+					connect(fullName, t)
+				}
+			}
+		}
+	}
+	if p.renderingOptions.Aliases {
+		for _, alias := range p.allAliases {
+			if !p.isHiddenType(alias.Name) {
+				connect(alias.Name, alias.AliasOf)
+			}
+		}
+	}
+	result := map[string]struct{}{}
+	for _, candidate := range candidates {
+		if _, ok := connected[candidate]; !ok {
+			result[candidate] = struct{}{}
+		}
+	}
+	return result
+}
+
+// hasMembers returns true if the structure has fields, enum values, type terms, methods or constructors. Embedded
+// fields are not members since they are rendered as compositions.
+func hasMembers(structure *Struct) bool {
+	if len(structure.EnumValues) > 0 || len(structure.TypeTerms) > 0 || len(structure.Functions) > 0 || len(structure.Constructors) > 0 {
+		return true
+	}
+	for _, field := range structure.Fields {
+		if !field.Embedded {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestOrphans(t *testing.T) {
+	tt := []struct {
+		Name             string
+		RenderingOptions map[RenderingOption]interface{}
+		Expected         []string
+	}{
+		{
+			Name:             "Default options",
+			RenderingOptions: map[RenderingOption]interface{}{},
+			Expected:         []string{"zoo.Any", "zoo.Collar", "zoo.Marker"},
+		},
+		{
+			Name: "Aggregated types are connected",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderAggregations: true,
+			},
+			Expected: []string{"zoo.Any", "zoo.Marker"},
+		},
+		{
+			Name: "Types connected by hidden relationships",
+			RenderingOptions: map[RenderingOption]interface{}{
+				RenderCompositions:    false,
+				RenderImplementations: false,
+			},
+			Expected: []string{"zoo.Any", "zoo.Collar", "zoo.Marker", "zoo.Puppy"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{"../testingsupport/orphans"},
+				RenderingOptions: tc.RenderingOptions,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			if orphans := parser.Orphans(); !reflect.DeepEqual(orphans, tc.Expected) {
+				t.Errorf("Expected orphans %v got %v", tc.Expected, orphans)
+			}
+		})
+	}
+}
+
+func TestRenderHideOrphans(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/orphans"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderHideOrphans: true,
+			RenderDocNotes:    true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	rendered := map[string]string{
+		"class %s":        parser.Render(),
+		`"zoo.%s" [label`: parser.RenderDOT(),
+	}
+	for format, result := range rendered {
+		for _, orphan := range []string{"Collar", "Marker"} {
+			if strings.Contains(result, fmt.Sprintf(format, orphan)) {
+				t.Errorf("Expected %s to be hidden in\n%s", orphan, result)
+			}
+		}
+		for _, connected := range []string{"Dog", "Kennel", "Puppy"} {
+			if !strings.Contains(result, fmt.Sprintf(format, connected)) {
+				t.Errorf("Expected %s to be rendered in\n%s", connected, result)
+			}
+		}
+		if strings.Contains(result, "empty marker") {
+			t.Errorf("Expected the notes of the orphans to be hidden in\n%s", result)
+		}
+	}
+	if err := parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderHideOrphans: false}); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if result := parser.Render(); !strings.Contains(result, "class Marker") {
+		t.Errorf("Expected the orphans to be rendered in\n%s", result)
+	}
+}
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"io"
+	"strings"
+)
+
+// RenderOverview returns the overview diagram of the parsed packages, see RenderOverviewTo
+func (p *ClassParser) RenderOverview() string {
+	str := &strings.Builder{}
+	// Writing into a strings.Builder never fails
+	p.RenderOverviewTo(str)
+	return str.String()
+}
+
+// RenderOverviewTo writes a diagram with all the parsed types, without their members, and only the relationships
+// among types of different packages into the given writer. Along with the diagrams of every package written by
+// RenderPackageTo it splits a large diagram into readable ones.
+func (p *ClassParser) RenderOverviewTo(w io.Writer) error {
+	p.Finalize()
+	return p.renderDiagram(w, p.getSortedPackages(), p.renderingOptions.Title, true)
+}
+
+// getOverviewStructures returns a copy of the given structures without methods and with only the relationships to
+// types of other packages. Fields are kept to find the multiplicity of the aggregations.
+func getOverviewStructures(structures map[string]*Struct) map[string]*Struct {
+	result := map[string]*Struct{}
+	for name, structure := range structures {
+		overview := *structure
+		overview.Functions = nil
+		overview.Constructors = nil
+		overview.Composition = getOtherPackageTypes(structure.Composition, structure)
+		overview.Extends = getOtherPackageTypes(structure.Extends, structure)
+		overview.Implements = getOtherPackageTypes(structure.Implements, structure)
+		overview.PointerImplements = getOtherPackageTypes(structure.PointerImplements, structure)
+		overview.Aggregations = getOtherPackageTypes(structure.Aggregations, structure)
+		overview.PrivateAggregations = getOtherPackageTypes(structure.PrivateAggregations, structure)
+		result[name] = &overview
+	}
+	return result
+}
+
+// getOtherPackageTypes returns the given types that are not declared in the package of the structure
+func getOtherPackageTypes(types map[string]struct{}, structure *Struct) map[string]struct{} {
+	result := map[string]struct{}{}
+	for t := range types {
+		if pack, _ := splitFullTypeName(getEmbeddedTypeName(t, structure)); pack != structure.PackageName {
+			result[t] = struct{}{}
+		}
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRenderOverview(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/interfaceembedding"},
+		Recursive:   true,
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderAggregations: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.RenderOverview()
+	for _, expected := range []string{
+		"namespace app {\n    interface Cache  {\n    }\n",
+		"namespace store {\n    interface Reader  {\n    }\n",
+		`"store.Reader" <|.. "app.Memory"`,
+		`"store.Writer" <|-- "app.Repository"`,
+		"hide members\n@enduml\n",
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected the overview to contain %s, got\n%s", expected, result)
+		}
+	}
+	for _, unexpected := range []string{"Get(key string)", `"app.Cache" <|.. "app.Memory"`, `"app.Getter" <|-- "app.Cache"`, `o--`} {
+		if strings.Contains(result, unexpected) {
+			t.Errorf("Expected the overview not to contain %s, got\n%s", unexpected, result)
+		}
+	}
+	pack, err := parser.RenderPackage("app")
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if !strings.Contains(pack, "+ Get(key string) string") || !strings.Contains(pack, `"app.Cache" <|.. "app.Memory"`) {
+		t.Errorf("Expected the diagram of the app package with its members, got\n%s", pack)
+	}
+	if _, err := parser.RenderPackage("missing"); err == nil {
+		t.Errorf("Expected an error for a missing package")
+	}
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+)
+
+// The files of a directory that are only built for some platforms or tags, like foo_linux.go and foo_windows.go, can
+// declare the same types, methods and functions. Only their first declaration, in the order of the file names, is
+// parsed so the classes are not merged into a mix of both. See ClassDiagramOptions.BuildContext to parse only the
+// files of a platform instead. Packages with the same name in different directories are still merged.
+
+// isRedeclaredType returns true, and adds a warning, if a type with the given name was already declared in the
+// given package by a file of the same directory
+func (p *ClassParser) isRedeclaredType(packageName string, name string, pos token.Pos) bool {
+	st, ok := p.structure[packageName][name]
+	if !ok || !st.Position.IsValid() || !p.isSameDirectory(st.Position, pos) {
+		return false
+	}
+	p.addRedeclarationWarning(getFullTypeName(packageName, name), pos, st.Position)
+	return true
+}
+
+// isRedeclaredMethod returns true, and adds a warning, if the structure already has a method with the given name
+// declared by a file of the same directory
+func (p *ClassParser) isRedeclaredMethod(st *Struct, name string, pos token.Pos) bool {
+	for _, method := range st.Functions {
+		if method.Name == name && p.isSameDirectory(method.Position, pos) {
+			p.addRedeclarationWarning(fmt.Sprintf("%s.%s.%s", st.PackageName, st.Name, name), pos, method.Position)
+			return true
+		}
+	}
+	return false
+}
+
+// isRedeclaredFunction returns true, and adds a warning, if a package level function with the given name was
+// already declared in the given package by a file of the same directory
+func (p *ClassParser) isRedeclaredFunction(packageName string, name string, pos token.Pos) bool {
+	fullName := getFullTypeName(packageName, name)
+	first, ok := p.functionPositions[fullName]
+	if !ok || !p.isSameDirectory(first, pos) {
+		if p.functionPositions == nil {
+			p.functionPositions = map[string]token.Position{}
+		}
+		p.functionPositions[fullName] = p.getPosition(pos)
+		return false
+	}
+	p.addRedeclarationWarning(fullName, pos, first)
+	return true
+}
+
+// isSameDirectory returns true if the given position of a declaration is in the directory of the file being parsed
+func (p *ClassParser) isSameDirectory(first token.Position, pos token.Pos) bool {
+	return filepath.Dir(first.Filename) == filepath.Dir(p.getPosition(pos).Filename)
+}
+
+// addRedeclarationWarning adds the warning of a declaration found again at the given position
+func (p *ClassParser) addRedeclarationWarning(name string, pos token.Pos, first token.Position) {
+	p.warnings = append(p.warnings, fmt.Errorf("%s: %s is declared again, keeping the declaration of %s", p.getPosition(pos), name, first))
+}
@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule is an architectural rule checked against the parsed types and the relationships among them, see Check. The
+// rules are ForbiddenDependencyRule, ImplementationRule and MaxImplementationsRule.
+type Rule interface {
+	// String describes the rule in the violations
+	String() string
+	// check returns the offending types of the rule, or an error if the rule can not be checked
+	check(p *ClassParser) ([]string, error)
+}
+
+// Violation is a rule broken by the parsed code with the offending types, like the relationships that are not
+// allowed or the types that do not implement the required interface
+type Violation struct {
+	Rule  Rule
+	Types []string
+}
+
+// ForbiddenDependencyRule forbids the types of a package to have any relationship, including dependencies, to the
+// types of the other packages. Packages are the names used in the diagram, import paths with the ImportPaths option,
+// and match their subpackages too.
+type ForbiddenDependencyRule struct {
+	Package   string
+	Forbidden []string
+}
+
+// ImplementationRule requires every struct whose package.TypeName matches the Types regular expression to implement
+// the Interface
+type ImplementationRule struct {
+	Types     string
+	Interface string
+}
+
+// MaxImplementationsRule limits the number of types implementing the Interface
+type MaxImplementationsRule struct {
+	Interface string
+	Max       int
+}
+
+// Check returns the violations of the given rules in the order of the rules. An error is returned for the rules that
+// can not be checked, like the ones with an invalid regular expression or an unknown interface.
+func (p *ClassParser) Check(rules []Rule) ([]*Violation, error) {
+	violations := []*Violation{}
+	for _, rule := range rules {
+		types, err := rule.check(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rule, err)
+		}
+		if len(types) > 0 {
+			violations = append(violations, &Violation{Rule: rule, Types: types})
+		}
+	}
+	return violations, nil
+}
+
+// String describes the rule
+func (r *ForbiddenDependencyRule) String() string {
+	return fmt.Sprintf("%s must not depend on %s", r.Package, strings.Join(r.Forbidden, ", "))
+}
+
+// check returns the sorted relationships from the package to the forbidden ones, like
+// store.Order -> http.Client (aggregates). The types referenced by the fields, methods and functions of the package
+// are dependencies too, like store.Send -> http.Request (depends), whether their package was parsed or not.
+func (r *ForbiddenDependencyRule) check(p *ClassParser) ([]string, error) {
+	result := []string{}
+	found := map[string]struct{}{}
+	add := func(relationship Relationship) {
+		fromPackage, _ := splitFullTypeName(relationship.From)
+		toPackage, _ := splitFullTypeName(relationship.To)
+		if !isPackageOrSubpackage(fromPackage, r.Package) || !r.isForbidden(toPackage) {
+			return
+		}
+		found[relationship.From+" "+relationship.To] = struct{}{}
+		result = append(result, fmt.Sprintf("%s -> %s (%s)", relationship.From, relationship.To, relationship.Kind))
+	}
+	for _, relationship := range p.Relationships() {
+		add(relationship)
+	}
+	for _, reference := range p.getReferences() {
+		// Dependencies are only reported between types without any other relationship
+		if _, ok := found[reference.From+" "+reference.To]; !ok {
+			add(reference)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// isForbidden returns true if the given package is one of the forbidden ones or one of their subpackages
+func (r *ForbiddenDependencyRule) isForbidden(pack string) bool {
+	for _, forbidden := range r.Forbidden {
+		if isPackageOrSubpackage(pack, forbidden) {
+			return true
+		}
+	}
+	return false
+}
+
+// getReferences returns a dependency from every parsed type to each type referenced by its fields, methods and
+// constructors, and from every package level function, named package.Function, to the types of its signature. The
+// referenced types are included whether they were parsed or not.
+func (p *ClassParser) getReferences() []Relationship {
+	var result []Relationship
+	add := func(from string, types []string) {
+		for _, t := range types {
+			result = append(result, Relationship{Kind: RelationshipDepends, From: from, To: t})
+		}
+	}
+	for _, pack := range p.Packages() {
+		for name, structure := range p.getStructures(pack) {
+			from := getFullTypeName(pack, name)
+			for _, field := range structure.Fields {
+				add(from, field.ReferencedTypes)
+			}
+			for _, function := range append(append([]*Function{}, structure.Functions...), structure.Constructors...) {
+				add(from, function.ReferencedTypes)
+			}
+		}
+	}
+	for _, function := range p.allFunctions {
+		add(getFullTypeName(function.PackageName, function.Name), function.ReferencedTypes)
+	}
+	return result
+}
+
+// isPackageOrSubpackage returns true if the given package is the other one or one of its subpackages
+func isPackageOrSubpackage(pack string, other string) bool {
+	return pack == other || strings.HasPrefix(pack, other+"/")
+}
+
+// String describes the rule
+func (r *ImplementationRule) String() string {
+	return fmt.Sprintf("the structs matching %s must implement %s", r.Types, r.Interface)
+}
+
+// check returns the sorted structs matching the expression that do not implement the interface
+func (r *ImplementationRule) check(p *ClassParser) ([]string, error) {
+	types, err := regexp.Compile(r.Types)
+	if err != nil {
+		return nil, fmt.Errorf("invalid types expression: %w", err)
+	}
+	inter, err := p.resolveInterfaceName(r.Interface)
+	if err != nil {
+		return nil, err
+	}
+	implementations, err := p.Implementations(inter)
+	if err != nil {
+		return nil, err
+	}
+	implemented := map[string]struct{}{}
+	for _, implementation := range implementations {
+		implemented[implementation] = struct{}{}
+	}
+	result := []string{}
+	for _, pack := range p.Packages() {
+		for name, structure := range p.getStructures(pack) {
+			fullName := getFullTypeName(pack, name)
+			if _, ok := implemented[fullName]; ok || structure.Kind() != ClassKind || !types.MatchString(fullName) {
+				continue
+			}
+			result = append(result, fullName)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// String describes the rule
+func (r *MaxImplementationsRule) String() string {
+	return fmt.Sprintf("%s must have at most %d implementations", r.Interface, r.Max)
+}
+
+// check returns all the implementations of the interface if there are too many of them
+func (r *MaxImplementationsRule) check(p *ClassParser) ([]string, error) {
+	inter, err := p.resolveInterfaceName(r.Interface)
+	if err != nil {
+		return nil, err
+	}
+	implementations, err := p.Implementations(inter)
+	if err != nil || len(implementations) <= r.Max {
+		return nil, err
+	}
+	return implementations, nil
+}
+
+// resolveInterfaceName returns the fully qualified name of the parsed interface with the given name, see
+// resolveTypeName
+func (p *ClassParser) resolveInterfaceName(name string) (string, error) {
+	fullName, err := p.resolveTypeName(name)
+	if err != nil {
+		return "", err
+	}
+	if st := p.getStruct(fullName); st == nil || st.Kind() != InterfaceKind {
+		return "", fmt.Errorf("%s is not an interface", fullName)
+	}
+	return fullName, nil
+}
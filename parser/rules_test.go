@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// getRulesParser returns the parser of a small layered application for the rule tests
+func getRulesParser(t *testing.T) *ClassParser {
+	parser, err := NewClassDiagramFromSource(map[string]string{
+		"domain/order.go": `package domain
+
+type Repository interface {
+	Save(o *Order) error
+}
+
+type Order struct {
+	ID string
+}
+`,
+		"store/store.go": `package store
+
+import (
+	"domain"
+	"net/http"
+	"web"
+)
+
+type MemoryStore struct {
+	orders []*domain.Order
+}
+
+func (s *MemoryStore) Save(o *domain.Order) error {
+	return nil
+}
+
+type FileStore struct{}
+
+func (s *FileStore) Save(o *domain.Order) error {
+	return nil
+}
+
+type CacheStore struct{}
+
+func (s *CacheStore) Send(r *http.Request) {}
+
+func Free(h *web.Handler) {}
+`,
+		"store/sql/sql.go": `package sql
+
+import "domain"
+
+type SQLStore struct{}
+
+func (s *SQLStore) Save(o *domain.Order) error {
+	return nil
+}
+`,
+	}, &ClassDiagramOptions{RenderingOptions: map[RenderingOption]interface{}{}})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	return parser
+}
+
+func TestCheck(t *testing.T) {
+	tt := []struct {
+		name     string
+		rule     Rule
+		expected []string
+	}{
+		{
+			name: "forbidden dependency",
+			rule: &ForbiddenDependencyRule{Package: "store", Forbidden: []string{"domain"}},
+			expected: []string{
+				"store.FileStore -> domain.Order (depends)",
+				"store.FileStore -> domain.Repository (implements)",
+				"store.MemoryStore -> domain.Order (aggregates)",
+				"store.MemoryStore -> domain.Repository (implements)",
+			},
+		},
+		{
+			name:     "dependency of a method on a package that was not parsed",
+			rule:     &ForbiddenDependencyRule{Package: "store", Forbidden: []string{"http"}},
+			expected: []string{"store.CacheStore -> http.Request (depends)"},
+		},
+		{
+			name:     "dependency of a package level function",
+			rule:     &ForbiddenDependencyRule{Package: "store", Forbidden: []string{"web"}},
+			expected: []string{"store.Free -> web.Handler (depends)"},
+		},
+		{
+			name:     "allowed dependency",
+			rule:     &ForbiddenDependencyRule{Package: "domain", Forbidden: []string{"store", "sql"}},
+			expected: nil,
+		},
+		{
+			name:     "must implement",
+			rule:     &ImplementationRule{Types: `Store$`, Interface: "Repository"},
+			expected: []string{"store.CacheStore"},
+		},
+		{
+			name:     "matching none",
+			rule:     &ImplementationRule{Types: `^domain\.`, Interface: "domain.Repository"},
+			expected: []string{"domain.Order"},
+		},
+		{
+			name:     "too many implementations",
+			rule:     &MaxImplementationsRule{Interface: "domain.Repository", Max: 2},
+			expected: []string{"sql.SQLStore", "store.FileStore", "store.MemoryStore"},
+		},
+		{
+			name:     "enough implementations",
+			rule:     &MaxImplementationsRule{Interface: "domain.Repository", Max: 3},
+			expected: nil,
+		},
+	}
+	parser := getRulesParser(t)
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			violations, err := parser.Check([]Rule{tc.rule})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			var result []string
+			for _, violation := range violations {
+				if violation.Rule != tc.rule {
+					t.Errorf("Expected the violation of %s, got %s", tc.rule, violation.Rule)
+				}
+				result = append(result, violation.Types...)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	parser := getRulesParser(t)
+	for _, rule := range []Rule{
+		&ImplementationRule{Types: `(`, Interface: "Repository"},
+		&ImplementationRule{Types: `.*`, Interface: "Missing"},
+		&MaxImplementationsRule{Interface: "domain.Order", Max: 1},
+	} {
+		if _, err := parser.Check([]Rule{rule}); err == nil {
+			t.Errorf("Expected an error checking %s", rule)
+		}
+	}
+}
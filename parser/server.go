@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxServerErrorLength is how much of the body of a failed response is used in the returned error
+const maxServerErrorLength = 1024
+
+// RenderImage sends the rendered diagram to the given PlantUML server, for example https://www.plantuml.com/plantuml,
+// and streams the image it returns in the given format (svg or png) into w. The request is cancelled with ctx.
+func (p *ClassParser) RenderImage(ctx context.Context, serverBase string, format string, w io.Writer) error {
+	return RenderPlantUMLImage(ctx, serverBase, format, p.Render(), w)
+}
+
+// RenderPlantUMLImage sends the given diagram text to the PlantUML server like RenderImage. It can be used to render
+// a diagram that was rendered before, so the ClassParser is not used while waiting for the server.
+func RenderPlantUMLImage(ctx context.Context, serverBase string, format string, text string, w io.Writer) error {
+	url := strings.TrimSuffix(serverBase, "/") + "/" + format
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(text))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return getServerError(response)
+	}
+	_, err = io.Copy(w, response.Body)
+	return err
+}
+
+// getServerError returns the error of a failed PlantUML server response. Diagram errors are reported in the
+// X-PlantUML-Diagram-Error header, anything else in the body.
+func getServerError(response *http.Response) error {
+	message := response.Header.Get("X-PlantUML-Diagram-Error")
+	if message != "" {
+		if line := response.Header.Get("X-PlantUML-Diagram-Error-Line"); line != "" {
+			message = fmt.Sprintf("%s at line %s", message, line)
+		}
+	} else {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxServerErrorLength))
+		message = strings.TrimSpace(string(body))
+	}
+	if message == "" {
+		return fmt.Errorf("the PlantUML server returned %s", response.Status)
+	}
+	return fmt.Errorf("the PlantUML server returned %s: %s", response.Status, message)
+}
@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRenderImage(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/connectionlabels"},
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	tt := []struct {
+		Name          string
+		Handler       http.HandlerFunc
+		Context       func() context.Context
+		Expected      string
+		ExpectedError string
+	}{
+		{
+			Name: "Image",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if r.Method != http.MethodPost || r.URL.Path != "/plantuml/svg" || string(body) != parser.Render() {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Write([]byte("<svg/>"))
+			},
+			Expected: "<svg/>",
+		},
+		{
+			Name: "Diagram error",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-PlantUML-Diagram-Error", "Syntax Error?")
+				w.Header().Set("X-PlantUML-Diagram-Error-Line", "3")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("<svg/>"))
+			},
+			ExpectedError: "the PlantUML server returned 400 Bad Request: Syntax Error? at line 3",
+		},
+		{
+			Name: "Server error",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "out of memory", http.StatusInternalServerError)
+			},
+			ExpectedError: "the PlantUML server returned 500 Internal Server Error: out of memory",
+		},
+		{
+			Name: "Cancelled",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("<svg/>"))
+			},
+			Context: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			ExpectedError: "context canceled",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			server := httptest.NewServer(tc.Handler)
+			defer server.Close()
+			ctx := context.Background()
+			if tc.Context != nil {
+				ctx = tc.Context()
+			}
+			result := &strings.Builder{}
+			err := parser.RenderImage(ctx, server.URL+"/plantuml/", "svg", result)
+			if tc.ExpectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.ExpectedError) {
+					t.Fatalf("Expected error %s but got %v", tc.ExpectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			if result.String() != tc.Expected {
+				t.Errorf("Expected %s got %s", tc.Expected, result.String())
+			}
+		})
+	}
+}
+
+func TestRenderPlantUMLImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte("<svg>" + string(body) + "</svg>"))
+	}))
+	defer server.Close()
+	result := &strings.Builder{}
+	if err := RenderPlantUMLImage(context.Background(), server.URL, "svg", "@startuml\n@enduml\n", result); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expected := "<svg>@startuml\n@enduml\n</svg>"
+	if result.String() != expected {
+		t.Errorf("Expected %s got %s", expected, result.String())
+	}
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+)
+
+// NewClassDiagramFromSource returns a ClassParser with the given go sources keyed by their file names, like the
+// contents of a commit read from a Git API, instead of the Directories and Files of the options. The files are
+// grouped into packages by their directory and their package clause, like the files of a directory on disk. Nil
+// options are the zero ClassDiagramOptions.
+func NewClassDiagramFromSource(sources map[string]string, options *ClassDiagramOptions) (*ClassParser, error) {
+	classParser, err := NewClassParser(options)
+	if err != nil {
+		return nil, err
+	}
+	byteSources := make(map[string][]byte, len(sources))
+	for filename, src := range sources {
+		byteSources[filename] = []byte(src)
+	}
+	if err := classParser.parseSources(byteSources); err != nil {
+		return nil, err
+	}
+	classParser.Finalize()
+	return classParser, nil
+}
+
+// ParseSource parses the given go source as the file with the given name into the structure and looks for interface
+// implementations again, like ParseFile does with a file on disk. The name is only used to group the file with the
+// other files of its directory and in the positions of the declarations.
+func (p *ClassParser) ParseSource(filename string, src []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.parseSources(map[string][]byte{filename: src}); err != nil {
+		return err
+	}
+	p.finalize()
+	return nil
+}
+
+// parseSources parses the given sources keyed by their file names and adds them into the structure one directory at
+// a time, in the order of the directory names. Test files, unless the IncludeTests option is set, and the files that
+// do not match the build context are skipped like in the directories on disk. The error of the first file that can not be parsed is returned and
+// nothing is added in that case.
+func (p *ClassParser) parseSources(sources map[string][]byte) error {
+	filenames := make([]string, 0, len(sources))
+	for filename := range sources {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	fileSet := token.NewFileSet()
+	directories := []string{}
+	packagesByDirectory := map[string]map[string]*ast.Package{}
+	for _, filename := range filenames {
+		f, err := parser.ParseFile(fileSet, filename, sources[filename], parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		if !p.options.BuildContext.matchFile(filepath.Base(filename), f) {
+			continue
+		}
+		dir := filepath.Dir(filename)
+		packages, ok := packagesByDirectory[dir]
+		if !ok {
+			packages = map[string]*ast.Package{}
+			packagesByDirectory[dir] = packages
+			directories = append(directories, dir)
+		}
+		addFileToPackages(packages, filename, f)
+	}
+	sort.Strings(directories)
+	for _, dir := range directories {
+		p.parsePackages(fileSet, packagesByDirectory[dir])
+	}
+	p.finalized = false
+	return nil
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestNewClassDiagramFromSource(t *testing.T) {
+	parser, err := NewClassDiagramFromSource(map[string]string{
+		// The methods are found before their type in the order of the file names
+		"app/methods.go": `package app
+
+func (u *User) GetName() string {
+	return u.Name
+}
+`,
+		"app/user.go": `package app
+
+// User is a user
+type User struct {
+	Name string
+}
+`,
+		"app/user_test.go": `package app
+
+type Fixture struct{}
+`,
+		"store/store.go": `package store
+
+type Named interface {
+	GetName() string
+}
+`,
+	}, &ClassDiagramOptions{RenderingOptions: map[RenderingOption]interface{}{}})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expected := `@startuml
+namespace app {
+    class User << (S,Aquamarine) >> {
+        + Name string
+        + GetName() string
+    }
+}
+"store.Named" <|.. "app.User"
+namespace store {
+    interface Named  {
+        + {abstract} GetName() string
+    }
+}
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("Expected \n%s\n got \n%s\n", expected, result)
+	}
+	if doc := parser.getStruct("app.User").Doc; doc != "User is a user" {
+		t.Errorf("Expected the doc comment of User, got %q", doc)
+	}
+}
+
+func TestNewClassDiagramFromSourceMatchesDirectories(t *testing.T) {
+	dir := "../testingsupport/platforms"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := map[string]string{}
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources[filepath.Join(dir, entry.Name())] = string(content)
+	}
+	for _, buildContext := range []*BuildContext{nil, {GOOS: "windows", GOARCH: "amd64"}} {
+		fromDirectory, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+			FileSystem:   afero.NewOsFs(),
+			Directories:  []string{dir},
+			BuildContext: buildContext,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error but got %s", err.Error())
+		}
+		fromSource, err := NewClassDiagramFromSource(sources, &ClassDiagramOptions{BuildContext: buildContext})
+		if err != nil {
+			t.Fatalf("Expected no error but got %s", err.Error())
+		}
+		if expected, result := fromDirectory.Render(), fromSource.Render(); result != expected {
+			t.Errorf("Expected the diagram of the sources to be\n%s\ngot\n%s", expected, result)
+		}
+		if expected, result := len(fromDirectory.Warnings()), len(fromSource.Warnings()); result != expected {
+			t.Errorf("Expected %d warnings, got %d", expected, result)
+		}
+	}
+}
+
+func TestNewClassDiagramFromSourceNilOptions(t *testing.T) {
+	parser, err := NewClassDiagramFromSource(map[string]string{"app/user.go": "package app\n\ntype User struct{}\n"}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if result := parser.Render(); !strings.Contains(result, "class User << (S,Aquamarine) >> {") {
+		t.Errorf("Expected User to be rendered with the default options, got\n%s", result)
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	parser, err := NewClassParser(&ClassDiagramOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if err := parser.ParseSource("app/broken.go", []byte("package app\n\ntype Broken struct {\n")); err == nil {
+		t.Errorf("Expected an error parsing an incomplete source")
+	}
+	if err := parser.ParseSource("app/user.go", []byte("package app\n\ntype User struct{}\n")); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	if err := parser.ParseSource("app/methods.go", []byte("package app\n\nfunc (u User) String() string {\n\treturn \"\"\n}\n")); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	if !strings.Contains(result, "class User << (S,Aquamarine) >> {\n        + String() string\n    }") {
+		t.Errorf("Expected User with its method, got\n%s", result)
+	}
+	if position := parser.getStruct("app.User").Position.String(); position != "app/user.go:3:6" {
+		t.Errorf("Expected User to be declared in app/user.go:3:6, got %s", position)
+	}
+	if strings.Contains(result, "Broken") {
+		t.Errorf("Expected nothing to be added from the incomplete source, got\n%s", result)
+	}
+}
@@ -2,20 +2,60 @@ package parser
 
 import (
 	"go/ast"
-	"unicode"
+	"go/token"
+	"strconv"
+	"strings"
 )
 
 //Struct represent a struct in golang, it can be of Type "class" or "interface" and can be associated
-//with other structs via Composition and Extends
+//with other structs via Composition, Extends and Implements
 type Struct struct {
+	// Name is the name of the type without its package
+	Name                string
 	PackageName         string
 	Functions           []*Function
 	Fields              []*Field
 	Type                string
+	TypeParameters      []*Field
 	Composition         map[string]struct{}
 	Extends             map[string]struct{}
+	Implements          map[string]struct{}
 	Aggregations        map[string]struct{}
 	PrivateAggregations map[string]struct{}
+	// Constructors are the package level functions that create this type. They are not part of its method set
+	Constructors []*Function
+	// EnumValues are the names of the constants declared with this named type. Types with values are rendered as
+	// enums
+	EnumValues []string
+	// Doc is the doc comment of the type declaration
+	Doc string
+	// Position is where the type is declared
+	Position token.Position
+	// PointerImplements are the interfaces in Implements that only the pointer to this type implements. They are
+	// only found with the BothMethodSets option
+	PointerImplements map[string]struct{}
+	// ExtendsTypeArguments are the full type arguments of the generic interfaces in Extends, like string for an
+	// embedded Getter[string]
+	ExtendsTypeArguments map[string][]string
+	// Label is the name rendered for the classes made for anonymous structs, like Post.Meta. The Name is used when
+	// it is empty
+	Label string
+	// TypeTerms are the type set elements of a constraint interface, like ~int | ~float64, one for each line of its
+	// declaration. Interfaces with type terms can only be used as type constraints so nothing implements them
+	TypeTerms []string
+}
+
+// isTest returns true if the struct is declared in a test file
+func (st *Struct) isTest() bool {
+	return isTestFile(st.Position.Filename)
+}
+
+// getLabel returns the name rendered for the struct
+func (st *Struct) getLabel() string {
+	if st.Label != "" {
+		return st.Label
+	}
+	return st.Name
 }
 
 // ImplementsInterface returns true if the struct st conforms ot the given interface
@@ -48,7 +88,7 @@ func (st *Struct) AddToComposition(fType string) {
 	if fType[0] == "*"[0] {
 		fType = fType[1:]
 	}
-	st.Composition[fType] = struct{}{}
+	st.addType(st.Composition, fType)
 }
 
 //AddToExtends Adds an extends relationship to this struct. We want to make sure that *ExampleStruct
@@ -61,7 +101,42 @@ func (st *Struct) AddToExtends(fType string) {
 	if fType[0] == "*"[0] {
 		fType = fType[1:]
 	}
-	st.Extends[fType] = struct{}{}
+	st.addType(st.Extends, fType)
+}
+
+//AddToImplements adds an interface realization to this struct. The given type is the full name of the
+//interface that this struct implements
+func (st *Struct) AddToImplements(fType string) {
+	if len(fType) == 0 {
+		return
+	}
+	if st.Implements == nil {
+		st.Implements = make(map[string]struct{})
+	}
+	st.addType(st.Implements, fType)
+}
+
+// addType adds the given type to the set unless it is already there, either qualified with the package of the
+// structure or without it, since both are rendered as the same class
+func (st *Struct) addType(types map[string]struct{}, fType string) {
+	equivalent := st.PackageName + "." + fType
+	if packageName, name := splitFullTypeName(fType); packageName == st.PackageName {
+		equivalent = name
+	} else if packageName != "" {
+		equivalent = ""
+	}
+	if _, ok := types[equivalent]; !ok {
+		types[fType] = struct{}{}
+	}
+}
+
+// addToPointerImplements adds an interface realization that only the pointer to this struct implements
+func (st *Struct) addToPointerImplements(fType string) {
+	st.AddToImplements(fType)
+	if st.PointerImplements == nil {
+		st.PointerImplements = make(map[string]struct{})
+	}
+	st.PointerImplements[fType] = struct{}{}
 }
 
 //AddToAggregation adds an aggregation type to the list of aggregations
@@ -75,27 +150,40 @@ func (st *Struct) addToPrivateAggregation(fType string) {
 }
 
 //AddField adds a field into this structure. It parses the ast.Field and extract all
-//needed information
+//needed information. Fields declared together, like x, y int, are added as separate fields in the same order.
+//Embedded types are added as compositions and as Embedded fields named after the type
 func (st *Struct) AddField(field *ast.Field, aliases map[string]string) {
-	theType, fundamentalTypes := getFieldType(field.Type, aliases)
-	theType = replacePackageConstant(theType, "")
+	rawType, fundamentalTypes := getFieldType(field.Type, aliases)
+	theType := replacePackageConstant(rawType, "")
+	tag := getFieldTag(field)
 	if field.Names != nil {
-		theType = replacePackageConstant(theType, "")
-		newField := &Field{
-			Name: field.Names[0].Name,
-			Type: theType,
-		}
-		st.Fields = append(st.Fields, newField)
-		if unicode.IsUpper(rune(newField.Name[0])) {
-			for _, t := range fundamentalTypes {
-				st.AddToAggregation(replacePackageConstant(t, st.PackageName))
+		for _, name := range field.Names {
+			newField := &Field{
+				Name:     name.Name,
+				Type:     theType,
+				FullType: replacePackageConstant(rawType, st.PackageName),
+				Tag:      tag,
 			}
-		} else {
 			for _, t := range fundamentalTypes {
-				st.addToPrivateAggregation(replacePackageConstant(t, st.PackageName))
+				if st.isTypeParameter(t) {
+					continue
+				}
+				newField.ReferencedTypes = append(newField.ReferencedTypes, replacePackageConstant(t, st.PackageName))
+			}
+			if len(newField.ReferencedTypes) > 0 {
+				newField.Multiplicity = getMultiplicity(field.Type)
 			}
+			st.Fields = append(st.Fields, newField)
+			st.addFieldAggregations(newField, newField.ReferencedTypes)
 		}
 	} else if field.Type != nil {
+		st.Fields = append(st.Fields, &Field{
+			Name:     getEmbeddedFieldName(theType),
+			Type:     theType,
+			FullType: replacePackageConstant(rawType, st.PackageName),
+			Tag:      tag,
+			Embedded: true,
+		})
 		if theType[0] == "*"[0] {
 			theType = theType[1:]
 		}
@@ -103,12 +191,122 @@ func (st *Struct) AddField(field *ast.Field, aliases map[string]string) {
 	}
 }
 
+// isEmbeddedPointer returns true if the given type, as stored in the compositions, is embedded as a pointer
+func (st *Struct) isEmbeddedPointer(embedded string) bool {
+	for _, field := range st.Fields {
+		if field.Embedded && field.Type == "*"+embedded {
+			return true
+		}
+	}
+	return false
+}
+
+// getFieldTag returns the unquoted tag of the given struct field
+func getFieldTag(field *ast.Field) string {
+	tag := ""
+	if field.Tag != nil {
+		tag, _ = strconv.Unquote(field.Tag.Value)
+	}
+	return tag
+}
+
+// getEmbeddedFieldName returns the name of the field of an embedded type, which is the type name without its
+// package, pointer and type arguments
+func getEmbeddedFieldName(embeddedType string) string {
+	name := strings.TrimPrefix(embeddedType, "*")
+	name = strings.SplitN(name, "[", 2)[0]
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// addFieldAggregations adds the aggregations to the given types, which are referenced by the given field
+func (st *Struct) addFieldAggregations(field *Field, referencedTypes []string) {
+	for _, t := range referencedTypes {
+		if isPrivate(field.Name) {
+			st.addToPrivateAggregation(t)
+		} else {
+			st.AddToAggregation(t)
+		}
+	}
+}
+
+// addChannelAggregations adds the aggregations to the types sent through the channels of the given field, whose
+// names are the last fields added with AddField. AddField does not aggregate them.
+func (st *Struct) addChannelAggregations(field *ast.Field, aliases map[string]string) {
+	if field.Names == nil || len(st.Fields) < len(field.Names) {
+		return
+	}
+	var channelTypes []string
+	for _, t := range getChannelTypes(field.Type, aliases) {
+		if !st.isTypeParameter(t) {
+			channelTypes = append(channelTypes, replacePackageConstant(t, st.PackageName))
+		}
+	}
+	if len(channelTypes) == 0 {
+		return
+	}
+	for _, newField := range st.Fields[len(st.Fields)-len(field.Names):] {
+		newField.ReferencedTypes = append(newField.ReferencedTypes, channelTypes...)
+		if newField.Multiplicity == "" {
+			newField.Multiplicity = getMultiplicity(field.Type)
+		}
+		st.addFieldAggregations(newField, channelTypes)
+	}
+}
+
 //AddMethod Parse the Field and if it is an ast.FuncType, then add the methods into the structure
 func (st *Struct) AddMethod(method *ast.Field, aliases map[string]string) {
 	f, ok := method.Type.(*ast.FuncType)
-	if !ok {
+	if !ok || len(method.Names) == 0 {
 		return
 	}
 	function := getFunction(f, method.Names[0].Name, aliases, st.PackageName)
 	st.Functions = append(st.Functions, function)
 }
+
+// isTypeTerm returns true if the given element of an interface is part of its type set, like ~int, int | string or
+// []byte, instead of a method or an embedded interface. The predeclared error and any are interfaces.
+func isTypeTerm(element ast.Expr) bool {
+	switch t := element.(type) {
+	case *ast.UnaryExpr, *ast.BinaryExpr, *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.StarExpr, *ast.StructType:
+		return true
+	case *ast.Ident:
+		return t.Name == "comparable" || isPrimitiveString(t.Name) && t.Name != "error" && t.Name != "any"
+	}
+	return false
+}
+
+// addTypeTerm adds the given type set element of a constraint interface, writing unions with | and approximations
+// with ~ like in the source code
+func (st *Struct) addTypeTerm(element ast.Expr, aliases map[string]string) {
+	term, _ := getFieldType(element, aliases)
+	st.TypeTerms = append(st.TypeTerms, replacePackageConstant(term, ""))
+}
+
+//AddTypeParameters adds the type parameters of a generic type declaration into this structure
+func (st *Struct) AddTypeParameters(typeParameters *ast.FieldList, aliases map[string]string) {
+	if typeParameters == nil {
+		return
+	}
+	for _, typeParameter := range typeParameters.List {
+		constraint, _ := getFieldType(typeParameter.Type, aliases)
+		constraint = replacePackageConstant(constraint, "")
+		for _, name := range typeParameter.Names {
+			st.TypeParameters = append(st.TypeParameters, &Field{
+				Name: name.Name,
+				Type: constraint,
+			})
+		}
+	}
+}
+
+// isTypeParameter returns true if the given type, as returned by getFieldType, is one of the type parameters
+// of this structure. Type parameters are not real types so they should not be related to anything.
+func (st *Struct) isTypeParameter(t string) bool {
+	t = replacePackageConstant(t, "")
+	for _, typeParameter := range st.TypeParameters {
+		if typeParameter.Name == t {
+			return true
+		}
+	}
+	return false
+}
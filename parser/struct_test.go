@@ -368,8 +368,9 @@ func TestAddField(t *testing.T) {
 		t.Errorf("TestAddField: Expected st.Fields to have exactly one element but it has %d elements", len(st.Fields))
 	}
 	testField := &Field{
-		Name: "foo",
-		Type: "int",
+		Name:     "foo",
+		Type:     "int",
+		FullType: "int",
 	}
 	if !reflect.DeepEqual(st.Fields[0], testField) {
 		t.Errorf("TestAddField: Expected st.Fields[0] to have %v, got %v", testField, st.Fields[0])
@@ -401,6 +402,36 @@ func TestAddField(t *testing.T) {
 	if !arrayContains(st.Aggregations, "main.FooComposed") {
 		t.Errorf("TestAddField: Expecting main.FooComposed to be part of the aggregations ,but the array had %v", st.Aggregations)
 	}
+	if fullType := st.Fields[1].FullType; fullType != "*main.FooComposed" {
+		t.Errorf("TestAddField: Expecting the full type of Foo to be *main.FooComposed, got %s", fullType)
+	}
+}
+
+func TestAddFieldMultipleNames(t *testing.T) {
+	st := &Struct{
+		PackageName:         "main",
+		Fields:              make([]*Field, 0),
+		Composition:         make(map[string]struct{}),
+		Aggregations:        make(map[string]struct{}),
+		PrivateAggregations: make(map[string]struct{}),
+	}
+	st.AddField(&ast.Field{
+		Names: []*ast.Ident{{Name: "x"}, {Name: "y"}, {Name: "Z"}},
+		Type:  &ast.StarExpr{X: &ast.Ident{Name: "Point"}},
+	}, make(map[string]string))
+	var names []string
+	for _, field := range st.Fields {
+		names = append(names, field.Name)
+		if field.Type != "*Point" || field.FullType != "*main.Point" || field.Multiplicity != optionalMultiplicity {
+			t.Errorf("TestAddFieldMultipleNames: Expected %s to be a *Point, got %v", field.Name, field)
+		}
+	}
+	if expected := []string{"x", "y", "Z"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("TestAddFieldMultipleNames: Expected fields %v, got %v", expected, names)
+	}
+	if !arrayContains(st.Aggregations, "main.Point") || !arrayContains(st.PrivateAggregations, "main.Point") {
+		t.Errorf("TestAddFieldMultipleNames: Expected main.Point to be aggregated by public and private fields, got %v and %v", st.Aggregations, st.PrivateAggregations)
+	}
 }
 
 func TestAddMethod(t *testing.T) {
@@ -477,3 +508,15 @@ func TestAddMethod(t *testing.T) {
 		t.Errorf("TestAddMethod: Expected st.Function[0] to have %v, got %v", testFunction, st.Functions[0])
 	}
 }
+
+func TestAddToImplements(t *testing.T) {
+	st := &Struct{}
+	st.AddToImplements("")
+	if len(st.Implements) != 0 {
+		t.Errorf("TestAddToImplements: expected empty types to be ignored, got %v", st.Implements)
+	}
+	st.AddToImplements("main.Interface")
+	if !arrayContains(st.Implements, "main.Interface") {
+		t.Errorf("TestAddToImplements: expected main.Interface to be implemented, got %v", st.Implements)
+	}
+}
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Summary lists the types found in every package with the number of their members and relationships, to check
+// what was parsed without rendering the diagram. Packages and types are sorted by name like in Model.
+type Summary struct {
+	Packages []*PackageSummary `json:"packages"`
+}
+
+// PackageSummary holds the summaries of the types of a package
+type PackageSummary struct {
+	Name  string         `json:"name"`
+	Types []*TypeSummary `json:"types"`
+}
+
+// TypeSummary counts the members and relationships of a type. Embedded fields are counted as relationships, not as
+// fields, and Relationships counts the extensions, realizations, compositions and aggregations found while parsing
+// whatever the rendering options are.
+type TypeSummary struct {
+	Name          string `json:"name"`
+	Kind          string `json:"kind"`
+	Fields        int    `json:"fields"`
+	Methods       int    `json:"methods"`
+	Relationships int    `json:"relationships"`
+}
+
+// Summary returns the summary of the parsed structure, see Summary
+func (p *ClassParser) Summary() *Summary {
+	summary := &Summary{Packages: []*PackageSummary{}}
+	for _, modelPackage := range p.Model().Packages {
+		packageSummary := &PackageSummary{
+			Name:  modelPackage.Name,
+			Types: []*TypeSummary{},
+		}
+		for _, modelType := range modelPackage.Types {
+			packageSummary.Types = append(packageSummary.Types, getTypeSummary(modelType))
+		}
+		summary.Packages = append(summary.Packages, packageSummary)
+	}
+	return summary
+}
+
+// ExportSummaryJSON returns the summary of the parsed structure as indented JSON, see Summary
+func (p *ClassParser) ExportSummaryJSON() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(p.Summary()); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// getTypeSummary counts the members and relationships of the given type
+func getTypeSummary(modelType *ModelType) *TypeSummary {
+	typeSummary := &TypeSummary{
+		Name:    modelType.Name,
+		Kind:    modelType.Kind,
+		Methods: len(modelType.Methods),
+	}
+	for _, field := range modelType.Fields {
+		if !field.Embedded {
+			typeSummary.Fields++
+		}
+	}
+	typeSummary.Relationships = len(modelType.Extends) + len(modelType.Implements) + len(modelType.Compositions) +
+		len(modelType.Aggregations) + len(modelType.PrivateAggregations)
+	return typeSummary
+}
+
+// RenderSummaryTo writes the summary of the parsed structure as plain text, with a table of the types of every
+// package under the name of the package
+func (p *ClassParser) RenderSummaryTo(w io.Writer) error {
+	for i, packageSummary := range p.Summary().Packages {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s: %d types\n", packageSummary.Name, len(packageSummary.Types)); err != nil {
+			return err
+		}
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(table, "%sNAME\tKIND\tFIELDS\tMETHODS\tRELATIONSHIPS\n", tab)
+		for _, typeSummary := range packageSummary.Types {
+			fmt.Fprintf(table, "%s%s\t%s\t%d\t%d\t%d\n", tab, typeSummary.Name, typeSummary.Kind, typeSummary.Fields, typeSummary.Methods, typeSummary.Relationships)
+		}
+		// The table writer keeps the first error of the writer
+		if err := table.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRenderSummary(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/queries"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := &strings.Builder{}
+	if err := parser.RenderSummaryTo(result); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	expected := `vault: 8 types
+    NAME        KIND       FIELDS  METHODS  RELATIONSHIPS
+    Audit       class      2       0        2
+    Cache       class      1       0        3
+    Entry       class      1       0        0
+    Env         class      0       1        1
+    Memory      class      1       2        3
+    ReadWriter  interface  0       0        2
+    Reader      interface  0       1        0
+    Writer      interface  0       1        0
+`
+	if result.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, result.String())
+	}
+}
+
+func TestExportSummaryJSON(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/queries"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	exported, err := parser.ExportSummaryJSON()
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	summary := &Summary{}
+	if err := json.Unmarshal(exported, summary); err != nil {
+		t.Fatalf("Expected valid JSON but got %s", err.Error())
+	}
+	if len(summary.Packages) != 1 || len(summary.Packages[0].Types) != 8 {
+		t.Fatalf("Expected one package with 8 types, got %s", exported)
+	}
+	memory := summary.Packages[0].Types[4]
+	if *memory != (TypeSummary{Name: "Memory", Kind: "class", Fields: 1, Methods: 2, Relationships: 3}) {
+		t.Errorf("Expected the summary of Memory, got %+v", memory)
+	}
+}
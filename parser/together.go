@@ -0,0 +1,59 @@
+package parser
+
+// renderGroupedStructures renders the rendered types of the package, in the order of the given names, with the given
+// function. With the GroupImplementations option, the types of a group are rendered in a together block where the
+// first of them would be rendered, see getImplementationGroups.
+func (p *ClassParser) renderGroupedStructures(pack string, structures map[string]*Struct, names []string, str *LineStringBuilder, render func(name string, str *LineStringBuilder)) {
+	groups := p.getImplementationGroups(pack, structures, names)
+	done := map[string]struct{}{}
+	for _, name := range names {
+		if _, ok := done[name]; ok || !p.isRenderedType(getFullTypeName(pack, name)) {
+			continue
+		}
+		group, grouped := groups[name]
+		if !grouped {
+			render(name, str)
+			continue
+		}
+		str.WriteLineWithDepth(1, "together {")
+		str.indentation++
+		for _, member := range group {
+			render(member, str)
+			done[member] = struct{}{}
+		}
+		str.indentation--
+		str.WriteLineWithDepth(1, "}")
+	}
+}
+
+// getImplementationGroups returns the groups made of every rendered interface of the package and the rendered types
+// of the package that implement it, keyed by the names of their types. The interfaces are taken in the order of
+// their names and a type implementing several of them only joins the group of the first one, so the groups never
+// change from one rendering to the next. Interfaces that no type implements are not grouped.
+func (p *ClassParser) getImplementationGroups(pack string, structures map[string]*Struct, names []string) map[string][]string {
+	groups := map[string][]string{}
+	if !p.renderingOptions.GroupImplementations {
+		return groups
+	}
+	for _, name := range names {
+		fullName := getFullTypeName(pack, name)
+		if structures[name].Kind() != InterfaceKind || !p.isRenderedType(fullName) {
+			continue
+		}
+		group := []string{name}
+		for _, implementer := range names {
+			if _, claimed := groups[implementer]; claimed || !p.isRenderedType(getFullTypeName(pack, implementer)) {
+				continue
+			}
+			if _, ok := structures[implementer].Implements[fullName]; ok {
+				group = append(group, implementer)
+			}
+		}
+		if len(group) > 1 {
+			for _, member := range group {
+				groups[member] = group
+			}
+		}
+	}
+	return groups
+}
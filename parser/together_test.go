@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRenderGroupImplementations(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:  afero.NewOsFs(),
+		Directories: []string{"../testingsupport/queries"},
+		RenderingOptions: map[RenderingOption]interface{}{
+			RenderGroupImplementations: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	result := parser.Render()
+	// Memory implements all the interfaces but only joins ReadWriter, the first one by name
+	expected := []string{
+		"    together {\n        interface ReadWriter  {\n        }\n        class Memory << (S,Aquamarine) >> {\n",
+		"    together {\n        interface Reader  {\n            + {abstract} Read(key string) (string, error)\n        }\n        class Cache << (S,Aquamarine) >> {\n        }\n        class Env << (S,Aquamarine) >> {\n",
+		"    interface Writer  {\n",
+		"    class Audit << (S,Aquamarine) >> {\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(result, e) {
+			t.Errorf("Expected the diagram to contain\n%s\ngot\n%s", e, result)
+		}
+	}
+	if count := strings.Count(result, "together {"); count != 2 {
+		t.Errorf("Expected 2 groups, got %d in\n%s", count, result)
+	}
+	if count := strings.Count(result, "class Memory "); count != 1 {
+		t.Errorf("Expected Memory to be rendered once, got %d in\n%s", count, result)
+	}
+	if result != parser.Render() {
+		t.Errorf("Expected the groups to be the same every time")
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderGroupImplementations: false})
+	if result := parser.Render(); strings.Contains(result, "together {") {
+		t.Errorf("Expected no groups without the option, got\n%s", result)
+	}
+}
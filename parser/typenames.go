@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExternalTypeNames selects how the types of the packages that were not parsed are written in fields and methods
+type ExternalTypeNames int
+
+const (
+	// FullExternalTypeNames writes the external types as they are declared. It is the default
+	FullExternalTypeNames ExternalTypeNames = iota
+	// BaseExternalTypeNames writes only the name of the external types, like Client for s3.Client
+	BaseExternalTypeNames
+	// ElidedExternalTypeNames writes the external types as an ellipsis
+	ElidedExternalTypeNames
+)
+
+// elidedTypeName replaces the external types with the ElidedExternalTypeNames option
+const elidedTypeName = "..."
+
+// typeEllipsis replaces the middle of the types longer than the MaxTypeLength option
+const typeEllipsis = "…"
+
+// qualifiedTypeRegexp matches the qualified type names, with a package name or an import path, in a type string
+var qualifiedTypeRegexp = regexp.MustCompile(`[A-Za-z_][\w.~/-]*\.[A-Za-z_]\w*`)
+
+// getMemberType returns the given type of a field, parameter or return value as it is written in the diagram. The
+// ShortTypeNames, ExternalTypeNames and MaxTypeLength options only change this text, never the ends of the
+// relationships.
+func (p *ClassParser) getMemberType(t string) string {
+	if p.renderingOptions.ShortTypeNames || p.renderingOptions.ExternalTypeNames != FullExternalTypeNames {
+		t = qualifiedTypeRegexp.ReplaceAllStringFunc(t, p.getQualifiedTypeName)
+	}
+	return p.limitTypeLength(t)
+}
+
+// getReturnValuesType returns the return values of the given method as they are written in the diagram, with every
+// type written by getMemberType so the MaxTypeLength option limits each one of them
+func (p *ClassParser) getReturnValuesType(method *Function) string {
+	written := *method
+	written.ReturnValues = make([]string, len(method.ReturnValues))
+	for i, returnValue := range method.ReturnValues {
+		written.ReturnValues[i] = p.getMemberType(returnValue)
+	}
+	return getReturnValuesString(&written)
+}
+
+// getQualifiedTypeName returns the given qualified type name as it is written with the ShortTypeNames and
+// ExternalTypeNames options
+func (p *ClassParser) getQualifiedTypeName(qualified string) string {
+	pack, name := splitFullTypeName(qualified)
+	if pack == "" {
+		return qualified
+	}
+	if _, parsed := p.structure[pack]; !parsed {
+		switch p.renderingOptions.ExternalTypeNames {
+		case BaseExternalTypeNames:
+			return name
+		case ElidedExternalTypeNames:
+			return elidedTypeName
+		}
+	}
+	if p.renderingOptions.ShortTypeNames && strings.Contains(pack, "/") {
+		return getImportedPackageName(pack) + "." + name
+	}
+	return qualified
+}
+
+// limitTypeLength shortens the given type when it is longer than the MaxTypeLength option. The package qualifiers are
+// dropped first and, if the type is still too long, its middle is replaced with an ellipsis. The font tags of the
+// keywords are not counted, but they are lost when the middle of the type is cut.
+func (p *ClassParser) limitTypeLength(t string) string {
+	maxLength := p.renderingOptions.MaxTypeLength
+	if maxLength <= 0 || utf8.RuneCountInString(getPlainType(t)) <= maxLength {
+		return t
+	}
+	t = qualifiedTypeRegexp.ReplaceAllStringFunc(t, func(qualified string) string {
+		_, name := splitFullTypeName(qualified)
+		return name
+	})
+	plain := []rune(getPlainType(t))
+	if len(plain) <= maxLength {
+		return t
+	}
+	tail := (maxLength - 1) / 2
+	head := maxLength - 1 - tail
+	return string(plain[:head]) + typeEllipsis + string(plain[len(plain)-tail:])
+}
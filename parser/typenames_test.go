@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestMemberTypeNames(t *testing.T) {
+	const store = "github.com/jfeliu007/goplantuml/testingsupport/externaltypes/store"
+	tt := []struct {
+		Name       string
+		Options    map[RenderingOption]interface{}
+		Expected   []string
+		Unexpected []string
+	}{
+		{
+			Name:    "Full",
+			Options: map[RenderingOption]interface{}{},
+			Expected: []string{
+				"+ Fs github.com/spf13/afero.Fs",
+				"+ Items <font color=blue>map</font>[string][]*" + store + ".Item",
+				"+ Sync(item *" + store + ".Item, request *net/http.Request) (github.com/spf13/afero.File, error)",
+			},
+		},
+		{
+			Name:    "Short",
+			Options: map[RenderingOption]interface{}{RenderShortTypeNames: true},
+			Expected: []string{
+				"+ Fs afero.Fs",
+				"+ Client *http.Client",
+				"+ Every time.Duration",
+				"+ Items <font color=blue>map</font>[string][]*store.Item",
+				"+ Sync(item *store.Item, request *http.Request) (afero.File, error)",
+			},
+			Unexpected: []string{"*" + store + ".Item"},
+		},
+		{
+			Name:    "Base external names",
+			Options: map[RenderingOption]interface{}{RenderShortTypeNames: true, RenderExternalTypeNames: BaseExternalTypeNames},
+			Expected: []string{
+				"+ Fs Fs",
+				"+ Client *Client",
+				"+ Every Duration",
+				"+ Items <font color=blue>map</font>[string][]*store.Item",
+				"+ Sync(item *store.Item, request *Request) (File, error)",
+			},
+		},
+		{
+			Name:    "Elided external names",
+			Options: map[RenderingOption]interface{}{RenderExternalTypeNames: ElidedExternalTypeNames},
+			Expected: []string{
+				"+ Fs ...",
+				"+ Client *...",
+				"+ Items <font color=blue>map</font>[string][]*" + store + ".Item",
+				"+ Sync(item *" + store + ".Item, request *...) (..., error)",
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			tc.Options[RenderAggregations] = true
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{"../testingsupport/externaltypes"},
+				Recursive:        true,
+				ImportPaths:      true,
+				RenderingOptions: tc.Options,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			result := parser.Render()
+			// The relationships always point at the full names
+			expected := append(tc.Expected, `o-- "1" "github_com_spf13_afero.Fs"`, `o-- "0..1" "net_http.Client"`)
+			for _, e := range expected {
+				if !strings.Contains(result, e) {
+					t.Errorf("Expected the diagram to contain %s, got\n%s", e, result)
+				}
+			}
+			for _, u := range tc.Unexpected {
+				if strings.Contains(result, u) {
+					t.Errorf("Expected the diagram not to contain %s, got\n%s", u, result)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxTypeLength(t *testing.T) {
+	tt := []struct {
+		Name      string
+		MaxLength int
+		Expected  []string
+	}{
+		{
+			Name:      "Without qualifiers",
+			MaxLength: 30,
+			Expected: []string{
+				"+ Items <font color=blue>map</font>[string][]*Item",
+				"+ Client *net/http.Client",
+				"+ Sync(item *Item, request *net/http.Request) (github.com/spf13/afero.File, error)",
+				"+ Every time.Duration",
+			},
+		},
+		{
+			Name:      "Ellipsis",
+			MaxLength: 10,
+			Expected: []string{
+				"+ Items map[s…Item",
+				"+ Sync(item *Item, request *Request) (File, error)",
+				"+ Do(request *Request) (*Response, error)",
+				"+ Every Duration",
+				"+ Fs Fs",
+			},
+		},
+		{
+			Name:      "Every return value",
+			MaxLength: 6,
+			Expected: []string{
+				"+ Do(request *Re…st) (*Re…se, error)",
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:       afero.NewOsFs(),
+				Directories:      []string{"../testingsupport/externaltypes"},
+				Recursive:        true,
+				ImportPaths:      true,
+				RenderingOptions: map[RenderingOption]interface{}{RenderMaxTypeLength: tc.MaxLength},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+			result := parser.Render()
+			for _, e := range tc.Expected {
+				if !strings.Contains(result, e) {
+					t.Errorf("Expected the diagram to contain %s, got\n%s", e, result)
+				}
+			}
+		})
+	}
+}
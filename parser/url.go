@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"strings"
+)
+
+// MaxURLLength is the longest URL most PlantUML servers, and the proxies in front of them, accept. Longer diagrams
+// should be sent to the server with a POST request instead.
+const MaxURLLength = 8192
+
+// plantUMLEncoding is base64 with the alphabet used by PlantUML servers
+var plantUMLEncoding = base64.NewEncoding("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz-_").WithPadding(base64.NoPadding)
+
+// EncodeURL returns the URL of the rendered diagram in the given PlantUML server, for example
+// https://www.plantuml.com/plantuml/svg. The caller should check the length of the URL against MaxURLLength.
+func (p *ClassParser) EncodeURL(serverBase string) (string, error) {
+	encoded, err := EncodePlantUML(p.Render())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(serverBase, "/") + "/" + encoded, nil
+}
+
+// EncodePlantUML compresses the given diagram text with deflate and encodes it with the PlantUML base64 alphabet
+// so it can be used in the URL of a PlantUML server
+func EncodePlantUML(text string) (string, error) {
+	buffer := &bytes.Buffer{}
+	writer, err := flate.NewWriter(buffer, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := writer.Write([]byte(text)); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	encoded := plantUMLEncoding.EncodeToString(buffer.Bytes())
+	// PlantUML always encodes groups of 3 bytes, filling the last one with zeros
+	if remainder := len(encoded) % 4; remainder != 0 {
+		encoded += strings.Repeat("0", 4-remainder)
+	}
+	return encoded, nil
+}
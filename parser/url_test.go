@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestEncodePlantUML(t *testing.T) {
+	tt := []string{
+		"",
+		"@startuml\nBob -> Alice : hello\n@enduml\n",
+		strings.Repeat("class Foo\n", 100),
+	}
+	for _, text := range tt {
+		encoded, err := EncodePlantUML(text)
+		if err != nil {
+			t.Fatalf("Expected no error but got %s", err.Error())
+		}
+		if len(encoded)%4 != 0 {
+			t.Errorf("Expected the encoded text to be made of groups of 4 characters, got %s", encoded)
+		}
+		decoded, err := plantUMLEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatalf("Expected no error decoding %s but got %s", encoded, err.Error())
+		}
+		inflated, err := io.ReadAll(flate.NewReader(bytes.NewReader(decoded)))
+		if err != nil {
+			t.Fatalf("Expected no error inflating %s but got %s", encoded, err.Error())
+		}
+		if string(inflated) != text {
+			t.Errorf("Expected %q got %q", text, string(inflated))
+		}
+	}
+}
+
+func TestEncodeURL(t *testing.T) {
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       afero.NewOsFs(),
+		Directories:      []string{"../testingsupport/connectionlabels"},
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	encoded, err := EncodePlantUML(parser.Render())
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+	for _, serverBase := range []string{"https://www.plantuml.com/plantuml/svg", "https://www.plantuml.com/plantuml/svg/"} {
+		url, err := parser.EncodeURL(serverBase)
+		if err != nil {
+			t.Fatalf("Expected no error but got %s", err.Error())
+		}
+		if expected := "https://www.plantuml.com/plantuml/svg/" + encoded; url != expected {
+			t.Errorf("Expected %s got %s", expected, url)
+		}
+	}
+}
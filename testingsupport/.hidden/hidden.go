@@ -0,0 +1,5 @@
+package hidden
+
+//Hidden lives in a hidden directory and should never be parsed when walking recursively
+type Hidden struct {
+}
@@ -0,0 +1,24 @@
+package aggregations
+
+// Node is referenced by Tree through several collections
+type Node struct {
+	Name string
+}
+
+// Leaf is referenced by Tree through a single value
+type Leaf struct {
+}
+
+// Branch is referenced by Tree through a pointer
+type Branch struct {
+}
+
+// Tree references other types of this package through its fields
+type Tree struct {
+	Root     *Node
+	Nodes    []Node
+	Index    map[string]*Node
+	Leaf     Leaf
+	Branch   *Branch
+	children []*Tree
+}
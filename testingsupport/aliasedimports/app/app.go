@@ -0,0 +1,18 @@
+package app
+
+import (
+	db "github.com/jfeliu007/goplantuml/testingsupport/aliasedimports/database"
+	st "github.com/jfeliu007/goplantuml/testingsupport/aliasedimports/storage"
+)
+
+// Service embeds the connection of an aliased import
+type Service struct {
+	db.Conn
+	*st.Record
+	Last st.Record
+}
+
+// Repository extends the interface of a package that is not named after its directory
+type Repository interface {
+	st.Querier
+}
@@ -0,0 +1,6 @@
+package database
+
+// Conn is a connection to the database
+type Conn struct {
+	DSN string
+}
@@ -0,0 +1,11 @@
+package store
+
+// Querier runs queries, its package is not named after its directory
+type Querier interface {
+	Query(q string) error
+}
+
+// Record is a stored record
+type Record struct {
+	ID string
+}
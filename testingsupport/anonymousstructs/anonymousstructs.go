@@ -0,0 +1,20 @@
+package blog
+
+import "time"
+
+// Post has fields with anonymous structs
+type Post struct {
+	Meta struct {
+		Created, Updated time.Time
+		Author           *struct {
+			Name    string
+			Contact struct {
+				Email struct {
+					Address string
+				}
+			}
+		}
+	} `json:"meta"`
+	Tags     []struct{ Name string }
+	Min, Max struct{ Value int }
+}
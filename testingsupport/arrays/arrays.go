@@ -0,0 +1,17 @@
+package board
+
+const size = 4
+
+// Cell is a cell of the board
+type Cell struct {
+	Value int
+}
+
+// Board has arrays of primitive and project types
+type Board struct {
+	Buffer [16]byte
+	Matrix [4][4]float64
+	Cells  [size][size]*Cell
+	Row    *[size]Cell
+	Free   []Cell
+}
@@ -0,0 +1,22 @@
+package associations
+
+// Node is a node of a linked tree
+type Node struct {
+	Value    int
+	Parent   *Node
+	Next     *Node
+	Children []*Node
+	tree     *Tree
+}
+
+// Tree holds its nodes
+type Tree struct {
+	Root  *Node
+	Nodes []Node
+	Size  Counter
+}
+
+// Counter counts
+type Counter struct {
+	Count int
+}
@@ -0,0 +1,23 @@
+package callbacks
+
+import "net/http"
+
+// Event is passed to the callbacks
+type Event struct {
+	Name string
+}
+
+// Callbacks holds function typed fields
+type Callbacks struct {
+	Handler    func(w http.ResponseWriter, r *http.Request) error
+	OnClose    func()
+	Split      func(s string) (head string, tail string, err error)
+	Log        func(format string, args ...interface{})
+	Pair       func(a, b int) int
+	Middleware func(next func(*Event) error) func(*Event) error
+	onEvent    func(event *Event)
+}
+
+// Subscribe takes a function as a parameter
+func (c *Callbacks) Subscribe(filter func(*Event) bool, handler func(*Event)) {
+}
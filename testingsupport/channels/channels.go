@@ -0,0 +1,24 @@
+package channels
+
+// Event is sent through the channels
+type Event struct {
+	Name string
+}
+
+// Result is sent back
+type Result struct {
+	Err error
+}
+
+// Worker communicates through channels
+type Worker struct {
+	Events  chan *Event
+	In      <-chan []Event
+	Out     chan<- Result
+	pending chan Event
+}
+
+// Run returns a receive only channel
+func (w *Worker) Run(done <-chan struct{}) <-chan Result {
+	return nil
+}
@@ -0,0 +1,89 @@
+package constraints
+
+import "fmt"
+
+// Signed is satisfied by the signed integer types
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned is satisfied by the unsigned integer types
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// Integer is the union of other constraints
+type Integer interface {
+	Signed | Unsigned
+}
+
+// Float is satisfied by the floating point types
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is satisfied by integers and floats
+type Number interface {
+	Integer | Float
+}
+
+// Bytes is satisfied by strings and byte slices
+type Bytes interface {
+	string | []byte
+}
+
+// Code is a constraint with a method and a type term
+type Code interface {
+	~int
+	fmt.Stringer
+	Valid() bool
+}
+
+// Key embeds the predeclared comparable constraint
+type Key interface {
+	comparable
+	Key() string
+}
+
+// NumberKey embeds a constraint so it is one too
+type NumberKey interface {
+	Number
+	Key() string
+}
+
+// Validator is a regular interface
+type Validator interface {
+	Valid() bool
+}
+
+// Status satisfies the Code constraint and implements Validator
+type Status int
+
+// String returns the name of the status
+func (s Status) String() string {
+	return "status"
+}
+
+// Valid returns true for known statuses
+func (s Status) Valid() bool {
+	return s >= 0
+}
+
+// Key returns the key of the status
+func (s Status) Key() string {
+	return s.String()
+}
+
+// Stats keeps values of any number type
+type Stats[T Number] struct {
+	Values []T
+}
+
+// Sum adds the given numbers
+func Sum[T Number](values ...T) T {
+	var sum T
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
@@ -0,0 +1,61 @@
+package constructors
+
+import "errors"
+
+// Client follows the constructor idiom
+type Client struct {
+	Address string
+}
+
+// NewClient returns the Client as its first return value so it is a constructor
+func NewClient(address string) (*Client, error) {
+	if address == "" {
+		return nil, errors.New("missing address")
+	}
+	return &Client{Address: address}, nil
+}
+
+// DefaultClient returns a single Client so it is a constructor as well
+func DefaultClient() *Client {
+	return &Client{Address: "localhost"}
+}
+
+// Connect is a regular method
+func (c *Client) Connect() error {
+	return nil
+}
+
+// Config is only created by a factory
+type Config struct {
+	Name string
+}
+
+// ParseConfig returns more than one value and it is not named after Config
+func ParseConfig(name string) (Config, error) {
+	return Config{Name: name}, nil
+}
+
+// Reader is an interface, interfaces do not get constructors
+type Reader interface {
+	Read() string
+}
+
+// NewReader returns an interface
+func NewReader() Reader {
+	return nil
+}
+
+// List is generic
+type List[T any] struct {
+	Items []T
+}
+
+// NewList returns a generic type
+func NewList[T any]() *List[T] {
+	return &List[T]{}
+}
+
+// Count does not return a type of this package
+func Count() int {
+	return 0
+}
@@ -0,0 +1,51 @@
+package creations
+
+// Store is only implemented by an unexported type, created by NewStore
+type Store interface {
+	Get(key string) string
+}
+
+type memoryStore struct {
+	values map[string]string
+}
+
+func (s *memoryStore) Get(key string) string {
+	return s.values[key]
+}
+
+// NewStore returns a Store kept in memory
+func NewStore() Store {
+	return &memoryStore{values: map[string]string{}}
+}
+
+// Cache is implemented by the exported DiskCache
+type Cache interface {
+	Put(key string, value string)
+}
+
+// DiskCache writes the values into files under its path
+type DiskCache struct {
+	Path string
+}
+
+func (c *DiskCache) Put(key string, value string) {}
+
+// NewCache returns a Cache writing into the given path
+func NewCache(path string) Cache {
+	return &DiskCache{Path: path}
+}
+
+// Server serves the values of its store
+type Server struct {
+	Name string
+}
+
+// NewServer returns the server and the store it serves
+func NewServer(name string) (*Server, Store) {
+	return &Server{Name: name}, NewStore()
+}
+
+// Version is not a constructor
+func Version() string {
+	return "1.0"
+}
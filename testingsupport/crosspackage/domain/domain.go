@@ -0,0 +1,12 @@
+package domain
+
+// Entity is stored by a Repository
+type Entity struct {
+	ID string
+}
+
+// Repository stores entities
+type Repository interface {
+	Save(e *Entity) error
+	FindAll() map[string]*Entity
+}
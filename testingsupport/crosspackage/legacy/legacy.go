@@ -0,0 +1,6 @@
+package legacy
+
+// Entity is the old representation of a domain entity
+type Entity struct {
+	Key string
+}
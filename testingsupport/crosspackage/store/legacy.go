@@ -0,0 +1,14 @@
+package store
+
+import domain "github.com/jfeliu007/goplantuml/testingsupport/crosspackage/legacy"
+
+// LegacyStore uses an alias that shadows the name of the domain package in this file only
+type LegacyStore struct {
+	entities []domain.Entity
+}
+
+// Save stores the legacy entity
+func (s *LegacyStore) Save(e *domain.Entity) error {
+	s.entities = append(s.entities, *e)
+	return nil
+}
@@ -0,0 +1,22 @@
+package store
+
+import (
+	"github.com/jfeliu007/goplantuml/testingsupport/crosspackage/domain"
+	d "github.com/jfeliu007/goplantuml/testingsupport/crosspackage/domain"
+)
+
+// Store implements domain.Repository
+type Store struct {
+	entities map[string]*domain.Entity
+}
+
+// Save stores the entity
+func (s *Store) Save(e *domain.Entity) error {
+	s.entities[e.ID] = e
+	return nil
+}
+
+// FindAll returns all the stored entities
+func (s *Store) FindAll() map[string]*d.Entity {
+	return s.entities
+}
@@ -0,0 +1,56 @@
+package shop
+
+import "time"
+
+// Item is aggregated by the Order
+type Item struct {
+	Price int
+}
+
+// Customer places orders
+type Customer struct {
+	Name string
+}
+
+// Invoice is returned by the Service
+type Invoice struct {
+	Total int
+}
+
+// Order holds the items
+type Order struct {
+	Items []Item
+}
+
+// Add only depends on Item, which is already aggregated
+func (o *Order) Add(i Item) {
+	o.Items = append(o.Items, i)
+}
+
+// Repository stores orders
+type Repository interface {
+	Save(o *Order) error
+}
+
+// Service uses everything else
+type Service struct {
+	repo Repository
+}
+
+// NewService creates a Service
+func NewService(r Repository) *Service {
+	return &Service{repo: r}
+}
+
+// Checkout creates the invoice of an order
+func (s *Service) Checkout(c Customer, o *Order, at time.Time) (*Invoice, error) {
+	return nil, nil
+}
+
+// Clone only depends on the Service itself
+func (s *Service) Clone() *Service {
+	return s
+}
+
+func (s *Service) audit(items map[string]Item) {
+}
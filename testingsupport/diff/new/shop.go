@@ -0,0 +1,27 @@
+package shop
+
+// Repository only renames the parameter of Save, which is not a change
+type Repository interface {
+	Save(order *Order) error
+	Delete(id string) error
+}
+
+// Cancel gets a reason
+func (o *Order) Cancel(reason string) error { return nil }
+
+// Order does not embed the Entity anymore
+type Order struct {
+	Items    []string
+	total    int
+	Customer *Customer
+}
+
+func (o *Order) Total() int { return o.total }
+
+// Customer is added
+type Customer struct {
+	Name string
+}
+
+// Entity is not changed
+type Entity struct{ ID string }
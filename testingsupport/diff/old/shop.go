@@ -0,0 +1,31 @@
+package shop
+
+// Entity is embedded by the Order
+type Entity struct {
+	ID string
+}
+
+// Order is changed in the new version
+type Order struct {
+	Entity
+	Items []string
+	total int
+}
+
+// Total is not changed
+func (o *Order) Total() int {
+	return o.total
+}
+
+// Cancel gets a reason in the new version
+func (o *Order) Cancel() error {
+	return nil
+}
+
+// Repository gets a new method in the new version
+type Repository interface {
+	Save(o *Order) error
+}
+
+// Legacy is removed in the new version
+type Legacy struct{}
@@ -0,0 +1,21 @@
+package docnotes
+
+// Documented has a doc comment that spans
+// several lines. Only its first sentence is rendered.
+type Documented struct {
+	Name string
+}
+
+type Undocumented struct {
+	Name string
+}
+
+type (
+	// Grouped uses <b>markup</b> and **bold** text.
+	Grouped interface {
+		Run()
+	}
+
+	// Identifier is an alias
+	Identifier string
+)
@@ -0,0 +1,20 @@
+package app
+
+import . "github.com/jfeliu007/goplantuml/testingsupport/dotimports/model"
+
+// Customer references the types of a dot imported package without a qualifier
+type Customer struct {
+	Entity
+	Home  Address
+	Phone Phone
+}
+
+// Phone is declared by this package
+type Phone struct {
+	Number string
+}
+
+// Locate returns the home of the customer
+func (c *Customer) Locate() Address {
+	return c.Home
+}
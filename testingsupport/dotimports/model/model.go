@@ -0,0 +1,16 @@
+package model
+
+// Entity is anything stored with an identifier
+type Entity struct {
+	ID string
+}
+
+// Address is where a customer lives
+type Address struct {
+	City string
+}
+
+// Locator is implemented by the types that have an address
+type Locator interface {
+	Locate() Address
+}
@@ -0,0 +1,23 @@
+package duplicates
+
+// Logger writes messages
+type Logger interface {
+	Log(message string)
+}
+
+// Base is embedded by the services
+type Base struct {
+	Name string
+}
+
+// Service embeds a logger so it is a logger too
+type Service struct {
+	Logger
+	Base
+}
+
+// Verbose logs everything
+type Verbose interface {
+	Logger
+	Debug(message string)
+}
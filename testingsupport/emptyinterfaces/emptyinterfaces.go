@@ -0,0 +1,25 @@
+package emptyinterfaces
+
+// Marker has no methods so every type implements it
+type Marker interface{}
+
+// Tagged only embeds Marker so it has no methods either
+type Tagged interface {
+	Marker
+}
+
+// Named has a method
+type Named interface {
+	Name() string
+}
+
+// User implements Named
+type User struct{}
+
+// Name returns the name of the user
+func (u *User) Name() string {
+	return ""
+}
+
+// Token has no methods
+type Token struct{}
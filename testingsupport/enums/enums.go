@@ -0,0 +1,42 @@
+package enums
+
+// Status is a classic iota enum
+type Status int
+
+const (
+	// StatusUnknown is the zero value
+	StatusUnknown Status = iota
+	StatusActive
+	_
+	StatusSuspended
+	statusDeleted
+)
+
+// String returns the name of the status
+func (s Status) String() string {
+	return ""
+}
+
+// State has explicit values
+type State string
+
+const (
+	StateOpen   State = "open"
+	StateClosed State = "closed"
+)
+
+// Untyped constants are not part of any enum
+const (
+	MaxRetries = 3
+	Converted  = State("converted")
+)
+
+// Level is a named type without constants
+type Level int
+
+// Account uses the enums
+type Account struct {
+	Status Status
+	State  State
+	Level  Level
+}
@@ -0,0 +1,61 @@
+package exportedonly
+
+// Closer is implemented by Service through the methods promoted from base
+type Closer interface {
+	Close() error
+}
+
+// closer is unexported so its implementations are hidden
+type closer interface {
+	Close() error
+}
+
+// Config is aggregated by the exported field promoted from base
+type Config struct {
+	Path string
+}
+
+// Service is exported and embeds an unexported type
+type Service struct {
+	base
+	Name  string
+	cache map[string]string
+}
+
+// Start is exported
+func (s *Service) Start() error {
+	return nil
+}
+
+// stop is unexported
+func (s *Service) stop() {
+}
+
+// base is unexported and its exported members are promoted to Service
+type base struct {
+	logger
+	ID     int
+	Config *Config
+	state  *state
+}
+
+// Close is promoted to Service
+func (b *base) Close() error {
+	return nil
+}
+
+// reset is unexported
+func (b *base) reset() {
+}
+
+// logger is promoted to Service through base
+type logger struct {
+}
+
+// Log is promoted to Service through base
+func (l logger) Log(message string) {
+}
+
+// state is only used by unexported fields
+type state struct {
+}
@@ -0,0 +1,23 @@
+package externalstubs
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Cache keeps the responses of a client
+type Cache struct {
+	sync.Mutex
+	Client  *http.Client
+	Entries map[string]*Entry
+}
+
+// Entry is a cached response
+type Entry struct {
+	Response *http.Response
+	Created  time.Time
+}
+
+// Timeout is the time a response is kept
+type Timeout = time.Duration
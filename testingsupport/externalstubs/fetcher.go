@@ -0,0 +1,9 @@
+package externalstubs
+
+import "net/http"
+
+// Fetcher fills the cache with the responses of its client
+type Fetcher struct {
+	Client *http.Client
+	Cache  *Cache
+}
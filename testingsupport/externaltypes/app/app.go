@@ -0,0 +1,27 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jfeliu007/goplantuml/testingsupport/externaltypes/store"
+	"github.com/spf13/afero"
+)
+
+// Syncer copies the items of the store into a file system
+type Syncer struct {
+	Fs     afero.Fs
+	Client *http.Client
+	Every  time.Duration
+	Items  map[string][]*store.Item
+}
+
+// Sync copies the given item into the file system
+func (s *Syncer) Sync(item *store.Item, request *http.Request) (afero.File, error) {
+	return nil, nil
+}
+
+// Do sends the given request
+func (s *Syncer) Do(request *http.Request) (*http.Response, error) {
+	return nil, nil
+}
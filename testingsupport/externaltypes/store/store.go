@@ -0,0 +1,6 @@
+package store
+
+// Item is a stored item
+type Item struct {
+	Name string
+}
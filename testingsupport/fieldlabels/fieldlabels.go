@@ -0,0 +1,20 @@
+package fieldlabels
+
+// Address is where a customer receives their orders and invoices
+type Address struct {
+	Street string
+	City   string
+}
+
+// Customer has several addresses, which the aggregations tell apart by the names of the fields
+type Customer struct {
+	Billing  Address
+	Shipping *Address
+	Previous []Address
+	Embedded
+}
+
+// Embedded is embedded in Customer
+type Embedded struct {
+	Notes string
+}
@@ -0,0 +1,28 @@
+package stringsutil
+
+// Builder has a constructor that is not rendered with the package functions
+type Builder struct {
+	parts []string
+}
+
+// NewBuilder creates a Builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+func init() {
+}
+
+// Reverse reverses the given string
+func Reverse(s string) string {
+	return s
+}
+
+// Join joins the given parts
+func Join(sep string, parts ...string) (string, error) {
+	return "", nil
+}
+
+func padLeft(s string, n int) string {
+	return s
+}
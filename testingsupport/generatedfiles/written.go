@@ -0,0 +1,5 @@
+package generatedfiles
+
+// Written is declared in a file written by hand
+type Written struct {
+}
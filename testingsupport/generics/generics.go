@@ -0,0 +1,32 @@
+package generics
+
+// List is a generic list
+type List[T any] struct {
+	items []T
+}
+
+// Push adds an element to the list
+func (l *List[T]) Push(v T) {
+	l.items = append(l.items, v)
+}
+
+// Map is a generic map
+type Map[K comparable, V any] struct {
+	values map[K]V
+}
+
+// Get returns the value stored for k
+func (m Map[K, V]) Get(k K) V {
+	return m.values[k]
+}
+
+// Getter is a generic interface
+type Getter[T any] interface {
+	Get() T
+}
+
+// Cache uses generic types in its fields
+type Cache struct {
+	Entries Map[string, int]
+	Keys    List[string]
+}
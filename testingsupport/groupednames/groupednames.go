@@ -0,0 +1,12 @@
+package groupednames
+
+// Vector declares several fields in a single line
+type Vector struct {
+	X, Y, Z float64
+	a, b    *Vector
+}
+
+// Scale takes parameters declared together
+func (v *Vector) Scale(x, y, z float64, factors ...float64) (length, angle float64) {
+	return 0, 0
+}
@@ -0,0 +1,16 @@
+package groupedtypedeclarations
+
+type (
+	// A is a test struct declared in a grouped type declaration
+	A struct {
+		Name string
+	}
+	// B is a test struct declared in a grouped type declaration
+	B struct {
+		Count int
+	}
+	// C is a test interface declared in a grouped type declaration
+	C interface {
+		Do() error
+	}
+)
@@ -0,0 +1,11 @@
+package models
+
+// User is a user of the application
+type User struct {
+	Name string
+}
+
+// Store saves users
+type Store interface {
+	Save(u *User) error
+}
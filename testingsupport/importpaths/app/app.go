@@ -0,0 +1,17 @@
+package app
+
+import (
+	"github.com/jfeliu007/goplantuml/testingsupport/importpaths/a/models"
+	bmodels "github.com/jfeliu007/goplantuml/testingsupport/importpaths/b/models"
+)
+
+// Service embeds the User of a/models and uses the User of b/models
+type Service struct {
+	models.User
+	Admin *bmodels.User
+}
+
+// Save implements models.Store
+func (s *Service) Save(u *models.User) error {
+	return nil
+}
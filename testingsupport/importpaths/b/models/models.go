@@ -0,0 +1,6 @@
+package models
+
+// User has the same name as the one in a/models but it is a different type
+type User struct {
+	ID int
+}
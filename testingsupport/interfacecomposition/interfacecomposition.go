@@ -0,0 +1,48 @@
+package interfacecomposition
+
+import "io"
+
+// Reader is a test interface modeled after io.Reader
+type Reader interface {
+	Read(p []byte) (n int, err error)
+}
+
+// Closer is a test interface modeled after io.Closer
+type Closer interface {
+	Close() error
+}
+
+// ReadCloser is composed of two other interfaces of this package
+type ReadCloser interface {
+	Reader
+	Closer
+}
+
+// ExternalReadCloser embeds an interface that is not part of the parsed code
+type ExternalReadCloser interface {
+	io.Reader
+	Closer
+}
+
+// File implements Reader, Closer and therefore ReadCloser
+type File struct {
+}
+
+// Read reads nothing
+func (f *File) Read(p []byte) (n int, err error) {
+	return 0, nil
+}
+
+// Close closes nothing
+func (f *File) Close() error {
+	return nil
+}
+
+// OnlyReader implements Reader only
+type OnlyReader struct {
+}
+
+// Read reads nothing
+func (o *OnlyReader) Read(p []byte) (n int, err error) {
+	return 0, nil
+}
@@ -0,0 +1,58 @@
+package app
+
+import st "github.com/jfeliu007/goplantuml/testingsupport/interfaceembedding/store"
+
+// Repository embeds interfaces of another package
+type Repository interface {
+	st.Reader
+	st.Writer
+	Close() error
+}
+
+// Getter is a generic interface
+type Getter[T any] interface {
+	Get(key string) T
+}
+
+// Pair is a generic interface with two type parameters
+type Pair[K comparable, V any] interface {
+	Lookup(key K) (V, bool)
+}
+
+// Cache embeds instantiated generic interfaces
+type Cache interface {
+	Getter[string]
+	Pair[string, *Entry]
+}
+
+// Entry is a cached value
+type Entry struct{}
+
+// Memory implements Repository and Cache
+type Memory struct{}
+
+// Get returns the value of the key
+func (m *Memory) Get(key string) string {
+	return ""
+}
+
+// Put sets the value of the key
+func (m *Memory) Put(key string, value string) {}
+
+// Close closes the memory
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Lookup returns the entry of the key
+func (m *Memory) Lookup(key string) (*Entry, bool) {
+	return nil, false
+}
+
+// Numbers only implements Getter[int]
+type Numbers struct{}
+
+// Get returns the number of the key
+func (n *Numbers) Get(key string) int {
+	return 0
+}
@@ -0,0 +1,11 @@
+package store
+
+// Reader reads values
+type Reader interface {
+	Get(key string) string
+}
+
+// Writer writes values
+type Writer interface {
+	Put(key string, value string)
+}
@@ -0,0 +1,30 @@
+package maps
+
+import "time"
+
+// Key identifies a Value
+type Key struct {
+	ID string
+}
+
+// Value is what the indexes hold
+type Value struct {
+	Name string
+}
+
+// Pair is a generic key made of two parts
+type Pair[K comparable, V any] struct {
+	First  K
+	Second V
+}
+
+// Index holds values in maps of every shape
+type Index struct {
+	ByKey     map[Key]*Value
+	ByPointer map[*Key][]Value
+	Nested    map[string]map[Key][]*Value
+	Pairs     map[Pair[Key, string]]Value
+	Timeouts  map[time.Duration]*Value
+	Counts    map[Key]int
+	Names     map[string]string
+}
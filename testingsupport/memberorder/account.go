@@ -0,0 +1,25 @@
+package memberorder
+
+// Account groups its fields by meaning rather than by visibility
+type Account struct {
+	ID      string
+	balance int
+
+	Owner   string
+	history []int
+}
+
+// NewAccount creates an empty account
+func NewAccount(owner string) *Account {
+	return &Account{Owner: owner}
+}
+
+// Deposit adds the amount to the balance
+func (a *Account) Deposit(amount int) {
+	a.record(amount)
+}
+
+func (a *Account) record(amount int) {
+	a.history = append(a.history, amount)
+	a.balance += amount
+}
@@ -0,0 +1,10 @@
+package memberorder
+
+func (a *Account) check() bool {
+	return a.balance >= 0
+}
+
+// Balance returns the current balance
+func (a *Account) Balance() int {
+	return a.balance
+}
@@ -0,0 +1,32 @@
+package methodsets
+
+// Reader reads
+type Reader interface {
+	Read() string
+}
+
+// Writer writes
+type Writer interface {
+	Write(s string)
+}
+
+// File has methods with value and pointer receivers
+type File struct{}
+
+// Read has a value receiver
+func (f File) Read() string {
+	return ""
+}
+
+// Write has a pointer receiver
+func (f *File) Write(s string) {}
+
+// Buffer embeds File so only *Buffer gets Write
+type Buffer struct {
+	File
+}
+
+// Stream embeds *File so Stream gets Read and Write
+type Stream struct {
+	*File
+}
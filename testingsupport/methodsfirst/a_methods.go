@@ -0,0 +1,21 @@
+package methodsfirst
+
+// String returns the temperature with its unit
+func (c Celsius) String() string {
+	return ""
+}
+
+// Fahrenheit converts the temperature
+func (c Celsius) Fahrenheit() float64 {
+	return 0
+}
+
+// Read returns the current temperature
+func (t *Thermometer) Read() Celsius {
+	return 0
+}
+
+// Names returns the names of the readings
+func (r Readings) Names() []string {
+	return nil
+}
@@ -0,0 +1,17 @@
+package methodsfirst
+
+// Celsius is a temperature
+type Celsius float64
+
+// Readings are the temperatures read by name
+type Readings map[string]Celsius
+
+// Sensor reads a temperature
+type Sensor interface {
+	Read() Celsius
+}
+
+// Thermometer is a sensor
+type Thermometer struct {
+	Location string
+}
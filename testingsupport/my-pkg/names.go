@@ -0,0 +1,20 @@
+package note
+
+// Renderer uses a name that PlantUML accepts
+type Renderer interface {
+	Render() string
+}
+
+// end uses a PlantUML keyword as its name
+type end struct {
+	Items []*object
+}
+
+// object uses a PlantUML keyword as its name
+type object struct {
+}
+
+// Render implements Renderer
+func (o *object) Render() string {
+	return ""
+}
@@ -0,0 +1,44 @@
+package namedtypes
+
+// Stringer is implemented by named types too
+type Stringer interface {
+	String() string
+}
+
+// UserID is a named basic type with methods
+type UserID int64
+
+// String returns the id as text
+func (u UserID) String() string {
+	return ""
+}
+
+// Email is an alias and can not have methods
+type Email = string
+
+// Frame is stored in a Stack
+type Frame struct {
+	Line int
+}
+
+// Stack is a named slice type
+type Stack []Frame
+
+// Push adds a frame
+func (s *Stack) Push(f Frame) {
+}
+
+// Index is a named map type
+type Index map[string]*Frame
+
+// Get returns the frame with the given name
+func (i Index) Get(name string) *Frame {
+	return i[name]
+}
+
+// User references the named types
+type User struct {
+	ID    UserID
+	Email Email
+	Calls Stack
+}
@@ -0,0 +1,33 @@
+package zoo
+
+// Marker is an empty marker type nothing refers to
+type Marker struct{}
+
+// Any is an empty interface
+type Any interface{}
+
+// Animal makes a sound
+type Animal interface {
+	Sound() string
+}
+
+// Dog is an Animal
+type Dog struct{}
+
+// Sound returns the sound of the dog
+func (d *Dog) Sound() string {
+	return "woof"
+}
+
+// Puppy has no members of its own
+type Puppy struct {
+	Dog
+}
+
+// Collar is only used by a field of Kennel
+type Collar struct{}
+
+// Kennel has fields
+type Kennel struct {
+	Collar *Collar
+}
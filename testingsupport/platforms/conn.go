@@ -0,0 +1,6 @@
+package platforms
+
+// Dialer opens connections
+type Dialer interface {
+	Dial(address string) (*Conn, error)
+}
@@ -0,0 +1,21 @@
+package platforms
+
+// Conn is a connection using a handle
+type Conn struct {
+	Handle uintptr
+}
+
+// Close closes the handle
+func (c *Conn) Close() error {
+	return nil
+}
+
+// Flush flushes the buffers of the handle
+func (c *Conn) Flush() error {
+	return nil
+}
+
+// NewConn opens a connection
+func NewConn(address string) *Conn {
+	return &Conn{}
+}
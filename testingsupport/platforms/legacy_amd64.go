@@ -0,0 +1,7 @@
+//go:build linux
+// +build linux
+
+package platforms
+
+// Legacy is only built on linux for amd64
+type Legacy struct{}
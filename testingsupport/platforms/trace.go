@@ -0,0 +1,8 @@
+//go:build debug
+
+package platforms
+
+// Tracer logs every call
+type Tracer struct {
+	Calls int
+}
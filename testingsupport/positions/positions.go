@@ -0,0 +1,37 @@
+package positions
+
+// Base is embedded by Node
+type Base struct {
+	ID int
+}
+
+// Node has embedded, grouped and tagged fields
+type Node struct {
+	Base
+	*Other
+	X, Y  int
+	Label string `json:"label"`
+}
+
+// NewNode creates a Node
+func NewNode() *Node {
+	return &Node{}
+}
+
+// Move is declared on a pointer receiver
+func (n *Node) Move(x, y int) {
+	n.X, n.Y = x, y
+}
+
+// Position is declared on a value receiver
+func (n Node) Position() (int, int) {
+	return n.X, n.Y
+}
+
+// Other is embedded through a pointer
+type Other struct{}
+
+// Mover is implemented by Node
+type Mover interface {
+	Move(x, y int)
+}
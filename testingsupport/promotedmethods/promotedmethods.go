@@ -0,0 +1,51 @@
+package promotedmethods
+
+// Handler is implemented through promoted methods only
+type Handler interface {
+	Handle(r *Request) error
+	Name() string
+}
+
+// Request is handled by a Handler
+type Request struct {
+	Path string
+}
+
+// BaseHandler handles requests but it has no name
+type BaseHandler struct {
+}
+
+// Handle handles the request
+func (b *BaseHandler) Handle(r *Request) error {
+	return nil
+}
+
+// NamedHandler gets Handle from the embedded *BaseHandler
+type NamedHandler struct {
+	*BaseHandler
+}
+
+// Name returns the name of the handler
+func (n NamedHandler) Name() string {
+	return "named"
+}
+
+// Server gets all of its methods from two levels of embedding
+type Server struct {
+	NamedHandler
+}
+
+// Wrapper gets its methods from an embedded interface
+type Wrapper struct {
+	Handler
+}
+
+// Loop embeds a type that embeds it back
+type Loop struct {
+	*Cycle
+}
+
+// Cycle embeds a type that embeds it back
+type Cycle struct {
+	*Loop
+}
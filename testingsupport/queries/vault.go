@@ -0,0 +1,55 @@
+package vault
+
+// Reader reads secrets
+type Reader interface {
+	Read(key string) (string, error)
+}
+
+// Writer writes secrets
+type Writer interface {
+	Write(key string, value string) error
+}
+
+// ReadWriter reads and writes secrets
+type ReadWriter interface {
+	Reader
+	Writer
+}
+
+// Memory keeps the secrets in memory
+type Memory struct {
+	secrets map[string]string
+}
+
+func (m *Memory) Read(key string) (string, error) {
+	return m.secrets[key], nil
+}
+
+func (m *Memory) Write(key string, value string) error {
+	m.secrets[key] = value
+	return nil
+}
+
+// Env reads the secrets from the environment
+type Env struct{}
+
+func (Env) Read(key string) (string, error) {
+	return "", nil
+}
+
+// Entry is a cached secret
+type Entry struct {
+	Value string
+}
+
+// Cache caches the secrets of the embedded Reader
+type Cache struct {
+	Reader
+	entries map[string]*Entry
+}
+
+// Audit records the last secret written to the store
+type Audit struct {
+	Last  *Entry
+	store ReadWriter
+}
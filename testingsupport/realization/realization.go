@@ -0,0 +1,21 @@
+package realization
+
+// Runner is implemented by Job
+type Runner interface {
+	Run() error
+}
+
+// Base is embedded by Job
+type Base struct {
+	ID int
+}
+
+// Job embeds Base and implements Runner
+type Job struct {
+	Base
+}
+
+// Run runs nothing
+func (j *Job) Run() error {
+	return nil
+}
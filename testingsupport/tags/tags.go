@@ -0,0 +1,12 @@
+package tags
+
+// User is stored in the database and sent as json
+type User struct {
+	ID       int64  `json:"id" db:"user_id"`
+	Name     string `json:"name,omitempty"`
+	Email    string `db:"email" validate:"required,email"`
+	Password string `json:"-"`
+	Comment  string `json:"comment" default:""`
+	Notes    string
+	Callback func() `json:"-"`
+}
@@ -0,0 +1,5 @@
+package brokenfile
+
+// Broken is half written
+type Broken struct {
+	Name string
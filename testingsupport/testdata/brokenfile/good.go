@@ -0,0 +1,6 @@
+package brokenfile
+
+// Parsed is declared in a file that can be parsed
+type Parsed struct {
+	Name string
+}
@@ -0,0 +1,5 @@
+package skipped
+
+//Skipped lives in a testdata directory and should never be parsed when walking recursively
+type Skipped struct {
+}
@@ -0,0 +1,16 @@
+package testfiles
+
+// Ledger records the amounts moved between accounts
+type Ledger interface {
+	Record(from string, to string, amount int) error
+}
+
+// Transfer moves amounts between accounts and records them in the Ledger
+type Transfer struct {
+	ledger Ledger
+}
+
+// Move records the amount moved between the accounts
+func (t *Transfer) Move(from string, to string, amount int) error {
+	return t.ledger.Record(from, to, amount)
+}
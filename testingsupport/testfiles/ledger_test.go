@@ -0,0 +1,11 @@
+package testfiles
+
+// fakeLedger keeps the recorded amounts in memory for the tests
+type fakeLedger struct {
+	amounts []int
+}
+
+func (l *fakeLedger) Record(from string, to string, amount int) error {
+	l.amounts = append(l.amounts, amount)
+	return nil
+}
@@ -0,0 +1,8 @@
+package testfiles_test
+
+import "github.com/jfeliu007/goplantuml/testingsupport/testfiles"
+
+// failingLedger fails to record every amount
+type failingLedger struct {
+	testfiles.Ledger
+}
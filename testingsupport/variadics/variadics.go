@@ -0,0 +1,24 @@
+package variadics
+
+// Entry is logged
+type Entry struct {
+	Message string
+}
+
+// Logger has variadic methods
+type Logger struct {
+	entries []*Entry
+}
+
+// Infof formats the message with the given arguments
+func (l *Logger) Infof(format string, args ...interface{}) {
+}
+
+// Debug logs the given values
+func (l *Logger) Debug(values ...any) {
+}
+
+// Add appends the given entries
+func (l *Logger) Add(level int, entries ...*Entry) int {
+	return 0
+}